@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// consoleInput 由唯一的 goroutine 读取标准输入并按行分发：有等待中的提示（智能体提问、
+// 工具审批）时交给最早的提示，否则留给主循环。提示对应的请求结束后立即撤下，
+// 之后输入的行不会再被过期的提示取走
+type consoleInput struct {
+	mu      sync.Mutex
+	prompts []*consolePrompt
+	// lines 主循环尚未读取的输入
+	lines []string
+	ready chan struct{}
+	err   error
+}
+
+type consolePrompt struct {
+	answer chan string
+}
+
+// newConsoleInput 开始读取 r
+func newConsoleInput(r io.Reader) *consoleInput {
+	c := &consoleInput{ready: make(chan struct{}, 1)}
+	go c.read(r)
+	return c
+}
+
+func (c *consoleInput) read(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		c.mu.Lock()
+		if len(c.prompts) > 0 {
+			p := c.prompts[0]
+			c.prompts = c.prompts[1:]
+			p.answer <- line
+		} else {
+			c.lines = append(c.lines, line)
+			c.notify()
+		}
+		c.mu.Unlock()
+	}
+	c.mu.Lock()
+	c.err = scanner.Err()
+	if c.err == nil {
+		c.err = io.EOF
+	}
+	c.notify()
+	c.mu.Unlock()
+}
+
+// notify 唤醒等待输入的主循环，调用方持有锁
+func (c *consoleInput) notify() {
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// ReadLine 返回主循环的下一行输入，输入结束时返回 io.EOF
+func (c *consoleInput) ReadLine() (string, error) {
+	for {
+		c.mu.Lock()
+		if len(c.lines) > 0 {
+			line := c.lines[0]
+			c.lines = c.lines[1:]
+			c.mu.Unlock()
+			return line, nil
+		}
+		err := c.err
+		c.mu.Unlock()
+		if err != nil {
+			return "", err
+		}
+		<-c.ready
+	}
+}
+
+// Prompt 显示提示并等待下一行输入；done 关闭（请求已超时、取消或在别处处理）时撤下提示，
+// 返回 false
+func (c *consoleInput) Prompt(text string, done <-chan struct{}) (string, bool) {
+	p := &consolePrompt{answer: make(chan string, 1)}
+	c.mu.Lock()
+	c.prompts = append(c.prompts, p)
+	c.mu.Unlock()
+	fmt.Print(text)
+
+	select {
+	case line := <-p.answer:
+		return line, true
+	case <-done:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, q := range c.prompts {
+		if q == p {
+			c.prompts = append(c.prompts[:i], c.prompts[i+1:]...)
+			return "", false
+		}
+	}
+	// 撤下之前已经收到输入
+	return <-p.answer, true
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// promptResult Prompt 的返回值
+type promptResult struct {
+	line string
+	ok   bool
+}
+
+// startPrompt 在后台显示提示，等待提示登记后返回结果通道
+func startPrompt(t *testing.T, c *consoleInput, done <-chan struct{}) <-chan promptResult {
+	t.Helper()
+	c.mu.Lock()
+	before := len(c.prompts)
+	c.mu.Unlock()
+
+	res := make(chan promptResult, 1)
+	go func() {
+		line, ok := c.Prompt("> ", done)
+		res <- promptResult{line, ok}
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		n := len(c.prompts)
+		c.mu.Unlock()
+		if n > before {
+			return res
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("prompt not registered")
+	return nil
+}
+
+func TestConsoleInputReadLine(t *testing.T) {
+	c := newConsoleInput(strings.NewReader(" first \nsecond\n"))
+	for _, want := range []string{"first", "second"} {
+		line, err := c.ReadLine()
+		if err != nil || line != want {
+			t.Fatalf("ReadLine = %q, %v; want %q", line, err, want)
+		}
+	}
+	if _, err := c.ReadLine(); err != io.EOF {
+		t.Fatalf("after input ends: %v", err)
+	}
+}
+
+func TestConsoleInputPrompt(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	c := newConsoleInput(r)
+
+	// 有等待中的提示时，输入按提示的顺序交给提示
+	first := startPrompt(t, c, make(chan struct{}))
+	second := startPrompt(t, c, make(chan struct{}))
+	io.WriteString(w, "yes\nno\ncommand\n")
+	if got := <-first; got != (promptResult{"yes", true}) {
+		t.Fatalf("first prompt = %+v", got)
+	}
+	if got := <-second; got != (promptResult{"no", true}) {
+		t.Fatalf("second prompt = %+v", got)
+	}
+	if line, err := c.ReadLine(); err != nil || line != "command" {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+}
+
+func TestConsoleInputWithdrawPrompt(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	c := newConsoleInput(r)
+
+	// 请求超时或在别处处理后撤下提示，之后的输入留给主循环
+	done := make(chan struct{})
+	res := startPrompt(t, c, done)
+	close(done)
+	if got := <-res; got.ok {
+		t.Fatalf("withdrawn prompt returned %+v", got)
+	}
+	c.mu.Lock()
+	remaining := len(c.prompts)
+	c.mu.Unlock()
+	if remaining != 0 {
+		t.Fatalf("%d prompts still registered", remaining)
+	}
+
+	io.WriteString(w, "next command\n")
+	if line, err := c.ReadLine(); err != nil || line != "next command" {
+		t.Fatalf("ReadLine = %q, %v", line, err)
+	}
+
+	// 已结束的请求不再登记提示
+	closed := make(chan struct{})
+	close(closed)
+	if line, ok := c.Prompt("> ", closed); ok {
+		t.Fatalf("prompt for a finished request returned %q", line)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"syscall"
 
 	"github.com/openmanus/openmanus-go/internal/agent"
+	"github.com/openmanus/openmanus-go/internal/bootstrap"
 	"github.com/openmanus/openmanus-go/internal/config"
 	"github.com/openmanus/openmanus-go/internal/flow"
 	"github.com/openmanus/openmanus-go/internal/llm"
@@ -44,6 +45,13 @@ func Run() error {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
+	// 工作区、权限策略和中间件，需在创建工具集合之前设置
+	toolEnv, err := bootstrap.SetupTools(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer toolEnv.Close()
+
 	// 创建 LLM 客户端和工具集合
 	llmClient := llm.NewClient(cfg.LLM.BaseURL, cfg.LLM.APIKey, cfg.LLM.MaxTokens, cfg.LLM.Temperature)
 
@@ -1,20 +1,23 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/openmanus/openmanus-go/internal/agent"
+	"github.com/openmanus/openmanus-go/internal/bootstrap"
 	"github.com/openmanus/openmanus-go/internal/config"
 	"github.com/openmanus/openmanus-go/internal/flow"
 	"github.com/openmanus/openmanus-go/internal/llm"
+	"github.com/openmanus/openmanus-go/internal/sandbox"
 	"github.com/openmanus/openmanus-go/internal/tool"
 )
 
@@ -22,6 +25,13 @@ var (
 	configPath  string
 	mode        string
 	interactive bool
+
+	// 由 OpenAPI 规范生成的工具，启动时加载一次
+	apiTools []*tool.OpenAPITool
+	// 外部插件提供的工具
+	plugins *tool.PluginManager
+	// 工具调用统计
+	toolMetrics *tool.ToolMetrics
 )
 
 func init() {
@@ -32,6 +42,9 @@ func init() {
 }
 
 func main() {
+	// 作为命名空间沙箱的初始化进程启动时在此执行命令并退出
+	sandbox.Init()
+
 	// 加载配置
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
@@ -51,8 +64,79 @@ func main() {
 		cancel()
 	}()
 
+	// 工作区、权限策略、中间件等工具默认配置；ask 决策通过命令行或 HTTP 接口审批
+	toolEnv, err := bootstrap.SetupTools(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer toolEnv.Close()
+	toolMetrics = toolEnv.Metrics
+
+	// 沙箱内命令的系统调用过滤
+	seccomp, err := sandbox.NewSeccompProfile(cfg.Sandbox.Seccomp.Profile, cfg.Sandbox.Seccomp.Allow,
+		cfg.Sandbox.Seccomp.Deny, cfg.Sandbox.Seccomp.Action)
+	if err != nil {
+		log.Fatalf("seccomp 配置无效: %v", err)
+	}
+	sandbox.SetDefaultSeccompProfile(seccomp)
+	sandbox.SetCgroupMoveSelf(cfg.Sandbox.CgroupMoveSelf)
+
+	// 沙箱中命令的资源限制，未注入沙箱的 shell 工具同样使用
+	limits := sandbox.ResourceLimits{
+		CPULimit:     cfg.Sandbox.Limits.CPULimit,
+		MemoryLimit:  cfg.Sandbox.Limits.MemoryLimit,
+		DiskLimit:    cfg.Sandbox.Limits.DiskLimit,
+		ProcessLimit: cfg.Sandbox.Limits.ProcessLimit,
+		TimeLimit:    cfg.Sandbox.Limits.TimeLimit,
+	}
+	sandbox.SetDefaultLimits(limits)
+
+	// 按会话分配的沙箱，退出时终止沙箱中的命令；默认共用工具工作区，
+	// 配置为独立工作区时退出时删除各沙箱的工作区
+	nsConfig := sandbox.DefaultNamespaceConfig()
+	nsConfig.Network = cfg.Sandbox.Network
+	sharedWorkspace := toolEnv.Workspace.Root()
+	if cfg.Sandbox.IsolatedWorkspace {
+		sharedWorkspace = ""
+	}
+	sandboxes, err := sandbox.NewManager(sandbox.ManagerConfig{
+		MaxSandboxes:    cfg.Sandbox.MaxSandboxes,
+		MaxIdle:         cfg.Sandbox.MaxIdle,
+		IdleTimeout:     time.Duration(cfg.Sandbox.IdleTimeout) * time.Second,
+		CleanupInterval: time.Duration(cfg.Sandbox.CleanupInterval) * time.Second,
+		Isolation:       cfg.Sandbox.Isolation,
+		Namespace:       nsConfig,
+		Workspace:       sharedWorkspace,
+		WorkspaceRoot:   cfg.Sandbox.WorkspaceRoot,
+		Limits:          limits,
+	})
+	if err != nil {
+		log.Fatalf("初始化沙箱管理器失败: %v", err)
+	}
+	defer sandboxes.Close()
+	sandbox.SetDefaultManager(sandboxes)
+
 	// 注册工具
 	tool.RegisterDefaultTools()
+	apiTools = loadOpenAPITools(ctx, cfg)
+	for _, t := range apiTools {
+		tool.GetRegistry().Register(t)
+	}
+	plugins = tool.NewPluginManager(tool.PluginConfig{
+		Dir:          cfg.Tools.Plugins.Dir,
+		Timeout:      time.Duration(cfg.Tools.Plugins.Timeout) * time.Second,
+		StartTimeout: time.Duration(cfg.Tools.Plugins.StartTimeout) * time.Second,
+		MaxRestarts:  cfg.Tools.Plugins.MaxRestarts,
+		Workspace:    toolEnv.Workspace.Root(),
+	})
+	defer plugins.Close()
+	pluginTools, err := plugins.Discover(ctx)
+	if err != nil {
+		log.Printf("加载插件失败: %v", err)
+	}
+	for _, t := range pluginTools {
+		tool.GetRegistry().Register(t)
+	}
 
 	if interactive {
 		runInteractive(ctx, cfg)
@@ -80,21 +164,53 @@ func runInteractive(ctx context.Context, cfg *config.Config) {
 	fmt.Println("  /mode flow    - 切换到多智能体协作模式")
 	fmt.Println("  /mode mcp     - 切换到工具模式")
 	fmt.Println("  /help         - 显示帮助信息")
+	fmt.Println("  /stats        - 显示工具调用统计")
 	fmt.Println("  /exit         - 退出程序")
 	fmt.Println("直接输入任务描述即可开始执行任务")
 	fmt.Println()
 
-	reader := bufio.NewReader(os.Stdin)
+	// 标准输入只由 console 读取，提问和审批的回答与主循环的命令不会互相抢占
+	console := newConsoleInput(os.Stdin)
 	currentMode := mode
 
+	// 任务执行期间智能体的提问在这里回答，问题超时或在别处回答后提示即撤下
+	humans := tool.DefaultHumanBroker()
+	humans.Subscribe(func(q tool.HumanQuestion) {
+		answer, ok := console.Prompt(fmt.Sprintf("\n[智能体提问] %s\n回答> ", q.Question), humans.Done(q.ID))
+		if !ok {
+			fmt.Printf("\n[问题 %s 已结束]\n", q.ID)
+			return
+		}
+		if err := humans.Answer(q.ID, answer); err != nil {
+			log.Printf("提交回答失败: %v", err)
+		}
+	})
+
+	// 需要人工确认的工具调用同样在这里审批，审批超时或已通过 HTTP 处理后提示即撤下
+	approvals := tool.DefaultApprovalBroker()
+	approvals.Subscribe(func(req tool.ApprovalRequest) {
+		text := fmt.Sprintf("\n[需要确认] 工具 %s: %s\n参数: %s\n允许? (y/n)> ", req.Tool, req.Reason, req.Input)
+		answer, ok := console.Prompt(text, approvals.Done(req.ID))
+		if !ok {
+			fmt.Printf("\n[审批 %s 已结束]\n", req.ID)
+			return
+		}
+		answer = strings.ToLower(answer)
+		approval := tool.Approval{Approved: answer == "y" || answer == "yes", By: "repl"}
+		if err := approvals.Decide(req.ID, approval); err != nil {
+			log.Printf("提交审批失败: %v", err)
+		}
+	})
+
 	for {
 		fmt.Print("> ")
-		input, err := reader.ReadString('\n')
+		input, err := console.ReadLine()
 		if err != nil {
-			log.Printf("读取输入失败: %v", err)
-			continue
+			if err != io.EOF {
+				log.Printf("读取输入失败: %v", err)
+			}
+			return
 		}
-		input = strings.TrimSpace(input)
 
 		switch {
 		case input == "/exit":
@@ -102,6 +218,8 @@ func runInteractive(ctx context.Context, cfg *config.Config) {
 			return
 		case input == "/help":
 			printHelp()
+		case input == "/stats":
+			fmt.Print(toolMetrics.String())
 		case strings.HasPrefix(input, "/mode "):
 			newMode := strings.TrimPrefix(input, "/mode ")
 			if newMode == "flow" || newMode == "mcp" {
@@ -141,6 +259,12 @@ func runFlow(ctx context.Context, cfg *config.Config, task string) error {
 	// 创建工具集合
 	tools := tool.NewToolCollection()
 	tool.RegisterDefaultTools()
+	tools.Register("http_request", tool.NewHTTPTool(tool.DefaultHTTPConfig()))
+	tools.Register("web_crawl", tool.NewCrawlTool(nil, tool.DefaultCrawlConfig()))
+	for _, t := range apiTools {
+		tools.Register(t.Name(), t)
+	}
+	plugins.Register(tools)
 
 	// 创建智能体
 	agents := map[string]agent.Agent{
@@ -165,6 +289,45 @@ func runFlow(ctx context.Context, cfg *config.Config, task string) error {
 	return nil
 }
 
+// loadOpenAPITools 根据配置中的 OpenAPI 服务生成工具，加载失败的服务只记录日志
+func loadOpenAPITools(ctx context.Context, cfg *config.Config) []*tool.OpenAPITool {
+	var tools []*tool.OpenAPITool
+	for _, s := range cfg.Tools.OpenAPI.Services {
+		timeout, maxResponse := s.Timeout, s.MaxResponse
+		if timeout <= 0 {
+			timeout = cfg.Tools.OpenAPI.Timeout
+		}
+		if maxResponse <= 0 {
+			maxResponse = cfg.Tools.OpenAPI.MaxResponse
+		}
+		loaded, err := tool.LoadOpenAPITools(ctx, tool.OpenAPIServiceConfig{
+			Name:       s.Name,
+			Spec:       s.Spec,
+			BaseURL:    s.BaseURL,
+			Operations: s.Operations,
+			Exclude:    s.Exclude,
+			Headers:    s.Headers,
+			Auth: tool.OpenAPIAuth{
+				Type:     s.Auth.Type,
+				Token:    s.Auth.Token,
+				Username: s.Auth.Username,
+				Password: s.Auth.Password,
+				Name:     s.Auth.Name,
+				In:       s.Auth.In,
+			},
+			Timeout:     time.Duration(timeout) * time.Second,
+			MaxResponse: maxResponse,
+		})
+		if err != nil {
+			log.Printf("加载 OpenAPI 服务 %s 失败: %v", s.Name, err)
+			continue
+		}
+		log.Printf("OpenAPI 服务 %s 生成 %d 个工具", s.Name, len(loaded))
+		tools = append(tools, loaded...)
+	}
+	return tools
+}
+
 // runMCP 运行 MCP 服务
 func runMCP(ctx context.Context, cfg *config.Config, task string) error {
 	// TODO: 实现 MCP 服务启动逻辑
@@ -4,23 +4,43 @@ import (
 	"context"
 	"flag"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 
+	"github.com/openmanus/openmanus-go/internal/bootstrap"
+	"github.com/openmanus/openmanus-go/internal/config"
 	"github.com/openmanus/openmanus-go/internal/mcp"
 )
 
 var (
-	port    = flag.Int("port", 8080, "MCP 服务端口")
-	timeout = flag.Int("timeout", 30, "执行超时时间(秒)")
-	debug   = flag.Bool("debug", false, "是否开启调试模式")
+	configFile = flag.String("config", "config/config.toml", "配置文件路径")
+	port       = flag.Int("port", 0, "MCP 服务端口，默认使用配置中的 server.port")
+	timeout    = flag.Int("timeout", 30, "执行超时时间(秒)")
+	debug      = flag.Bool("debug", false, "是否开启调试模式")
 )
 
 func Run() error {
 	// 解析命令行参数
 	flag.Parse()
 
+	cfg, err := config.LoadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if *port == 0 {
+		*port = cfg.Server.Port
+	}
+
+	// 审批和回答接口在本进程提供，权限策略和中间件也必须在本进程生效
+	toolEnv, err := bootstrap.SetupTools(cfg)
+	if err != nil {
+		return err
+	}
+	defer toolEnv.Close()
+
 	// 创建上下文
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -35,7 +55,10 @@ func Run() error {
 	}()
 
 	// 创建 MCP 服务
-	server := mcp.NewServer()
+	server := mcp.NewServer(mcp.ServerConfig{
+		Addr:   net.JoinHostPort(cfg.Server.Host, strconv.Itoa(*port)),
+		Tokens: cfg.Server.Tokens,
+	})
 
 	// 启动服务
 	log.Printf("MCP 服务启动中... 监听端口: %d", *port)
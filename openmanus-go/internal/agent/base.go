@@ -7,9 +7,12 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/openmanus/openmanus-go/internal/config"
 	"github.com/openmanus/openmanus-go/internal/llm"
+	"github.com/openmanus/openmanus-go/internal/sandbox"
 	"github.com/openmanus/openmanus-go/internal/tool"
 )
 
@@ -86,7 +89,9 @@ type Agent interface {
 
 // BaseAgent 通用基础智能体结构体
 type BaseAgent struct {
-	Name        string
+	Name string
+	// SessionID 智能体的会话 ID，用于从沙箱管理器分配该会话独占的沙箱
+	SessionID   string
 	Description string
 	State       AgentState
 	MaxSteps    int
@@ -98,6 +103,9 @@ type BaseAgent struct {
 	startTime   time.Time
 	endTime     time.Time
 	lastError   error
+	// finalStatus 和 finalAnswer 由 terminate 工具设置
+	finalStatus string
+	finalAnswer string
 	llmClient   llm.LLMClient
 	tools       *tool.ToolCollection
 }
@@ -135,38 +143,63 @@ func (a *BaseAgent) Act(ctx context.Context, prompt string) (string, error) {
 	}
 
 	// 3. 解析 LLM 输出
-	action, actionInput, err := a.parseLLMAction(llmResp.Choices[0].Message.Content)
+	calls, err := a.parseLLMActions(llmResp.Choices[0].Message.Content)
 	if err != nil {
 		return "", fmt.Errorf("解析 LLM 输出失败: %v", err)
 	}
 
-	// 4. 查找并调用工具
+	// 4. 查找并调用工具；terminate、ask_human 等工具通过上下文控制智能体状态
+	toolCtx := tool.WithAgentController(ctx, a)
+	if len(calls) > 1 {
+		// 同一轮的多个调用并行执行，结果按调用顺序反馈
+		results := tool.NewBatchExecutor(a.tools, batchConfig()).Execute(toolCtx, calls)
+		if a.IsDone() {
+			return formatBatchResults(results), nil
+		}
+		return a.handleToolResult(ctx, prompt, formatBatchResults(results))
+	}
+	action, actionInput := calls[0].Name, calls[0].Input
 	tool, err := a.tools.Get(action)
 	if err != nil {
 		return "", fmt.Errorf("未找到工具: %s", action)
 	}
 
 	// 5. 执行工具
-	result, err := tool.Run(ctx, actionInput)
+	result, err := tool.Run(toolCtx, actionInput)
 	if err != nil {
 		return "", fmt.Errorf("工具执行失败: %v", err)
 	}
 
-	// 6. 将结果反馈给 LLM，进入下一轮
+	// 6. 将结果反馈给 LLM，进入下一轮；已调用 terminate 时直接返回
 	resultStr, ok := result.(string)
 	if !ok {
 		resultStr = fmt.Sprintf("%v", result)
 	}
+	if a.IsDone() {
+		return resultStr, nil
+	}
 	return a.handleToolResult(ctx, prompt, resultStr)
 }
 
+// toolDescription 返回工具描述，工具未提供描述时返回空字符串
+func toolDescription(tools *tool.ToolCollection, name string) string {
+	t, err := tools.Get(name)
+	if err != nil {
+		return ""
+	}
+	if d, ok := t.(interface{ Description() string }); ok {
+		return d.Description()
+	}
+	return ""
+}
+
 // buildPrompt 构造 LLM prompt
 func (a *BaseAgent) buildPrompt(prompt string) string {
 	// 获取可用工具列表
 	toolList := a.tools.List()
 	toolDescs := make([]string, 0, len(toolList))
-	for name, desc := range toolList {
-		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, desc))
+	for _, name := range toolList {
+		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, toolDescription(a.tools, name)))
 	}
 
 	// 构造 ReAct 风格 prompt
@@ -180,28 +213,41 @@ Thought: 思考下一步行动
 Action: 工具名称
 Action Input: {"参数1": "值1", "参数2": "值2"}
 
+互不依赖的多个调用可以在一次回复中输出多组 Action 和 Action Input，它们会并行执行。
+需要用户补充信息时调用 ask_human 工具；任务完成或无法继续时调用 terminate 工具，
+Action Input 为 {"status": "success 或 failure", "answer": "最终答案"}。
+
 用户输入：%s`, strings.Join(toolDescs, "\n"), prompt)
 }
 
-// parseLLMAction 解析 LLM 输出
-func (a *BaseAgent) parseLLMAction(llmOutput string) (string, map[string]interface{}, error) {
+// parseLLMActions 解析 LLM 输出，一轮中可以包含多组 Action 和 Action Input
+func (a *BaseAgent) parseLLMActions(llmOutput string) ([]tool.BatchCall, error) {
 	// 提取 Action 和 Action Input
 	re := regexp.MustCompile(`Action: (\w+)\nAction Input: (.*)`)
-	matches := re.FindStringSubmatch(llmOutput)
-	if len(matches) < 3 {
-		return "", nil, fmt.Errorf("无法解析 LLM 输出: %s", llmOutput)
+	matches := re.FindAllStringSubmatch(llmOutput, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("无法解析 LLM 输出: %s", llmOutput)
 	}
 
-	action := matches[1]
-	actionInput := matches[2]
-
-	// 解析 JSON 参数
-	var params map[string]interface{}
-	if err := json.Unmarshal([]byte(actionInput), &params); err != nil {
-		return "", nil, fmt.Errorf("解析工具参数失败: %v", err)
+	calls := make([]tool.BatchCall, 0, len(matches))
+	for i, m := range matches {
+		// 解析 JSON 参数
+		var params map[string]interface{}
+		if err := json.Unmarshal([]byte(m[2]), &params); err != nil {
+			return nil, fmt.Errorf("解析工具 %s 的参数失败: %v", m[1], err)
+		}
+		calls = append(calls, tool.BatchCall{ID: fmt.Sprint(i + 1), Name: m[1], Input: params})
 	}
+	return calls, nil
+}
 
-	return action, params, nil
+// formatBatchResults 按调用顺序拼接多个工具的结果
+func formatBatchResults(results []tool.BatchResult) string {
+	parts := make([]string, 0, len(results))
+	for _, r := range results {
+		parts = append(parts, fmt.Sprintf("[%s] %s:\n%s", r.ID, r.Name, r))
+	}
+	return strings.Join(parts, "\n\n")
 }
 
 // handleToolResult 处理工具执行结果
@@ -254,10 +300,19 @@ func (a *BaseAgent) IsStuck(duplicateThreshold int) bool {
 	return false
 }
 
-// NewBaseAgent 创建基础智能体
+// NewBaseAgent 创建基础智能体，工具集合中缺少 terminate、ask_human 时自动注册
 func NewBaseAgent(name string, llmClient llm.LLMClient, tools *tool.ToolCollection) *BaseAgent {
+	if tools != nil {
+		if _, err := tools.Get("terminate"); err != nil {
+			tools.Register("terminate", tool.NewTerminateTool())
+		}
+		if _, err := tools.Get("ask_human"); err != nil {
+			tools.Register("ask_human", tool.NewAskHumanTool(nil, askHumanTimeout()))
+		}
+	}
 	return &BaseAgent{
 		Name:        name,
+		SessionID:   newSessionID(name),
 		State:       Idle,
 		MaxSteps:    10,
 		CurrentStep: 0,
@@ -269,6 +324,43 @@ func NewBaseAgent(name string, llmClient llm.LLMClient, tools *tool.ToolCollecti
 	}
 }
 
+// sessionSeq 会话 ID 的序号
+var sessionSeq atomic.Int64
+
+// newSessionID 生成进程内唯一的会话 ID
+func newSessionID(name string) string {
+	return fmt.Sprintf("%s-%d", name, sessionSeq.Add(1))
+}
+
+// sessionSandbox 返回会话在全局沙箱管理器中的沙箱，未设置管理器时返回 nil，由工具自行选择沙箱
+func sessionSandbox(sessionID string) sandbox.Sandbox {
+	if m := sandbox.DefaultManager(); m != nil {
+		return m.Session(sessionID)
+	}
+	return nil
+}
+
+// batchConfig 返回配置中并行执行工具调用的参数
+func batchConfig() tool.BatchConfig {
+	appCfg := config.GetConfig()
+	if appCfg == nil {
+		return tool.BatchConfig{}
+	}
+	return tool.BatchConfig{
+		MaxConcurrent: appCfg.Agents.MaxConcurrent,
+		Exclusive:     appCfg.Agents.ExclusiveTools,
+	}
+}
+
+// askHumanTimeout 返回配置中等待人工回答的时间
+func askHumanTimeout() time.Duration {
+	appCfg := config.GetConfig()
+	if appCfg == nil {
+		return 0
+	}
+	return time.Duration(appCfg.Tools.AskHuman.Timeout) * time.Second
+}
+
 // NewMemory 创建内存
 func NewMemory() *Memory {
 	return &Memory{
@@ -331,12 +423,15 @@ func (a *BaseAgent) SetLifecycle(lifecycle *AgentLifecycle) {
 	a.lifecycle = lifecycle
 }
 
-// EmitEvent 发送事件
+// EmitEvent 发送事件，通道已满时丢弃，避免在持有锁时阻塞状态转换
 func (a *BaseAgent) EmitEvent(eventType string, data interface{}) {
-	a.eventChan <- AgentEvent{
+	select {
+	case a.eventChan <- AgentEvent{
 		Type:      eventType,
 		Timestamp: time.Now(),
 		Data:      data,
+	}:
+	default:
 	}
 }
 
@@ -443,6 +538,46 @@ func (a *BaseAgent) SetState(state AgentState) error {
 	return nil
 }
 
+// Pause 暂停运行中的智能体，实现 tool.AgentController
+func (a *BaseAgent) Pause() error {
+	return a.SetState(Paused)
+}
+
+// Resume 恢复暂停的智能体，经 Resuming 回到 Running
+func (a *BaseAgent) Resume() error {
+	if err := a.SetState(Resuming); err != nil {
+		return err
+	}
+	return a.SetState(Running)
+}
+
+// Finish 记录最终结果；智能体运行中时转为 Finished
+func (a *BaseAgent) Finish(status, answer string) error {
+	a.mu.Lock()
+	a.finalStatus = status
+	a.finalAnswer = answer
+	running := a.State == Running
+	a.mu.Unlock()
+	if !running {
+		return nil
+	}
+	return a.SetState(Finished)
+}
+
+// IsDone 是否已通过 terminate 工具结束
+func (a *BaseAgent) IsDone() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.finalStatus != ""
+}
+
+// FinalAnswer 返回 terminate 工具设置的状态和最终答案
+func (a *BaseAgent) FinalAnswer() (string, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.finalStatus, a.finalAnswer
+}
+
 // GetState 获取状态（线程安全）
 func (a *BaseAgent) GetState() AgentState {
 	a.mu.Lock()
@@ -501,6 +636,13 @@ func (a *BaseAgent) Run(ctx context.Context) error {
 				Role:    "assistant",
 				Content: result,
 			})
+			// terminate 工具已结束智能体
+			if a.IsDone() {
+				if a.GetState() == Finished {
+					return nil
+				}
+				return a.SetState(Finished)
+			}
 			// 检查是否卡住
 			if a.IsStuck(2) {
 				return a.SetState(Finished)
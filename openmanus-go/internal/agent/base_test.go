@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openmanus/openmanus-go/internal/llm"
+	"github.com/openmanus/openmanus-go/internal/tool"
+)
+
+// scriptedLLM 依次返回预设回复的 LLM 客户端，回复用完后重复最后一条
+type scriptedLLM struct {
+	mu      sync.Mutex
+	replies []string
+	prompts []string
+}
+
+func (s *scriptedLLM) GetCompletion(messages []llm.Message) (*llm.CompletionResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prompts = append(s.prompts, messages[len(messages)-1].Content)
+	reply := s.replies[0]
+	if len(s.replies) > 1 {
+		s.replies = s.replies[1:]
+	}
+	var resp llm.CompletionResponse
+	data, _ := json.Marshal(map[string]interface{}{
+		"choices": []interface{}{map[string]interface{}{"message": llm.Message{Role: "assistant", Content: reply}}},
+	})
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Prompts 返回收到的 prompt
+func (s *scriptedLLM) Prompts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.prompts...)
+}
+
+// action 构造 LLM 的工具调用回复
+func action(name, input string) string {
+	return "Thought: 下一步\nAction: " + name + "\nAction Input: " + input
+}
+
+// newRunningAgent 创建处于运行状态的基础智能体
+func newRunningAgent(t *testing.T, client llm.LLMClient, tools *tool.ToolCollection) *BaseAgent {
+	t.Helper()
+	a := NewBaseAgent("test", client, tools)
+	if err := a.SetState(Initializing); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetState(Running); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestAgentTerminateFinishes(t *testing.T) {
+	client := &scriptedLLM{replies: []string{action("terminate", `{"status": "success", "answer": "42"}`)}}
+	a := newRunningAgent(t, client, tool.NewToolCollection())
+
+	out, err := a.Act(context.Background(), "计算答案")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "42" {
+		t.Fatalf("output = %q", out)
+	}
+	if state := a.GetState(); state != Finished {
+		t.Fatalf("state = %v, want Finished", state)
+	}
+	if status, answer := a.FinalAnswer(); status != tool.TerminateSuccess || answer != "42" {
+		t.Fatalf("final answer = %q, %q", status, answer)
+	}
+	// terminate 之后不再把结果反馈给 LLM
+	if n := len(client.Prompts()); n != 1 {
+		t.Fatalf("LLM called %d times", n)
+	}
+}
+
+func TestAgentAskHumanPausesUntilAnswered(t *testing.T) {
+	broker := tool.NewHumanBroker()
+	tools := tool.NewToolCollection()
+	tools.Register("ask_human", tool.NewAskHumanTool(broker, time.Minute))
+	client := &scriptedLLM{replies: []string{
+		action("ask_human", `{"question": "喜欢什么颜色？"}`),
+		action("terminate", `{"answer": "蓝色"}`),
+	}}
+	a := newRunningAgent(t, client, tools)
+
+	stateWhileAsking := make(chan AgentState, 1)
+	broker.Subscribe(func(q tool.HumanQuestion) {
+		stateWhileAsking <- a.GetState()
+		broker.Answer(q.ID, "蓝色")
+	})
+
+	out, err := a.Act(context.Background(), "问用户")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := <-stateWhileAsking; state != Paused {
+		t.Fatalf("state while asking = %v, want Paused", state)
+	}
+	if out != "蓝色" || a.GetState() != Finished {
+		t.Fatalf("output = %q, state = %v", out, a.GetState())
+	}
+	// 回答作为工具结果反馈给 LLM
+	prompts := client.Prompts()
+	if len(prompts) < 2 || !strings.Contains(prompts[1], "蓝色") {
+		t.Fatalf("answer not fed back to LLM: %q", prompts)
+	}
+}
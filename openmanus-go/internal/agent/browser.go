@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"regexp"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/openmanus/openmanus-go/internal/config"
 	"github.com/openmanus/openmanus-go/internal/llm"
 	"github.com/openmanus/openmanus-go/internal/tool"
 	"github.com/sirupsen/logrus"
@@ -21,12 +23,52 @@ type BrowserAgent struct {
 }
 
 // NewBrowserAgent 创建浏览器智能体
-func NewBrowserAgent(llmClient llm.Client) *BrowserAgent {
+func NewBrowserAgent(llmClient llm.LLMClient) *BrowserAgent {
+	tools := tool.NewToolCollection()
+	// 注册浏览器工具
+	tools.Register("browser_use", tool.NewBrowserTool(browserToolConfig()))
+	tools.Register("http_request", tool.NewHTTPTool(tool.DefaultHTTPConfig()))
+	tools.Register("web_crawl", tool.NewCrawlTool(nil, tool.DefaultCrawlConfig()))
 	return &BrowserAgent{
-		BaseAgent: NewBaseAgent("browser", llmClient, tool.NewToolCollection()),
+		BaseAgent: NewBaseAgent("browser", llmClient, tools),
+		tools:     tools,
 	}
 }
 
+// browserToolConfig 从全局配置构造浏览器工具配置
+func browserToolConfig() tool.BrowserConfig {
+	cfg := tool.DefaultBrowserConfig()
+	appCfg := config.GetConfig()
+	if appCfg == nil {
+		return cfg
+	}
+	b := appCfg.Tools.Browser
+	cfg.ChromePath = b.ChromePath
+	cfg.RemoteURL = b.RemoteURL
+	cfg.Headless = b.Headless
+	cfg.NoSandbox = b.NoSandbox
+	cfg.ExtraArgs = b.ExtraArgs
+	cfg.WindowWidth = b.WindowWidth
+	cfg.WindowHeight = b.WindowHeight
+	cfg.Timeout = time.Duration(b.Timeout) * time.Second
+	cfg.MaxContentLen = b.MaxContentLen
+	cfg.SearchURL = b.SearchURL
+	cfg.WorkspaceDir = b.WorkspaceDir
+	return cfg
+}
+
+// Close 关闭浏览器工具
+func (a *BrowserAgent) Close() error {
+	t, err := a.tools.Get("browser_use")
+	if err != nil {
+		return err
+	}
+	if browser, ok := tool.UnwrapTool(t).(*tool.BrowserTool); ok {
+		return browser.Close()
+	}
+	return nil
+}
+
 // Run 运行智能体
 func (a *BrowserAgent) Run(ctx context.Context) error {
 	hlog.Infof("BrowserAgent 智能体开始运行")
@@ -74,7 +116,16 @@ func (a *BrowserAgent) Act(ctx context.Context, prompt string) (string, error) {
 3. 分析网页结构
 4. 执行网页操作
 
-请根据用户需求，选择合适的工具并执行。`
+可用工具：
+- browser_use: 支持的 action 有 go_to_url、click_element、input_text、scroll_down、scroll_up、
+  scroll_to_text、send_keys、get_dropdown_options、select_dropdown_option、go_back、go_forward、
+  refresh、web_search、wait、extract_content、screenshot、get_state、switch_tab、open_tab、close_tab。
+  元素序号来自最近一次返回的 elements 列表。
+
+请根据用户需求，选择合适的工具并按照以下格式输出：
+Thought: 思考下一步行动
+Action: browser_use
+Action Input: {"action": "go_to_url", "url": "https://example.com"}`
 
 	// 2. 调用 LLM 分析任务
 	llmResp, err := a.llmClient.GetCompletion([]llm.Message{
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/openmanus/openmanus-go/internal/config"
 	"github.com/openmanus/openmanus-go/internal/llm"
 	"github.com/openmanus/openmanus-go/internal/tool"
 	"github.com/sirupsen/logrus"
@@ -19,7 +21,7 @@ type DataAnalysisAgent struct {
 }
 
 // NewDataAnalysisAgent 创建 DataAnalysis 智能体
-func NewDataAnalysisAgent(llmClient llm.Client) *DataAnalysisAgent {
+func NewDataAnalysisAgent(llmClient llm.LLMClient) *DataAnalysisAgent {
 	base := NewBaseAgent("DataAnalysisAgent", llmClient, tool.NewToolCollection())
 	agent := &DataAnalysisAgent{
 		BaseAgent: base,
@@ -29,9 +31,26 @@ func NewDataAnalysisAgent(llmClient llm.Client) *DataAnalysisAgent {
 	// 注册数据分析相关工具
 	agent.tools.Register("python", &tool.AITool{})
 	agent.tools.Register("data_analysis", &tool.DataAnalysisTool{})
+	agent.tools.Register("sql", tool.NewSQLTool(sqlToolConfig(), nil))
+	agent.tools.Register("read_document", tool.NewDocumentTool(nil))
 	return agent
 }
 
+// sqlToolConfig 返回配置中的 SQL 工具参数
+func sqlToolConfig() tool.SQLConfig {
+	appCfg := config.GetConfig()
+	if appCfg == nil {
+		return tool.SQLConfig{}
+	}
+	c := appCfg.Tools.SQL
+	return tool.SQLConfig{
+		AllowWrite:    c.AllowWrite,
+		MaxRows:       c.MaxRows,
+		MaxCellLength: c.MaxCellLength,
+		Timeout:       time.Duration(c.Timeout) * time.Second,
+	}
+}
+
 // Run 运行智能体
 func (a *DataAnalysisAgent) Run(ctx context.Context) error {
 	hlog.Infof("DataAnalysisAgent 智能体开始运行")
@@ -14,7 +14,7 @@ type FlowAgent struct {
 }
 
 // NewFlowAgent 创建工作流智能体
-func NewFlowAgent(llmClient llm.Client, tools *tool.ToolCollection) *FlowAgent {
+func NewFlowAgent(llmClient llm.LLMClient, tools *tool.ToolCollection) *FlowAgent {
 	return &FlowAgent{
 		BaseAgent: NewBaseAgent("flow", llmClient, tools),
 	}
@@ -25,8 +25,8 @@ func (f *FlowAgent) buildPrompt(prompt string) string {
 	// 获取可用工具列表
 	toolList := f.tools.List()
 	toolDescs := make([]string, 0, len(toolList))
-	for name, desc := range toolList {
-		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, desc))
+	for _, name := range toolList {
+		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, toolDescription(f.tools, name)))
 	}
 
 	// 构造工作流风格的 prompt
@@ -272,8 +272,8 @@ func (m *ManusAgent) buildPrompt(prompt string) string {
 	// 获取可用工具列表
 	toolList := m.tools.List()
 	toolDescs := make([]string, 0, len(toolList))
-	for name, desc := range toolList {
-		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, desc))
+	for _, name := range toolList {
+		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, toolDescription(m.tools, name)))
 	}
 
 	// 构造 Manus 风格的 prompt
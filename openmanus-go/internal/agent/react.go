@@ -20,7 +20,7 @@ type ReactAgent struct {
 }
 
 // NewReactAgent 创建 ReAct 智能体
-func NewReactAgent(llmClient llm.Client, tools *tool.ToolCollection) *ReactAgent {
+func NewReactAgent(llmClient llm.LLMClient, tools *tool.ToolCollection) *ReactAgent {
 	return &ReactAgent{
 		BaseAgent: NewBaseAgent("react", llmClient, tools),
 		log:       logger.GetLogger(),
@@ -43,8 +43,8 @@ func (r *ReactAgent) buildPrompt(prompt string) string {
 	// 获取可用工具列表
 	toolList := r.tools.List()
 	toolDescs := make([]string, 0, len(toolList))
-	for name, desc := range toolList {
-		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, desc))
+	for _, name := range toolList {
+		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, toolDescription(r.tools, name)))
 	}
 
 	// 构造 ReAct 风格的 prompt
@@ -86,7 +86,8 @@ func (a *ReactAgent) Act(ctx context.Context, prompt string) (string, error) {
 		},
 	}
 
-	// 3. ReAct 循环
+	// 3. ReAct 循环，terminate、ask_human 等工具通过上下文控制智能体状态
+	toolCtx := tool.WithAgentController(ctx, a)
 	maxSteps := 5 // 最大循环次数
 	for step := 0; step < maxSteps; step++ {
 		// 3.1 思考下一步行动
@@ -112,10 +113,14 @@ func (a *ReactAgent) Act(ctx context.Context, prompt string) (string, error) {
 			return "", fmt.Errorf("未找到工具: %s", action)
 		}
 
-		result, err := tool.Run(ctx, actionInput)
+		result, err := tool.Run(toolCtx, actionInput)
 		if err != nil {
 			return "", fmt.Errorf("工具执行失败: %v", err)
 		}
+		// 调用 terminate 后以其最终答案结束
+		if a.IsDone() {
+			return fmt.Sprintf("%v", result), nil
+		}
 
 		// 3.5 将结果添加到对话历史
 		messages = append(messages, llm.Message{
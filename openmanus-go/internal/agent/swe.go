@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/openmanus/openmanus-go/internal/config"
 	"github.com/openmanus/openmanus-go/internal/llm"
 	"github.com/openmanus/openmanus-go/internal/tool"
 	"github.com/sirupsen/logrus"
@@ -19,20 +21,45 @@ type SWEAgent struct {
 }
 
 // NewSWEAgent 创建 SWE 智能体
-func NewSWEAgent(llmClient llm.Client) *SWEAgent {
+func NewSWEAgent(llmClient llm.LLMClient) *SWEAgent {
 	base := NewBaseAgent("SWEAgent", llmClient, tool.NewToolCollection())
 	agent := &SWEAgent{
 		BaseAgent: base,
 		log:       logrus.New(),
 		tools:     tool.NewToolCollection(),
 	}
-	// 注册代码相关工具
-	agent.tools.Register("shell", &tool.ShellTool{})
+	// 注册代码相关工具，shell 命令在该会话独占的沙箱中执行，沙箱的工作区即文件工具使用的工作区
+	agent.tools.Register("shell", tool.NewShellTool(sessionSandbox(base.SessionID)))
 	agent.tools.Register("file", &tool.FileTool{})
+	// 注册代码检索工具，使用默认工作区
+	agent.tools.Register("glob", tool.NewGlobTool(nil))
+	agent.tools.Register("grep", tool.NewGrepTool(nil))
+	agent.tools.Register("go_symbols", tool.NewGoSymbolTool(nil))
+	// 注册补丁工具，应用模型生成的统一 diff
+	agent.tools.Register("patch", tool.NewPatchTool(nil))
+	// 注册 git 工具，仅操作本地仓库
+	agent.tools.Register("git", tool.NewGitTool(gitToolConfig(), nil))
 	// TODO: 可扩展注册 PythonExecute、StrReplaceEditor 等
 	return agent
 }
 
+// gitToolConfig 从全局配置构造 git 工具配置
+func gitToolConfig() tool.GitConfig {
+	appCfg := config.GetConfig()
+	if appCfg == nil {
+		return tool.GitConfig{}
+	}
+	g := appCfg.Tools.Git
+	return tool.GitConfig{
+		AllowHistoryRewrite: g.AllowHistoryRewrite,
+		AuthorName:          g.AuthorName,
+		AuthorEmail:         g.AuthorEmail,
+		EnableHooks:         g.EnableHooks,
+		Timeout:             time.Duration(g.Timeout) * time.Second,
+		MaxOutput:           g.MaxOutput,
+	}
+}
+
 // Run 运行智能体
 func (a *SWEAgent) Run(ctx context.Context) error {
 	hlog.Infof("SWEAgent 智能体开始运行")
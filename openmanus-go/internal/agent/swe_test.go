@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openmanus/openmanus-go/internal/sandbox"
+	"github.com/openmanus/openmanus-go/internal/tool"
+)
+
+// TestMain 测试程序同样作为命名空间沙箱的初始化进程重新执行
+func TestMain(m *testing.M) {
+	sandbox.Init()
+	os.Exit(m.Run())
+}
+
+func TestSWEAgentShellSharesToolWorkspace(t *testing.T) {
+	ws, err := tool.NewWorkspace(tool.DefaultWorkspaceConfig(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool.SetDefaultWorkspace(ws)
+	m, err := sandbox.NewManager(sandbox.ManagerConfig{
+		Workspace: ws.Root(),
+		Namespace: sandbox.DefaultNamespaceConfig(),
+		Limits:    sandbox.ResourceLimits{TimeLimit: 30},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sandbox.SetDefaultManager(m)
+	t.Cleanup(func() {
+		sandbox.SetDefaultManager(nil)
+		m.Close()
+	})
+
+	// 文件工具写入的文件在会话沙箱中可见，shell 写入的文件在工作区中可见；
+	// 反馈工具结果后的回复作为下一轮 Act 的输入再次调用 LLM，因此后续每步的回复出现两次
+	shell := action("shell", `{"command": "cat notes/todo.txt && echo done > notes/status.txt"}`)
+	read := action("file", `{"action": "read", "path": "notes/status.txt"}`)
+	client := &scriptedLLM{replies: []string{
+		action("file", `{"action": "write", "path": "notes/todo.txt", "content": "fix the parser"}`),
+		shell, shell,
+		read, read,
+		action("terminate", `{"status": "success", "answer": "ok"}`),
+	}}
+	swe := NewSWEAgent(client)
+	a := newRunningAgent(t, client, swe.tools)
+	if _, err := a.Act(context.Background(), "整理待办"); err != nil {
+		t.Fatal(err)
+	}
+	prompts := client.Prompts()
+	if len(prompts) != 7 {
+		t.Fatalf("LLM called %d times", len(prompts))
+	}
+	if !strings.Contains(prompts[3], "fix the parser") || !strings.Contains(prompts[3], "[exit code 0]") {
+		t.Fatalf("shell result not fed back:\n%s", prompts[3])
+	}
+	if !strings.Contains(prompts[5], "done") {
+		t.Fatalf("file read result not fed back:\n%s", prompts[5])
+	}
+
+	// 释放会话的沙箱不清空工具工作区
+	m.Release(swe.SessionID)
+	if _, err := os.Stat(filepath.Join(ws.Root(), "notes", "todo.txt")); err != nil {
+		t.Fatalf("workspace cleared on release: %v", err)
+	}
+}
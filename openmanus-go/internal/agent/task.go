@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/openmanus/openmanus-go/internal/config"
 	"github.com/openmanus/openmanus-go/internal/llm"
 	"github.com/openmanus/openmanus-go/internal/tool"
 )
@@ -13,20 +14,35 @@ type TaskAgent struct {
 	*BaseAgent
 }
 
-// NewTaskAgent 创建任务分解智能体
-func NewTaskAgent(llmClient llm.Client, tools *tool.ToolCollection) *TaskAgent {
+// NewTaskAgent 创建任务分解智能体，未提供 planning 工具时自动注册
+func NewTaskAgent(llmClient llm.LLMClient, tools *tool.ToolCollection) *TaskAgent {
+	if tools == nil {
+		tools = tool.NewToolCollection()
+	}
+	if _, err := tools.Get("planning"); err != nil {
+		tools.Register("planning", tool.NewPlanningTool(planStoreDir()))
+	}
 	return &TaskAgent{
 		BaseAgent: NewBaseAgent("task", llmClient, tools),
 	}
 }
 
+// planStoreDir 返回配置中的计划持久化目录
+func planStoreDir() string {
+	appCfg := config.GetConfig()
+	if appCfg == nil {
+		return ""
+	}
+	return appCfg.Tools.Planning.StoreDir
+}
+
 // buildPrompt 定制任务分解智能体的 prompt
 func (t *TaskAgent) buildPrompt(prompt string) string {
 	// 获取可用工具列表
 	toolList := t.tools.List()
 	toolDescs := make([]string, 0, len(toolList))
-	for name, desc := range toolList {
-		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, desc))
+	for _, name := range toolList {
+		toolDescs = append(toolDescs, fmt.Sprintf("- %s: %s", name, toolDescription(t.tools, name)))
 	}
 
 	// 构造任务分解风格的 prompt
@@ -35,8 +51,14 @@ func (t *TaskAgent) buildPrompt(prompt string) string {
 可用工具：
 %s
 
+任务分析得到的子任务使用 planning 工具保存：先用 create 命令创建计划（steps 为子任务列表），
+开始执行子任务前用 mark_step 标记为 in_progress，完成后标记为 completed 并在 step_notes 中记录结果，
+无法继续时标记为 blocked 并说明原因。
+
 请按照以下格式输出：
 Task Analysis: 分析任务并列出子任务
+Action: planning
+Action Input: {"command": "create", "title": "任务标题", "steps": ["子任务1", "子任务2"]}
 Current Task: 当前要执行的子任务
 Action: 工具名称
 Action Input: {"参数1": "值1", "参数2": "值2"}
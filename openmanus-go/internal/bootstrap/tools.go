@@ -0,0 +1,143 @@
+// Package bootstrap 按配置初始化各个入口共用的运行环境
+package bootstrap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openmanus/openmanus-go/internal/config"
+	"github.com/openmanus/openmanus-go/internal/tool"
+)
+
+// ToolEnv 工具调用的运行环境
+type ToolEnv struct {
+	Workspace *tool.Workspace
+	Policy    *tool.PolicyEngine
+	Audit     *tool.AuditLog
+	Metrics   *tool.ToolMetrics
+}
+
+// SetupTools 按配置设置工具包的默认工作区、权限策略、中间件、结果缓存和 HTTP、爬取配置；
+// 权限策略的 ask 决策交给 DefaultApprovalBroker，可通过命令行或 HTTP 审批接口处理。
+// 处理审批的进程必须先调用 SetupTools，之后创建的工具集合才会经过策略检查
+func SetupTools(cfg *config.Config) (*ToolEnv, error) {
+	workspace, err := tool.NewWorkspace(tool.WorkspaceConfig{
+		Root:     cfg.Tools.Workspace.Root,
+		ReadOnly: cfg.Tools.Workspace.ReadOnly,
+		Deny:     cfg.Tools.Workspace.Deny,
+		TrashDir: cfg.Tools.Workspace.TrashDir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("初始化工作区失败: %w", err)
+	}
+	tool.SetDefaultWorkspace(workspace)
+
+	// 工具权限策略：所有决策写入审计日志
+	auditLog, err := tool.NewAuditLog(cfg.Tools.Policy.AuditLog)
+	if err != nil {
+		return nil, fmt.Errorf("初始化审计日志失败: %w", err)
+	}
+	policy, err := tool.NewPolicyEngine(tool.PolicyConfig{
+		Default:         cfg.Tools.Policy.Default,
+		Rules:           policyRules(cfg.Tools.Policy.Rules),
+		Approver:        tool.DefaultApprovalBroker(),
+		ApprovalTimeout: time.Duration(cfg.Tools.Policy.ApprovalTimeout) * time.Second,
+		Audit:           auditLog,
+		Workspace:       workspace,
+	})
+	if err != nil {
+		auditLog.Close()
+		return nil, fmt.Errorf("初始化工具权限策略失败: %w", err)
+	}
+
+	// 所有工具调用统一经过日志、权限策略、超时、截断等中间件
+	metrics := tool.NewToolMetrics()
+	timeouts := make(map[string]time.Duration, len(cfg.Tools.Middleware.Timeouts))
+	for name, sec := range cfg.Tools.Middleware.Timeouts {
+		timeouts[name] = time.Duration(sec) * time.Second
+	}
+	tool.SetDefaultMiddleware(tool.StandardMiddleware(tool.MiddlewareConfig{
+		Timeout:   time.Duration(cfg.Tools.Middleware.Timeout) * time.Second,
+		Timeouts:  timeouts,
+		MaxOutput: cfg.Tools.Middleware.MaxOutput,
+		Metrics:   metrics,
+		Policy:    policy,
+	})...)
+
+	// 幂等工具的结果缓存，每个工具集合各自一份内存缓存，持久缓存目录共享
+	tool.SetDefaultResultCacheConfig(tool.ResultCacheConfig{
+		Disabled:   !cfg.Tools.Cache.Enabled,
+		TTL:        time.Duration(cfg.Tools.Cache.TTL) * time.Second,
+		MaxEntries: cfg.Tools.Cache.MaxEntries,
+		Dir:        cfg.Tools.Cache.Dir,
+	})
+
+	// 出站 HTTP 请求的主机限制和认证配置
+	tool.SetDefaultHTTPConfig(httpConfig(cfg))
+
+	// 网站爬取的上限和请求间隔
+	tool.SetDefaultCrawlConfig(tool.CrawlConfig{
+		MaxDepth:    cfg.Tools.Crawl.MaxDepth,
+		MaxPages:    cfg.Tools.Crawl.MaxPages,
+		MaxBytes:    cfg.Tools.Crawl.MaxBytes,
+		MaxPageText: cfg.Tools.Crawl.MaxPageText,
+		Delay:       time.Duration(cfg.Tools.Crawl.Delay * float64(time.Second)),
+		UserAgent:   cfg.Tools.Crawl.UserAgent,
+	})
+
+	return &ToolEnv{Workspace: workspace, Policy: policy, Audit: auditLog, Metrics: metrics}, nil
+}
+
+// Close 关闭审计日志
+func (e *ToolEnv) Close() error {
+	return e.Audit.Close()
+}
+
+// httpConfig 转换配置中的 HTTP 工具设置
+func httpConfig(cfg *config.Config) tool.HTTPConfig {
+	h := cfg.Tools.HTTP
+	auth := make(map[string]tool.HTTPAuthProfile, len(h.Auth))
+	for name, a := range h.Auth {
+		auth[name] = tool.HTTPAuthProfile{
+			HTTPAuth: tool.HTTPAuth{
+				Type:     a.Type,
+				Token:    a.Token,
+				Username: a.Username,
+				Password: a.Password,
+				Name:     a.Name,
+				In:       a.In,
+			},
+			Hosts: a.Hosts,
+		}
+	}
+	return tool.HTTPConfig{
+		Hosts: tool.HostPolicy{
+			Allow:        h.Allow,
+			Deny:         h.Deny,
+			BlockPrivate: h.BlockPrivate,
+		},
+		Auth:        auth,
+		Headers:     h.Headers,
+		Timeout:     time.Duration(h.Timeout) * time.Second,
+		MaxResponse: h.MaxResponse,
+	}
+}
+
+// policyRules 转换配置中的策略规则
+func policyRules(rules []config.PolicyRule) []tool.PolicyRule {
+	out := make([]tool.PolicyRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, tool.PolicyRule{
+			Name:             r.Name,
+			Tool:             r.Tool,
+			Action:           r.Action,
+			Command:          r.Command,
+			Args:             r.Args,
+			Paths:            r.Paths,
+			OutsideWorkspace: r.OutsideWorkspace,
+			Hosts:            r.Hosts,
+			Reason:           r.Reason,
+		})
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openmanus/openmanus-go/internal/config"
+	"github.com/openmanus/openmanus-go/internal/tool"
+)
+
+// echoTool 返回输入的工具
+type echoTool struct{ name string }
+
+func (t *echoTool) Name() string { return t.name }
+
+func (t *echoTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	return input, nil
+}
+
+func TestSetupTools(t *testing.T) {
+	dir := t.TempDir()
+	auditPath := filepath.Join(dir, "audit.jsonl")
+	configPath := filepath.Join(dir, "config.toml")
+	data := `
+[tools.workspace]
+root = "` + filepath.ToSlash(dir) + `"
+
+[tools.policy]
+audit_log = "` + filepath.ToSlash(auditPath) + `"
+approval_timeout = 5
+
+[[tools.policy.rules]]
+name = "confirm-deploy"
+tool = "deploy"
+action = "ask"
+
+[[tools.policy.rules]]
+name = "no-drop"
+tool = "drop"
+action = "deny"
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := SetupTools(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+	defer tool.SetDefaultMiddleware()
+	if env.Workspace.Root() != dir {
+		t.Fatalf("workspace root = %s", env.Workspace.Root())
+	}
+
+	// 之后创建的工具集合经过策略检查，ask 决策交给默认审批中心
+	tc := tool.NewToolCollection()
+	for _, name := range []string{"deploy", "drop", "echo"} {
+		tc.Register(name, &echoTool{name: name})
+	}
+	run := func(name string) (interface{}, error) {
+		tl, err := tc.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tl.Run(context.Background(), "v1")
+	}
+
+	if out, err := run("echo"); err != nil || out != "v1" {
+		t.Fatalf("echo = %v, %v", out, err)
+	}
+	if _, err := run("drop"); !errors.Is(err, tool.ErrPolicyDenied) {
+		t.Fatalf("drop = %v", err)
+	}
+
+	type result struct {
+		out interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := run("deploy")
+		done <- result{out, err}
+	}()
+	broker := tool.DefaultApprovalBroker()
+	var pending []tool.ApprovalRequest
+	for deadline := time.Now().Add(5 * time.Second); len(pending) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("approval request not submitted")
+		}
+		time.Sleep(5 * time.Millisecond)
+		pending = broker.Pending()
+	}
+	if pending[0].Tool != "deploy" || pending[0].Rule != "confirm-deploy" {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if err := broker.Decide(pending[0].ID, tool.Approval{Approved: true, By: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-done; r.err != nil || r.out != "v1" {
+		t.Fatalf("deploy = %v, %v", r.out, r.err)
+	}
+
+	// 指标和审计日志记录全部调用
+	if s := env.Metrics.Snapshot(); s["echo"].Calls != 1 || s["drop"].Errors != 1 || s["deploy"].Calls != 1 {
+		t.Fatalf("metrics = %+v", s)
+	}
+	logData, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outcomes []string
+	for _, line := range strings.Split(strings.TrimSpace(string(logData)), "\n") {
+		var record tool.AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		outcomes = append(outcomes, record.Tool+":"+record.Decision+":"+record.Approver)
+	}
+	if got := strings.Join(outcomes, ","); got != "echo:allow:,drop:deny:,deploy:ask:alice" {
+		t.Fatalf("audit = %s", got)
+	}
+}
+
+func TestSetupToolsInvalidPolicy(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.Tools.Workspace.Root = dir
+	cfg.Tools.Policy.AuditLog = filepath.Join(dir, "audit.jsonl")
+	cfg.Tools.Policy.Rules = []config.PolicyRule{{Name: "bad", Action: "block"}}
+	if _, err := SetupTools(cfg); !errors.Is(err, tool.ErrInvalidArgs) {
+		t.Fatalf("got %v, want ErrInvalidArgs", err)
+	}
+}
@@ -26,6 +26,50 @@ type LLMConfig struct {
 	Temperature float64     `mapstructure:"temperature"`
 }
 
+// OpenAPIService 通过 OpenAPI 规范生成工具的服务
+type OpenAPIService struct {
+	Name        string            `mapstructure:"name"`
+	Spec        string            `mapstructure:"spec"`
+	BaseURL     string            `mapstructure:"base_url"`
+	Operations  []string          `mapstructure:"operations"`
+	Exclude     []string          `mapstructure:"exclude"`
+	Headers     map[string]string `mapstructure:"headers"`
+	Timeout     int               `mapstructure:"timeout"`
+	MaxResponse int               `mapstructure:"max_response"`
+	Auth        struct {
+		Type     string `mapstructure:"type"`
+		Token    string `mapstructure:"token"`
+		Username string `mapstructure:"username"`
+		Password string `mapstructure:"password"`
+		Name     string `mapstructure:"name"`
+		In       string `mapstructure:"in"`
+	} `mapstructure:"auth"`
+}
+
+// PolicyRule 工具权限策略规则
+type PolicyRule struct {
+	Name             string            `mapstructure:"name"`
+	Tool             string            `mapstructure:"tool"`
+	Action           string            `mapstructure:"action"`
+	Command          string            `mapstructure:"command"`
+	Args             map[string]string `mapstructure:"args"`
+	Paths            []string          `mapstructure:"paths"`
+	OutsideWorkspace bool              `mapstructure:"outside_workspace"`
+	Hosts            []string          `mapstructure:"hosts"`
+	Reason           string            `mapstructure:"reason"`
+}
+
+// HTTPAuthProfile HTTP 工具的命名认证配置
+type HTTPAuthProfile struct {
+	Type     string   `mapstructure:"type"`
+	Token    string   `mapstructure:"token"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	Name     string   `mapstructure:"name"`
+	In       string   `mapstructure:"in"`
+	Hosts    []string `mapstructure:"hosts"`
+}
+
 // Config 应用配置结构
 type Config struct {
 	// 服务配置
@@ -33,6 +77,8 @@ type Config struct {
 		Port    int    `mapstructure:"port"`
 		Host    string `mapstructure:"host"`
 		Timeout int    `mapstructure:"timeout"`
+		// Tokens 操作者名称到访问令牌，回答问题和审批接口需要
+		Tokens map[string]string `mapstructure:"tokens"`
 	} `mapstructure:"server"`
 
 	// 日志配置
@@ -52,18 +98,146 @@ type Config struct {
 
 	// 工具配置
 	Tools struct {
+		Workspace struct {
+			Root     string   `mapstructure:"root"`
+			ReadOnly []string `mapstructure:"read_only"`
+			Deny     []string `mapstructure:"deny"`
+			TrashDir string   `mapstructure:"trash_dir"`
+		} `mapstructure:"workspace"`
+
 		PythonService struct {
 			URL     string `mapstructure:"url"`
 			Timeout int    `mapstructure:"timeout"`
 		} `mapstructure:"python_service"`
+
+		AskHuman struct {
+			Timeout int `mapstructure:"timeout"`
+		} `mapstructure:"ask_human"`
+
+		Planning struct {
+			StoreDir string `mapstructure:"store_dir"`
+		} `mapstructure:"planning"`
+
+		Git struct {
+			AllowHistoryRewrite bool   `mapstructure:"allow_history_rewrite"`
+			AuthorName          string `mapstructure:"author_name"`
+			AuthorEmail         string `mapstructure:"author_email"`
+			EnableHooks         bool   `mapstructure:"enable_hooks"`
+			Timeout             int    `mapstructure:"timeout"`
+			MaxOutput           int    `mapstructure:"max_output"`
+		} `mapstructure:"git"`
+
+		SQL struct {
+			AllowWrite    bool `mapstructure:"allow_write"`
+			MaxRows       int  `mapstructure:"max_rows"`
+			MaxCellLength int  `mapstructure:"max_cell_length"`
+			Timeout       int  `mapstructure:"timeout"`
+		} `mapstructure:"sql"`
+
+		OpenAPI struct {
+			Timeout     int              `mapstructure:"timeout"`
+			MaxResponse int              `mapstructure:"max_response"`
+			Services    []OpenAPIService `mapstructure:"services"`
+		} `mapstructure:"openapi"`
+
+		Middleware struct {
+			Timeout   int            `mapstructure:"timeout"`
+			Timeouts  map[string]int `mapstructure:"timeouts"`
+			MaxOutput int            `mapstructure:"max_output"`
+		} `mapstructure:"middleware"`
+
+		Policy struct {
+			Default         string       `mapstructure:"default"`
+			AuditLog        string       `mapstructure:"audit_log"`
+			ApprovalTimeout int          `mapstructure:"approval_timeout"`
+			Rules           []PolicyRule `mapstructure:"rules"`
+		} `mapstructure:"policy"`
+
+		HTTP struct {
+			Timeout      int                        `mapstructure:"timeout"`
+			MaxResponse  int                        `mapstructure:"max_response"`
+			Allow        []string                   `mapstructure:"allow"`
+			Deny         []string                   `mapstructure:"deny"`
+			BlockPrivate bool                       `mapstructure:"block_private"`
+			Headers      map[string]string          `mapstructure:"headers"`
+			Auth         map[string]HTTPAuthProfile `mapstructure:"auth"`
+		} `mapstructure:"http"`
+
+		Crawl struct {
+			MaxDepth    int     `mapstructure:"max_depth"`
+			MaxPages    int     `mapstructure:"max_pages"`
+			MaxBytes    int     `mapstructure:"max_bytes"`
+			MaxPageText int     `mapstructure:"max_page_text"`
+			Delay       float64 `mapstructure:"delay"`
+			UserAgent   string  `mapstructure:"user_agent"`
+		} `mapstructure:"crawl"`
+
+		Cache struct {
+			Enabled    bool   `mapstructure:"enabled"`
+			TTL        int    `mapstructure:"ttl"`
+			MaxEntries int    `mapstructure:"max_entries"`
+			Dir        string `mapstructure:"dir"`
+		} `mapstructure:"cache"`
+
+		Plugins struct {
+			Dir          string `mapstructure:"dir"`
+			Timeout      int    `mapstructure:"timeout"`
+			StartTimeout int    `mapstructure:"start_timeout"`
+			MaxRestarts  int    `mapstructure:"max_restarts"`
+		} `mapstructure:"plugins"`
+
+		Browser struct {
+			ChromePath    string   `mapstructure:"chrome_path"`
+			RemoteURL     string   `mapstructure:"remote_url"`
+			Headless      bool     `mapstructure:"headless"`
+			NoSandbox     bool     `mapstructure:"no_sandbox"`
+			ExtraArgs     []string `mapstructure:"extra_args"`
+			WindowWidth   int      `mapstructure:"window_width"`
+			WindowHeight  int      `mapstructure:"window_height"`
+			Timeout       int      `mapstructure:"timeout"`
+			MaxContentLen int      `mapstructure:"max_content_length"`
+			SearchURL     string   `mapstructure:"search_url"`
+			WorkspaceDir  string   `mapstructure:"workspace_dir"`
+		} `mapstructure:"browser"`
 	} `mapstructure:"tools"`
 
 	// 智能体配置
 	Agents struct {
-		MaxConcurrent int `mapstructure:"max_concurrent"`
-		Timeout       int `mapstructure:"timeout"`
+		MaxConcurrent  int      `mapstructure:"max_concurrent"`
+		Timeout        int      `mapstructure:"timeout"`
+		ExclusiveTools []string `mapstructure:"exclusive_tools"`
 	} `mapstructure:"agents"`
 
+	// 沙箱配置；命名空间沙箱和 seccomp 过滤要求程序在 main 开头调用 sandbox.Init()
+	Sandbox struct {
+		MaxSandboxes    int    `mapstructure:"max_sandboxes"`
+		MaxIdle         int    `mapstructure:"max_idle"`
+		IdleTimeout     int    `mapstructure:"idle_timeout"`
+		CleanupInterval int    `mapstructure:"cleanup_interval"`
+		Isolation       string `mapstructure:"isolation"`
+		WorkspaceRoot   string `mapstructure:"workspace_root"`
+		// IsolatedWorkspace 各沙箱使用独立的临时工作区，否则共用工具工作区
+		IsolatedWorkspace bool `mapstructure:"isolated_workspace"`
+		Network           bool `mapstructure:"network"`
+		CgroupMoveSelf    bool `mapstructure:"cgroup_move_self"`
+
+		// 沙箱中每条命令的资源限制，0 表示不限制该项
+		Limits struct {
+			TimeLimit    int     `mapstructure:"time_limit"`
+			CPULimit     float64 `mapstructure:"cpu_limit"`
+			MemoryLimit  int64   `mapstructure:"memory_limit"`
+			DiskLimit    int64   `mapstructure:"disk_limit"`
+			ProcessLimit int     `mapstructure:"process_limit"`
+		} `mapstructure:"limits"`
+
+		Seccomp struct {
+			Profile string   `mapstructure:"profile"`
+			Allow   []string `mapstructure:"allow"`
+			Deny    []string `mapstructure:"deny"`
+			Action  string   `mapstructure:"action"`
+		} `mapstructure:"seccomp"`
+	} `mapstructure:"sandbox"`
+
 	// LLM 配置
 	LLM LLMConfig `mapstructure:"llm"`
 }
@@ -137,12 +311,76 @@ func setDefaults() {
 	v.SetDefault("mcp.retry_delay", 5)
 
 	// 工具默认配置
+	v.SetDefault("tools.workspace.root", "workspace")
+	v.SetDefault("tools.workspace.read_only", []string{".git"})
+	v.SetDefault("tools.workspace.deny", []string{".env", "*.pem", "*.key", ".ssh"})
+	v.SetDefault("tools.workspace.trash_dir", ".trash")
 	v.SetDefault("tools.python_service.timeout", 30)
+	v.SetDefault("tools.ask_human.timeout", 300)
+	v.SetDefault("tools.planning.store_dir", "data/plans")
+	v.SetDefault("tools.git.allow_history_rewrite", false)
+	v.SetDefault("tools.git.enable_hooks", false)
+	v.SetDefault("tools.git.timeout", 60)
+	v.SetDefault("tools.git.max_output", 102400)
+	v.SetDefault("tools.sql.allow_write", false)
+	v.SetDefault("tools.sql.max_rows", 100)
+	v.SetDefault("tools.sql.max_cell_length", 200)
+	v.SetDefault("tools.sql.timeout", 30)
+	v.SetDefault("tools.openapi.timeout", 30)
+	v.SetDefault("tools.openapi.max_response", 20000)
+	v.SetDefault("tools.middleware.timeout", 120)
+	v.SetDefault("tools.middleware.timeouts", map[string]int{"ask_human": 0})
+	v.SetDefault("tools.middleware.max_output", 50000)
+	v.SetDefault("tools.policy.default", "allow")
+	v.SetDefault("tools.policy.audit_log", "logs/tool_audit.jsonl")
+	v.SetDefault("tools.policy.approval_timeout", 300)
+	v.SetDefault("tools.http.timeout", 30)
+	v.SetDefault("tools.http.max_response", 100000)
+	v.SetDefault("tools.http.deny", []string{"169.254.169.254", "metadata.google.internal"})
+	v.SetDefault("tools.http.block_private", true)
+	v.SetDefault("tools.crawl.max_depth", 2)
+	v.SetDefault("tools.crawl.max_pages", 20)
+	v.SetDefault("tools.crawl.max_bytes", 2097152)
+	v.SetDefault("tools.crawl.max_page_text", 5000)
+	v.SetDefault("tools.crawl.delay", 1.0)
+	v.SetDefault("tools.crawl.user_agent", "OpenManus-Go/1.0")
+	v.SetDefault("tools.cache.enabled", true)
+	v.SetDefault("tools.cache.ttl", 300)
+	v.SetDefault("tools.cache.max_entries", 256)
+	v.SetDefault("tools.cache.dir", "")
+	v.SetDefault("tools.plugins.dir", "plugins")
+	v.SetDefault("tools.plugins.timeout", 60)
+	v.SetDefault("tools.plugins.start_timeout", 10)
+	v.SetDefault("tools.plugins.max_restarts", 5)
+	v.SetDefault("tools.browser.headless", true)
+	v.SetDefault("tools.browser.window_width", 1280)
+	v.SetDefault("tools.browser.window_height", 800)
+	v.SetDefault("tools.browser.timeout", 30)
+	v.SetDefault("tools.browser.max_content_length", 20000)
+	v.SetDefault("tools.browser.search_url", "https://www.bing.com/search?q=%s")
+	v.SetDefault("tools.browser.workspace_dir", "workspace")
 
 	// 智能体默认配置
 	v.SetDefault("agents.max_concurrent", 5)
+	v.SetDefault("agents.exclusive_tools", []string{"ask_human"})
 	v.SetDefault("agents.timeout", 300)
 
+	// 沙箱默认值
+	v.SetDefault("sandbox.max_sandboxes", 100)
+	v.SetDefault("sandbox.max_idle", 4)
+	v.SetDefault("sandbox.idle_timeout", 3600)
+	v.SetDefault("sandbox.cleanup_interval", 300)
+	v.SetDefault("sandbox.isolation", "auto")
+	v.SetDefault("sandbox.isolated_workspace", false)
+	v.SetDefault("sandbox.cgroup_move_self", false)
+	v.SetDefault("sandbox.limits.time_limit", 600)
+	v.SetDefault("sandbox.limits.cpu_limit", 200)
+	v.SetDefault("sandbox.limits.memory_limit", 4096)
+	v.SetDefault("sandbox.limits.disk_limit", 10240)
+	v.SetDefault("sandbox.limits.process_limit", 1024)
+	v.SetDefault("sandbox.seccomp.profile", "default")
+	v.SetDefault("sandbox.seccomp.action", "kill")
+
 	// LLM 默认配置
 	v.SetDefault("llm.provider", ProviderOpenAI)
 	v.SetDefault("llm.model", "gpt-4")
@@ -2,29 +2,55 @@ package mcp
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/openmanus/openmanus-go/internal/agent"
+	"github.com/openmanus/openmanus-go/internal/tool"
 	"github.com/openmanus/openmanus-go/pkg/logger"
 	"github.com/sirupsen/logrus"
 )
 
+// 默认只监听本机
+const defaultServerAddr = "127.0.0.1:8080"
+
+// operatorKey 请求上下文中已认证的操作者名称
+const operatorKey = "operator"
+
+// ServerConfig MCP 服务端配置
+type ServerConfig struct {
+	// Addr 监听地址，默认 127.0.0.1:8080
+	Addr string
+	// Tokens 操作者名称到访问令牌的映射；问题回答和审批接口要求 "Authorization: Bearer <令牌>"，
+	// 未配置令牌时这些接口一律拒绝
+	Tokens map[string]string
+}
+
 // Server MCP 服务端
 type Server struct {
-	mcp   *agent.MCPAgent
-	log   *logrus.Logger
-	hertz *server.Hertz
+	mcp    *agent.MCPAgent
+	log    *logrus.Logger
+	hertz  *server.Hertz
+	addr   string
+	tokens map[string]string
 }
 
 // NewServer 创建 MCP 服务端
-func NewServer() *Server {
-	h := server.New(server.WithHostPorts(":8080"))
+func NewServer(config ServerConfig) *Server {
+	if config.Addr == "" {
+		config.Addr = defaultServerAddr
+	}
+	h := server.New(server.WithHostPorts(config.Addr))
 	s := &Server{
-		mcp:   agent.NewMCPAgent(),
-		log:   logger.GetLogger(),
-		hertz: h,
+		mcp:    agent.NewMCPAgent(),
+		log:    logger.GetLogger(),
+		hertz:  h,
+		addr:   config.Addr,
+		tokens: config.Tokens,
 	}
 	s.routes()
 	return s
@@ -32,11 +58,39 @@ func NewServer() *Server {
 
 func (s *Server) routes() {
 	s.hertz.POST("/run", s.handleRun)
+	// 回答问题和审批工具调用会直接影响智能体的行为，需要认证
+	operator := s.hertz.Group("", s.authenticate)
+	// ask_human 工具的问题列表和回答入口
+	operator.GET("/human/questions", s.handleQuestions)
+	operator.POST("/human/answer", s.handleAnswer)
+	// 权限策略中需要人工确认的工具调用
+	operator.GET("/approvals", s.handleApprovals)
+	operator.POST("/approvals/decide", s.handleDecide)
+}
+
+// authenticate 校验 Bearer 令牌，并把令牌对应的操作者名称记录到请求上下文
+func (s *Server) authenticate(c context.Context, ctx *app.RequestContext) {
+	if len(s.tokens) == 0 {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, map[string]string{"error": "未配置访问令牌，接口已禁用"})
+		return
+	}
+	token, ok := strings.CutPrefix(string(ctx.GetHeader("Authorization")), "Bearer ")
+	if ok && token != "" {
+		for name, want := range s.tokens {
+			if want != "" && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1 {
+				ctx.Set(operatorKey, name)
+				ctx.Next(c)
+				return
+			}
+		}
+	}
+	ctx.Header("WWW-Authenticate", "Bearer")
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{"error": "访问令牌无效"})
 }
 
 // Run 启动 HTTP 服务
 func (s *Server) Run(ctx context.Context) error {
-	s.log.Info("MCP Hertz HTTP 服务启动中... 监听 :8080")
+	s.log.Infof("MCP Hertz HTTP 服务启动中... 监听 %s", s.addr)
 	s.hertz.Spin()
 	return nil
 }
@@ -49,3 +103,56 @@ func (s *Server) handleRun(c context.Context, ctx *app.RequestContext) {
 	}()
 	ctx.JSON(http.StatusOK, map[string]string{"status": "MCP 智能体已启动"})
 }
+
+// handleQuestions 返回等待人工回答的问题
+func (s *Server) handleQuestions(c context.Context, ctx *app.RequestContext) {
+	ctx.JSON(http.StatusOK, map[string]interface{}{"questions": tool.DefaultHumanBroker().Pending()})
+}
+
+// answerRequest 人工回答请求
+type answerRequest struct {
+	ID     string `json:"id"`
+	Answer string `json:"answer"`
+}
+
+// handleAnswer 回答 ask_human 工具提出的问题，恢复等待中的智能体
+func (s *Server) handleAnswer(c context.Context, ctx *app.RequestContext) {
+	var req answerRequest
+	if err := json.Unmarshal(ctx.Request.Body(), &req); err != nil || req.ID == "" {
+		ctx.JSON(http.StatusBadRequest, map[string]string{"error": "请求体需要包含 id 和 answer"})
+		return
+	}
+	if err := tool.DefaultHumanBroker().Answer(req.ID, req.Answer); err != nil {
+		ctx.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, map[string]string{"status": "已回答"})
+}
+
+// handleApprovals 返回等待审批的工具调用
+func (s *Server) handleApprovals(c context.Context, ctx *app.RequestContext) {
+	ctx.JSON(http.StatusOK, map[string]interface{}{"approvals": tool.DefaultApprovalBroker().Pending()})
+}
+
+// decideRequest 审批请求，审批人取自访问令牌
+type decideRequest struct {
+	ID       string `json:"id"`
+	Approved bool   `json:"approved"`
+	Note     string `json:"note"`
+}
+
+// handleDecide 批准或拒绝工具调用
+func (s *Server) handleDecide(c context.Context, ctx *app.RequestContext) {
+	var req decideRequest
+	if err := json.Unmarshal(ctx.Request.Body(), &req); err != nil || req.ID == "" {
+		ctx.JSON(http.StatusBadRequest, map[string]string{"error": "请求体需要包含 id 和 approved"})
+		return
+	}
+	by := "http:" + ctx.GetString(operatorKey)
+	approval := tool.Approval{Approved: req.Approved, By: by, Note: req.Note}
+	if err := tool.DefaultApprovalBroker().Decide(req.ID, approval); err != nil {
+		ctx.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, map[string]string{"status": "已处理"})
+}
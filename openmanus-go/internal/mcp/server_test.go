@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/openmanus/openmanus-go/internal/bootstrap"
+	"github.com/openmanus/openmanus-go/internal/config"
+	"github.com/openmanus/openmanus-go/internal/tool"
+)
+
+// echoTool 返回输入的工具
+type echoTool struct{ name string }
+
+func (t *echoTool) Name() string { return t.name }
+
+func (t *echoTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	return input, nil
+}
+
+func TestApprovalEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	auditPath := filepath.Join(dir, "audit.jsonl")
+	cfg := &config.Config{}
+	cfg.Tools.Workspace.Root = dir
+	cfg.Tools.Policy.AuditLog = auditPath
+	cfg.Tools.Policy.ApprovalTimeout = 5
+	cfg.Tools.Policy.Rules = []config.PolicyRule{{Name: "confirm-deploy", Tool: "deploy", Action: tool.PolicyAsk}}
+	env, err := bootstrap.SetupTools(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Close()
+	defer tool.SetDefaultMiddleware()
+
+	s := NewServer(ServerConfig{Addr: "127.0.0.1:0", Tokens: map[string]string{"alice": "secret"}})
+	auth := ut.Header{Key: "Authorization", Value: "Bearer secret"}
+	jsonType := ut.Header{Key: "Content-Type", Value: "application/json"}
+
+	if w := ut.PerformRequest(s.hertz.Engine, http.MethodGet, "/approvals", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated status = %d", w.Code)
+	}
+
+	// 工具调用等待审批，直到通过 HTTP 接口批准或拒绝
+	tc := tool.NewToolCollection()
+	tc.Register("deploy", &echoTool{name: "deploy"})
+	deploy, err := tc.Get("deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type result struct {
+		out interface{}
+		err error
+	}
+	call := func() chan result {
+		done := make(chan result, 1)
+		go func() {
+			out, err := deploy.Run(context.Background(), "v1")
+			done <- result{out, err}
+		}()
+		return done
+	}
+	pendingID := func() string {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			w := ut.PerformRequest(s.hertz.Engine, http.MethodGet, "/approvals", nil, auth)
+			if w.Code != http.StatusOK {
+				t.Fatalf("list status = %d: %s", w.Code, w.Body.Bytes())
+			}
+			var body struct {
+				Approvals []tool.ApprovalRequest `json:"approvals"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Approvals) > 0 {
+				if body.Approvals[0].Tool != "deploy" || body.Approvals[0].Rule != "confirm-deploy" {
+					t.Fatalf("approval = %+v", body.Approvals[0])
+				}
+				return body.Approvals[0].ID
+			}
+		}
+		t.Fatal("approval request not listed")
+		return ""
+	}
+	decide := func(body string) int {
+		return ut.PerformRequest(s.hertz.Engine, http.MethodPost, "/approvals/decide",
+			&ut.Body{Body: strings.NewReader(body), Len: len(body)}, auth, jsonType).Code
+	}
+
+	done := call()
+	id := pendingID()
+	if code := decide(`{"id": "` + id + `", "approved": true}`); code != http.StatusOK {
+		t.Fatalf("approve status = %d", code)
+	}
+	if r := <-done; r.err != nil || r.out != "v1" {
+		t.Fatalf("approved call = %v, %v", r.out, r.err)
+	}
+	// 已处理的审批不能再次决定
+	if code := decide(`{"id": "` + id + `", "approved": false}`); code != http.StatusNotFound {
+		t.Fatalf("repeated decision status = %d", code)
+	}
+
+	done = call()
+	id = pendingID()
+	if code := decide(`{"id": "` + id + `", "approved": false, "note": "freeze"}`); code != http.StatusOK {
+		t.Fatalf("reject status = %d", code)
+	}
+	if r := <-done; !errors.Is(r.err, tool.ErrPolicyDenied) || !strings.Contains(r.err.Error(), "freeze") {
+		t.Fatalf("rejected call = %v", r.err)
+	}
+	if code := decide(`{"approved": true}`); code != http.StatusBadRequest {
+		t.Fatalf("missing id status = %d", code)
+	}
+
+	// 审计日志记录通过 HTTP 审批的操作者
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var approvers []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record tool.AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		approvers = append(approvers, record.Outcome+":"+record.Approver)
+	}
+	if got := strings.Join(approvers, ","); got != "approved:http:alice,rejected:http:alice" {
+		t.Fatalf("audit = %s", got)
+	}
+}
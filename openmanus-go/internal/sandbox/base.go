@@ -2,10 +2,11 @@ package sandbox
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
-	"runtime"
-	"syscall"
+	"sync"
 	"time"
 )
 
@@ -23,19 +24,62 @@ type ResourceLimits struct {
 	TimeLimit int
 }
 
-// DefaultResourceLimits 默认资源限制
+// DefaultResourceLimits 内置的默认资源限制，足够运行 go build、JVM、Node 等工具链
 var DefaultResourceLimits = ResourceLimits{
-	CPULimit:     50.0, // 50% CPU
-	MemoryLimit:  512,  // 512MB
-	DiskLimit:    1024, // 1GB
-	ProcessLimit: 10,   // 最多10个进程
-	TimeLimit:    30,   // 30秒
+	CPULimit:     200.0, // 两个核
+	MemoryLimit:  4096,  // 4GB
+	DiskLimit:    10240, // 单个文件 10GB
+	ProcessLimit: 1024,  // 1024 个进程或线程
+	TimeLimit:    600,   // 10 分钟
+}
+
+var (
+	defaultLimits   = DefaultResourceLimits
+	defaultLimitsMu sync.RWMutex
+)
+
+// SetDefaultLimits 设置新建沙箱和管理器使用的资源限制
+func SetDefaultLimits(limits ResourceLimits) {
+	defaultLimitsMu.Lock()
+	defer defaultLimitsMu.Unlock()
+	defaultLimits = limits
+}
+
+// DefaultLimits 返回新建沙箱和管理器使用的资源限制，未设置时为 DefaultResourceLimits
+func DefaultLimits() ResourceLimits {
+	defaultLimitsMu.RLock()
+	defer defaultLimitsMu.RUnlock()
+	return defaultLimits
+}
+
+var (
+	cgroupMoveSelf   bool
+	cgroupMoveSelfMu sync.RWMutex
+)
+
+// SetCgroupMoveSelf 设置当前进程所在的 cgroup 中有其他进程、无法启用子树控制器时，
+// 是否把整个进程移入子 cgroup "openmanus" 后重试。默认关闭，此时退回 setrlimit；
+// 只在 Linux 上生效，需要在第一次执行命令之前调用
+func SetCgroupMoveSelf(enabled bool) {
+	cgroupMoveSelfMu.Lock()
+	defer cgroupMoveSelfMu.Unlock()
+	cgroupMoveSelf = enabled
+}
+
+// CgroupMoveSelf 返回是否允许把当前进程移入子 cgroup
+func CgroupMoveSelf() bool {
+	cgroupMoveSelfMu.RLock()
+	defer cgroupMoveSelfMu.RUnlock()
+	return cgroupMoveSelf
 }
 
 // Sandbox 定义沙箱接口
 type Sandbox interface {
-	// Execute 在沙箱中执行命令
+	// Execute 在沙箱中执行命令，返回合并的标准输出和标准错误
 	Execute(ctx context.Context, cmd string) (string, error)
+	// Run 在沙箱中执行命令，返回分开的标准输出和标准错误、退出码和资源使用情况；
+	// 命令已执行时即使返回错误结果也不为 nil
+	Run(ctx context.Context, cmd string, opts ExecOptions) (*ExecResult, error)
 	// SetResourceLimits 设置资源限制
 	SetResourceLimits(limits ResourceLimits)
 	// GetResourceLimits 获取资源限制
@@ -44,13 +88,17 @@ type Sandbox interface {
 
 // BaseSandbox 基础沙箱实现
 type BaseSandbox struct {
-	limits ResourceLimits
+	limits  ResourceLimits
+	seccomp *SeccompProfile
+	// dir 命令的工作目录，为空时使用当前目录
+	dir string
 }
 
-// NewBaseSandbox 创建基础沙箱
+// NewBaseSandbox 创建基础沙箱，使用 DefaultLimits 中的资源限制和 DefaultSeccomp 中的系统调用过滤
 func NewBaseSandbox() *BaseSandbox {
 	return &BaseSandbox{
-		limits: DefaultResourceLimits,
+		limits:  DefaultLimits(),
+		seccomp: DefaultSeccomp(),
 	}
 }
 
@@ -64,52 +112,171 @@ func (s *BaseSandbox) GetResourceLimits() ResourceLimits {
 	return s.limits
 }
 
+// SetSeccompProfile 设置系统调用过滤，nil 表示不过滤；只在 Linux 上生效，
+// 程序需要在 main 开头调用 Init
+func (s *BaseSandbox) SetSeccompProfile(p *SeccompProfile) {
+	s.seccomp = p
+}
+
+// GetSeccompProfile 获取系统调用过滤配置
+func (s *BaseSandbox) GetSeccompProfile() *SeccompProfile {
+	return s.seccomp
+}
+
+// 资源限制的实现方式
+const (
+	LimitCgroup = "cgroup" // cgroup v2：cpu.max、memory.max、pids.max
+	LimitRlimit = "rlimit" // setrlimit：RLIMIT_NPROC、RLIMIT_CPU、RLIMIT_FSIZE，不限制内存
+	LimitNone   = "none"   // 当前平台不限制资源
+)
+
+// 资源限制项，用于 ExecResult.Unenforced
+const (
+	ResourceCPU     = "cpu"
+	ResourceMemory  = "memory"
+	ResourceDisk    = "disk"
+	ResourceProcess = "process"
+)
+
+// 触发的资源限制
+const (
+	ViolationTimeout = "timeout" // 超过执行时间限制
+	ViolationOOM     = "oom"     // 超过内存限制被终止
+	ViolationCPU     = "cpu"     // 超过 CPU 时间限制（SIGXCPU）
+	ViolationPids    = "pids"    // 进程数达到上限，创建进程失败
+	ViolationFsize   = "fsize"   // 写入的文件超过磁盘限制（SIGXFSZ）
+	ViolationSeccomp = "seccomp" // 调用了 seccomp 配置禁止的系统调用被终止（SIGSYS）
+)
+
+// ExecResult 一次执行的结果
+type ExecResult struct {
+	// Output 按到达顺序合并的标准输出和标准错误
+	Output string
+	Stdout string
+	Stderr string
+	// StdoutTruncated、StderrTruncated 输出超过 ExecOptions.MaxOutput 被截断
+	StdoutTruncated bool
+	StderrTruncated bool
+	// ExitCode 退出码，命令被信号终止或未能启动时为 -1
+	ExitCode int
+	// Signal 终止命令的信号名，例如 SIGKILL；正常退出或平台不支持时为空
+	Signal   string
+	Duration time.Duration
+	// LimitMechanism 实际使用的资源限制方式
+	LimitMechanism string
+	// Unenforced 已配置但当前限制方式无法施加的资源限制，例如 setrlimit 下的内存限制
+	Unenforced []string
+	// Violation 触发的资源限制，未触发时为空
+	Violation string
+	// PeakMemory 内存峰值（字节），无法获取时为 0
+	PeakMemory int64
+}
+
+// limiter 一次执行的资源限制，由各平台实现
+type limiter interface {
+	mechanism() string
+	// unenforced 已配置但没有施加的资源限制项
+	unenforced() []string
+	// start 启动命令，需要时把进程放入限制组
+	start(cmd *exec.Cmd) error
+	// inspect 命令结束后检查是否触发了限制
+	inspect(res *ExecResult, state *os.ProcessState)
+	// cleanup 结束残留进程并释放限制组
+	cleanup()
+}
+
 // Execute 在沙箱中执行命令
 func (s *BaseSandbox) Execute(ctx context.Context, cmd string) (string, error) {
+	res, err := s.Run(ctx, cmd, ExecOptions{})
+	if res == nil {
+		return "", err
+	}
+	return res.Output, err
+}
+
+// execHooks 在基础执行流程上定制命令，例如把命令放入隔离的命名空间
+type execHooks interface {
+	// prepare 在设置资源限制之后、启动之前修改命令
+	prepare(cmd *exec.Cmd) error
+	// started 命令启动之后调用，无论是否启动成功
+	started()
+	// finish 命令结束后调用，可以补充违规原因或替换返回的错误；state 在命令未启动时为 nil
+	finish(res *ExecResult, state *os.ProcessState, err error) error
+}
+
+// Run 在沙箱中执行命令，返回输出、退出码、资源限制方式和触发的限制
+func (s *BaseSandbox) Run(ctx context.Context, cmd string, opts ExecOptions) (*ExecResult, error) {
+	return s.run(ctx, cmd, opts, s.execHooks())
+}
+
+// run 执行命令，hooks 为 nil 时直接在宿主上执行
+func (s *BaseSandbox) run(ctx context.Context, cmd string, opts ExecOptions, hooks execHooks) (*ExecResult, error) {
 	// 创建带超时的上下文
-	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(s.limits.TimeLimit)*time.Second)
-	defer cancel()
-
-	// 根据操作系统创建命令
-	var execCmd *exec.Cmd
-	switch runtime.GOOS {
-	case "linux":
-		execCmd = exec.CommandContext(timeoutCtx, "bash", "-c", cmd)
-	case "windows":
-		execCmd = exec.CommandContext(timeoutCtx, "cmd", "/C", cmd)
-	default:
-		return "", fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+	timeoutCtx := ctx
+	if s.limits.TimeLimit > 0 {
+		var cancel context.CancelFunc
+		timeoutCtx, cancel = context.WithTimeout(ctx, time.Duration(s.limits.TimeLimit)*time.Second)
+		defer cancel()
 	}
 
-	// 设置资源限制
-	if err := s.setResourceLimits(execCmd); err != nil {
-		return "", fmt.Errorf("设置资源限制失败: %v", err)
+	execCmd, err := shellCommand(timeoutCtx, cmd)
+	if err != nil {
+		return nil, err
 	}
 
-	// 执行命令并获取输出
-	output, err := execCmd.CombinedOutput()
+	execCmd.Dir = s.dir
+
+	// 设置资源限制
+	lim, err := s.newLimiter(execCmd)
 	if err != nil {
-		return string(output), fmt.Errorf("命令执行失败: %v", err)
+		return nil, fmt.Errorf("设置资源限制失败: %v", err)
+	}
+	defer lim.cleanup()
+	if hooks != nil {
+		if err := hooks.prepare(execCmd); err != nil {
+			return nil, err
+		}
 	}
 
-	return string(output), nil
-}
-
-// setResourceLimits 设置进程资源限制
-func (s *BaseSandbox) setResourceLimits(cmd *exec.Cmd) error {
-	// 根据操作系统设置不同的资源限制
-	switch runtime.GOOS {
-	case "linux":
-		// Linux 系统使用 cgroups 进行资源限制
-		// 这里需要系统管理员权限和 cgroups 支持
-		return nil
-	case "windows":
-		// Windows 系统使用作业对象进行资源限制
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	output := newOutputCapture(opts)
+	execCmd.Stdout = &output.stdout
+	execCmd.Stderr = &output.stderr
+	// 后台进程持有输出管道时不无限等待
+	execCmd.WaitDelay = time.Second
+
+	startTime := time.Now()
+	err = lim.start(execCmd)
+	if hooks != nil {
+		hooks.started()
+	}
+	if err == nil {
+		err = execCmd.Wait()
+	}
+	res := &ExecResult{
+		ExitCode:       -1,
+		Duration:       time.Since(startTime),
+		LimitMechanism: lim.mechanism(),
+		Unenforced:     lim.unenforced(),
+	}
+	output.fill(res)
+	if execCmd.ProcessState != nil {
+		res.ExitCode = execCmd.ProcessState.ExitCode()
+		lim.inspect(res, execCmd.ProcessState)
+	}
+	if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		res.Violation = ViolationTimeout
+	}
+	if hooks != nil {
+		err = hooks.finish(res, execCmd.ProcessState, err)
+	}
+	if err != nil {
+		if res.Violation == ViolationSeccomp {
+			return res, fmt.Errorf("命令执行失败: 调用了 seccomp 配置禁止的系统调用，进程已被终止: %w", err)
+		}
+		if res.Violation != "" {
+			return res, fmt.Errorf("命令执行失败: 超出资源限制 %s: %w", res.Violation, err)
 		}
-		return nil
-	default:
-		return fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+		return res, fmt.Errorf("命令执行失败: %w", err)
 	}
+	return res, nil
 }
@@ -0,0 +1,63 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// HostSandbox 在宿主上以独立的工作区为工作目录执行命令，只有资源限制和 seccomp 过滤，
+// 不隔离文件系统和网络；用于不支持命名空间沙箱的环境
+type HostSandbox struct {
+	*BaseSandbox
+	workspace string
+	// tempWorkspace 工作区为创建时生成的临时目录
+	tempWorkspace bool
+}
+
+// NewHostSandbox 创建宿主沙箱，workspace 为空时使用临时目录，Close 时删除
+func NewHostSandbox(workspace string) (*HostSandbox, error) {
+	s := &HostSandbox{BaseSandbox: NewBaseSandbox()}
+	if workspace == "" {
+		dir, err := os.MkdirTemp("", "openmanus-workspace-")
+		if err != nil {
+			return nil, fmt.Errorf("创建工作区失败: %v", err)
+		}
+		s.workspace = dir
+		s.tempWorkspace = true
+	} else {
+		abs, err := filepath.Abs(workspace)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(abs, 0755); err != nil {
+			return nil, fmt.Errorf("创建工作区失败: %v", err)
+		}
+		s.workspace = abs
+	}
+	s.dir = s.workspace
+	return s, nil
+}
+
+// Execute 在工作区中执行命令
+func (s *HostSandbox) Execute(ctx context.Context, cmd string) (string, error) {
+	res, err := s.Run(ctx, cmd, ExecOptions{})
+	if res == nil {
+		return "", err
+	}
+	return res.Output, err
+}
+
+// Workspace 返回命令的工作目录
+func (s *HostSandbox) Workspace() string {
+	return s.workspace
+}
+
+// Close 删除临时工作区
+func (s *HostSandbox) Close() error {
+	if s.tempWorkspace {
+		return os.RemoveAll(s.workspace)
+	}
+	return nil
+}
@@ -0,0 +1,416 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// cgroup v2 的挂载点
+const cgroupMount = "/sys/fs/cgroup"
+
+// cpu.max 的周期（微秒）
+const cgroupCPUPeriod = 100000
+
+// shellCommand 使用 bash -c 执行命令
+func shellCommand(ctx context.Context, cmd string) (*exec.Cmd, error) {
+	return exec.CommandContext(ctx, "bash", "-c", cmd), nil
+}
+
+// newLimiter 优先为本次执行创建 cgroup v2，不可用时通过 ulimit 设置 setrlimit 限制。
+// setrlimit 没有合适的内存限制：RLIMIT_AS 限制的是虚拟地址空间，Go、JVM、Node 等预留大量地址空间的
+// 运行时在远未用到限制时就无法启动，因此不设置，并在结果中报告内存限制未生效
+func (s *BaseSandbox) newLimiter(cmd *exec.Cmd) (limiter, error) {
+	// 放入新的进程组，超时时结束整个进程组
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	l := &linuxLimiter{limits: s.limits}
+	if cg, err := newExecCgroup(s.limits); err == nil {
+		l.cgroup = cg
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = cg.fd
+		// cgroup 不限制单个文件的大小
+		wrapUlimit(cmd, ulimitArgs(ResourceLimits{DiskLimit: s.limits.DiskLimit}))
+	} else {
+		wrapUlimit(cmd, ulimitArgs(s.limits))
+	}
+	return l, nil
+}
+
+// linuxLimiter cgroup 或 setrlimit 限制
+type linuxLimiter struct {
+	limits ResourceLimits
+	cgroup *execCgroup
+}
+
+func (l *linuxLimiter) mechanism() string {
+	if l.cgroup != nil {
+		return LimitCgroup
+	}
+	return LimitRlimit
+}
+
+func (l *linuxLimiter) unenforced() []string {
+	if l.cgroup != nil {
+		return nil
+	}
+	var missing []string
+	if l.limits.MemoryLimit > 0 {
+		missing = append(missing, ResourceMemory)
+	}
+	// RLIMIT_CPU 按执行时间折算 CPU 秒数，没有执行时间限制时无法折算
+	if l.limits.CPULimit > 0 && l.limits.TimeLimit <= 0 {
+		missing = append(missing, ResourceCPU)
+	}
+	return missing
+}
+
+func (l *linuxLimiter) start(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
+
+func (l *linuxLimiter) inspect(res *ExecResult, state *os.ProcessState) {
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		res.PeakMemory = ru.Maxrss * 1024
+	}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		res.Signal = unix.SignalName(ws.Signal())
+	}
+	// 命令本身或 bash 的子进程收到 SIGXCPU、SIGXFSZ
+	switch exitSignal(state) {
+	case syscall.SIGXCPU:
+		res.Violation = ViolationCPU
+	case syscall.SIGXFSZ:
+		res.Violation = ViolationFsize
+	}
+	if l.cgroup == nil {
+		return
+	}
+	if peak, err := readCgroupInt(l.cgroup.path, "memory.peak"); err == nil {
+		res.PeakMemory = peak
+	}
+	if events, err := readCgroupKeyed(l.cgroup.path, "memory.events"); err == nil && events["oom_kill"] > 0 {
+		res.Violation = ViolationOOM
+	} else if events, err := readCgroupKeyed(l.cgroup.path, "pids.events"); err == nil && events["max"] > 0 && res.Violation == "" {
+		res.Violation = ViolationPids
+	}
+}
+
+func (l *linuxLimiter) cleanup() {
+	if l.cgroup != nil {
+		l.cgroup.remove()
+	}
+}
+
+// ulimitArgs 把进程数、CPU 时间和文件大小限制转换为 bash ulimit 命令，不超过当前的硬限制；
+// 内存限制只由 cgroup 施加
+func ulimitArgs(limits ResourceLimits) []string {
+	var args []string
+	add := func(flag string, resource int, value, unit uint64) {
+		var cur unix.Rlimit
+		if err := unix.Getrlimit(resource, &cur); err == nil && cur.Max != unix.RLIM_INFINITY && value > cur.Max/unit {
+			value = cur.Max / unit
+		}
+		args = append(args, "ulimit "+flag+" "+strconv.FormatUint(value, 10))
+	}
+	if limits.ProcessLimit > 0 {
+		// RLIMIT_NPROC 按用户统计线程数，在当前用户已有的数量上增加
+		add("-u", unix.RLIMIT_NPROC, uint64(userTaskCount()+limits.ProcessLimit), 1)
+	}
+	if limits.CPULimit > 0 && limits.TimeLimit > 0 {
+		// 执行时间内按 CPU 百分比折算的 CPU 秒数
+		secs := uint64(float64(limits.TimeLimit)*limits.CPULimit/100 + 0.999)
+		if secs == 0 {
+			secs = 1
+		}
+		// 软限制发送 SIGXCPU，硬限制留出一秒余量，避免直接被 SIGKILL 而无法区分原因
+		add("-S -t", unix.RLIMIT_CPU, secs, 1)
+		add("-H -t", unix.RLIMIT_CPU, secs+1, 1)
+	}
+	if limits.DiskLimit > 0 {
+		// ulimit -f 的单位为 1024 字节
+		add("-f", unix.RLIMIT_FSIZE, uint64(limits.DiskLimit)*1024, 1024)
+	}
+	return args
+}
+
+// wrapUlimit 先设置限制再 exec 原命令，原命令作为参数传入，避免转义
+func wrapUlimit(cmd *exec.Cmd, args []string) {
+	if len(args) == 0 {
+		return
+	}
+	script := cmd.Args[len(cmd.Args)-1]
+	cmd.Args = []string{"bash", "-c", strings.Join(args, " && ") + ` && exec bash -c "$1"`, "sandbox", script}
+}
+
+// userTaskCount 统计当前用户的线程数
+func userTaskCount() int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0
+	}
+	uid := uint32(os.Getuid())
+	n := 0
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if st, ok := info.Sys().(*syscall.Stat_t); !ok || st.Uid != uid {
+			continue
+		}
+		if tasks, err := os.ReadDir(filepath.Join("/proc", e.Name(), "task")); err == nil {
+			n += len(tasks)
+		}
+	}
+	return n
+}
+
+// execCgroup 一次执行使用的 cgroup
+type execCgroup struct {
+	path string
+	fd   int
+}
+
+var (
+	cgroupParentOnce sync.Once
+	cgroupParentDir  string
+	cgroupParentErr  error
+	cgroupSeq        atomic.Int64
+)
+
+// newExecCgroup 在可用的父 cgroup 下创建子 cgroup 并写入限制
+func newExecCgroup(limits ResourceLimits) (*execCgroup, error) {
+	cgroupParentOnce.Do(func() {
+		cgroupParentDir, cgroupParentErr = findCgroupParent()
+	})
+	if cgroupParentErr != nil {
+		return nil, cgroupParentErr
+	}
+	enabled, err := readCgroupControllers(cgroupParentDir, "cgroup.subtree_control")
+	if err != nil {
+		return nil, err
+	}
+	for _, need := range []struct {
+		controller string
+		set        bool
+	}{
+		{"cpu", limits.CPULimit > 0},
+		{"memory", limits.MemoryLimit > 0},
+		{"pids", limits.ProcessLimit > 0},
+	} {
+		if need.set && !enabled[need.controller] {
+			return nil, fmt.Errorf("cgroup 控制器 %s 不可用", need.controller)
+		}
+	}
+
+	dir := filepath.Join(cgroupParentDir, fmt.Sprintf("sandbox-%d-%d", os.Getpid(), cgroupSeq.Add(1)))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, err
+	}
+	cg := &execCgroup{path: dir, fd: -1}
+	writes := []struct {
+		file, value string
+		set         bool
+	}{
+		{"cpu.max", fmt.Sprintf("%d %d", max(int64(limits.CPULimit*cgroupCPUPeriod/100), 1000), cgroupCPUPeriod), limits.CPULimit > 0},
+		{"memory.max", strconv.FormatInt(limits.MemoryLimit*1024*1024, 10), limits.MemoryLimit > 0},
+		{"pids.max", strconv.Itoa(limits.ProcessLimit), limits.ProcessLimit > 0},
+	}
+	for _, w := range writes {
+		if !w.set {
+			continue
+		}
+		if err := writeCgroupFile(dir, w.file, w.value); err != nil {
+			cg.remove()
+			return nil, err
+		}
+	}
+	if limits.MemoryLimit > 0 {
+		// 禁止使用交换分区绕过内存限制，内核未启用 swap 控制时忽略
+		_ = writeCgroupFile(dir, "memory.swap.max", "0")
+	}
+	fd, err := syscall.Open(dir, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		cg.remove()
+		return nil, err
+	}
+	cg.fd = fd
+	return cg, nil
+}
+
+// findCgroupParent 使用当前进程所在的 cgroup 作为父 cgroup 并启用 cpu、memory、pids 控制器。
+// 非根 cgroup 中有进程时不能启用子树控制器：SetCgroupMoveSelf 开启时把当前进程移入子 cgroup 后重试，
+// 否则返回错误，由调用方退回 setrlimit。移动进程会改变整个服务所在的 cgroup，
+// 可能与 systemd 等外部管理冲突，因此默认不做
+func findCgroupParent() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupMount, "cgroup.controllers")); err != nil {
+		return "", errors.New("cgroup v2 不可用")
+	}
+	// 通过 CLONE_INTO_CGROUP 直接在目标 cgroup 中创建进程需要 5.7 以上的内核
+	if !kernelAtLeast(5, 7) {
+		return "", errors.New("内核不支持 CLONE_INTO_CGROUP")
+	}
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	var own string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			own = strings.TrimPrefix(line, "0::")
+		}
+	}
+	if own == "" {
+		return "", errors.New("未找到当前进程的 cgroup v2 路径")
+	}
+	dir := filepath.Join(cgroupMount, own)
+	err = enableCgroupControllers(dir)
+	if errors.Is(err, syscall.EBUSY) && CgroupMoveSelf() {
+		leaf := filepath.Join(dir, "openmanus")
+		if err := os.Mkdir(leaf, 0755); err != nil && !os.IsExist(err) {
+			return "", err
+		}
+		if err := writeCgroupFile(leaf, "cgroup.procs", strconv.Itoa(os.Getpid())); err != nil {
+			return "", err
+		}
+		err = enableCgroupControllers(dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("启用 cgroup 控制器失败: %v", err)
+	}
+	return dir, nil
+}
+
+// enableCgroupControllers 在 dir 的子树中启用可用的 cpu、memory、pids 控制器
+func enableCgroupControllers(dir string) error {
+	available, err := readCgroupControllers(dir, "cgroup.controllers")
+	if err != nil {
+		return err
+	}
+	var add []string
+	for _, c := range []string{"cpu", "memory", "pids"} {
+		if available[c] {
+			add = append(add, "+"+c)
+		}
+	}
+	if len(add) == 0 {
+		return errors.New("没有可用的 cgroup 控制器")
+	}
+	return writeCgroupFile(dir, "cgroup.subtree_control", strings.Join(add, " "))
+}
+
+// remove 结束 cgroup 中的残留进程并删除 cgroup
+func (cg *execCgroup) remove() {
+	if cg.fd >= 0 {
+		syscall.Close(cg.fd)
+		cg.fd = -1
+	}
+	// cgroup.kill 需要 5.14 以上的内核，不支持时逐个结束进程
+	if err := writeCgroupFile(cg.path, "cgroup.kill", "1"); err != nil {
+		if data, err := os.ReadFile(filepath.Join(cg.path, "cgroup.procs")); err == nil {
+			for _, f := range strings.Fields(string(data)) {
+				if pid, err := strconv.Atoi(f); err == nil {
+					syscall.Kill(pid, syscall.SIGKILL)
+				}
+			}
+		}
+	}
+	// 进程退出后才能删除
+	for i := 0; i < 50; i++ {
+		if err := os.Remove(cg.path); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func writeCgroupFile(dir, name, value string) error {
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(value); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+func readCgroupControllers(dir, name string) (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool)
+	for _, c := range strings.Fields(string(data)) {
+		set[c] = true
+	}
+	return set, nil
+}
+
+func readCgroupInt(dir, name string) (int64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readCgroupKeyed 读取 "key value" 格式的文件，例如 memory.events
+func readCgroupKeyed(dir, name string) (map[string]int64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]int64)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if f := strings.Fields(scanner.Text()); len(f) == 2 {
+			if v, err := strconv.ParseInt(f[1], 10, 64); err == nil {
+				values[f[0]] = v
+			}
+		}
+	}
+	return values, nil
+}
+
+// kernelAtLeast 内核版本是否不低于 major.minor
+func kernelAtLeast(major, minor int) bool {
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err != nil {
+		return false
+	}
+	release := unix.ByteSliceToString(uts.Release[:])
+	parts := strings.SplitN(release, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	maj, _ := strconv.Atoi(parts[0])
+	minStr := parts[1]
+	if i := strings.IndexFunc(minStr, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		minStr = minStr[:i]
+	}
+	mn, _ := strconv.Atoi(minStr)
+	return maj > major || (maj == major && mn >= minor)
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUlimitArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits ResourceLimits
+		want   []string
+		absent []string
+	}{
+		{"memory is not limited by rlimit", ResourceLimits{MemoryLimit: 64}, nil, []string{"ulimit -v"}},
+		{"process", ResourceLimits{ProcessLimit: 5}, []string{"ulimit -u "}, nil},
+		{"cpu needs time limit", ResourceLimits{CPULimit: 50}, nil, []string{"-t"}},
+		{"cpu", ResourceLimits{CPULimit: 50, TimeLimit: 10}, []string{"ulimit -S -t 5", "ulimit -H -t 6"}, nil},
+		{"disk", ResourceLimits{DiskLimit: 2}, []string{"ulimit -f 2048"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := strings.Join(ulimitArgs(tt.limits), "; ")
+			for _, w := range tt.want {
+				if !strings.Contains(args, w) {
+					t.Fatalf("args %q missing %q", args, w)
+				}
+			}
+			for _, a := range tt.absent {
+				if strings.Contains(args, a) {
+					t.Fatalf("args %q contains %q", args, a)
+				}
+			}
+		})
+	}
+}
+
+func TestRlimitFallbackReportsUnenforcedMemory(t *testing.T) {
+	l := &linuxLimiter{limits: ResourceLimits{MemoryLimit: 64, CPULimit: 50, TimeLimit: 10}}
+	if got := l.unenforced(); !slices.Equal(got, []string{ResourceMemory}) {
+		t.Fatalf("unenforced = %v", got)
+	}
+	l.cgroup = &execCgroup{}
+	if got := l.unenforced(); got != nil {
+		t.Fatalf("unenforced with cgroup = %v", got)
+	}
+}
+
+func TestRuntimeStartsUnderMemoryLimit(t *testing.T) {
+	// 预留大量虚拟地址空间的运行时在限制内存时仍能启动
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go not found")
+	}
+	s := NewBaseSandbox()
+	s.SetResourceLimits(ResourceLimits{MemoryLimit: 64, TimeLimit: 30})
+	res, err := s.Run(context.Background(), goBin+" version", ExecOptions{})
+	if err != nil {
+		t.Fatalf("go version under memory limit: %v\n%s", err, res.Output)
+	}
+	if res.LimitMechanism == LimitRlimit && !slices.Contains(res.Unenforced, ResourceMemory) {
+		t.Fatalf("rlimit result does not report memory as unenforced: %+v", res)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+)
+
+// noLimiter 不施加资源限制，只有执行时间限制生效
+type noLimiter struct {
+	limits ResourceLimits
+}
+
+func (noLimiter) mechanism() string                     { return LimitNone }
+func (l noLimiter) unenforced() []string                { return configuredResources(l.limits) }
+func (noLimiter) start(cmd *exec.Cmd) error             { return cmd.Start() }
+func (noLimiter) inspect(*ExecResult, *os.ProcessState) {}
+func (noLimiter) cleanup()                              {}
+
+// configuredResources 返回 limits 中设置了的 CPU、内存、磁盘和进程数限制项
+func configuredResources(limits ResourceLimits) []string {
+	var set []string
+	if limits.CPULimit > 0 {
+		set = append(set, ResourceCPU)
+	}
+	if limits.MemoryLimit > 0 {
+		set = append(set, ResourceMemory)
+	}
+	if limits.DiskLimit > 0 {
+		set = append(set, ResourceDisk)
+	}
+	if limits.ProcessLimit > 0 {
+		set = append(set, ResourceProcess)
+	}
+	return set
+}
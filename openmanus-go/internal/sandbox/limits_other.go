@@ -0,0 +1,18 @@
+//go:build !unix && !windows
+
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+func shellCommand(ctx context.Context, cmd string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+}
+
+func (s *BaseSandbox) newLimiter(cmd *exec.Cmd) (limiter, error) {
+	return nil, fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+}
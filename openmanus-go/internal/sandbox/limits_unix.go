@@ -0,0 +1,23 @@
+//go:build unix && !linux
+
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand 使用 sh -c 执行命令
+func shellCommand(ctx context.Context, cmd string) (*exec.Cmd, error) {
+	return exec.CommandContext(ctx, "sh", "-c", cmd), nil
+}
+
+// newLimiter macOS、BSD 等系统上没有 cgroup，只把命令放入新的进程组，超时时结束整个进程组
+func (s *BaseSandbox) newLimiter(cmd *exec.Cmd) (limiter, error) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return noLimiter{limits: s.limits}, nil
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand 使用 cmd /C 执行命令
+func shellCommand(ctx context.Context, cmd string) (*exec.Cmd, error) {
+	return exec.CommandContext(ctx, "cmd", "/C", cmd), nil
+}
+
+// newLimiter Windows 上只把命令放入新的进程组，资源限制需要作业对象，尚未实现
+func (s *BaseSandbox) newLimiter(cmd *exec.Cmd) (limiter, error) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+	return noLimiter{limits: s.limits}, nil
+}
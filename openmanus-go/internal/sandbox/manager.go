@@ -0,0 +1,652 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 管理器创建的沙箱的隔离方式
+const (
+	IsolationAuto      = "auto"      // 优先使用命名空间沙箱，不支持时退回宿主沙箱
+	IsolationNamespace = "namespace" // 命名空间沙箱
+	IsolationHost      = "host"      // 宿主沙箱，只有资源限制和 seccomp 过滤
+)
+
+// 管理器默认配置，与 Python 版 SandboxManager 一致
+const (
+	defaultMaxSandboxes    = 100
+	defaultMaxIdle         = 4
+	defaultIdleTimeout     = time.Hour
+	defaultCleanupInterval = 5 * time.Minute
+)
+
+var (
+	// ErrManagerClosed 管理器已关闭
+	ErrManagerClosed = errors.New("沙箱管理器已关闭")
+	// ErrSandboxReleased 会话的沙箱已被释放或回收
+	ErrSandboxReleased = errors.New("沙箱已被释放或因空闲被回收")
+)
+
+// ManagerConfig 沙箱管理器配置
+type ManagerConfig struct {
+	// MaxSandboxes 同时存在的沙箱上限，包括等待复用的空闲沙箱，默认 100
+	MaxSandboxes int
+	// MaxIdle 会话释放后保留以供复用的沙箱数，默认 4，负数表示不复用
+	MaxIdle int
+	// IdleTimeout 沙箱超过该时间未执行命令时回收，默认 1 小时
+	IdleTimeout time.Duration
+	// CleanupInterval 检查空闲沙箱的间隔，默认 5 分钟
+	CleanupInterval time.Duration
+	// Isolation 隔离方式，默认 auto；命名空间沙箱要求程序在 main 开头调用 Init
+	Isolation string
+	// Namespace 命名空间沙箱配置，其中的 Workspace 被忽略
+	Namespace NamespaceConfig
+	// Workspace 所有沙箱共用的工作区，通常为工具工作区的根目录：以可写方式挂载为沙箱的工作目录，
+	// 沙箱复用和删除时不清空、不删除。为空时每个沙箱在 WorkspaceRoot 下创建独立的临时工作区
+	Workspace string
+	// WorkspaceRoot 创建各沙箱工作区的目录，为空时使用系统临时目录；设置 Workspace 时不使用
+	WorkspaceRoot string
+	// Limits 沙箱的资源限制，零值时使用 DefaultLimits
+	Limits ResourceLimits
+}
+
+// pooledSandbox 管理器中可以复用的沙箱
+type pooledSandbox interface {
+	Sandbox
+	Close() error
+}
+
+// managedEntry 管理器中的一个沙箱
+type managedEntry struct {
+	id        string
+	sandbox   pooledSandbox
+	workspace string
+	// shared 工作区为 ManagerConfig.Workspace，不属于该沙箱
+	shared bool
+	// session 占用沙箱的会话，空闲时为空
+	session  string
+	lastUsed time.Time
+	// active 正在执行的命令数
+	active int
+	// released 会话已释放，最后一条命令结束后回收；reuse 回收后放回空闲池
+	released bool
+	reuse    bool
+	// removed 已从管理器中移除
+	removed bool
+}
+
+// ManagerStats 管理器统计信息
+type ManagerStats struct {
+	Total        int    `json:"total_sandboxes"`
+	Sessions     int    `json:"sessions"`
+	Idle         int    `json:"idle_sandboxes"`
+	Active       int    `json:"active_operations"`
+	MaxSandboxes int    `json:"max_sandboxes"`
+	Isolation    string `json:"isolation"`
+	Closed       bool   `json:"is_shutting_down"`
+}
+
+// Manager 按会话分配沙箱：限制沙箱总数，会话释放的沙箱清空工作区后复用，
+// 定期回收空闲沙箱；关闭时终止所有正在执行的命令并删除工作区
+type Manager struct {
+	config ManagerConfig
+
+	// ctx 在关闭时取消，终止所有正在执行的命令
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	sessions  map[string]*managedEntry
+	idle      []*managedEntry
+	count     int
+	nextID    int
+	isolation string
+	closed    bool
+
+	// wg 等待正在执行的命令和回收中的沙箱
+	wg   sync.WaitGroup
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewManager 创建沙箱管理器并启动空闲回收
+func NewManager(config ManagerConfig) (*Manager, error) {
+	if config.MaxSandboxes <= 0 {
+		config.MaxSandboxes = defaultMaxSandboxes
+	}
+	if config.MaxIdle == 0 {
+		config.MaxIdle = defaultMaxIdle
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = defaultIdleTimeout
+	}
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = defaultCleanupInterval
+	}
+	if config.Limits == (ResourceLimits{}) {
+		config.Limits = DefaultLimits()
+	}
+	switch config.Isolation {
+	case "":
+		config.Isolation = IsolationAuto
+	case IsolationAuto, IsolationNamespace, IsolationHost:
+	default:
+		return nil, fmt.Errorf("不支持的沙箱隔离方式 %q，可选 auto、namespace、host", config.Isolation)
+	}
+	if config.Workspace != "" {
+		abs, err := filepath.Abs(config.Workspace)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(abs, 0755); err != nil {
+			return nil, fmt.Errorf("创建沙箱工作区失败: %v", err)
+		}
+		config.Workspace = abs
+	}
+	if config.WorkspaceRoot != "" {
+		abs, err := filepath.Abs(config.WorkspaceRoot)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(abs, 0755); err != nil {
+			return nil, fmt.Errorf("创建沙箱工作区目录失败: %v", err)
+		}
+		config.WorkspaceRoot = abs
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		config:    config,
+		ctx:       ctx,
+		cancel:    cancel,
+		sessions:  make(map[string]*managedEntry),
+		isolation: config.Isolation,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go m.cleanupLoop()
+	return m, nil
+}
+
+// Get 返回会话的沙箱：已分配时直接返回，否则优先复用空闲沙箱，再创建新的沙箱
+func (m *Manager) Get(sessionID string) (*ManagedSandbox, error) {
+	if sessionID == "" {
+		return nil, errors.New("会话 ID 不能为空")
+	}
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrManagerClosed
+	}
+	if e, ok := m.sessions[sessionID]; ok {
+		e.lastUsed = time.Now()
+		m.mu.Unlock()
+		return &ManagedSandbox{m: m, e: e, session: sessionID}, nil
+	}
+	if n := len(m.idle); n > 0 {
+		e := m.idle[n-1]
+		m.idle = m.idle[:n-1]
+		m.assign(e, sessionID)
+		m.mu.Unlock()
+		return &ManagedSandbox{m: m, e: e, session: sessionID}, nil
+	}
+	if m.count >= m.config.MaxSandboxes {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("沙箱数量已达上限 (%d)", m.config.MaxSandboxes)
+	}
+	m.count++
+	m.nextID++
+	id := fmt.Sprintf("sandbox-%d", m.nextID)
+	m.mu.Unlock()
+
+	e, err := m.create(id)
+
+	m.mu.Lock()
+	if err != nil {
+		m.count--
+		m.mu.Unlock()
+		return nil, err
+	}
+	if m.closed {
+		e.removed = true
+		m.mu.Unlock()
+		m.destroy(e)
+		return nil, ErrManagerClosed
+	}
+	if existing, ok := m.sessions[sessionID]; ok {
+		// 同一会话并发获取，新建的沙箱在空闲池未满时留作空闲，否则删除
+		existing.lastUsed = time.Now()
+		if len(m.idle) < m.config.MaxIdle {
+			e.lastUsed = time.Now()
+			m.idle = append(m.idle, e)
+			m.mu.Unlock()
+		} else {
+			e.removed = true
+			m.mu.Unlock()
+			m.destroy(e)
+		}
+		return &ManagedSandbox{m: m, e: existing, session: sessionID}, nil
+	}
+	m.assign(e, sessionID)
+	m.mu.Unlock()
+	return &ManagedSandbox{m: m, e: e, session: sessionID}, nil
+}
+
+// assign 把沙箱分配给会话，调用时持有 m.mu
+func (m *Manager) assign(e *managedEntry, sessionID string) {
+	e.session = sessionID
+	e.lastUsed = time.Now()
+	e.released = false
+	m.sessions[sessionID] = e
+}
+
+// Release 会话结束时调用：沙箱在正在执行的命令结束后清空工作区（共用的工作区除外），
+// 放回空闲池供其他会话复用
+func (m *Manager) Release(sessionID string) {
+	m.release(sessionID, true)
+}
+
+// Remove 删除会话的沙箱，不再复用
+func (m *Manager) Remove(sessionID string) {
+	m.release(sessionID, false)
+}
+
+func (m *Manager) release(sessionID string, reuse bool) {
+	m.mu.Lock()
+	e, ok := m.sessions[sessionID]
+	if !ok || m.closed {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.sessions, sessionID)
+	e.released = true
+	e.reuse = reuse
+	recycle := e.active == 0
+	if recycle {
+		m.wg.Add(1)
+	}
+	m.mu.Unlock()
+	if recycle {
+		m.recycle(e)
+	}
+}
+
+// recycle 重置已释放的沙箱并放回空闲池，空闲池已满或管理器已关闭时删除；调用前已 wg.Add
+func (m *Manager) recycle(e *managedEntry) {
+	defer m.wg.Done()
+	if e.reuse && m.config.MaxIdle > 0 {
+		// 共用的工作区保存着工具写入的文件，不清空
+		if !e.shared {
+			if err := clearDir(e.workspace); err != nil {
+				log.Printf("清空沙箱 %s 的工作区失败: %v", e.id, err)
+				e.reuse = false
+			}
+		}
+		e.sandbox.SetResourceLimits(m.config.Limits)
+	}
+	m.mu.Lock()
+	if e.reuse && !m.closed && len(m.idle) < m.config.MaxIdle {
+		e.session = ""
+		e.lastUsed = time.Now()
+		m.idle = append(m.idle, e)
+		m.mu.Unlock()
+		return
+	}
+	e.removed = true
+	m.mu.Unlock()
+	m.destroy(e)
+}
+
+// create 创建新的沙箱，未配置共用工作区时同时创建它的工作区
+func (m *Manager) create(id string) (*managedEntry, error) {
+	if m.config.Workspace != "" {
+		sb, err := m.newSandbox(m.config.Workspace)
+		if err != nil {
+			return nil, err
+		}
+		sb.SetResourceLimits(m.config.Limits)
+		return &managedEntry{id: id, sandbox: sb, workspace: m.config.Workspace, shared: true}, nil
+	}
+	workspace, err := os.MkdirTemp(m.config.WorkspaceRoot, "openmanus-sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("创建沙箱工作区失败: %v", err)
+	}
+	sb, err := m.newSandbox(workspace)
+	if err != nil {
+		os.RemoveAll(workspace)
+		return nil, err
+	}
+	sb.SetResourceLimits(m.config.Limits)
+	return &managedEntry{id: id, sandbox: sb, workspace: workspace}, nil
+}
+
+// newSandbox 按隔离方式创建沙箱；auto 第一次创建时试运行命名空间沙箱，失败后改用宿主沙箱
+func (m *Manager) newSandbox(workspace string) (pooledSandbox, error) {
+	m.mu.Lock()
+	isolation := m.isolation
+	m.mu.Unlock()
+	if isolation == IsolationHost {
+		return NewHostSandbox(workspace)
+	}
+	nsConfig := m.config.Namespace
+	nsConfig.Workspace = workspace
+	sb, err := NewNamespaceSandbox(nsConfig)
+	if err == nil && isolation == IsolationAuto {
+		// 容器等环境中可能可以创建命名空间沙箱但无法启动
+		ctx, cancel := context.WithTimeout(m.ctx, 10*time.Second)
+		_, err = sb.Run(ctx, "true", ExecOptions{})
+		cancel()
+		if err != nil {
+			sb.Close()
+		}
+	}
+	if isolation == IsolationNamespace {
+		if err != nil {
+			return nil, err
+		}
+		return sb, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		m.isolation = IsolationNamespace
+		return sb, nil
+	}
+	if m.isolation == IsolationAuto {
+		log.Printf("命名空间沙箱不可用，改用宿主沙箱: %v", err)
+		m.isolation = IsolationHost
+	}
+	return NewHostSandbox(workspace)
+}
+
+// destroy 关闭沙箱并删除工作区，共用的工作区保留
+func (m *Manager) destroy(e *managedEntry) {
+	if err := e.sandbox.Close(); err != nil {
+		log.Printf("关闭沙箱 %s 失败: %v", e.id, err)
+	}
+	if !e.shared {
+		if err := os.RemoveAll(e.workspace); err != nil {
+			log.Printf("删除沙箱 %s 的工作区失败: %v", e.id, err)
+		}
+	}
+	m.mu.Lock()
+	m.count--
+	m.mu.Unlock()
+}
+
+// cleanupLoop 定期回收空闲沙箱
+func (m *Manager) cleanupLoop() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.config.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.cleanupIdle()
+		}
+	}
+}
+
+// cleanupIdle 删除超过 IdleTimeout 未执行命令的沙箱，包括仍分配给会话的沙箱
+func (m *Manager) cleanupIdle() {
+	deadline := time.Now().Add(-m.config.IdleTimeout)
+	var expired []*managedEntry
+	m.mu.Lock()
+	for id, e := range m.sessions {
+		if e.active == 0 && e.lastUsed.Before(deadline) {
+			delete(m.sessions, id)
+			e.removed = true
+			expired = append(expired, e)
+		}
+	}
+	idle := m.idle[:0]
+	for _, e := range m.idle {
+		if e.lastUsed.Before(deadline) {
+			e.removed = true
+			expired = append(expired, e)
+		} else {
+			idle = append(idle, e)
+		}
+	}
+	m.idle = idle
+	m.mu.Unlock()
+	for _, e := range expired {
+		m.destroy(e)
+	}
+}
+
+// Close 关闭管理器：终止正在执行的命令及其子进程，等待命令退出后关闭所有沙箱并删除工作区
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	close(m.stop)
+	<-m.done
+	m.cancel()
+	m.wg.Wait()
+
+	m.mu.Lock()
+	entries := m.idle
+	for _, e := range m.sessions {
+		entries = append(entries, e)
+	}
+	for _, e := range entries {
+		e.removed = true
+	}
+	m.sessions = make(map[string]*managedEntry)
+	m.idle = nil
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, e := range entries {
+		wg.Add(1)
+		go func(e *managedEntry) {
+			defer wg.Done()
+			m.destroy(e)
+		}(e)
+	}
+	wg.Wait()
+	return nil
+}
+
+// Stats 返回管理器统计信息
+func (m *Manager) Stats() ManagerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := ManagerStats{
+		Total:        m.count,
+		Sessions:     len(m.sessions),
+		Idle:         len(m.idle),
+		MaxSandboxes: m.config.MaxSandboxes,
+		Isolation:    m.isolation,
+		Closed:       m.closed,
+	}
+	for _, e := range m.sessions {
+		stats.Active += e.active
+	}
+	return stats
+}
+
+// begin 开始执行命令，沙箱已不属于该会话或管理器已关闭时返回错误
+func (m *Manager) begin(e *managedEntry, session string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrManagerClosed
+	}
+	if e.removed || e.released || e.session != session {
+		return ErrSandboxReleased
+	}
+	e.active++
+	e.lastUsed = time.Now()
+	m.wg.Add(1)
+	return nil
+}
+
+// end 命令结束，会话已释放且没有其他命令时回收沙箱
+func (m *Manager) end(e *managedEntry) {
+	m.mu.Lock()
+	e.active--
+	e.lastUsed = time.Now()
+	recycle := e.released && e.active == 0 && !e.removed
+	if recycle {
+		// 由 recycle 结束这次计数
+		m.mu.Unlock()
+		m.recycle(e)
+		return
+	}
+	m.mu.Unlock()
+	m.wg.Done()
+}
+
+// clearDir 删除目录中的所有内容，保留目录本身
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ManagedSandbox 分配给会话的沙箱：执行命令时记录使用时间，管理器关闭时终止正在执行的命令
+type ManagedSandbox struct {
+	m       *Manager
+	e       *managedEntry
+	session string
+}
+
+// ID 返回沙箱 ID
+func (s *ManagedSandbox) ID() string {
+	return s.e.id
+}
+
+// SessionID 返回占用沙箱的会话
+func (s *ManagedSandbox) SessionID() string {
+	return s.session
+}
+
+// Workspace 返回沙箱工作区在宿主上的路径
+func (s *ManagedSandbox) Workspace() string {
+	return s.e.workspace
+}
+
+// Run 在沙箱中执行命令
+func (s *ManagedSandbox) Run(ctx context.Context, cmd string, opts ExecOptions) (*ExecResult, error) {
+	if err := s.m.begin(s.e, s.session); err != nil {
+		return nil, err
+	}
+	defer s.m.end(s.e)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.m.ctx, cancel)
+	defer stop()
+	return s.e.sandbox.Run(ctx, cmd, opts)
+}
+
+// Execute 在沙箱中执行命令
+func (s *ManagedSandbox) Execute(ctx context.Context, cmd string) (string, error) {
+	res, err := s.Run(ctx, cmd, ExecOptions{})
+	if res == nil {
+		return "", err
+	}
+	return res.Output, err
+}
+
+// SetResourceLimits 设置资源限制，沙箱被复用前恢复为管理器的配置
+func (s *ManagedSandbox) SetResourceLimits(limits ResourceLimits) {
+	s.e.sandbox.SetResourceLimits(limits)
+}
+
+// GetResourceLimits 获取资源限制
+func (s *ManagedSandbox) GetResourceLimits() ResourceLimits {
+	return s.e.sandbox.GetResourceLimits()
+}
+
+// Session 返回会话的沙箱：每次执行命令时通过 Get 取得会话的沙箱，
+// 沙箱因空闲被回收后自动重新分配，适合在创建工具时注入
+func (m *Manager) Session(sessionID string) Sandbox {
+	return &sessionSandbox{m: m, session: sessionID}
+}
+
+// sessionSandbox 按需获取会话沙箱的 Sandbox
+type sessionSandbox struct {
+	m       *Manager
+	session string
+}
+
+// Run 在会话的沙箱中执行命令
+func (s *sessionSandbox) Run(ctx context.Context, cmd string, opts ExecOptions) (*ExecResult, error) {
+	for retried := false; ; retried = true {
+		sb, err := s.m.Get(s.session)
+		if err != nil {
+			return nil, err
+		}
+		res, err := sb.Run(ctx, cmd, opts)
+		// 取得沙箱后、执行前沙箱恰好被空闲回收时重新获取一次
+		if errors.Is(err, ErrSandboxReleased) && !retried {
+			continue
+		}
+		return res, err
+	}
+}
+
+// Execute 在会话的沙箱中执行命令
+func (s *sessionSandbox) Execute(ctx context.Context, cmd string) (string, error) {
+	res, err := s.Run(ctx, cmd, ExecOptions{})
+	if res == nil {
+		return "", err
+	}
+	return res.Output, err
+}
+
+// SetResourceLimits 设置会话沙箱的资源限制
+func (s *sessionSandbox) SetResourceLimits(limits ResourceLimits) {
+	if sb, err := s.m.Get(s.session); err == nil {
+		sb.SetResourceLimits(limits)
+	}
+}
+
+// GetResourceLimits 获取会话沙箱的资源限制，无法取得沙箱时返回管理器的配置
+func (s *sessionSandbox) GetResourceLimits() ResourceLimits {
+	if sb, err := s.m.Get(s.session); err == nil {
+		return sb.GetResourceLimits()
+	}
+	return s.m.config.Limits
+}
+
+var (
+	defaultManager   *Manager
+	defaultManagerMu sync.RWMutex
+)
+
+// SetDefaultManager 设置全局沙箱管理器
+func SetDefaultManager(m *Manager) {
+	defaultManagerMu.Lock()
+	defer defaultManagerMu.Unlock()
+	defaultManager = m
+}
+
+// DefaultManager 返回全局沙箱管理器，未设置时为 nil
+func DefaultManager() *Manager {
+	defaultManagerMu.RLock()
+	defer defaultManagerMu.RUnlock()
+	return defaultManager
+}
@@ -0,0 +1,168 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestManager 创建测试用的沙箱管理器，测试结束时关闭
+func newTestManager(t *testing.T, config ManagerConfig) *Manager {
+	t.Helper()
+	if config.WorkspaceRoot == "" {
+		config.WorkspaceRoot = t.TempDir()
+	}
+	if config.Limits == (ResourceLimits{}) {
+		config.Limits = ResourceLimits{TimeLimit: 30}
+	}
+	m, err := NewManager(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestManagerSessionSandbox(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands use sh syntax")
+	}
+	m := newTestManager(t, ManagerConfig{Namespace: DefaultNamespaceConfig()})
+	sb := m.Session("s1")
+
+	res, err := sb.Run(context.Background(), "hostname; pwd; echo data > f.txt", ExecOptions{})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// Linux 上 auto 优先使用命名空间沙箱
+	if runtime.GOOS == "linux" && m.Stats().Isolation == IsolationNamespace {
+		if res.Stdout != "sandbox\n"+defaultNamespaceWorkDir+"\n" {
+			t.Fatalf("stdout = %q", res.Stdout)
+		}
+	}
+	out, err := sb.Execute(context.Background(), "cat f.txt")
+	if err != nil || strings.TrimSpace(out) != "data" {
+		t.Fatalf("session does not keep its workspace: %q, %v", out, err)
+	}
+
+	// 空闲回收后重新分配新的沙箱
+	m.Remove("s1")
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Stats().Total != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	out, err = sb.Execute(context.Background(), "cat f.txt 2>/dev/null || echo missing")
+	if err != nil || strings.TrimSpace(out) != "missing" {
+		t.Fatalf("after remove: %q, %v", out, err)
+	}
+	if s := m.Stats(); s.Sessions != 1 || s.Total != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestManagerConcurrentGetRespectsMaxIdle(t *testing.T) {
+	for _, maxIdle := range []int{-1, 1} {
+		// auto 第一次创建时试运行命名空间沙箱，并发的创建相互重叠
+		m := newTestManager(t, ManagerConfig{MaxIdle: maxIdle})
+		var wg sync.WaitGroup
+		ids := make(chan string, 16)
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sb, err := m.Get("s")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids <- sb.ID()
+			}()
+		}
+		wg.Wait()
+		close(ids)
+		first := <-ids
+		for id := range ids {
+			if id != first {
+				t.Fatalf("max_idle %d: session got sandboxes %s and %s", maxIdle, first, id)
+			}
+		}
+		s := m.Stats()
+		if s.Idle > max(maxIdle, 0) || s.Total != s.Sessions+s.Idle {
+			t.Fatalf("max_idle %d: stats = %+v", maxIdle, s)
+		}
+	}
+}
+
+func TestManagerReleaseReusesSandbox(t *testing.T) {
+	m := newTestManager(t, ManagerConfig{Isolation: IsolationHost, MaxIdle: 1})
+	a, err := m.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Execute(context.Background(), "echo x > f.txt"); err != nil {
+		t.Fatal(err)
+	}
+	m.Release("a")
+	if _, err := a.Execute(context.Background(), "true"); !errors.Is(err, ErrSandboxReleased) {
+		t.Fatalf("run after release: %v", err)
+	}
+
+	b, err := m.Get("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ID() != a.ID() {
+		t.Fatalf("released sandbox not reused: %s, %s", a.ID(), b.ID())
+	}
+	entries, err := os.ReadDir(b.Workspace())
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("workspace not cleared: %v, %v", entries, err)
+	}
+}
+
+func TestManagerSharedWorkspace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands use sh syntax")
+	}
+	dir := t.TempDir()
+	m := newTestManager(t, ManagerConfig{Workspace: dir, MaxIdle: 1, Namespace: DefaultNamespaceConfig()})
+
+	// 各会话的沙箱都以共用工作区为工作目录，写入的文件在宿主上可见
+	res, err := m.Session("a").Run(context.Background(), "pwd; echo data > f.txt", ExecOptions{})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := dir + "\n"
+	if m.Stats().Isolation == IsolationNamespace {
+		want = defaultNamespaceWorkDir + "\n"
+	}
+	if res.Stdout != want {
+		t.Fatalf("working directory = %q, want %q", res.Stdout, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "f.txt")); err != nil || string(data) != "data\n" {
+		t.Fatalf("file on host = %q, %v", data, err)
+	}
+	os.WriteFile(filepath.Join(dir, "host.txt"), []byte("from host\n"), 0644)
+	out, err := m.Session("b").Execute(context.Background(), "cat f.txt host.txt")
+	if err != nil || out != "data\nfrom host\n" {
+		t.Fatalf("other session: %q, %v", out, err)
+	}
+
+	// 复用、删除沙箱和关闭管理器都不清空共用工作区
+	m.Release("a")
+	m.Remove("b")
+	if _, err := m.Get("c"); err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("shared workspace changed: %v, %v", entries, err)
+	}
+}
@@ -0,0 +1,65 @@
+package sandbox
+
+import "context"
+
+// DefaultReadOnlyPaths 未指定根文件系统时只读绑定到沙箱内的宿主目录
+var DefaultReadOnlyPaths = []string{"/usr", "/bin", "/sbin", "/lib", "/lib64", "/lib32", "/etc"}
+
+// 沙箱内的默认工作目录
+const defaultNamespaceWorkDir = "/workspace"
+
+// NamespaceConfig 命名空间沙箱配置
+type NamespaceConfig struct {
+	// Workspace 以可写方式挂载到 WorkDir 的宿主目录，为空时使用临时目录，Close 时删除
+	Workspace string
+	// WorkDir 沙箱内的工作目录，默认 /workspace
+	WorkDir string
+	// Rootfs 最小根文件系统目录，以只读方式作为根目录，需要包含 bash；缺少的 /proc、/dev、/tmp
+	// 和工作目录挂载点会在其中创建。为空时只读绑定 ReadOnlyPaths 中的宿主目录
+	Rootfs string
+	// ReadOnlyPaths 只读绑定的宿主目录，默认 DefaultReadOnlyPaths
+	ReadOnlyPaths []string
+	// Network 允许访问网络，此时与宿主共享网络命名空间；否则只有回环网卡
+	Network bool
+	// Hostname 沙箱内的主机名，默认 sandbox
+	Hostname string
+	// Env 命令的环境变量，不继承宿主的环境变量，避免泄露密钥
+	Env []string
+}
+
+// DefaultNamespaceConfig 返回默认命名空间沙箱配置：绑定宿主系统目录、临时工作区、无网络
+func DefaultNamespaceConfig() NamespaceConfig {
+	return NamespaceConfig{
+		WorkDir:       defaultNamespaceWorkDir,
+		ReadOnlyPaths: DefaultReadOnlyPaths,
+		Hostname:      "sandbox",
+	}
+}
+
+// NamespaceSandbox 在独立的 user、mount、PID、IPC、UTS 和网络命名空间中执行命令，
+// 只能看到只读的系统目录和可写的工作区，资源限制与 BaseSandbox 相同
+type NamespaceSandbox struct {
+	*BaseSandbox
+	config NamespaceConfig
+	// tempWorkspace 工作区为创建时生成的临时目录
+	tempWorkspace bool
+}
+
+// Execute 在沙箱中执行命令
+func (s *NamespaceSandbox) Execute(ctx context.Context, cmd string) (string, error) {
+	res, err := s.Run(ctx, cmd, ExecOptions{})
+	if res == nil {
+		return "", err
+	}
+	return res.Output, err
+}
+
+// Workspace 返回挂载到沙箱工作目录的宿主目录
+func (s *NamespaceSandbox) Workspace() string {
+	return s.config.Workspace
+}
+
+// Config 返回沙箱配置
+func (s *NamespaceSandbox) Config() NamespaceConfig {
+	return s.config
+}
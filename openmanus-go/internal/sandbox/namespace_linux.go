@@ -0,0 +1,319 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// 沙箱内命令的 PATH
+const nsDefaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// 绑定到沙箱 /dev 的宿主设备
+var nsDevices = []string{"null", "zero", "full", "random", "urandom", "tty"}
+
+// NewNamespaceSandbox 创建命名空间沙箱，需要内核允许非特权用户命名空间；
+// 使用该沙箱的程序需要在 main 开头调用 Init
+func NewNamespaceSandbox(config NamespaceConfig) (*NamespaceSandbox, error) {
+	if err := checkUserNamespaces(); err != nil {
+		return nil, err
+	}
+	def := DefaultNamespaceConfig()
+	if config.WorkDir == "" {
+		config.WorkDir = def.WorkDir
+	}
+	if !filepath.IsAbs(config.WorkDir) || filepath.Clean(config.WorkDir) == "/" {
+		return nil, fmt.Errorf("沙箱工作目录必须是 / 以外的绝对路径: %s", config.WorkDir)
+	}
+	if config.Rootfs == "" && len(config.ReadOnlyPaths) == 0 {
+		config.ReadOnlyPaths = def.ReadOnlyPaths
+	}
+	if config.Hostname == "" {
+		config.Hostname = def.Hostname
+	}
+	if config.Rootfs != "" {
+		abs, err := filepath.Abs(config.Rootfs)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("根文件系统目录不存在: %s", config.Rootfs)
+		}
+		config.Rootfs = abs
+	}
+
+	s := &NamespaceSandbox{BaseSandbox: NewBaseSandbox(), config: config}
+	if config.Workspace == "" {
+		dir, err := os.MkdirTemp("", "openmanus-workspace-")
+		if err != nil {
+			return nil, fmt.Errorf("创建工作区失败: %v", err)
+		}
+		s.config.Workspace = dir
+		s.tempWorkspace = true
+	} else {
+		abs, err := filepath.Abs(config.Workspace)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(abs, 0755); err != nil {
+			return nil, fmt.Errorf("创建工作区失败: %v", err)
+		}
+		s.config.Workspace = abs
+	}
+	return s, nil
+}
+
+// checkUserNamespaces 检查内核是否允许当前用户创建用户命名空间
+func checkUserNamespaces() error {
+	if data, err := os.ReadFile("/proc/sys/user/max_user_namespaces"); err == nil && strings.TrimSpace(string(data)) == "0" {
+		return errors.New("内核禁用了用户命名空间 (user.max_user_namespaces=0)")
+	}
+	if os.Geteuid() != 0 {
+		if data, err := os.ReadFile("/proc/sys/kernel/unprivileged_userns_clone"); err == nil && strings.TrimSpace(string(data)) == "0" {
+			return errors.New("内核禁止非特权用户创建用户命名空间 (kernel.unprivileged_userns_clone=0)")
+		}
+	}
+	return nil
+}
+
+// Run 在沙箱中执行命令
+func (s *NamespaceSandbox) Run(ctx context.Context, cmd string, opts ExecOptions) (*ExecResult, error) {
+	return s.run(ctx, cmd, opts, &reexec{ns: &s.config, seccomp: s.seccomp})
+}
+
+// Close 删除临时工作区
+func (s *NamespaceSandbox) Close() error {
+	if s.tempWorkspace {
+		return os.RemoveAll(s.config.Workspace)
+	}
+	return nil
+}
+
+// setupRoot 在 tmpfs 上组装新的根目录并切换过去：只读的系统目录或根文件系统、
+// 新的 /proc、最小的 /dev、独立的 /tmp 和可写的工作区
+func setupRoot(spec nsSpec) error {
+	// 挂载事件不传播回宿主
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("设置挂载传播失败: %v", err)
+	}
+	root := spec.Root
+	var readOnly []string
+	if spec.Rootfs != "" {
+		if err := unix.Mount(spec.Rootfs, root, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("绑定根文件系统失败: %v", err)
+		}
+	} else {
+		if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755"); err != nil {
+			return fmt.Errorf("挂载根目录失败: %v", err)
+		}
+		for _, p := range spec.ReadOnlyPaths {
+			bound, err := bindHostPath(root, p)
+			if err != nil {
+				return err
+			}
+			if bound {
+				readOnly = append(readOnly, filepath.Join(root, p))
+			}
+		}
+	}
+
+	mounts := []struct {
+		target, fstype string
+		flags          uintptr
+		data           string
+	}{
+		{"proc", "proc", unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC, ""},
+		{"dev", "tmpfs", unix.MS_NOSUID, "mode=0755"},
+		{"tmp", "tmpfs", unix.MS_NOSUID | unix.MS_NODEV, "mode=1777"},
+	}
+	for _, m := range mounts {
+		target := filepath.Join(root, m.target)
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return err
+		}
+		if err := unix.Mount(m.fstype, target, m.fstype, m.flags, m.data); err != nil {
+			return fmt.Errorf("挂载 /%s 失败: %v", m.target, err)
+		}
+	}
+	if err := setupDev(filepath.Join(root, "dev")); err != nil {
+		return err
+	}
+
+	workDir := filepath.Join(root, spec.WorkDir)
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return err
+	}
+	if err := unix.Mount(spec.Workspace, workDir, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("绑定工作区失败: %v", err)
+	}
+
+	for _, p := range readOnly {
+		if err := remountReadOnly(p); err != nil {
+			return err
+		}
+	}
+
+	// pivot_root(".", ".") 把旧根目录叠放在新根目录之下，卸载后不需要额外的目录
+	if err := unix.Chdir(root); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("pivot_root 失败: %v", err)
+	}
+	if err := unix.Unmount(".", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("卸载宿主根目录失败: %v", err)
+	}
+	if err := unix.Chdir("/"); err != nil {
+		return err
+	}
+	// 除工作区、/tmp 和 /dev 外根目录只读
+	return remountReadOnly("/")
+}
+
+// bindHostPath 把宿主目录绑定到新根目录的同一路径，符号链接原样复制；宿主上不存在时跳过
+func bindHostPath(root, p string) (bool, error) {
+	info, err := os.Lstat(p)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	target := filepath.Join(root, p)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return false, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		// 例如 /bin -> usr/bin
+		link, err := os.Readlink(p)
+		if err != nil {
+			return false, err
+		}
+		return false, os.Symlink(link, target)
+	}
+	if info.IsDir() {
+		err = os.MkdirAll(target, 0755)
+	} else {
+		err = os.WriteFile(target, nil, 0644)
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := unix.Mount(p, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return false, fmt.Errorf("绑定 %s 失败: %v", p, err)
+	}
+	return true, nil
+}
+
+// setupDev 绑定常用设备并创建标准链接
+func setupDev(dev string) error {
+	for _, name := range nsDevices {
+		target := filepath.Join(dev, name)
+		if err := os.WriteFile(target, nil, 0666); err != nil {
+			return err
+		}
+		if err := unix.Mount("/dev/"+name, target, "", unix.MS_BIND, ""); err != nil {
+			// 宿主没有控制终端时不存在 /dev/tty
+			os.Remove(target)
+			if name == "tty" {
+				continue
+			}
+			return fmt.Errorf("绑定 /dev/%s 失败: %v", name, err)
+		}
+	}
+	links := map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dev, name)); err != nil {
+			return err
+		}
+	}
+	shm := filepath.Join(dev, "shm")
+	if err := os.Mkdir(shm, 01777); err != nil {
+		return err
+	}
+	return unix.Mount("tmpfs", shm, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777")
+}
+
+// remountReadOnly 把挂载点重新挂载为只读；用户命名空间中必须保留原挂载被锁定的标志
+func remountReadOnly(p string) error {
+	var st unix.Statfs_t
+	if err := unix.Statfs(p, &st); err != nil {
+		return err
+	}
+	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY)
+	for _, f := range []struct {
+		st int64
+		ms uintptr
+	}{
+		{unix.ST_NOSUID, unix.MS_NOSUID},
+		{unix.ST_NODEV, unix.MS_NODEV},
+		{unix.ST_NOEXEC, unix.MS_NOEXEC},
+		{unix.ST_NOATIME, unix.MS_NOATIME},
+		{unix.ST_NODIRATIME, unix.MS_NODIRATIME},
+		{unix.ST_RELATIME, unix.MS_RELATIME},
+	} {
+		if int64(st.Flags)&f.st != 0 {
+			flags |= f.ms
+		}
+	}
+	if err := unix.Mount("", p, "", flags, ""); err != nil {
+		return fmt.Errorf("只读挂载 %s 失败: %v", p, err)
+	}
+	return nil
+}
+
+// loopbackUp 启用新网络命名空间中的回环网卡，使 localhost 可用
+func loopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
+
+// dropPrivileges 清空权能边界集并设置 no_new_privs，命令即使以沙箱内的 root 运行也没有任何权能
+func dropPrivileges() error {
+	for c := 0; c <= unix.CAP_LAST_CAP; c++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+			return fmt.Errorf("丢弃权能 %d 失败: %v", c, err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+		return fmt.Errorf("清除环境权能失败: %v", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("设置 no_new_privs 失败: %v", err)
+	}
+	return nil
+}
+
+// pathFromEnv 取环境变量中的 PATH，没有时使用默认值
+func pathFromEnv(env []string) string {
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "PATH="); ok {
+			return v
+		}
+	}
+	return nsDefaultPath
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// Init 只在 Linux 上需要，其他平台立即返回
+func Init() {}
+
+// NewNamespaceSandbox 命名空间沙箱只支持 Linux
+func NewNamespaceSandbox(config NamespaceConfig) (*NamespaceSandbox, error) {
+	return nil, fmt.Errorf("命名空间沙箱不支持当前操作系统: %s", runtime.GOOS)
+}
+
+// Run 在沙箱中执行命令
+func (s *NamespaceSandbox) Run(ctx context.Context, cmd string, opts ExecOptions) (*ExecResult, error) {
+	return nil, fmt.Errorf("命名空间沙箱不支持当前操作系统: %s", runtime.GOOS)
+}
+
+// Close 释放沙箱
+func (s *NamespaceSandbox) Close() error {
+	return nil
+}
@@ -0,0 +1,85 @@
+package sandbox
+
+import (
+	"bytes"
+	"sync"
+)
+
+// DefaultMaxOutput 未指定时标准输出和标准错误各自保留的最大字节数
+const DefaultMaxOutput = 1 << 20
+
+// 输出流名称，传给 ExecOptions.OnOutput
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// ExecOptions 一次执行的选项
+type ExecOptions struct {
+	// MaxOutput 标准输出和标准错误各自保留的最大字节数，超出部分丢弃并在结果中标记截断；
+	// 为 0 时使用 DefaultMaxOutput
+	MaxOutput int
+	// OnOutput 收到输出时调用，stream 为 stdout 或 stderr；调用依次进行，按输出到达的顺序，
+	// 不受 MaxOutput 限制。data 在回调返回后会被复用，需要保留时复制
+	OnOutput func(stream string, data []byte)
+}
+
+// outputCapture 分别收集标准输出和标准错误，同时保留两者交错的合并输出
+type outputCapture struct {
+	mu       sync.Mutex
+	limit    int
+	onOutput func(stream string, data []byte)
+	combined bytes.Buffer
+	stdout   streamBuffer
+	stderr   streamBuffer
+}
+
+// streamBuffer 一个输出流的缓冲
+type streamBuffer struct {
+	c         *outputCapture
+	name      string
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func newOutputCapture(opts ExecOptions) *outputCapture {
+	c := &outputCapture{limit: opts.MaxOutput, onOutput: opts.OnOutput}
+	if c.limit <= 0 {
+		c.limit = DefaultMaxOutput
+	}
+	c.stdout = streamBuffer{c: c, name: StreamStdout}
+	c.stderr = streamBuffer{c: c, name: StreamStderr}
+	return c
+}
+
+// Write 保存不超过上限的部分，总是报告全部写入，避免命令因写入失败提前退出
+func (b *streamBuffer) Write(p []byte) (int, error) {
+	c := b.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if room := c.limit - b.buf.Len(); room >= len(p) {
+		b.buf.Write(p)
+		c.combined.Write(p)
+	} else {
+		if room > 0 {
+			b.buf.Write(p[:room])
+			c.combined.Write(p[:room])
+		}
+		b.truncated = true
+	}
+	if c.onOutput != nil {
+		c.onOutput(b.name, p)
+	}
+	return len(p), nil
+}
+
+// fill 把收集的输出写入结果
+func (c *outputCapture) fill(res *ExecResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res.Output = c.combined.String()
+	res.Stdout = c.stdout.buf.String()
+	res.Stderr = c.stderr.buf.String()
+	res.StdoutTruncated = c.stdout.truncated
+	res.StderrTruncated = c.stderr.truncated
+}
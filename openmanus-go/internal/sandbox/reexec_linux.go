@@ -0,0 +1,310 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// 重新执行当前程序时的 argv[0] 和传递配置的环境变量：
+// 命名空间沙箱的初始化进程，以及安装 seccomp 过滤后执行命令的进程
+const (
+	nsInitArg          = "openmanus-sandbox-init"
+	nsInitSpecEnv      = "_OPENMANUS_SANDBOX_SPEC"
+	seccompExecArg     = "openmanus-sandbox-exec"
+	seccompExecSpecEnv = "_OPENMANUS_SECCOMP_SPEC"
+)
+
+// initCalled 当前程序已调用 Init，可以通过重新执行自身进入沙箱
+var initCalled atomic.Bool
+
+// Init 在沙箱的初始化进程中完成隔离并执行命令，不会返回；在其他进程中立即返回。
+// 使用命名空间沙箱或 seccomp 过滤的程序需要在 main 开头调用
+func Init() {
+	initCalled.Store(true)
+	if len(os.Args) == 0 {
+		return
+	}
+	switch os.Args[0] {
+	case nsInitArg:
+		var spec nsSpec
+		if err := json.Unmarshal([]byte(os.Getenv(nsInitSpecEnv)), &spec); err != nil {
+			fmt.Fprintf(os.Stderr, "沙箱配置无效: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(nsInit(spec))
+	case seccompExecArg:
+		var spec seccompSpec
+		if err := json.Unmarshal([]byte(os.Getenv(seccompExecSpecEnv)), &spec); err != nil {
+			fmt.Fprintf(os.Stderr, "seccomp 配置无效: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(seccompExec(spec))
+	}
+}
+
+// nsSpec 传给命名空间初始化进程的配置
+type nsSpec struct {
+	// Args 在沙箱内执行的命令，Args[0] 在沙箱的 PATH 中查找
+	Args []string `json:"args"`
+	Env  []string `json:"env"`
+	// Root 新根目录的挂载点，在宿主上始终为空目录
+	Root          string          `json:"root"`
+	Rootfs        string          `json:"rootfs,omitempty"`
+	ReadOnlyPaths []string        `json:"read_only_paths,omitempty"`
+	Workspace     string          `json:"workspace"`
+	WorkDir       string          `json:"work_dir"`
+	Network       bool            `json:"network"`
+	Hostname      string          `json:"hostname"`
+	Seccomp       *SeccompProfile `json:"seccomp,omitempty"`
+	// ErrFD 报告初始化错误的文件描述符
+	ErrFD int `json:"err_fd"`
+}
+
+// seccompSpec 传给 seccomp 执行进程的配置
+type seccompSpec struct {
+	Args    []string        `json:"args"`
+	Env     []string        `json:"env"`
+	Profile *SeccompProfile `json:"profile"`
+	ErrFD   int             `json:"err_fd"`
+}
+
+// execHooks 需要隔离时返回通过重新执行自身启动命令的钩子
+func (s *BaseSandbox) execHooks() execHooks {
+	if s.seccomp == nil {
+		return nil
+	}
+	return &reexec{seccomp: s.seccomp}
+}
+
+// reexec 通过重新执行当前程序，在命令启动前进入命名空间或安装 seccomp 过滤
+type reexec struct {
+	// ns 为 nil 时不隔离命名空间，只安装 seccomp 过滤
+	ns      *NamespaceConfig
+	seccomp *SeccompProfile
+	root    string
+	errRead *os.File
+	errFile *os.File
+}
+
+// prepare 改为执行当前程序，原命令（包括资源限制参数）通过环境变量传入
+func (e *reexec) prepare(cmd *exec.Cmd) error {
+	if !initCalled.Load() {
+		return errors.New("使用命名空间沙箱或 seccomp 前需要在 main 开头调用 sandbox.Init")
+	}
+	var err error
+	e.errRead, e.errFile, err = os.Pipe()
+	if err != nil {
+		return err
+	}
+	args := append([]string{filepath.Base(cmd.Args[0])}, cmd.Args[1:]...)
+	errFD := 3 + len(cmd.ExtraFiles)
+	cmd.ExtraFiles = append(cmd.ExtraFiles, e.errFile)
+	cmd.Path = "/proc/self/exe"
+
+	if e.ns == nil {
+		env := cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		data, err := json.Marshal(seccompSpec{Args: args, Env: env, Profile: e.seccomp, ErrFD: errFD})
+		if err != nil {
+			return err
+		}
+		cmd.Args = []string{seccompExecArg}
+		cmd.Env = []string{seccompExecSpecEnv + "=" + string(data)}
+		return nil
+	}
+
+	root, err := os.MkdirTemp("", "openmanus-root-")
+	if err != nil {
+		return fmt.Errorf("创建沙箱根目录失败: %v", err)
+	}
+	e.root = root
+	env := e.ns.Env
+	if len(env) == 0 {
+		env = []string{"PATH=" + nsDefaultPath, "HOME=" + e.ns.WorkDir, "LANG=C.UTF-8", "TERM=dumb"}
+	}
+	data, err := json.Marshal(nsSpec{
+		Args:          args,
+		Env:           env,
+		Root:          root,
+		Rootfs:        e.ns.Rootfs,
+		ReadOnlyPaths: e.ns.ReadOnlyPaths,
+		Workspace:     e.ns.Workspace,
+		WorkDir:       e.ns.WorkDir,
+		Network:       e.ns.Network,
+		Hostname:      e.ns.Hostname,
+		Seccomp:       e.seccomp,
+		ErrFD:         errFD,
+	})
+	if err != nil {
+		return err
+	}
+	cmd.Args = []string{nsInitArg}
+	cmd.Env = []string{nsInitSpecEnv + "=" + string(data)}
+
+	attr := cmd.SysProcAttr
+	if attr == nil {
+		attr = &syscall.SysProcAttr{}
+		cmd.SysProcAttr = attr
+	}
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+		syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS | syscall.CLONE_NEWCGROUP
+	if !e.ns.Network {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	// 沙箱内的 root 映射为当前用户，没有宿主上的额外权限
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+	// 宿主进程退出时结束沙箱
+	attr.Pdeathsig = syscall.SIGKILL
+	return nil
+}
+
+func (e *reexec) started() {
+	e.errFile.Close()
+}
+
+// finish 初始化进程报告了错误时返回该错误；命令因 seccomp 被终止时记录违规
+func (e *reexec) finish(res *ExecResult, state *os.ProcessState, err error) error {
+	if e.root != "" {
+		defer os.Remove(e.root)
+	}
+	msg, _ := io.ReadAll(e.errRead)
+	e.errRead.Close()
+	if len(msg) > 0 {
+		return fmt.Errorf("沙箱初始化失败: %s", msg)
+	}
+	// 初始化进程以 128+信号 的退出码转述命令被信号终止
+	if e.ns != nil && res.Signal == "" && res.ExitCode > 128 {
+		res.Signal = unix.SignalName(syscall.Signal(res.ExitCode - 128))
+	}
+	if e.seccomp != nil && e.seccomp.Action != SeccompErrno && state != nil && exitSignal(state) == syscall.SIGSYS {
+		res.Violation = ViolationSeccomp
+	}
+	return err
+}
+
+// exitSignal 进程或 bash、沙箱初始化进程转述的子进程终止信号，正常退出时返回 -1
+func exitSignal(state *os.ProcessState) syscall.Signal {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ws.Signal()
+	}
+	if code := state.ExitCode(); code > 128 {
+		return syscall.Signal(code - 128)
+	}
+	return -1
+}
+
+// nsInit 在新的命名空间中作为 PID 1 运行：准备根目录、丢弃权限、启动命令并回收子进程
+func nsInit(spec nsSpec) int {
+	// 权能边界集和 no_new_privs 按线程设置，命令需要从同一线程创建
+	runtime.LockOSThread()
+	errFile := os.NewFile(uintptr(spec.ErrFD), "sandbox-err")
+	fail := func(err error) int {
+		fmt.Fprint(errFile, err)
+		return 1
+	}
+	if err := setupRoot(spec); err != nil {
+		return fail(err)
+	}
+	if err := unix.Sethostname([]byte(spec.Hostname)); err != nil {
+		return fail(fmt.Errorf("设置主机名失败: %v", err))
+	}
+	if !spec.Network {
+		if err := loopbackUp(); err != nil {
+			return fail(fmt.Errorf("启用回环网卡失败: %v", err))
+		}
+	}
+	if err := dropPrivileges(); err != nil {
+		return fail(err)
+	}
+
+	attr := &os.ProcAttr{
+		Dir:   spec.WorkDir,
+		Env:   spec.Env,
+		Files: []*os.File{os.Stdin, os.Stdout, os.Stderr},
+	}
+	var path string
+	args := spec.Args
+	if spec.Seccomp != nil {
+		// 由下一级进程安装过滤后执行命令，初始化进程本身不受过滤限制
+		data, err := json.Marshal(seccompSpec{Args: spec.Args, Env: spec.Env, Profile: spec.Seccomp, ErrFD: 3})
+		if err != nil {
+			return fail(err)
+		}
+		path, args = "/proc/self/exe", []string{seccompExecArg}
+		attr.Env = []string{seccompExecSpecEnv + "=" + string(data)}
+		attr.Files = append(attr.Files, errFile)
+	} else {
+		os.Setenv("PATH", pathFromEnv(spec.Env))
+		var err error
+		if path, err = exec.LookPath(spec.Args[0]); err != nil {
+			return fail(fmt.Errorf("沙箱内找不到 %s: %v", spec.Args[0], err))
+		}
+	}
+	syscall.CloseOnExec(spec.ErrFD)
+	proc, err := os.StartProcess(path, args, attr)
+	if err != nil {
+		return fail(fmt.Errorf("启动命令失败: %v", err))
+	}
+	errFile.Close()
+
+	// 作为 PID 1 回收所有退出的子进程，命令退出后结束，命名空间中的其他进程随之被内核结束
+	for {
+		var ws unix.WaitStatus
+		pid, err := unix.Wait4(-1, &ws, 0, nil)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return 1
+		}
+		if pid != proc.Pid {
+			continue
+		}
+		if ws.Signaled() {
+			return 128 + int(ws.Signal())
+		}
+		return ws.ExitStatus()
+	}
+}
+
+// seccompExec 安装 seccomp 过滤后用命令替换当前进程；只有失败时返回
+func seccompExec(spec seccompSpec) int {
+	// 过滤器按线程安装，execve 之后保留
+	runtime.LockOSThread()
+	errFile := os.NewFile(uintptr(spec.ErrFD), "sandbox-err")
+	fail := func(err error) int {
+		fmt.Fprint(errFile, err)
+		return 1
+	}
+	os.Setenv("PATH", pathFromEnv(spec.Env))
+	path, err := exec.LookPath(spec.Args[0])
+	if err != nil {
+		return fail(fmt.Errorf("找不到 %s: %v", spec.Args[0], err))
+	}
+	filter, err := buildSeccompFilter(spec.Profile)
+	if err != nil {
+		return fail(err)
+	}
+	syscall.CloseOnExec(spec.ErrFD)
+	if err := installSeccompFilter(filter); err != nil {
+		return fail(err)
+	}
+	err = unix.Exec(path, spec.Args, spec.Env)
+	return fail(fmt.Errorf("执行 %s 失败: %v", path, err))
+}
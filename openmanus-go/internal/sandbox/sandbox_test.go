@@ -0,0 +1,12 @@
+package sandbox
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain 测试程序同样作为命名空间沙箱和 seccomp 过滤的初始化进程重新执行
+func TestMain(m *testing.M) {
+	Init()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,108 @@
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 违反 seccomp 配置时的处理
+const (
+	SeccompKill  = "kill"  // 终止整个进程（SIGSYS）
+	SeccompErrno = "errno" // 系统调用返回 EPERM
+)
+
+// 内置的 seccomp 配置
+const (
+	SeccompProfileNone    = "none"    // 不过滤
+	SeccompProfileDefault = "default" // 禁止 DefaultSeccompDeny 中的系统调用
+	SeccompProfileCustom  = "custom"  // 使用自定义的白名单或黑名单
+)
+
+// DefaultSeccompDeny 默认禁止的系统调用：调试其他进程、挂载和命名空间、内核模块与 kexec、
+// BPF 和性能事件、密钥管理、修改系统时间和主机配置等。当前架构没有的调用忽略
+var DefaultSeccompDeny = []string{
+	"ptrace", "process_vm_readv", "process_vm_writev", "kcmp", "pidfd_getfd",
+	"mount", "umount", "umount2", "pivot_root", "chroot", "move_mount", "open_tree",
+	"fsopen", "fsconfig", "fsmount", "fspick", "mount_setattr", "setns", "unshare",
+	"init_module", "finit_module", "delete_module", "create_module", "query_module", "get_kernel_syms",
+	"kexec_load", "kexec_file_load", "reboot",
+	"bpf", "perf_event_open", "userfaultfd", "io_uring_setup", "io_uring_enter", "io_uring_register",
+	"add_key", "request_key", "keyctl",
+	"settimeofday", "clock_settime", "clock_adjtime", "adjtimex", "stime",
+	"sethostname", "setdomainname", "syslog", "acct", "swapon", "swapoff", "quotactl",
+	"iopl", "ioperm", "lookup_dcookie", "nfsservctl", "uselib", "ustat", "sysfs", "_sysctl",
+	"vm86", "vm86old", "open_by_handle_at", "name_to_handle_at",
+}
+
+// SeccompProfile 系统调用过滤配置
+type SeccompProfile struct {
+	// Allow 非空时只允许这些系统调用（白名单），其余按 Action 处理
+	Allow []string
+	// Deny 禁止的系统调用，白名单模式下同样生效
+	Deny []string
+	// Action 违反时的处理，默认 kill
+	Action string
+}
+
+// DefaultSeccompProfile 返回默认配置：禁止 DefaultSeccompDeny 中的系统调用并终止进程
+func DefaultSeccompProfile() *SeccompProfile {
+	return &SeccompProfile{Deny: append([]string(nil), DefaultSeccompDeny...), Action: SeccompKill}
+}
+
+// NewSeccompProfile 按配置构造 seccomp 配置：default 在默认黑名单中去掉 allow、加上 deny；
+// custom 使用 allow 作为白名单（为空时不限制）、deny 作为黑名单；none 或空返回 nil。
+// 返回前检查系统调用名在当前架构上是否存在
+func NewSeccompProfile(name string, allow, deny []string, action string) (*SeccompProfile, error) {
+	switch action {
+	case "":
+		action = SeccompKill
+	case SeccompKill, SeccompErrno:
+	default:
+		return nil, fmt.Errorf("不支持的 seccomp 处理方式 %q，可选 kill、errno", action)
+	}
+	var p *SeccompProfile
+	switch strings.ToLower(name) {
+	case "", SeccompProfileNone:
+		return nil, nil
+	case SeccompProfileDefault:
+		p = &SeccompProfile{Action: action}
+		allowed := make(map[string]bool, len(allow))
+		for _, s := range allow {
+			allowed[s] = true
+		}
+		for _, s := range DefaultSeccompDeny {
+			if !allowed[s] {
+				p.Deny = append(p.Deny, s)
+			}
+		}
+		p.Deny = append(p.Deny, deny...)
+	case SeccompProfileCustom:
+		p = &SeccompProfile{Allow: allow, Deny: deny, Action: action}
+	default:
+		return nil, fmt.Errorf("不支持的 seccomp 配置 %q，可选 none、default、custom", name)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+var (
+	defaultSeccomp   *SeccompProfile
+	defaultSeccompMu sync.RWMutex
+)
+
+// SetDefaultSeccompProfile 设置新建沙箱使用的 seccomp 配置，nil 表示不过滤
+func SetDefaultSeccompProfile(p *SeccompProfile) {
+	defaultSeccompMu.Lock()
+	defer defaultSeccompMu.Unlock()
+	defaultSeccomp = p
+}
+
+// DefaultSeccomp 返回新建沙箱使用的 seccomp 配置
+func DefaultSeccomp() *SeccompProfile {
+	defaultSeccompMu.RLock()
+	defer defaultSeccompMu.RUnlock()
+	return defaultSeccomp
+}
@@ -0,0 +1,111 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+)
+
+// seccomp_data 中系统调用号和架构的偏移
+const (
+	seccompDataNr   = 0
+	seccompDataArch = 4
+)
+
+// x32 ABI 的系统调用号带有该标志，x86_64 上统一按违规处理，避免绕过按调用号的过滤
+const x32SyscallBit = 0x40000000
+
+// 白名单模式下总是允许的系统调用：执行命令和退出需要
+var seccompRequired = []string{"execve", "exit", "exit_group", "rt_sigreturn"}
+
+// validate 检查配置中的系统调用名在当前架构上存在；默认黑名单中当前架构没有的调用忽略
+func (p *SeccompProfile) validate() error {
+	if seccompArch == 0 {
+		return errors.New("当前架构不支持 seccomp 过滤")
+	}
+	builtin := make(map[string]bool, len(DefaultSeccompDeny))
+	for _, s := range DefaultSeccompDeny {
+		builtin[s] = true
+	}
+	for _, list := range [][]string{p.Allow, p.Deny} {
+		for _, s := range list {
+			if _, ok := syscallNumbers[s]; !ok && !builtin[s] {
+				return fmt.Errorf("未知的系统调用: %s", s)
+			}
+		}
+	}
+	return nil
+}
+
+// buildSeccompFilter 生成 BPF 过滤程序：架构不符时终止，黑名单中的调用按 Action 处理，
+// 白名单非空时只允许其中的调用
+func buildSeccompFilter(p *SeccompProfile) ([]unix.SockFilter, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	deny := uint32(unix.SECCOMP_RET_KILL_PROCESS)
+	if p.Action == SeccompErrno {
+		deny = unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+	}
+	prog := []bpf.Instruction{
+		bpf.LoadAbsolute{Off: seccompDataArch, Size: 4},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: seccompArch, SkipTrue: 1},
+		bpf.RetConstant{Val: unix.SECCOMP_RET_KILL_PROCESS},
+		bpf.LoadAbsolute{Off: seccompDataNr, Size: 4},
+	}
+	if seccompArch == unix.AUDIT_ARCH_X86_64 {
+		prog = append(prog,
+			bpf.JumpIf{Cond: bpf.JumpGreaterOrEqual, Val: x32SyscallBit, SkipFalse: 1},
+			bpf.RetConstant{Val: deny},
+		)
+	}
+	// 每个调用号比较一次，相等时执行紧随其后的返回指令
+	match := func(names []string, ret uint32) {
+		seen := make(map[uint32]bool)
+		for _, name := range names {
+			nr, ok := syscallNumbers[name]
+			if !ok || seen[nr] {
+				continue
+			}
+			seen[nr] = true
+			prog = append(prog,
+				bpf.JumpIf{Cond: bpf.JumpEqual, Val: nr, SkipFalse: 1},
+				bpf.RetConstant{Val: ret},
+			)
+		}
+	}
+	match(p.Deny, deny)
+	if len(p.Allow) > 0 {
+		match(append(append([]string(nil), p.Allow...), seccompRequired...), unix.SECCOMP_RET_ALLOW)
+		prog = append(prog, bpf.RetConstant{Val: deny})
+	} else {
+		prog = append(prog, bpf.RetConstant{Val: unix.SECCOMP_RET_ALLOW})
+	}
+
+	raw, err := bpf.Assemble(prog)
+	if err != nil {
+		return nil, fmt.Errorf("生成 seccomp 过滤程序失败: %v", err)
+	}
+	filter := make([]unix.SockFilter, len(raw))
+	for i, ins := range raw {
+		filter[i] = unix.SockFilter{Code: ins.Op, Jt: ins.Jt, Jf: ins.Jf, K: ins.K}
+	}
+	return filter, nil
+}
+
+// installSeccompFilter 为当前线程设置 no_new_privs 并安装过滤程序
+func installSeccompFilter(filter []unix.SockFilter) error {
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("设置 no_new_privs 失败: %v", err)
+	}
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("安装 seccomp 过滤失败: %v", err)
+	}
+	return nil
+}
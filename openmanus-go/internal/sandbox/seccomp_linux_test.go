@@ -0,0 +1,207 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+)
+
+// runSeccompFilter 用 BPF 虚拟机执行过滤程序，返回对系统调用 nr 的处理结果
+func runSeccompFilter(t *testing.T, filter []unix.SockFilter, arch, nr uint32) uint32 {
+	t.Helper()
+	raw := make([]bpf.RawInstruction, len(filter))
+	for i, f := range filter {
+		raw[i] = bpf.RawInstruction{Op: f.Code, Jt: f.Jt, Jf: f.Jf, K: f.K}
+	}
+	prog, ok := bpf.Disassemble(raw)
+	if !ok {
+		t.Fatal("filter contains unknown instructions")
+	}
+	vm, err := bpf.NewVM(prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// seccomp_data：nr、arch、instruction_pointer、args[6]；内核按本机字节序读取，
+	// 虚拟机按大端读取，因此按大端写入
+	data := make([]byte, 64)
+	binary.BigEndian.PutUint32(data[seccompDataNr:], nr)
+	binary.BigEndian.PutUint32(data[seccompDataArch:], arch)
+	ret, err := vm.Run(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uint32(ret)
+}
+
+func TestBuildSeccompFilter(t *testing.T) {
+	if seccompArch == 0 {
+		t.Skip("seccomp is not supported on this architecture")
+	}
+	const (
+		allow = unix.SECCOMP_RET_ALLOW
+		kill  = unix.SECCOMP_RET_KILL_PROCESS
+		errno = unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+	)
+	tests := []struct {
+		name    string
+		profile *SeccompProfile
+		syscall string
+		want    uint32
+	}{
+		{"default denies ptrace", DefaultSeccompProfile(), "ptrace", kill},
+		{"default denies unshare", DefaultSeccompProfile(), "unshare", kill},
+		{"default allows read", DefaultSeccompProfile(), "read", allow},
+		{"default allows execve", DefaultSeccompProfile(), "execve", allow},
+		{"errno action", &SeccompProfile{Deny: []string{"ptrace"}, Action: SeccompErrno}, "ptrace", errno},
+		{"errno action allows others", &SeccompProfile{Deny: []string{"ptrace"}, Action: SeccompErrno}, "read", allow},
+		{"allow list permits listed", &SeccompProfile{Allow: []string{"read", "write"}}, "write", allow},
+		{"allow list permits required", &SeccompProfile{Allow: []string{"read"}}, "exit_group", allow},
+		{"allow list rejects others", &SeccompProfile{Allow: []string{"read"}}, "openat", kill},
+		{"allow list rejects with errno", &SeccompProfile{Allow: []string{"read"}, Action: SeccompErrno}, "openat", errno},
+		{"deny wins over allow", &SeccompProfile{Allow: []string{"read", "write"}, Deny: []string{"write"}}, "write", kill},
+		{"empty profile allows all", &SeccompProfile{}, "ptrace", allow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := buildSeccompFilter(tt.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nr, ok := syscallNumbers[tt.syscall]
+			if !ok {
+				t.Fatalf("unknown syscall %s", tt.syscall)
+			}
+			if got := runSeccompFilter(t, filter, seccompArch, nr); got != tt.want {
+				t.Fatalf("%s: got %#x, want %#x", tt.syscall, got, tt.want)
+			}
+		})
+	}
+
+	filter, err := buildSeccompFilter(&SeccompProfile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 其他架构的调用号含义不同，一律终止
+	if got := runSeccompFilter(t, filter, unix.AUDIT_ARCH_I386, syscallNumbers["read"]); got != kill {
+		t.Fatalf("foreign arch: got %#x", got)
+	}
+	if seccompArch == unix.AUDIT_ARCH_X86_64 {
+		// x32 调用号带 0x40000000 标志，不能绕过按调用号的过滤
+		if got := runSeccompFilter(t, filter, seccompArch, x32SyscallBit|syscallNumbers["ptrace"]); got != kill {
+			t.Fatalf("x32 syscall: got %#x", got)
+		}
+	}
+
+	// 重复的调用名只比较一次
+	once, _ := buildSeccompFilter(&SeccompProfile{Deny: []string{"ptrace"}})
+	twice, _ := buildSeccompFilter(&SeccompProfile{Deny: []string{"ptrace", "ptrace"}})
+	if len(once) != len(twice) {
+		t.Fatalf("duplicate deny entries: %d instructions, want %d", len(twice), len(once))
+	}
+}
+
+func TestNewSeccompProfile(t *testing.T) {
+	if seccompArch == 0 {
+		t.Skip("seccomp is not supported on this architecture")
+	}
+	tests := []struct {
+		name    string
+		profile string
+		allow   []string
+		deny    []string
+		action  string
+		check   func(p *SeccompProfile) bool
+		wantErr bool
+	}{
+		{name: "none", profile: "none", check: func(p *SeccompProfile) bool { return p == nil }},
+		{name: "empty", check: func(p *SeccompProfile) bool { return p == nil }},
+		{name: "default", profile: "default", check: func(p *SeccompProfile) bool {
+			return len(p.Allow) == 0 && len(p.Deny) == len(DefaultSeccompDeny) && p.Action == SeccompKill
+		}},
+		{name: "default with overrides", profile: "Default", allow: []string{"ptrace"}, deny: []string{"chroot", "kill"}, action: SeccompErrno,
+			check: func(p *SeccompProfile) bool {
+				return !slices.Contains(p.Deny, "ptrace") && slices.Contains(p.Deny, "kill") && p.Action == SeccompErrno
+			}},
+		{name: "custom", profile: "custom", allow: []string{"read"}, deny: []string{"write"}, check: func(p *SeccompProfile) bool {
+			return len(p.Allow) == 1 && len(p.Deny) == 1 && p.Action == SeccompKill
+		}},
+		{name: "unknown profile", profile: "strict", wantErr: true},
+		{name: "unknown action", profile: "default", action: "trap", wantErr: true},
+		{name: "unknown syscall", profile: "custom", allow: []string{"no_such_call"}, wantErr: true},
+		{name: "unknown syscall in deny", profile: "default", deny: []string{"no_such_call"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewSeccompProfile(tt.profile, tt.allow, tt.deny, tt.action)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want error", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(p) {
+				t.Fatalf("profile = %+v", p)
+			}
+		})
+	}
+}
+
+func TestSeccompEnforced(t *testing.T) {
+	if seccompArch == 0 {
+		t.Skip("seccomp is not supported on this architecture")
+	}
+	var deny []string
+	for _, s := range []string{"mkdir", "mkdirat"} {
+		if _, ok := syscallNumbers[s]; ok {
+			deny = append(deny, s)
+		}
+	}
+	tests := []struct {
+		action    string
+		violation string
+	}{
+		{SeccompKill, ViolationSeccomp},
+		{SeccompErrno, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			dir := t.TempDir()
+			s := NewBaseSandbox()
+			s.dir = dir
+			s.SetResourceLimits(ResourceLimits{TimeLimit: 30})
+			s.SetSeccompProfile(&SeccompProfile{Deny: deny, Action: tt.action})
+
+			res, err := s.Run(context.Background(), "mkdir sub", ExecOptions{})
+			if err == nil {
+				t.Fatal("mkdir succeeded under seccomp")
+			}
+			if res == nil || res.Violation != tt.violation {
+				t.Fatalf("result = %+v, %v", res, err)
+			}
+			if tt.action == SeccompErrno && !strings.Contains(res.Stderr, "not permitted") {
+				t.Fatalf("stderr = %q", res.Stderr)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "sub")); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("directory created: %v", err)
+			}
+
+			// 未被禁止的调用正常执行
+			if _, err := s.Run(context.Background(), "echo ok > f.txt", ExecOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
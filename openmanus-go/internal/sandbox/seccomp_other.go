@@ -0,0 +1,13 @@
+//go:build !linux
+
+package sandbox
+
+// validate seccomp 只在 Linux 上生效，其他平台忽略配置
+func (p *SeccompProfile) validate() error {
+	return nil
+}
+
+// execHooks 其他平台直接在宿主上执行命令
+func (s *BaseSandbox) execHooks() execHooks {
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+// 当前架构没有系统调用表，不支持 seccomp 过滤
+const seccompArch = 0
+
+var syscallNumbers = map[string]uint32{}
@@ -0,0 +1,658 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// 分析参数默认值
+const (
+	defaultTopValues      = 5
+	defaultClusterK       = 3
+	defaultClusterMaxIter = 300
+	defaultClusterRuns    = 4
+	defaultForecastWindow = 3
+	defaultForecastSteps  = 5
+	maxCorrelationPairs   = 20
+)
+
+// 预测方法
+const (
+	ForecastMovingAverage        = "moving_average"
+	ForecastExponentialSmoothing = "exponential_smoothing"
+)
+
+// runNative 在 Go 中直接执行分析操作
+func (d *DataAnalysisTool) runNative(in DataAnalysisInput) (interface{}, error) {
+	ds, source, err := loadDataset(nil, in.Path, in.Format, in.Data)
+	if err != nil {
+		return nil, err
+	}
+	meta := map[string]interface{}{
+		"operation": in.Operation,
+		"source":    source,
+		"rows":      len(ds.rows),
+		"columns":   len(ds.columns),
+		"engine":    "go",
+	}
+
+	var result interface{}
+	switch in.Operation {
+	case OpAnalyze:
+		result, err = analyzeDataset(ds, in.Options)
+	case OpCorrelate:
+		result, err = correlateDataset(ds, in.Options)
+	case OpRegression:
+		result, err = regressDataset(ds, in.Options, meta)
+	case OpCluster:
+		result, err = clusterDataset(ds, in.Options, meta)
+	case OpForecast:
+		result, err = forecastDataset(ds, in.Options, meta)
+	case OpVisualize:
+		if len(in.Charts) == 0 {
+			in.Charts = []ChartSpec{{Type: ChartLine}}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	output := DataAnalysisOutput{Result: result, Metadata: meta}
+	if len(in.Charts) > 0 {
+		if output.Visualization, err = d.renderCharts(ds, in, result); err != nil {
+			return nil, err
+		}
+		if in.Operation == OpVisualize {
+			output.Result = output.Visualization
+		}
+	}
+	return output, nil
+}
+
+// decodeOptions 解析操作参数，未提供时保留默认值
+func decodeOptions(options map[string]interface{}, out interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	return decodeArgs(options, out)
+}
+
+// NumericSummary 数值列的描述统计
+type NumericSummary struct {
+	Name    string  `json:"name"`
+	Count   int     `json:"count"`
+	Missing int     `json:"missing"`
+	Mean    float64 `json:"mean"`
+	Std     float64 `json:"std"`
+	Min     float64 `json:"min"`
+	P25     float64 `json:"25%"`
+	Median  float64 `json:"50%"`
+	P75     float64 `json:"75%"`
+	Max     float64 `json:"max"`
+}
+
+// ValueCount 取值及其出现次数
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// CategoricalSummary 非数值列的描述统计
+type CategoricalSummary struct {
+	Name    string       `json:"name"`
+	Count   int          `json:"count"`
+	Missing int          `json:"missing"`
+	Unique  int          `json:"unique"`
+	Top     []ValueCount `json:"top"`
+}
+
+// DescribeResult analyze 操作结果
+type DescribeResult struct {
+	Rows        int                  `json:"rows"`
+	Columns     []string             `json:"columns"`
+	Numeric     []NumericSummary     `json:"numeric,omitempty"`
+	Categorical []CategoricalSummary `json:"categorical,omitempty"`
+}
+
+type analyzeOptions struct {
+	Columns []string `json:"columns,omitempty"`
+	// TopValues 非数值列列出的高频取值个数
+	TopValues int `json:"top_values,omitempty"`
+}
+
+// analyzeDataset 计算各列的描述统计
+func analyzeDataset(ds *dataset, options map[string]interface{}) (DescribeResult, error) {
+	opts := analyzeOptions{TopValues: defaultTopValues}
+	if err := decodeOptions(options, &opts); err != nil {
+		return DescribeResult{}, err
+	}
+	cols := make([]int, 0, len(ds.columns))
+	if len(opts.Columns) == 0 {
+		for i := range ds.columns {
+			cols = append(cols, i)
+		}
+	}
+	for _, name := range opts.Columns {
+		i, err := ds.column(name)
+		if err != nil {
+			return DescribeResult{}, err
+		}
+		cols = append(cols, i)
+	}
+
+	result := DescribeResult{Rows: len(ds.rows), Columns: ds.columnNames(cols)}
+	for _, c := range cols {
+		if ds.isNumeric(c) {
+			result.Numeric = append(result.Numeric, summarizeNumeric(ds, c))
+		} else {
+			result.Categorical = append(result.Categorical, summarizeCategorical(ds, c, opts.TopValues))
+		}
+	}
+	return result, nil
+}
+
+func summarizeNumeric(ds *dataset, col int) NumericSummary {
+	values := dropNaN(ds.floats(col))
+	sort.Float64s(values)
+	return NumericSummary{
+		Name:    ds.columns[col],
+		Count:   len(values),
+		Missing: len(ds.rows) - len(values),
+		Mean:    roundFloat(mean(values)),
+		Std:     roundFloat(stddev(values)),
+		Min:     values[0],
+		P25:     roundFloat(quantile(values, 0.25)),
+		Median:  roundFloat(quantile(values, 0.5)),
+		P75:     roundFloat(quantile(values, 0.75)),
+		Max:     values[len(values)-1],
+	}
+}
+
+func summarizeCategorical(ds *dataset, col, top int) CategoricalSummary {
+	summary := CategoricalSummary{Name: ds.columns[col]}
+	counts := make(map[string]int)
+	for i := range ds.rows {
+		v := cellString(ds.cell(i, col))
+		if v == "" {
+			summary.Missing++
+			continue
+		}
+		summary.Count++
+		counts[v]++
+	}
+	summary.Unique = len(counts)
+	for v, n := range counts {
+		summary.Top = append(summary.Top, ValueCount{Value: v, Count: n})
+	}
+	sort.Slice(summary.Top, func(i, j int) bool {
+		if summary.Top[i].Count != summary.Top[j].Count {
+			return summary.Top[i].Count > summary.Top[j].Count
+		}
+		return summary.Top[i].Value < summary.Top[j].Value
+	})
+	if top > 0 && len(summary.Top) > top {
+		summary.Top = summary.Top[:top]
+	}
+	return summary
+}
+
+// CorrelationPair 两列之间的相关系数
+type CorrelationPair struct {
+	A string  `json:"a"`
+	B string  `json:"b"`
+	R float64 `json:"r"`
+	N int     `json:"n"`
+}
+
+// CorrelationResult correlate 操作结果，方差为 0 的列对应的系数为 null
+type CorrelationResult struct {
+	Method  string       `json:"method"`
+	Columns []string     `json:"columns"`
+	Matrix  [][]*float64 `json:"matrix"`
+	// Pairs 按相关系数绝对值从大到小排列
+	Pairs []CorrelationPair `json:"pairs"`
+}
+
+type correlateOptions struct {
+	Columns []string `json:"columns,omitempty"`
+	// Method pearson 或 spearman
+	Method string `json:"method,omitempty"`
+}
+
+// correlateDataset 计算数值列两两之间的相关系数，缺失值按列对成对剔除
+func correlateDataset(ds *dataset, options map[string]interface{}) (CorrelationResult, error) {
+	opts := correlateOptions{Method: "pearson"}
+	if err := decodeOptions(options, &opts); err != nil {
+		return CorrelationResult{}, err
+	}
+	opts.Method = strings.ToLower(opts.Method)
+	if opts.Method != "pearson" && opts.Method != "spearman" {
+		return CorrelationResult{}, fmt.Errorf("%w: method must be pearson or spearman", ErrInvalidArgs)
+	}
+	cols, err := ds.numericColumns(opts.Columns)
+	if err != nil {
+		return CorrelationResult{}, err
+	}
+	if len(cols) < 2 {
+		return CorrelationResult{}, fmt.Errorf("%w: correlation needs at least two numeric columns", ErrInvalidArgs)
+	}
+
+	series := make([][]float64, len(cols))
+	for i, c := range cols {
+		series[i] = ds.floats(c)
+	}
+	result := CorrelationResult{Method: opts.Method, Columns: ds.columnNames(cols)}
+	result.Matrix = make([][]*float64, len(cols))
+	for i := range result.Matrix {
+		result.Matrix[i] = make([]*float64, len(cols))
+	}
+	for i := range cols {
+		one := 1.0
+		result.Matrix[i][i] = &one
+		for j := i + 1; j < len(cols); j++ {
+			var x, y []float64
+			for k := range series[i] {
+				if !math.IsNaN(series[i][k]) && !math.IsNaN(series[j][k]) {
+					x = append(x, series[i][k])
+					y = append(y, series[j][k])
+				}
+			}
+			if len(x) < 2 {
+				continue
+			}
+			if opts.Method == "spearman" {
+				x, y = ranks(x), ranks(y)
+			}
+			r := pearson(x, y)
+			if math.IsNaN(r) {
+				continue
+			}
+			r = roundFloat(r)
+			result.Matrix[i][j], result.Matrix[j][i] = &r, &r
+			result.Pairs = append(result.Pairs, CorrelationPair{A: result.Columns[i], B: result.Columns[j], R: r, N: len(x)})
+		}
+	}
+	sort.SliceStable(result.Pairs, func(i, j int) bool {
+		return math.Abs(result.Pairs[i].R) > math.Abs(result.Pairs[j].R)
+	})
+	if len(result.Pairs) > maxCorrelationPairs {
+		result.Pairs = result.Pairs[:maxCorrelationPairs]
+	}
+	return result, nil
+}
+
+// RegressionCoefficient 回归系数
+type RegressionCoefficient struct {
+	Name     string  `json:"name"`
+	Estimate float64 `json:"estimate"`
+	StdError float64 `json:"std_error"`
+	TValue   float64 `json:"t_value,omitempty"`
+}
+
+// RegressionResult regression 操作结果
+type RegressionResult struct {
+	Target   string   `json:"target"`
+	Features []string `json:"features"`
+	// Coefficients 首项为截距
+	Coefficients []RegressionCoefficient `json:"coefficients"`
+	RSquared     float64                 `json:"r_squared"`
+	AdjRSquared  float64                 `json:"adj_r_squared"`
+	RMSE         float64                 `json:"rmse"`
+	N            int                     `json:"n"`
+	Equation     string                  `json:"equation"`
+}
+
+type regressionOptions struct {
+	// Target 因变量，默认最后一个数值列
+	Target string `json:"target,omitempty"`
+	// Features 自变量，默认除因变量外的全部数值列
+	Features []string `json:"features,omitempty"`
+}
+
+// regressDataset 多元线性回归（普通最小二乘），缺失值所在行整行剔除
+func regressDataset(ds *dataset, options map[string]interface{}, meta map[string]interface{}) (RegressionResult, error) {
+	var opts regressionOptions
+	if err := decodeOptions(options, &opts); err != nil {
+		return RegressionResult{}, err
+	}
+	numeric, err := ds.numericColumns(nil)
+	if err != nil {
+		return RegressionResult{}, err
+	}
+	var target int
+	if opts.Target != "" {
+		targets, err := ds.numericColumns([]string{opts.Target})
+		if err != nil {
+			return RegressionResult{}, err
+		}
+		target = targets[0]
+	} else if len(numeric) > 0 {
+		target = numeric[len(numeric)-1]
+	} else {
+		return RegressionResult{}, fmt.Errorf("%w: regression needs numeric columns", ErrInvalidArgs)
+	}
+	var features []int
+	if len(opts.Features) > 0 {
+		if features, err = ds.numericColumns(opts.Features); err != nil {
+			return RegressionResult{}, err
+		}
+	} else {
+		for _, c := range numeric {
+			if c != target {
+				features = append(features, c)
+			}
+		}
+	}
+	for _, c := range features {
+		if c == target {
+			return RegressionResult{}, fmt.Errorf("%w: target %q cannot also be a feature", ErrInvalidArgs, ds.columns[c])
+		}
+	}
+	if len(features) == 0 {
+		return RegressionResult{}, fmt.Errorf("%w: regression needs at least one numeric feature column", ErrInvalidArgs)
+	}
+
+	index, m := ds.matrix(append(append([]int(nil), features...), target))
+	n, p := len(m), len(features)
+	meta["used_rows"] = n
+	meta["dropped_rows"] = len(ds.rows) - n
+	if n <= p+1 {
+		return RegressionResult{}, fmt.Errorf("%w: regression with %d features needs more than %d complete rows, got %d", ErrInvalidArgs, p, p+1, len(index))
+	}
+	x := make([][]float64, n)
+	y := make([]float64, n)
+	for i, row := range m {
+		x[i], y[i] = row[:p], row[p]
+	}
+	beta, inv, err := olsFit(x, y)
+	if errors.Is(err, errSingularMatrix) {
+		return RegressionResult{}, fmt.Errorf("features are collinear or constant, remove redundant columns: %v", err)
+	}
+	if err != nil {
+		return RegressionResult{}, err
+	}
+
+	var sse, sst float64
+	ym := mean(y)
+	for i := range x {
+		pred := beta[0]
+		for j, v := range x[i] {
+			pred += beta[j+1] * v
+		}
+		sse += (y[i] - pred) * (y[i] - pred)
+		sst += (y[i] - ym) * (y[i] - ym)
+	}
+	df := float64(n - p - 1)
+	r2 := 1.0
+	if sst > 0 {
+		r2 = 1 - sse/sst
+	}
+	sigma2 := sse / df
+
+	names := append([]string{"intercept"}, ds.columnNames(features)...)
+	result := RegressionResult{
+		Target:      ds.columns[target],
+		Features:    names[1:],
+		RSquared:    roundFloat(r2),
+		AdjRSquared: roundFloat(1 - (1-r2)*float64(n-1)/df),
+		RMSE:        roundFloat(math.Sqrt(sse / float64(n))),
+		N:           n,
+	}
+	var eq strings.Builder
+	fmt.Fprintf(&eq, "%s = %s", result.Target, formatFloat(roundFloat(beta[0])))
+	for j, b := range beta {
+		coef := RegressionCoefficient{Name: names[j], Estimate: roundFloat(b)}
+		if se := math.Sqrt(math.Max(sigma2*inv[j][j], 0)); se > 0 {
+			coef.StdError = roundFloat(se)
+			coef.TValue = roundFloat(b / se)
+		}
+		result.Coefficients = append(result.Coefficients, coef)
+		if j > 0 {
+			sign := "+"
+			if b < 0 {
+				sign = "-"
+			}
+			fmt.Fprintf(&eq, " %s %s*%s", sign, formatFloat(roundFloat(math.Abs(b))), names[j])
+		}
+	}
+	result.Equation = eq.String()
+	return result, nil
+}
+
+func formatFloat(v float64) string {
+	return fmt.Sprintf("%g", v)
+}
+
+// ClusterResult cluster 操作结果
+type ClusterResult struct {
+	K       int      `json:"k"`
+	Columns []string `json:"columns"`
+	// Centroids 各簇中心，使用原始量纲
+	Centroids [][]float64 `json:"centroids"`
+	Sizes     []int       `json:"sizes"`
+	// Labels 每行所属簇，含缺失值而未参与聚类的行为 -1
+	Labels []int `json:"labels"`
+	// Inertia 簇内平方和，标准化时在标准化后的空间中计算
+	Inertia      float64 `json:"inertia"`
+	Iterations   int     `json:"iterations"`
+	Standardized bool    `json:"standardized"`
+}
+
+type clusterOptions struct {
+	K       int      `json:"k,omitempty"`
+	Columns []string `json:"columns,omitempty"`
+	MaxIter int      `json:"max_iter,omitempty"`
+	// NInit 不同初始中心的运行次数，取簇内平方和最小的结果
+	NInit int    `json:"n_init,omitempty"`
+	Seed  *int64 `json:"seed,omitempty"`
+	// Standardize 聚类前对各列做 z-score 标准化，默认开启
+	Standardize *bool `json:"standardize,omitempty"`
+}
+
+// clusterDataset k-means 聚类
+func clusterDataset(ds *dataset, options map[string]interface{}, meta map[string]interface{}) (ClusterResult, error) {
+	opts := clusterOptions{K: defaultClusterK, MaxIter: defaultClusterMaxIter, NInit: defaultClusterRuns}
+	if err := decodeOptions(options, &opts); err != nil {
+		return ClusterResult{}, err
+	}
+	if opts.K < 1 {
+		return ClusterResult{}, fmt.Errorf("%w: k must be positive", ErrInvalidArgs)
+	}
+	if opts.MaxIter < 1 {
+		opts.MaxIter = defaultClusterMaxIter
+	}
+	if opts.NInit < 1 {
+		opts.NInit = 1
+	}
+	cols, err := ds.numericColumns(opts.Columns)
+	if err != nil {
+		return ClusterResult{}, err
+	}
+	if len(cols) == 0 {
+		return ClusterResult{}, fmt.Errorf("%w: clustering needs numeric columns", ErrInvalidArgs)
+	}
+	index, points := ds.matrix(cols)
+	meta["used_rows"] = len(points)
+	meta["dropped_rows"] = len(ds.rows) - len(points)
+	if len(points) < opts.K {
+		return ClusterResult{}, fmt.Errorf("%w: k=%d exceeds the %d complete rows", ErrInvalidArgs, opts.K, len(points))
+	}
+
+	standardize := opts.Standardize == nil || *opts.Standardize
+	space := points
+	if standardize {
+		space = make([][]float64, len(points))
+		for i := range space {
+			space[i] = make([]float64, len(cols))
+		}
+		for j := range cols {
+			column := make([]float64, len(points))
+			for i := range points {
+				column[i] = points[i][j]
+			}
+			m, s := mean(column), stddev(column)
+			if s == 0 {
+				s = 1
+			}
+			for i := range points {
+				space[i][j] = (points[i][j] - m) / s
+			}
+		}
+	}
+
+	seed := int64(1)
+	if opts.Seed != nil {
+		seed = *opts.Seed
+	}
+	res := kmeans(space, opts.K, opts.MaxIter, opts.NInit, rand.New(rand.NewSource(seed)))
+
+	result := ClusterResult{
+		K:            opts.K,
+		Columns:      ds.columnNames(cols),
+		Centroids:    make([][]float64, opts.K),
+		Sizes:        make([]int, opts.K),
+		Labels:       make([]int, len(ds.rows)),
+		Inertia:      roundFloat(res.inertia),
+		Iterations:   res.iterations,
+		Standardized: standardize,
+	}
+	for i := range result.Labels {
+		result.Labels[i] = -1
+	}
+	for c := range result.Centroids {
+		result.Centroids[c] = make([]float64, len(cols))
+	}
+	for i, label := range res.labels {
+		result.Labels[index[i]] = label
+		result.Sizes[label]++
+		for j, v := range points[i] {
+			result.Centroids[label][j] += v
+		}
+	}
+	for c, centroid := range result.Centroids {
+		for j := range centroid {
+			if result.Sizes[c] > 0 {
+				centroid[j] = roundFloat(centroid[j] / float64(result.Sizes[c]))
+			}
+		}
+	}
+	return result, nil
+}
+
+// ForecastResult forecast 操作结果
+type ForecastResult struct {
+	Column string  `json:"column"`
+	Method string  `json:"method"`
+	Window int     `json:"window,omitempty"`
+	Alpha  float64 `json:"alpha,omitempty"`
+	// Forecast 之后 horizon 期的预测值
+	Forecast  []float64 `json:"forecast"`
+	LastValue float64   `json:"last_value"`
+	// MAE、RMSE 为历史数据上一步预测的误差
+	MAE  float64 `json:"mae"`
+	RMSE float64 `json:"rmse"`
+	N    int     `json:"n"`
+}
+
+type forecastOptions struct {
+	// Column 预测的数值列，默认第一个数值列
+	Column string `json:"column,omitempty"`
+	Method string `json:"method,omitempty"`
+	Window int    `json:"window,omitempty"`
+	// Alpha 平滑系数，取值 (0, 1]，未设置时按历史一步预测误差自动选择
+	Alpha   float64 `json:"alpha,omitempty"`
+	Horizon int     `json:"horizon,omitempty"`
+}
+
+// forecastDataset 用移动平均或简单指数平滑预测数值列，数据按行顺序视为时间序列
+func forecastDataset(ds *dataset, options map[string]interface{}, meta map[string]interface{}) (ForecastResult, error) {
+	opts := forecastOptions{Method: ForecastExponentialSmoothing, Window: defaultForecastWindow, Horizon: defaultForecastSteps}
+	if err := decodeOptions(options, &opts); err != nil {
+		return ForecastResult{}, err
+	}
+	switch strings.ToLower(opts.Method) {
+	case "ma", "sma", "moving_average":
+		opts.Method = ForecastMovingAverage
+	case "ses", "exponential", "exponential_smoothing":
+		opts.Method = ForecastExponentialSmoothing
+	default:
+		return ForecastResult{}, fmt.Errorf("%w: method must be moving_average or exponential_smoothing", ErrInvalidArgs)
+	}
+	if opts.Horizon < 1 {
+		opts.Horizon = defaultForecastSteps
+	}
+	if opts.Alpha < 0 || opts.Alpha > 1 {
+		return ForecastResult{}, fmt.Errorf("%w: alpha must be in (0, 1]", ErrInvalidArgs)
+	}
+
+	var names []string
+	if opts.Column != "" {
+		names = []string{opts.Column}
+	}
+	cols, err := ds.numericColumns(names)
+	if err != nil {
+		return ForecastResult{}, err
+	}
+	if len(cols) == 0 {
+		return ForecastResult{}, fmt.Errorf("%w: forecast needs a numeric column", ErrInvalidArgs)
+	}
+	values := dropNaN(ds.floats(cols[0]))
+	meta["used_rows"] = len(values)
+	meta["dropped_rows"] = len(ds.rows) - len(values)
+	if len(values) < 2 {
+		return ForecastResult{}, fmt.Errorf("%w: forecast needs at least two values", ErrInvalidArgs)
+	}
+
+	result := ForecastResult{
+		Column:    ds.columns[cols[0]],
+		Method:    opts.Method,
+		LastValue: values[len(values)-1],
+		N:         len(values),
+	}
+	var fitted []float64
+	var next float64
+	switch opts.Method {
+	case ForecastMovingAverage:
+		window := opts.Window
+		if window < 1 {
+			window = defaultForecastWindow
+		}
+		if window >= len(values) {
+			window = len(values) - 1
+		}
+		fitted, next = movingAverageFit(values, window)
+		result.Window = window
+	case ForecastExponentialSmoothing:
+		alpha := opts.Alpha
+		if alpha == 0 {
+			alpha = bestSmoothingAlpha(values)
+		}
+		fitted, next = exponentialSmoothingFit(values, alpha)
+		result.Alpha = roundFloat(alpha)
+	}
+	mae, rmse := fitErrors(values, fitted)
+	result.MAE, result.RMSE = roundFloat(mae), roundFloat(rmse)
+	result.Forecast = make([]float64, opts.Horizon)
+	for i := range result.Forecast {
+		result.Forecast[i] = roundFloat(next)
+	}
+	return result, nil
+}
+
+// bestSmoothingAlpha 在 0.01~1 之间搜索使一步预测平方误差最小的平滑系数
+func bestSmoothingAlpha(values []float64) float64 {
+	best, bestErr := 0.5, math.Inf(1)
+	for i := 1; i <= 100; i++ {
+		alpha := float64(i) / 100
+		fitted, _ := exponentialSmoothingFit(values, alpha)
+		_, rmse := fitErrors(values, fitted)
+		if rmse < bestErr {
+			best, bestErr = alpha, rmse
+		}
+	}
+	return best
+}
@@ -0,0 +1,276 @@
+package tool
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+const testDatasetCSV = "x,y,g\n1,2,a\n2,4,b\n3,5,a\n4,4,c\n5,5,a\n"
+
+func newTestDataset(t *testing.T) *dataset {
+	t.Helper()
+	ds, err := parseDatasetText(testDatasetCSV, "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+func TestAnalyzeDataset(t *testing.T) {
+	ds := newTestDataset(t)
+	got, err := analyzeDataset(ds, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DescribeResult{
+		Rows:    5,
+		Columns: []string{"x", "y", "g"},
+		Numeric: []NumericSummary{
+			{Name: "x", Count: 5, Mean: 3, Std: 1.58113883, Min: 1, P25: 2, Median: 3, P75: 4, Max: 5},
+			{Name: "y", Count: 5, Mean: 4, Std: 1.224744871, Min: 2, P25: 4, Median: 4, P75: 5, Max: 5},
+		},
+		Categorical: []CategoricalSummary{
+			{Name: "g", Count: 5, Unique: 3, Top: []ValueCount{{"a", 3}, {"b", 1}, {"c", 1}}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("analyze =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// 缺失值单独计数，不参与统计
+	ds, err = parseDatasetText("v,label\n1,\nNA,x\n3,x\n", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = analyzeDataset(ds, map[string]interface{}{"top_values": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := got.Numeric[0]; s.Count != 2 || s.Missing != 1 || s.Mean != 2 || s.Median != 2 {
+		t.Fatalf("numeric with missing = %+v", s)
+	}
+	if s := got.Categorical[0]; s.Count != 2 || s.Missing != 1 || !reflect.DeepEqual(s.Top, []ValueCount{{"x", 2}}) {
+		t.Fatalf("categorical with missing = %+v", s)
+	}
+
+	if _, err := analyzeDataset(newTestDataset(t), map[string]interface{}{"columns": []interface{}{"nope"}}); !errors.Is(err, ErrInvalidArgs) {
+		t.Fatalf("unknown column: %v", err)
+	}
+}
+
+func TestCorrelateDataset(t *testing.T) {
+	tests := []struct {
+		method string
+		want   float64
+	}{
+		{"pearson", 0.7745966692},
+		{"spearman", 0.7378647874},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			got, err := correlateDataset(newTestDataset(t), map[string]interface{}{"method": tt.method})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Columns, []string{"x", "y"}) {
+				t.Fatalf("columns = %v", got.Columns)
+			}
+			if *got.Matrix[0][0] != 1 || *got.Matrix[0][1] != tt.want || *got.Matrix[1][0] != tt.want {
+				t.Fatalf("matrix = [[%g %g] [%g %g]]", *got.Matrix[0][0], *got.Matrix[0][1], *got.Matrix[1][0], *got.Matrix[1][1])
+			}
+			if want := []CorrelationPair{{A: "x", B: "y", R: tt.want, N: 5}}; !reflect.DeepEqual(got.Pairs, want) {
+				t.Fatalf("pairs = %+v", got.Pairs)
+			}
+		})
+	}
+
+	// 方差为 0 的列系数为 null
+	ds, err := parseDatasetText("a,b\n1,3\n2,3\n3,3\n", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := correlateDataset(ds, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Matrix[0][1] != nil || len(got.Pairs) != 0 {
+		t.Fatalf("constant column correlation = %v, %+v", got.Matrix[0][1], got.Pairs)
+	}
+
+	if _, err := correlateDataset(newTestDataset(t), map[string]interface{}{"method": "kendall"}); !errors.Is(err, ErrInvalidArgs) {
+		t.Fatalf("unknown method: %v", err)
+	}
+}
+
+func TestRegressDataset(t *testing.T) {
+	meta := make(map[string]interface{})
+	got, err := regressDataset(newTestDataset(t), map[string]interface{}{"target": "y"}, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RegressionResult{
+		Target:   "y",
+		Features: []string{"x"},
+		Coefficients: []RegressionCoefficient{
+			{Name: "intercept", Estimate: 2.2, StdError: roundFloat(math.Sqrt(0.88)), TValue: roundFloat(2.2 / math.Sqrt(0.88))},
+			{Name: "x", Estimate: 0.6, StdError: roundFloat(math.Sqrt(0.08)), TValue: roundFloat(0.6 / math.Sqrt(0.08))},
+		},
+		RSquared:    0.6,
+		AdjRSquared: 0.4666666667,
+		RMSE:        roundFloat(math.Sqrt(0.48)),
+		N:           5,
+		Equation:    "y = 2.2 + 0.6*x",
+	}
+	if len(got.Coefficients) != len(want.Coefficients) {
+		t.Fatalf("coefficients = %+v", got.Coefficients)
+	}
+	for i, c := range got.Coefficients {
+		w := want.Coefficients[i]
+		if c.Name != w.Name || !approxEqual(c.Estimate, w.Estimate) || !approxEqual(c.StdError, w.StdError) || !approxEqual(c.TValue, w.TValue) {
+			t.Fatalf("coefficient %d = %+v, want %+v", i, c, w)
+		}
+	}
+	if got.Target != want.Target || !reflect.DeepEqual(got.Features, want.Features) || got.N != want.N || got.Equation != want.Equation ||
+		!approxEqual(got.RSquared, want.RSquared) || !approxEqual(got.AdjRSquared, want.AdjRSquared) || !approxEqual(got.RMSE, want.RMSE) {
+		t.Fatalf("regression = %+v", got)
+	}
+	if meta["used_rows"] != 5 || meta["dropped_rows"] != 0 {
+		t.Fatalf("meta = %v", meta)
+	}
+
+	// 负系数在方程中显示为减号，缺失值所在行被剔除
+	ds, err := parseDatasetText("x,y\n1,9\n2,7\n3,\n4,3\n5,1\n", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta = make(map[string]interface{})
+	got, err = regressDataset(ds, nil, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Equation != "y = 11 - 2*x" || got.RSquared != 1 || meta["dropped_rows"] != 1 {
+		t.Fatalf("regression = %+v, meta = %v", got, meta)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		options map[string]interface{}
+	}{
+		{"target is feature", testDatasetCSV, map[string]interface{}{"target": "y", "features": []interface{}{"y"}}},
+		{"categorical target", testDatasetCSV, map[string]interface{}{"target": "g"}},
+		{"too few rows", "x,y\n1,2\n2,3\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, err := parseDatasetText(tt.data, "csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := regressDataset(ds, tt.options, make(map[string]interface{})); !errors.Is(err, ErrInvalidArgs) {
+				t.Fatalf("got %v, want ErrInvalidArgs", err)
+			}
+		})
+	}
+}
+
+func TestClusterDataset(t *testing.T) {
+	ds, err := parseDatasetText("a,b\n0,0\n0,1\n1,0\n10,10\n10,11\n11,10\n", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := clusterDataset(ds, map[string]interface{}{"k": 2, "standardize": false}, make(map[string]interface{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 簇编号取决于初始中心，只比较分组
+	low, high := got.Labels[0], got.Labels[3]
+	if low == high || !reflect.DeepEqual(got.Labels, []int{low, low, low, high, high, high}) {
+		t.Fatalf("labels = %v", got.Labels)
+	}
+	if !reflect.DeepEqual(got.Centroids[low], []float64{0.3333333333, 0.3333333333}) ||
+		!reflect.DeepEqual(got.Centroids[high], []float64{10.33333333, 10.33333333}) {
+		t.Fatalf("centroids = %v", got.Centroids)
+	}
+	if !reflect.DeepEqual(got.Sizes, []int{3, 3}) || got.Inertia != 2.666666667 || got.Standardized {
+		t.Fatalf("cluster = %+v", got)
+	}
+
+	// 相同种子结果可复现，含缺失值的行标记为 -1
+	ds, err = parseDatasetText("a,b\n0,0\n0,1\n,5\n10,10\n10,11\n", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := map[string]interface{}{"k": 2, "seed": 7}
+	first, err := clusterDataset(ds, options, make(map[string]interface{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := clusterDataset(ds, options, make(map[string]interface{}))
+	if !reflect.DeepEqual(first, second) || first.Labels[2] != -1 || !first.Standardized {
+		t.Fatalf("cluster = %+v, again %+v", first, second)
+	}
+
+	if _, err := clusterDataset(ds, map[string]interface{}{"k": 5}, make(map[string]interface{})); !errors.Is(err, ErrInvalidArgs) {
+		t.Fatalf("k larger than rows: %v", err)
+	}
+}
+
+func TestForecastDataset(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+		want    ForecastResult
+	}{
+		{
+			name:    "moving average",
+			options: map[string]interface{}{"column": "y", "method": "ma", "window": 2, "horizon": 2},
+			want: ForecastResult{Column: "y", Method: ForecastMovingAverage, Window: 2,
+				Forecast: []float64{4.5, 4.5}, LastValue: 5, MAE: 1, RMSE: 1.224744871, N: 5},
+		},
+		{
+			name:    "window capped",
+			options: map[string]interface{}{"column": "y", "method": "moving_average", "window": 10, "horizon": 1},
+			want: ForecastResult{Column: "y", Method: ForecastMovingAverage, Window: 4,
+				Forecast: []float64{4.5}, LastValue: 5, MAE: 1.25, RMSE: 1.25, N: 5},
+		},
+		{
+			name:    "exponential smoothing",
+			options: map[string]interface{}{"column": "y", "alpha": 0.5, "horizon": 3},
+			want: ForecastResult{Column: "y", Method: ForecastExponentialSmoothing, Alpha: 0.5,
+				Forecast: []float64{4.5, 4.5, 4.5}, LastValue: 5, MAE: 1.25, RMSE: 1.5, N: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := forecastDataset(newTestDataset(t), tt.options, make(map[string]interface{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("forecast =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+
+	// 未指定 alpha 时自动选择的系数落在 (0, 1]
+	got, err := forecastDataset(newTestDataset(t), map[string]interface{}{"column": "y"}, make(map[string]interface{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Alpha <= 0 || got.Alpha > 1 || len(got.Forecast) != defaultForecastSteps {
+		t.Fatalf("auto alpha forecast = %+v", got)
+	}
+
+	for _, options := range []map[string]interface{}{
+		{"method": "arima"},
+		{"alpha": 1.5},
+		{"column": "g"},
+	} {
+		if _, err := forecastDataset(newTestDataset(t), options, make(map[string]interface{})); !errors.Is(err, ErrInvalidArgs) {
+			t.Fatalf("options %v: %v", options, err)
+		}
+	}
+}
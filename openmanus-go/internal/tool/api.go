@@ -33,7 +33,10 @@ func (a *APITool) Run(ctx context.Context, input interface{}) (interface{}, erro
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	// 与 http_request 工具使用相同的主机限制
+	client := NewHTTPClient(DefaultHTTPConfig().Hosts, 0)
+	defer client.CloseIdleConnections()
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,93 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AuditRecord 一次策略决策的审计记录
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	Tool     string    `json:"tool"`
+	Input    string    `json:"input"`
+	Decision string    `json:"decision"`
+	Rule     string    `json:"rule,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	// Outcome ask 决策的审批结果
+	Outcome  string `json:"outcome,omitempty"`
+	Approver string `json:"approver,omitempty"`
+	Note     string `json:"note,omitempty"`
+	Allowed  bool   `json:"allowed"`
+}
+
+// AuditLog 把审计记录按行追加到 JSONL 文件，同时写入日志
+type AuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewAuditLog 创建审计日志，path 为空时只写入日志
+func NewAuditLog(path string) (*AuditLog, error) {
+	a := &AuditLog{}
+	if path == "" {
+		return a, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create audit log directory failed: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log failed: %v", err)
+	}
+	a.file = f
+	return a, nil
+}
+
+// Record 写入一条记录
+func (a *AuditLog) Record(r AuditRecord) {
+	entry := logrus.WithFields(logrus.Fields{
+		"tool":     r.Tool,
+		"decision": r.Decision,
+		"rule":     r.Rule,
+		"allowed":  r.Allowed,
+	})
+	if r.Outcome != "" {
+		entry = entry.WithField("outcome", r.Outcome)
+	}
+	if r.Allowed {
+		entry.Debug("tool policy decision")
+	} else {
+		entry.Warn("tool call denied by policy")
+	}
+
+	if a.file == nil {
+		return
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		logrus.WithError(err).Warn("write audit log failed")
+	}
+}
+
+// Close 关闭日志文件
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
@@ -0,0 +1,227 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrFatal 工具返回包装了该错误的错误时，批量执行取消同批次的其他调用
+var ErrFatal = errors.New("fatal tool error")
+
+// ErrCallCanceled 调用因同批次的致命错误或上下文取消而未执行
+var ErrCallCanceled = errors.New("tool call canceled")
+
+// 默认并发数
+const defaultMaxConcurrent = 5
+
+// Exclusive 工具实现该接口声明一次调用占用的资源，例如 shell 会话或被写入的文件路径；
+// 占用相同资源的调用按提交顺序依次执行
+type Exclusive interface {
+	ExclusiveKeys(input interface{}) []string
+}
+
+// BatchCall 批量执行中的一次调用
+type BatchCall struct {
+	// ID 调用标识，原样带回结果，例如模型返回的 tool_call_id
+	ID    string      `json:"id,omitempty"`
+	Name  string      `json:"name"`
+	Input interface{} `json:"input"`
+}
+
+// BatchResult 一次调用的结果，顺序与提交的调用一致
+type BatchResult struct {
+	ID       string        `json:"id,omitempty"`
+	Name     string        `json:"name"`
+	Output   interface{}   `json:"output,omitempty"`
+	Error    error         `json:"-"`
+	Duration time.Duration `json:"duration"`
+}
+
+// String 输出供下一轮 LLM 使用的文本
+func (r BatchResult) String() string {
+	if r.Error != nil {
+		return fmt.Sprintf("%s 执行失败: %v", r.Name, r.Error)
+	}
+	if s, ok := r.Output.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", r.Output)
+}
+
+// BatchConfig 批量执行配置
+type BatchConfig struct {
+	// MaxConcurrent 同时执行的调用数，默认 5
+	MaxConcurrent int
+	// Exclusive 需要串行执行的工具名，同名工具的调用依次执行
+	Exclusive []string
+	// IsFatal 判断错误是否需要取消同批次的其他调用，默认为包装了 ErrFatal 或 ErrToolPanic 的错误
+	IsFatal func(error) bool
+}
+
+// BatchExecutor 并行执行模型在同一轮中发出的多个工具调用
+type BatchExecutor struct {
+	tools     *ToolCollection
+	config    BatchConfig
+	exclusive map[string]bool
+}
+
+// NewBatchExecutor 创建批量执行器
+func NewBatchExecutor(tools *ToolCollection, config BatchConfig) *BatchExecutor {
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = defaultMaxConcurrent
+	}
+	if config.IsFatal == nil {
+		config.IsFatal = isFatalToolError
+	}
+	e := &BatchExecutor{tools: tools, config: config, exclusive: make(map[string]bool)}
+	for _, name := range config.Exclusive {
+		e.exclusive[strings.ToLower(name)] = true
+	}
+	return e
+}
+
+func isFatalToolError(err error) bool {
+	return errors.Is(err, ErrFatal) || errors.Is(err, ErrToolPanic)
+}
+
+// batchTask 调度中的调用
+type batchTask struct {
+	index int
+	tool  Tool
+	keys  []string
+}
+
+type batchDone struct {
+	task *batchTask
+	out  interface{}
+	err  error
+	dur  time.Duration
+}
+
+// Execute 执行一批调用，返回与 calls 顺序一致的结果。
+// 调用按提交顺序启动，与更早的调用占用相同资源的调用等待其完成；
+// 出现致命错误或 ctx 取消时，正在执行的调用收到取消信号，尚未启动的调用返回 ErrCallCanceled
+func (e *BatchExecutor) Execute(ctx context.Context, calls []BatchCall) []BatchResult {
+	results := make([]BatchResult, len(calls))
+	var pending []*batchTask
+	for i, c := range calls {
+		results[i] = BatchResult{ID: c.ID, Name: c.Name}
+		t, err := e.tools.Get(c.Name)
+		if err != nil {
+			results[i].Error = err
+			continue
+		}
+		pending = append(pending, &batchTask{index: i, tool: t, keys: e.keys(c.Name, t, c.Input)})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan batchDone)
+	busy := make(map[string]bool)
+	running := 0
+	var fatal error
+
+	for len(pending) > 0 || running > 0 {
+		if fatal == nil && ctx.Err() == nil {
+			pending = e.startReady(ctx, calls, pending, busy, &running, done)
+		}
+		if running == 0 {
+			// 没有可执行的调用：已取消，剩余的调用不再启动
+			break
+		}
+		d := <-done
+		running--
+		for _, k := range d.task.keys {
+			delete(busy, k)
+		}
+		if fatal != nil && errors.Is(d.err, context.Canceled) {
+			// 因同批次的致命错误被取消
+			d.err = fatal
+		}
+		results[d.task.index].Output = d.out
+		results[d.task.index].Error = d.err
+		results[d.task.index].Duration = d.dur
+		if d.err != nil && fatal == nil && e.config.IsFatal(d.err) {
+			fatal = fmt.Errorf("%w: %s failed: %v", ErrCallCanceled, calls[d.task.index].Name, d.err)
+			cancel()
+		}
+	}
+
+	for _, t := range pending {
+		err := fatal
+		if err == nil {
+			err = fmt.Errorf("%w: %v", ErrCallCanceled, ctx.Err())
+		}
+		results[t.index].Error = err
+	}
+	return results
+}
+
+// startReady 按顺序启动可以执行的调用，返回仍在等待的调用；
+// 资源被占用或被更早的等待中调用声明的调用不启动，以保持同一资源上的执行顺序
+func (e *BatchExecutor) startReady(ctx context.Context, calls []BatchCall, pending []*batchTask, busy map[string]bool, running *int, done chan<- batchDone) []*batchTask {
+	waiting := pending[:0]
+	claimed := make(map[string]bool)
+	for _, t := range pending {
+		ready := *running < e.config.MaxConcurrent
+		for _, k := range t.keys {
+			if busy[k] || claimed[k] {
+				ready = false
+			}
+		}
+		for _, k := range t.keys {
+			claimed[k] = true
+		}
+		if !ready {
+			waiting = append(waiting, t)
+			continue
+		}
+		for _, k := range t.keys {
+			busy[k] = true
+		}
+		*running++
+		go func(t *batchTask) {
+			start := time.Now()
+			out, err := t.tool.Run(ctx, calls[t.index].Input)
+			done <- batchDone{task: t, out: out, err: err, dur: time.Since(start)}
+		}(t)
+	}
+	return waiting
+}
+
+// keys 汇总调用占用的资源：工具声明的资源，以及配置为串行的工具名
+func (e *BatchExecutor) keys(name string, t Tool, input interface{}) []string {
+	var keys []string
+	if x, ok := UnwrapTool(t).(Exclusive); ok {
+		keys = append(keys, x.ExclusiveKeys(input)...)
+	}
+	if e.exclusive[strings.ToLower(name)] {
+		keys = append(keys, "tool:"+name)
+	}
+	seen := make(map[string]bool, len(keys))
+	unique := keys[:0]
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			unique = append(unique, k)
+		}
+	}
+	return unique
+}
+
+// fileKeys 把路径转换为资源标识，无法解析的路径忽略
+func fileKeys(ws *Workspace, paths ...string) []string {
+	var keys []string
+	for _, p := range paths {
+		if p == "" || ws == nil {
+			continue
+		}
+		if abs, err := ws.Resolve(p); err == nil {
+			keys = append(keys, "file:"+abs)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,966 @@
+package tool
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BrowserConfig 浏览器工具配置
+type BrowserConfig struct {
+	ChromePath    string
+	RemoteURL     string
+	Headless      bool
+	NoSandbox     bool
+	ExtraArgs     []string
+	WindowWidth   int
+	WindowHeight  int
+	Timeout       time.Duration
+	MaxContentLen int
+	SearchURL     string
+	WorkspaceDir  string
+}
+
+// DefaultBrowserConfig 默认浏览器配置
+func DefaultBrowserConfig() BrowserConfig {
+	return BrowserConfig{
+		Headless:      true,
+		WindowWidth:   1280,
+		WindowHeight:  800,
+		Timeout:       30 * time.Second,
+		MaxContentLen: 20000,
+		SearchURL:     "https://www.bing.com/search?q=%s",
+		WorkspaceDir:  os.TempDir(),
+	}
+}
+
+// BrowserTool 基于 Chrome DevTools Protocol 的浏览器工具
+type BrowserTool struct {
+	name        string
+	description string
+	config      BrowserConfig
+
+	mu       sync.Mutex
+	proc     *chromeProcess
+	conn     *cdpConn
+	tabs     []*browserTab
+	current  int
+	elements []BrowserElement
+}
+
+// browserTab 已附加的标签页
+type browserTab struct {
+	targetID  string
+	sessionID string
+}
+
+// NewBrowserTool 创建浏览器工具
+func NewBrowserTool(config BrowserConfig) *BrowserTool {
+	defaults := DefaultBrowserConfig()
+	if config.WindowWidth <= 0 {
+		config.WindowWidth = defaults.WindowWidth
+	}
+	if config.WindowHeight <= 0 {
+		config.WindowHeight = defaults.WindowHeight
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxContentLen <= 0 {
+		config.MaxContentLen = defaults.MaxContentLen
+	}
+	if config.SearchURL == "" {
+		config.SearchURL = defaults.SearchURL
+	}
+	if config.WorkspaceDir == "" {
+		config.WorkspaceDir = defaults.WorkspaceDir
+	}
+	return &BrowserTool{
+		name:        "browser_use",
+		description: "浏览器自动化工具，支持打开网页、按序号点击元素、输入文本、滚动、提取内容、截图、标签页管理和前进后退",
+		config:      config,
+	}
+}
+
+// Name 返回工具名称
+func (t *BrowserTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *BrowserTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *BrowserTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// BrowserAction 浏览器操作参数
+type BrowserAction struct {
+	Action       string `json:"action"`
+	URL          string `json:"url,omitempty"`
+	Index        *int   `json:"index,omitempty"`
+	Text         string `json:"text,omitempty"`
+	ScrollAmount int    `json:"scroll_amount,omitempty"`
+	TabID        *int   `json:"tab_id,omitempty"`
+	Query        string `json:"query,omitempty"`
+	Goal         string `json:"goal,omitempty"`
+	Keys         string `json:"keys,omitempty"`
+	Seconds      int    `json:"seconds,omitempty"`
+	FullPage     bool   `json:"full_page,omitempty"`
+}
+
+// BrowserElement 页面中可交互的元素
+type BrowserElement struct {
+	Index int    `json:"index"`
+	Tag   string `json:"tag"`
+	Type  string `json:"type,omitempty"`
+	Text  string `json:"text,omitempty"`
+	Href  string `json:"href,omitempty"`
+}
+
+// BrowserTabInfo 标签页信息
+type BrowserTabInfo struct {
+	TabID  int    `json:"tab_id"`
+	URL    string `json:"url"`
+	Title  string `json:"title"`
+	Active bool   `json:"active"`
+}
+
+// BrowserResult 浏览器操作结果
+type BrowserResult struct {
+	Success    bool                   `json:"success"`
+	Output     string                 `json:"output,omitempty"`
+	URL        string                 `json:"url,omitempty"`
+	Title      string                 `json:"title,omitempty"`
+	Tabs       []BrowserTabInfo       `json:"tabs,omitempty"`
+	Elements   []BrowserElement       `json:"elements,omitempty"`
+	Screenshot string                 `json:"screenshot,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// 预定义的浏览器操作
+const (
+	BrowserGoToURL         = "go_to_url"
+	BrowserClickElement    = "click_element"
+	BrowserInputText       = "input_text"
+	BrowserScrollDown      = "scroll_down"
+	BrowserScrollUp        = "scroll_up"
+	BrowserScrollToText    = "scroll_to_text"
+	BrowserSendKeys        = "send_keys"
+	BrowserDropdownOptions = "get_dropdown_options"
+	BrowserSelectOption    = "select_dropdown_option"
+	BrowserGoBack          = "go_back"
+	BrowserGoForward       = "go_forward"
+	BrowserRefresh         = "refresh"
+	BrowserWebSearch       = "web_search"
+	BrowserWait            = "wait"
+	BrowserExtractContent  = "extract_content"
+	BrowserScreenshot      = "screenshot"
+	BrowserGetState        = "get_state"
+	BrowserSwitchTab       = "switch_tab"
+	BrowserOpenTab         = "open_tab"
+	BrowserCloseTab        = "close_tab"
+)
+
+// ExclusiveKeys 所有操作共用一个浏览器会话，依次执行
+func (t *BrowserTool) ExclusiveKeys(input interface{}) []string {
+	return []string{"browser"}
+}
+
+// Run 执行浏览器操作
+func (t *BrowserTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var action BrowserAction
+	switch v := input.(type) {
+	case string:
+		// 如果是字符串，假设是 URL，执行跳转
+		action = BrowserAction{
+			Action: BrowserGoToURL,
+			URL:    v,
+		}
+	case map[string]interface{}:
+		jsonData, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input format: %v", err)
+		}
+		if err := json.Unmarshal(jsonData, &action); err != nil {
+			return nil, fmt.Errorf("invalid input format: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported input type: %T", input)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, t.config.Timeout+time.Duration(action.Seconds)*time.Second)
+	defer cancel()
+
+	if err := t.ensureBrowser(ctx); err != nil {
+		return nil, err
+	}
+
+	output, err := t.dispatch(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// dispatch 根据操作类型分发
+func (t *BrowserTool) dispatch(ctx context.Context, action BrowserAction) (*BrowserResult, error) {
+	switch action.Action {
+	case BrowserGoToURL:
+		if action.URL == "" {
+			return nil, fmt.Errorf("url is required for %s", action.Action)
+		}
+		if err := t.navigate(ctx, action.URL); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, fmt.Sprintf("Navigated to %s", action.URL))
+
+	case BrowserWebSearch:
+		if action.Query == "" {
+			return nil, fmt.Errorf("query is required for %s", action.Action)
+		}
+		searchURL := fmt.Sprintf(t.config.SearchURL, url.QueryEscape(action.Query))
+		if err := t.navigate(ctx, searchURL); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, fmt.Sprintf("Searched for '%s'", action.Query))
+
+	case BrowserClickElement:
+		if action.Index == nil {
+			return nil, fmt.Errorf("index is required for %s", action.Action)
+		}
+		if err := t.click(ctx, *action.Index); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, fmt.Sprintf("Clicked element at index %d", *action.Index))
+
+	case BrowserInputText:
+		if action.Index == nil {
+			return nil, fmt.Errorf("index is required for %s", action.Action)
+		}
+		if err := t.inputText(ctx, *action.Index, action.Text); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, fmt.Sprintf("Input '%s' into element at index %d", action.Text, *action.Index))
+
+	case BrowserScrollDown, BrowserScrollUp:
+		amount := action.ScrollAmount
+		if amount == 0 {
+			amount = t.config.WindowHeight
+		}
+		if action.Action == BrowserScrollUp {
+			amount = -amount
+		}
+		if _, err := t.evaluate(ctx, fmt.Sprintf("window.scrollBy(0, %d)", amount)); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, fmt.Sprintf("Scrolled by %d pixels", amount))
+
+	case BrowserScrollToText:
+		if action.Text == "" {
+			return nil, fmt.Errorf("text is required for %s", action.Action)
+		}
+		found, err := t.evaluate(ctx, fmt.Sprintf(scrollToTextJS, jsString(action.Text)))
+		if err != nil {
+			return nil, err
+		}
+		if ok, _ := found.(bool); !ok {
+			return nil, fmt.Errorf("text '%s' not found on page", action.Text)
+		}
+		return t.state(ctx, fmt.Sprintf("Scrolled to text: %s", action.Text))
+
+	case BrowserSendKeys:
+		if action.Keys == "" {
+			return nil, fmt.Errorf("keys is required for %s", action.Action)
+		}
+		if err := t.sendKeys(ctx, action.Keys); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, fmt.Sprintf("Sent keys: %s", action.Keys))
+
+	case BrowserDropdownOptions:
+		if action.Index == nil {
+			return nil, fmt.Errorf("index is required for %s", action.Action)
+		}
+		options, err := t.evaluate(ctx, fmt.Sprintf(dropdownOptionsJS, *action.Index))
+		if err != nil {
+			return nil, err
+		}
+		if options == nil {
+			return nil, fmt.Errorf("element at index %d is not a select element", *action.Index)
+		}
+		data, _ := json.Marshal(options)
+		return &BrowserResult{Success: true, Output: string(data)}, nil
+
+	case BrowserSelectOption:
+		if action.Index == nil {
+			return nil, fmt.Errorf("index is required for %s", action.Action)
+		}
+		selected, err := t.evaluate(ctx, fmt.Sprintf(selectOptionJS, *action.Index, jsString(action.Text)))
+		if err != nil {
+			return nil, err
+		}
+		if ok, _ := selected.(bool); !ok {
+			return nil, fmt.Errorf("option '%s' not found in element at index %d", action.Text, *action.Index)
+		}
+		return t.state(ctx, fmt.Sprintf("Selected option '%s' in element at index %d", action.Text, *action.Index))
+
+	case BrowserGoBack, BrowserGoForward:
+		if err := t.history(ctx, action.Action == BrowserGoBack); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, fmt.Sprintf("Performed %s", action.Action))
+
+	case BrowserRefresh:
+		if err := t.conn.Call(ctx, t.session(), "Page.reload", nil, nil); err != nil {
+			return nil, err
+		}
+		if err := t.waitLoad(ctx); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, "Refreshed current page")
+
+	case BrowserWait:
+		seconds := action.Seconds
+		if seconds <= 0 {
+			seconds = 3
+		}
+		select {
+		case <-time.After(time.Duration(seconds) * time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return t.state(ctx, fmt.Sprintf("Waited for %d seconds", seconds))
+
+	case BrowserExtractContent:
+		return t.extract(ctx, action.Goal)
+
+	case BrowserScreenshot:
+		return t.screenshot(ctx, action.FullPage)
+
+	case BrowserGetState:
+		return t.state(ctx, "")
+
+	case BrowserOpenTab:
+		if action.URL == "" {
+			return nil, fmt.Errorf("url is required for %s", action.Action)
+		}
+		if err := t.openTab(ctx, action.URL); err != nil {
+			return nil, err
+		}
+		if err := t.waitLoad(ctx); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, fmt.Sprintf("Opened new tab with %s", action.URL))
+
+	case BrowserSwitchTab:
+		if action.TabID == nil {
+			return nil, fmt.Errorf("tab_id is required for %s", action.Action)
+		}
+		if err := t.switchTab(ctx, *action.TabID); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, fmt.Sprintf("Switched to tab %d", *action.TabID))
+
+	case BrowserCloseTab:
+		if err := t.closeTab(ctx); err != nil {
+			return nil, err
+		}
+		return t.state(ctx, "Closed current tab")
+
+	default:
+		return nil, fmt.Errorf("unsupported browser action: %s", action.Action)
+	}
+}
+
+// ensureBrowser 按需启动或连接浏览器
+func (t *BrowserTool) ensureBrowser(ctx context.Context) error {
+	if t.conn != nil {
+		select {
+		case <-t.conn.closed:
+			// 连接已断开，重新建立
+			t.shutdown()
+		default:
+			return nil
+		}
+	}
+
+	wsURL := t.config.RemoteURL
+	if wsURL != "" {
+		resolved, err := resolveDevToolsURL(ctx, wsURL)
+		if err != nil {
+			return err
+		}
+		wsURL = resolved
+	} else {
+		proc, err := launchChrome(ctx, t.config)
+		if err != nil {
+			return err
+		}
+		t.proc = proc
+		wsURL = proc.wsURL
+	}
+
+	conn, err := dialCDP(ctx, wsURL)
+	if err != nil {
+		t.shutdown()
+		return err
+	}
+	t.conn = conn
+
+	var targets struct {
+		TargetInfos []struct {
+			TargetID string `json:"targetId"`
+			Type     string `json:"type"`
+		} `json:"targetInfos"`
+	}
+	if err := conn.Call(ctx, "", "Target.getTargets", nil, &targets); err != nil {
+		t.shutdown()
+		return err
+	}
+	for _, info := range targets.TargetInfos {
+		if info.Type != "page" {
+			continue
+		}
+		if err := t.attach(ctx, info.TargetID); err != nil {
+			t.shutdown()
+			return err
+		}
+	}
+	if len(t.tabs) == 0 {
+		if err := t.openTab(ctx, "about:blank"); err != nil {
+			t.shutdown()
+			return err
+		}
+	}
+	t.current = 0
+	return nil
+}
+
+// attach 附加到目标页面并记录 session
+func (t *BrowserTool) attach(ctx context.Context, targetID string) error {
+	var attached struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := t.conn.Call(ctx, "", "Target.attachToTarget", map[string]interface{}{
+		"targetId": targetID,
+		"flatten":  true,
+	}, &attached); err != nil {
+		return err
+	}
+	if err := t.conn.Call(ctx, attached.SessionID, "Page.enable", nil, nil); err != nil {
+		return err
+	}
+	if err := t.conn.Call(ctx, attached.SessionID, "Emulation.setDeviceMetricsOverride", map[string]interface{}{
+		"width":             t.config.WindowWidth,
+		"height":            t.config.WindowHeight,
+		"deviceScaleFactor": 1,
+		"mobile":            false,
+	}, nil); err != nil {
+		return err
+	}
+	t.tabs = append(t.tabs, &browserTab{targetID: targetID, sessionID: attached.SessionID})
+	return nil
+}
+
+// session 返回当前标签页的 session
+func (t *BrowserTool) session() string {
+	return t.tabs[t.current].sessionID
+}
+
+// navigate 当前标签页跳转到指定 URL
+func (t *BrowserTool) navigate(ctx context.Context, rawURL string) error {
+	var nav struct {
+		ErrorText string `json:"errorText"`
+	}
+	if err := t.conn.Call(ctx, t.session(), "Page.navigate", map[string]interface{}{"url": rawURL}, &nav); err != nil {
+		return err
+	}
+	if nav.ErrorText != "" {
+		return fmt.Errorf("navigate to %s failed: %s", rawURL, nav.ErrorText)
+	}
+	return t.waitLoad(ctx)
+}
+
+// waitLoad 等待页面加载完成
+func (t *BrowserTool) waitLoad(ctx context.Context) error {
+	for {
+		state, err := t.evaluate(ctx, "document.readyState")
+		if err == nil && state == "complete" {
+			return nil
+		}
+		select {
+		case <-time.After(waitInterval):
+		case <-ctx.Done():
+			return fmt.Errorf("wait for page load: %v", ctx.Err())
+		}
+	}
+}
+
+// evaluate 在当前页面执行 JavaScript 并返回结果值
+func (t *BrowserTool) evaluate(ctx context.Context, expression string) (interface{}, error) {
+	var res struct {
+		Result struct {
+			Type  string      `json:"type"`
+			Value interface{} `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text      string `json:"text"`
+			Exception struct {
+				Description string `json:"description"`
+			} `json:"exception"`
+		} `json:"exceptionDetails"`
+	}
+	if err := t.conn.Call(ctx, t.session(), "Runtime.evaluate", map[string]interface{}{
+		"expression":    expression,
+		"returnByValue": true,
+		"awaitPromise":  true,
+	}, &res); err != nil {
+		return nil, err
+	}
+	if res.ExceptionDetails != nil {
+		msg := res.ExceptionDetails.Exception.Description
+		if msg == "" {
+			msg = res.ExceptionDetails.Text
+		}
+		return nil, fmt.Errorf("javascript error: %s", msg)
+	}
+	return res.Result.Value, nil
+}
+
+// click 通过真实鼠标事件点击指定序号的元素
+func (t *BrowserTool) click(ctx context.Context, index int) error {
+	point, err := t.elementCenter(ctx, index)
+	if err != nil {
+		return err
+	}
+	for _, typ := range []string{"mouseMoved", "mousePressed", "mouseReleased"} {
+		params := map[string]interface{}{
+			"type": typ,
+			"x":    point[0],
+			"y":    point[1],
+		}
+		if typ != "mouseMoved" {
+			params["button"] = "left"
+			params["clickCount"] = 1
+		}
+		if err := t.conn.Call(ctx, t.session(), "Input.dispatchMouseEvent", params, nil); err != nil {
+			return err
+		}
+	}
+	// 点击可能触发跳转，稍作等待后再确认加载状态
+	select {
+	case <-time.After(3 * waitInterval):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.waitLoad(ctx)
+}
+
+// elementCenter 滚动到元素并返回其中心坐标
+func (t *BrowserTool) elementCenter(ctx context.Context, index int) ([2]float64, error) {
+	value, err := t.evaluate(ctx, fmt.Sprintf(elementCenterJS, index))
+	if err != nil {
+		return [2]float64{}, err
+	}
+	point, ok := value.([]interface{})
+	if !ok || len(point) != 2 {
+		return [2]float64{}, fmt.Errorf("element with index %d not found, call get_state to refresh element indices", index)
+	}
+	x, _ := point[0].(float64)
+	y, _ := point[1].(float64)
+	return [2]float64{x, y}, nil
+}
+
+// inputText 清空元素内容后输入文本
+func (t *BrowserTool) inputText(ctx context.Context, index int, text string) error {
+	focused, err := t.evaluate(ctx, fmt.Sprintf(focusClearJS, index))
+	if err != nil {
+		return err
+	}
+	if ok, _ := focused.(bool); !ok {
+		return fmt.Errorf("element with index %d not found, call get_state to refresh element indices", index)
+	}
+	return t.conn.Call(ctx, t.session(), "Input.insertText", map[string]interface{}{"text": text}, nil)
+}
+
+// browserKey 特殊按键定义
+type browserKey struct {
+	code    string
+	keyCode int
+	text    string
+}
+
+var browserKeys = map[string]browserKey{
+	"Enter":      {"Enter", 13, "\r"},
+	"Tab":        {"Tab", 9, ""},
+	"Escape":     {"Escape", 27, ""},
+	"Backspace":  {"Backspace", 8, ""},
+	"Delete":     {"Delete", 46, ""},
+	"ArrowUp":    {"ArrowUp", 38, ""},
+	"ArrowDown":  {"ArrowDown", 40, ""},
+	"ArrowLeft":  {"ArrowLeft", 37, ""},
+	"ArrowRight": {"ArrowRight", 39, ""},
+	"PageUp":     {"PageUp", 33, ""},
+	"PageDown":   {"PageDown", 34, ""},
+	"Home":       {"Home", 36, ""},
+	"End":        {"End", 35, ""},
+	"Space":      {"Space", 32, " "},
+}
+
+// sendKeys 发送按键组合，例如 "Enter"、"Control+a"
+func (t *BrowserTool) sendKeys(ctx context.Context, keys string) error {
+	parts := strings.Split(keys, "+")
+	key := parts[len(parts)-1]
+	modifiers := 0
+	for _, m := range parts[:len(parts)-1] {
+		switch strings.ToLower(m) {
+		case "alt":
+			modifiers |= 1
+		case "control", "ctrl":
+			modifiers |= 2
+		case "meta", "command", "cmd":
+			modifiers |= 4
+		case "shift":
+			modifiers |= 8
+		default:
+			return fmt.Errorf("unsupported modifier: %s", m)
+		}
+	}
+
+	def, ok := browserKeys[key]
+	if !ok {
+		if len([]rune(key)) != 1 {
+			return fmt.Errorf("unsupported key: %s", key)
+		}
+		def = browserKey{code: "Key" + strings.ToUpper(key), keyCode: int(strings.ToUpper(key)[0]), text: key}
+	}
+
+	down := map[string]interface{}{
+		"type":                  "keyDown",
+		"key":                   key,
+		"code":                  def.code,
+		"windowsVirtualKeyCode": def.keyCode,
+		"modifiers":             modifiers,
+	}
+	// 带 Ctrl/Alt/Meta 的组合键不产生文本输入
+	if def.text != "" && modifiers&^8 == 0 {
+		down["text"] = def.text
+	}
+	if err := t.conn.Call(ctx, t.session(), "Input.dispatchKeyEvent", down, nil); err != nil {
+		return err
+	}
+	return t.conn.Call(ctx, t.session(), "Input.dispatchKeyEvent", map[string]interface{}{
+		"type":                  "keyUp",
+		"key":                   key,
+		"code":                  def.code,
+		"windowsVirtualKeyCode": def.keyCode,
+		"modifiers":             modifiers,
+	}, nil)
+}
+
+// history 在历史记录中后退或前进
+func (t *BrowserTool) history(ctx context.Context, back bool) error {
+	var hist struct {
+		CurrentIndex int `json:"currentIndex"`
+		Entries      []struct {
+			ID int `json:"id"`
+		} `json:"entries"`
+	}
+	if err := t.conn.Call(ctx, t.session(), "Page.getNavigationHistory", nil, &hist); err != nil {
+		return err
+	}
+	target := hist.CurrentIndex + 1
+	if back {
+		target = hist.CurrentIndex - 1
+	}
+	if target < 0 || target >= len(hist.Entries) {
+		return fmt.Errorf("no history entry to navigate to")
+	}
+	if err := t.conn.Call(ctx, t.session(), "Page.navigateToHistoryEntry", map[string]interface{}{
+		"entryId": hist.Entries[target].ID,
+	}, nil); err != nil {
+		return err
+	}
+	select {
+	case <-time.After(3 * waitInterval):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.waitLoad(ctx)
+}
+
+// extract 提取页面正文，超出长度时截断
+func (t *BrowserTool) extract(ctx context.Context, goal string) (*BrowserResult, error) {
+	value, err := t.evaluate(ctx, extractContentJS)
+	if err != nil {
+		return nil, err
+	}
+	page, _ := value.(map[string]interface{})
+	text, _ := page["text"].(string)
+	truncated := false
+	if len(text) > t.config.MaxContentLen {
+		text = text[:t.config.MaxContentLen]
+		truncated = true
+	}
+
+	result := &BrowserResult{
+		Success: true,
+		Output:  text,
+		Metadata: map[string]interface{}{
+			"goal":      goal,
+			"truncated": truncated,
+			"links":     page["links"],
+		},
+	}
+	result.URL, _ = page["url"].(string)
+	result.Title, _ = page["title"].(string)
+	return result, nil
+}
+
+// screenshot 截取当前页面并保存到工作目录
+func (t *BrowserTool) screenshot(ctx context.Context, fullPage bool) (*BrowserResult, error) {
+	var shot struct {
+		Data string `json:"data"`
+	}
+	if err := t.conn.Call(ctx, t.session(), "Page.captureScreenshot", map[string]interface{}{
+		"format":                "png",
+		"captureBeyondViewport": fullPage,
+	}, &shot); err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(shot.Data)
+	if err != nil {
+		return nil, fmt.Errorf("decode screenshot failed: %v", err)
+	}
+
+	dir := filepath.Join(t.config.WorkspaceDir, "screenshots")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create screenshot directory failed: %v", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("screenshot-%d.png", time.Now().UnixNano()))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("write screenshot failed: %v", err)
+	}
+
+	result, err := t.state(ctx, fmt.Sprintf("Screenshot saved to %s", path))
+	if err != nil {
+		return nil, err
+	}
+	result.Screenshot = path
+	result.Metadata = map[string]interface{}{"size": len(data)}
+	return result, nil
+}
+
+// openTab 新建标签页并切换过去
+func (t *BrowserTool) openTab(ctx context.Context, rawURL string) error {
+	var created struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := t.conn.Call(ctx, "", "Target.createTarget", map[string]interface{}{"url": rawURL}, &created); err != nil {
+		return err
+	}
+	if err := t.attach(ctx, created.TargetID); err != nil {
+		return err
+	}
+	t.current = len(t.tabs) - 1
+	return nil
+}
+
+// switchTab 切换到指定标签页
+func (t *BrowserTool) switchTab(ctx context.Context, tabID int) error {
+	if tabID < 0 || tabID >= len(t.tabs) {
+		return fmt.Errorf("tab %d does not exist", tabID)
+	}
+	if err := t.conn.Call(ctx, "", "Target.activateTarget", map[string]interface{}{
+		"targetId": t.tabs[tabID].targetID,
+	}, nil); err != nil {
+		return err
+	}
+	t.current = tabID
+	return nil
+}
+
+// closeTab 关闭当前标签页，最后一个标签页关闭后自动打开空白页
+func (t *BrowserTool) closeTab(ctx context.Context) error {
+	tab := t.tabs[t.current]
+	if err := t.conn.Call(ctx, "", "Target.closeTarget", map[string]interface{}{
+		"targetId": tab.targetID,
+	}, nil); err != nil {
+		return err
+	}
+	t.tabs = append(t.tabs[:t.current], t.tabs[t.current+1:]...)
+	if len(t.tabs) == 0 {
+		return t.openTab(ctx, "about:blank")
+	}
+	if t.current >= len(t.tabs) {
+		t.current = len(t.tabs) - 1
+	}
+	return nil
+}
+
+// state 收集当前页面状态并为可交互元素编号
+func (t *BrowserTool) state(ctx context.Context, output string) (*BrowserResult, error) {
+	value, err := t.evaluate(ctx, indexElementsJS)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(value)
+	var page struct {
+		URL      string           `json:"url"`
+		Title    string           `json:"title"`
+		Elements []BrowserElement `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("decode page state failed: %v", err)
+	}
+	t.elements = page.Elements
+
+	tabs := make([]BrowserTabInfo, 0, len(t.tabs))
+	for i, tab := range t.tabs {
+		var info struct {
+			TargetInfo struct {
+				URL   string `json:"url"`
+				Title string `json:"title"`
+			} `json:"targetInfo"`
+		}
+		if err := t.conn.Call(ctx, "", "Target.getTargetInfo", map[string]interface{}{
+			"targetId": tab.targetID,
+		}, &info); err != nil {
+			return nil, err
+		}
+		tabs = append(tabs, BrowserTabInfo{
+			TabID:  i,
+			URL:    info.TargetInfo.URL,
+			Title:  info.TargetInfo.Title,
+			Active: i == t.current,
+		})
+	}
+
+	return &BrowserResult{
+		Success:  true,
+		Output:   output,
+		URL:      page.URL,
+		Title:    page.Title,
+		Tabs:     tabs,
+		Elements: page.Elements,
+	}, nil
+}
+
+// shutdown 断开连接并结束浏览器进程
+func (t *BrowserTool) shutdown() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn = nil
+	}
+	if t.proc != nil {
+		t.proc.Close()
+		t.proc = nil
+	}
+	t.tabs = nil
+	t.current = 0
+	t.elements = nil
+}
+
+// Close 关闭浏览器
+func (t *BrowserTool) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.shutdown()
+	return nil
+}
+
+// jsString 将 Go 字符串编码为 JavaScript 字符串字面量
+func jsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// indexElementsJS 为可见的可交互元素打上 data-om-index 编号并返回页面状态
+const indexElementsJS = `(() => {
+  const sel = 'a[href],button,input:not([type=hidden]),select,textarea,summary,[role=button],[role=link],[role=checkbox],[role=tab],[role=menuitem],[onclick],[contenteditable=""],[contenteditable=true]';
+  document.querySelectorAll('[data-om-index]').forEach(e => e.removeAttribute('data-om-index'));
+  const elements = [];
+  let i = 0;
+  for (const el of document.querySelectorAll(sel)) {
+    const r = el.getBoundingClientRect();
+    const st = getComputedStyle(el);
+    if (r.width === 0 || r.height === 0 || st.visibility === 'hidden' || st.display === 'none') continue;
+    el.setAttribute('data-om-index', String(i));
+    const text = (el.innerText || el.value || el.getAttribute('aria-label') || el.getAttribute('placeholder') || el.getAttribute('title') || '').trim().replace(/\s+/g, ' ').slice(0, 100);
+    elements.push({index: i, tag: el.tagName.toLowerCase(), type: el.getAttribute('type') || '', text: text, href: el.getAttribute('href') || ''});
+    i++;
+  }
+  return {url: location.href, title: document.title, elements: elements};
+})()`
+
+// elementCenterJS 滚动到指定元素并返回中心坐标
+const elementCenterJS = `(() => {
+  const el = document.querySelector('[data-om-index="%d"]');
+  if (!el) return null;
+  el.scrollIntoView({block: 'center', inline: 'center'});
+  const r = el.getBoundingClientRect();
+  return [r.left + r.width / 2, r.top + r.height / 2];
+})()`
+
+// focusClearJS 聚焦指定元素并清空已有内容
+const focusClearJS = `(() => {
+  const el = document.querySelector('[data-om-index="%d"]');
+  if (!el) return false;
+  el.scrollIntoView({block: 'center'});
+  el.focus();
+  if ('value' in el) { el.value = ''; } else if (el.isContentEditable) { el.textContent = ''; }
+  return true;
+})()`
+
+// scrollToTextJS 滚动到包含指定文本的元素
+const scrollToTextJS = `(() => {
+  const needle = %s;
+  const walker = document.createTreeWalker(document.body, NodeFilter.SHOW_TEXT);
+  while (walker.nextNode()) {
+    if (walker.currentNode.textContent.includes(needle)) {
+      walker.currentNode.parentElement.scrollIntoView({block: 'center'});
+      return true;
+    }
+  }
+  return false;
+})()`
+
+// dropdownOptionsJS 返回下拉框的全部选项
+const dropdownOptionsJS = `(() => {
+  const el = document.querySelector('[data-om-index="%d"]');
+  if (!el || el.tagName !== 'SELECT') return null;
+  return Array.from(el.options).map((o, i) => ({index: i, text: o.text, value: o.value, selected: o.selected}));
+})()`
+
+// selectOptionJS 按文本或值选择下拉框选项并触发 change 事件
+const selectOptionJS = `(() => {
+  const el = document.querySelector('[data-om-index="%d"]');
+  const want = %s;
+  if (!el || el.tagName !== 'SELECT') return false;
+  const opt = Array.from(el.options).find(o => o.text.trim() === want || o.value === want);
+  if (!opt) return false;
+  el.value = opt.value;
+  el.dispatchEvent(new Event('input', {bubbles: true}));
+  el.dispatchEvent(new Event('change', {bubbles: true}));
+  return true;
+})()`
+
+// extractContentJS 提取页面可见文本和链接
+const extractContentJS = `(() => {
+  const links = Array.from(document.querySelectorAll('a[href]')).slice(0, 100).map(a => ({text: a.innerText.trim().slice(0, 100), href: a.href}));
+  return {url: location.href, title: document.title, text: document.body ? document.body.innerText : '', links: links};
+})()`
@@ -0,0 +1,159 @@
+package tool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const browserTestIndex = `<!DOCTYPE html>
+<html><head><title>Index</title></head><body>
+<h1>Browser test</h1>
+<a id="next" href="/next">Next page</a>
+<input id="name" type="text">
+<select id="color"><option value="r">Red</option><option value="g">Green</option></select>
+<p>filler</p>
+</body></html>`
+
+const browserTestNext = `<!DOCTYPE html>
+<html><head><title>Next</title></head><body><p>Second page content</p></body></html>`
+
+// newBrowserTestTool 启动无头浏览器和测试站点；找不到 Chromium 时跳过，
+// 可以用 CHROME_PATH 指定可执行文件
+func newBrowserTestTool(t *testing.T) (*BrowserTool, string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping browser test in short mode")
+	}
+	chrome, err := findChrome(os.Getenv("CHROME_PATH"))
+	if err != nil {
+		t.Skipf("no Chromium found: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(browserTestIndex))
+	})
+	mux.HandleFunc("/next", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(browserTestNext))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	config := DefaultBrowserConfig()
+	config.ChromePath = chrome
+	config.NoSandbox = os.Geteuid() == 0
+	config.Timeout = 20 * time.Second
+	config.WorkspaceDir = t.TempDir()
+	bt := NewBrowserTool(config)
+	t.Cleanup(func() { bt.Close() })
+	return bt, srv.URL
+}
+
+func runBrowser(t *testing.T, bt *BrowserTool, args map[string]interface{}) *BrowserResult {
+	t.Helper()
+	out, err := bt.Run(context.Background(), args)
+	if err != nil {
+		t.Fatalf("%v: %v", args["action"], err)
+	}
+	return out.(*BrowserResult)
+}
+
+func findElement(t *testing.T, res *BrowserResult, tag, text string) int {
+	t.Helper()
+	for _, e := range res.Elements {
+		if e.Tag == tag && strings.Contains(e.Text, text) {
+			return e.Index
+		}
+	}
+	t.Fatalf("element <%s> %q not found in %+v", tag, text, res.Elements)
+	return -1
+}
+
+func TestBrowserNavigateAndInteract(t *testing.T) {
+	bt, base := newBrowserTestTool(t)
+
+	res := runBrowser(t, bt, map[string]interface{}{"action": BrowserGoToURL, "url": base + "/"})
+	if res.Title != "Index" {
+		t.Fatalf("title = %q", res.Title)
+	}
+	if len(res.Tabs) != 1 || !res.Tabs[0].Active {
+		t.Fatalf("tabs = %+v", res.Tabs)
+	}
+
+	input := findElement(t, res, "input", "")
+	runBrowser(t, bt, map[string]interface{}{"action": BrowserInputText, "index": input, "text": "hello"})
+	value, err := bt.evaluate(context.Background(), `document.getElementById("name").value`)
+	if err != nil || value != "hello" {
+		t.Fatalf("input value = %v, %v", value, err)
+	}
+
+	sel := findElement(t, res, "select", "")
+	opts := runBrowser(t, bt, map[string]interface{}{"action": BrowserDropdownOptions, "index": sel})
+	if !strings.Contains(opts.Output, "Green") {
+		t.Fatalf("dropdown options = %s", opts.Output)
+	}
+	runBrowser(t, bt, map[string]interface{}{"action": BrowserSelectOption, "index": sel, "text": "Green"})
+	value, err = bt.evaluate(context.Background(), `document.getElementById("color").value`)
+	if err != nil || value != "g" {
+		t.Fatalf("select value = %v, %v", value, err)
+	}
+
+	link := findElement(t, res, "a", "Next page")
+	res = runBrowser(t, bt, map[string]interface{}{"action": BrowserClickElement, "index": link})
+	// 点击触发的跳转是异步的，等待页面切换
+	for i := 0; i < 50 && res.Title != "Next"; i++ {
+		time.Sleep(100 * time.Millisecond)
+		res = runBrowser(t, bt, map[string]interface{}{"action": BrowserGetState})
+	}
+	if res.Title != "Next" {
+		t.Fatalf("after click title = %q", res.Title)
+	}
+
+	content := runBrowser(t, bt, map[string]interface{}{"action": BrowserExtractContent})
+	if !strings.Contains(content.Output, "Second page content") {
+		t.Fatalf("extract = %q", content.Output)
+	}
+
+	res = runBrowser(t, bt, map[string]interface{}{"action": BrowserGoBack})
+	if res.Title != "Index" {
+		t.Fatalf("after back title = %q", res.Title)
+	}
+}
+
+func TestBrowserTabsAndScreenshot(t *testing.T) {
+	bt, base := newBrowserTestTool(t)
+
+	runBrowser(t, bt, map[string]interface{}{"action": BrowserGoToURL, "url": base + "/"})
+	res := runBrowser(t, bt, map[string]interface{}{"action": BrowserOpenTab, "url": base + "/next"})
+	if len(res.Tabs) != 2 || !res.Tabs[1].Active || res.Title != "Next" {
+		t.Fatalf("after open_tab: title %q tabs %+v", res.Title, res.Tabs)
+	}
+	res = runBrowser(t, bt, map[string]interface{}{"action": BrowserSwitchTab, "tab_id": 0})
+	if res.Title != "Index" || !res.Tabs[0].Active {
+		t.Fatalf("after switch_tab: title %q tabs %+v", res.Title, res.Tabs)
+	}
+	res = runBrowser(t, bt, map[string]interface{}{"action": BrowserCloseTab})
+	if len(res.Tabs) != 1 || res.Title != "Next" {
+		t.Fatalf("after close_tab: title %q tabs %+v", res.Title, res.Tabs)
+	}
+
+	shot := runBrowser(t, bt, map[string]interface{}{"action": BrowserScreenshot})
+	data, err := os.ReadFile(shot.Screenshot)
+	if err != nil || !strings.HasPrefix(string(data), "\x89PNG") {
+		t.Fatalf("screenshot %s: %v", shot.Screenshot, err)
+	}
+
+	if _, err := bt.Run(context.Background(), map[string]interface{}{"action": "fly"}); err == nil {
+		t.Fatal("unsupported action should fail")
+	}
+	if _, err := bt.Run(context.Background(), map[string]interface{}{"action": BrowserSwitchTab, "tab_id": 5}); err == nil {
+		t.Fatal("switching to a missing tab should fail")
+	}
+}
@@ -0,0 +1,380 @@
+package tool
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CachePolicy 一次调用结果的缓存方式
+type CachePolicy struct {
+	// TTL 结果的有效期，0 表示使用缓存的默认有效期，负数表示在缓存生命周期内一直有效
+	TTL time.Duration
+	// Files 结果依赖的文件，任一文件的修改时间或大小变化时缓存失效
+	Files []string
+	// Persistent 是否允许写入持久缓存
+	Persistent bool
+}
+
+// Cacheable 幂等工具实现该接口以允许缓存结果；
+// CachePolicy 返回 false 表示该次调用有副作用或结果不可复用，不缓存
+type Cacheable interface {
+	CachePolicy(input interface{}) (CachePolicy, bool)
+}
+
+// CacheableResult 结果实现该接口时由结果本身决定能否缓存，例如表示失败的 HTTP 响应
+type CacheableResult interface {
+	Cacheable() bool
+}
+
+// ResultCacheConfig 结果缓存配置
+type ResultCacheConfig struct {
+	// Disabled 关闭缓存
+	Disabled bool
+	// TTL 默认有效期
+	TTL time.Duration
+	// MaxEntries 内存中保留的条目数，超出时淘汰最久未使用的
+	MaxEntries int
+	// Dir 持久缓存目录，为空时只使用内存缓存
+	Dir string
+}
+
+// 默认缓存配置
+const (
+	defaultCacheTTL        = 5 * time.Minute
+	defaultCacheMaxEntries = 256
+)
+
+var (
+	defaultResultCacheConfig   = ResultCacheConfig{TTL: defaultCacheTTL, MaxEntries: defaultCacheMaxEntries}
+	defaultResultCacheConfigMu sync.RWMutex
+)
+
+// SetDefaultResultCacheConfig 设置新建工具集合使用的缓存配置
+func SetDefaultResultCacheConfig(config ResultCacheConfig) {
+	defaultResultCacheConfigMu.Lock()
+	defer defaultResultCacheConfigMu.Unlock()
+	defaultResultCacheConfig = config
+}
+
+// DefaultResultCacheConfig 返回默认缓存配置
+func DefaultResultCacheConfig() ResultCacheConfig {
+	defaultResultCacheConfigMu.RLock()
+	defer defaultResultCacheConfigMu.RUnlock()
+	return defaultResultCacheConfig
+}
+
+// fileStamp 文件的修改时间和大小，用于判断缓存是否失效
+type fileStamp struct {
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+}
+
+// cacheEntry 一条缓存结果
+type cacheEntry struct {
+	key     string
+	output  interface{}
+	expires time.Time
+	files   []fileStamp
+}
+
+// persistedEntry 持久缓存文件的内容；结果保存为 cacheText 的文本形式
+type persistedEntry struct {
+	Tool    string      `json:"tool"`
+	Input   string      `json:"input"`
+	Output  string      `json:"output"`
+	Created time.Time   `json:"created"`
+	Expires time.Time   `json:"expires"`
+	Files   []fileStamp `json:"files,omitempty"`
+}
+
+// CacheStats 缓存命中统计
+type CacheStats struct {
+	Hits           int64 `json:"hits"`
+	Misses         int64 `json:"misses"`
+	PersistentHits int64 `json:"persistent_hits"`
+	Entries        int   `json:"entries"`
+}
+
+// ResultCache 工具结果缓存：内存中的 LRU，按需回落到持久缓存目录；
+// 键由工具名和规范化后的参数组成
+type ResultCache struct {
+	config  ResultCacheConfig
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	stats   CacheStats
+}
+
+// NewResultCache 创建结果缓存
+func NewResultCache(config ResultCacheConfig) *ResultCache {
+	if config.TTL == 0 {
+		config.TTL = defaultCacheTTL
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultCacheMaxEntries
+	}
+	return &ResultCache{
+		config:  config,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// cacheKey 由工具名和规范化参数生成键；JSON 编码时 map 的键按字典序排列
+func cacheKey(name string, input interface{}) (key string, canonical string, ok bool) {
+	switch v := input.(type) {
+	case string:
+		// 字符串参数可能是 JSON 对象，解析后再编码以忽略空白和键顺序的差异；
+		// 其他字符串（包括 "42"、"\"x\"" 这类合法的 JSON 标量）按原文作为键
+		var parsed map[string]interface{}
+		if json.Unmarshal([]byte(v), &parsed) == nil && parsed != nil {
+			input = parsed
+		}
+	case []byte:
+		input = string(v)
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", "", false
+	}
+	return name + "\x00" + string(data), string(data), true
+}
+
+// get 查找未过期的结果
+func (c *ResultCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*cacheEntry)
+		if e.valid() {
+			c.lru.MoveToFront(el)
+			c.stats.Hits++
+			return e.output, true
+		}
+		c.lru.Remove(el)
+		delete(c.entries, key)
+	}
+	if e, ok := c.loadPersisted(key); ok {
+		c.insert(e)
+		c.stats.Hits++
+		c.stats.PersistentHits++
+		return e.output, true
+	}
+	c.stats.Misses++
+	return nil, false
+}
+
+// put 保存结果，files 为调用前记录的依赖文件状态；写入持久缓存的结果在内存中同样保存为文本，
+// 使内存命中和持久缓存命中返回相同的内容
+func (c *ResultCache) put(key, tool, canonical string, output interface{}, policy CachePolicy, files []fileStamp) {
+	persistent := policy.Persistent && c.config.Dir != ""
+	if persistent {
+		output = cacheText(output)
+	}
+	e := &cacheEntry{key: key, output: output, files: files}
+	ttl := policy.TTL
+	if ttl == 0 {
+		ttl = c.config.TTL
+	}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.insert(e)
+	if persistent {
+		c.persist(e, tool, canonical)
+	}
+}
+
+// cacheText 结果的文本形式：实现 fmt.Stringer 的结果使用 String，其他结构化结果编码为 JSON
+func cacheText(out interface{}) string {
+	switch v := out.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", out)
+	}
+	return string(data)
+}
+
+// Clear 清空内存缓存，持久缓存不受影响
+func (c *ResultCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// Stats 返回命中统计
+func (c *ResultCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.lru.Len()
+	return s
+}
+
+func (c *ResultCache) insert(e *cacheEntry) {
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.config.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// valid 判断条目是否过期，依赖的文件是否变化
+func (e *cacheEntry) valid() bool {
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		return false
+	}
+	for _, f := range e.files {
+		info, err := os.Stat(f.Path)
+		if err != nil || !info.ModTime().Equal(f.ModTime) || info.Size() != f.Size {
+			return false
+		}
+	}
+	return true
+}
+
+// statFiles 记录文件当前的修改时间和大小；不存在的文件记为零值，相应条目不会命中
+func statFiles(paths []string) []fileStamp {
+	stamps := make([]fileStamp, 0, len(paths))
+	for _, p := range paths {
+		s := fileStamp{Path: p}
+		if info, err := os.Stat(p); err == nil {
+			s.ModTime, s.Size = info.ModTime(), info.Size()
+		}
+		stamps = append(stamps, s)
+	}
+	return stamps
+}
+
+func (c *ResultCache) persistPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.config.Dir, name[:2], name+".json")
+}
+
+// persist 把文本形式的结果写入持久缓存，写入失败只记录日志
+func (c *ResultCache) persist(e *cacheEntry, tool, canonical string) {
+	data, err := json.Marshal(persistedEntry{
+		Tool:    tool,
+		Input:   canonical,
+		Output:  e.output.(string),
+		Created: time.Now(),
+		Expires: e.expires,
+		Files:   e.files,
+	})
+	if err != nil {
+		return
+	}
+	p := c.persistPath(e.key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		logrus.WithError(err).Warn("create tool cache directory failed")
+		return
+	}
+	// 先写临时文件再重命名，避免并发读取到不完整的内容
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logrus.WithError(err).Warn("write tool cache failed")
+		return
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// loadPersisted 读取持久缓存，过期或失效的条目被删除
+func (c *ResultCache) loadPersisted(key string) (*cacheEntry, bool) {
+	if c.config.Dir == "" {
+		return nil, false
+	}
+	p := c.persistPath(key)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	var pe persistedEntry
+	if err := json.Unmarshal(data, &pe); err != nil {
+		os.Remove(p)
+		return nil, false
+	}
+	e := &cacheEntry{key: key, output: pe.Output, expires: pe.Expires, files: pe.Files}
+	if !e.valid() {
+		os.Remove(p)
+		return nil, false
+	}
+	return e, true
+}
+
+// cachePolicyOf 取得工具对本次调用的缓存策略
+func cachePolicyOf(t Tool, input interface{}) (CachePolicy, bool) {
+	var c Cacheable
+	switch v := t.(type) {
+	case Cacheable:
+		c = v
+	case registryTool:
+		c, _ = v.ITool.(Cacheable)
+	}
+	if c == nil {
+		return CachePolicy{}, false
+	}
+	return c.CachePolicy(input)
+}
+
+// CacheMiddleware 对声明为可缓存的工具复用相同参数的结果；出错的调用和 CacheableResult
+// 拒绝缓存的结果不缓存。
+// 放在中间件链的最内层，使权限策略、日志和指标对命中的调用同样生效
+func CacheMiddleware(cache *ResultCache) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (interface{}, error) {
+			policy, ok := cachePolicyOf(call.Tool, call.Input)
+			if !ok {
+				return next(ctx, call)
+			}
+			key, canonical, ok := cacheKey(call.Name, call.Input)
+			if !ok {
+				return next(ctx, call)
+			}
+			if out, hit := cache.get(key); hit {
+				logrus.WithField("tool", call.Name).Debug("tool cache hit")
+				return out, nil
+			}
+			// 依赖文件的状态在调用前记录，调用期间文件被修改时下次读取会重新执行
+			files := statFiles(policy.Files)
+			out, err := next(ctx, call)
+			if err != nil {
+				return out, err
+			}
+			if r, ok := out.(CacheableResult); ok && !r.Cacheable() {
+				return out, nil
+			}
+			cache.put(key, call.Name, canonical, out, policy, files)
+			return out, nil
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheKeyCanonicalization(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b interface{}
+		same bool
+	}{
+		{"key order", map[string]interface{}{"a": 1, "b": 2}, map[string]interface{}{"b": 2, "a": 1}, true},
+		{"nested key order", map[string]interface{}{"o": map[string]interface{}{"x": 1, "y": 2}}, map[string]interface{}{"o": map[string]interface{}{"y": 2, "x": 1}}, true},
+		{"json string and map", `{"path": "a.txt", "action": "read"}`, map[string]interface{}{"action": "read", "path": "a.txt"}, true},
+		{"json whitespace", `{"a":1}`, "{ \"a\" : 1 }\n", true},
+		{"bytes and string", []byte("ls"), "ls", true},
+		{"different values", map[string]interface{}{"a": 1}, map[string]interface{}{"a": 2}, false},
+		{"number and string", map[string]interface{}{"a": 1}, map[string]interface{}{"a": "1"}, false},
+		{"extra key", map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1, "b": nil}, false},
+		{"array order", []interface{}{1, 2}, []interface{}{2, 1}, false},
+		{"scalar json string", "42", float64(42), false},
+		{"quoted string", `"x"`, "x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ka, _, okA := cacheKey("tool", tt.a)
+			kb, _, okB := cacheKey("tool", tt.b)
+			if !okA || !okB {
+				t.Fatal("cacheKey failed")
+			}
+			if (ka == kb) != tt.same {
+				t.Fatalf("keys %q and %q: same = %v, want %v", ka, kb, ka == kb, tt.same)
+			}
+		})
+	}
+
+	k1, _, _ := cacheKey("read", "x")
+	k2, _, _ := cacheKey("write", "x")
+	if k1 == k2 {
+		t.Fatal("tool name is not part of the key")
+	}
+	if _, _, ok := cacheKey("tool", map[string]interface{}{"f": func() {}}); ok {
+		t.Fatal("unencodable input should not be cached")
+	}
+}
+
+// countingTool 记录调用次数的可缓存工具
+type countingTool struct {
+	calls  int
+	policy CachePolicy
+	err    error
+}
+
+func (t *countingTool) Name() string { return "counting" }
+
+func (t *countingTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	t.calls++
+	if t.err != nil {
+		return nil, t.err
+	}
+	return t.calls, nil
+}
+
+func (t *countingTool) CachePolicy(input interface{}) (CachePolicy, bool) {
+	return t.policy, true
+}
+
+func cachedCall(t *testing.T, cache *ResultCache, ct *countingTool, input interface{}) (interface{}, error) {
+	t.Helper()
+	h := CacheMiddleware(cache)(func(ctx context.Context, call *ToolCall) (interface{}, error) {
+		return call.Tool.Run(ctx, call.Input)
+	})
+	return h(context.Background(), &ToolCall{Name: ct.Name(), Tool: ct, Input: input})
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	cache := NewResultCache(ResultCacheConfig{})
+	ct := &countingTool{}
+
+	cachedCall(t, cache, ct, map[string]interface{}{"q": "a", "n": 1})
+	out, _ := cachedCall(t, cache, ct, `{"n": 1, "q": "a"}`)
+	if ct.calls != 1 || out != 1 {
+		t.Fatalf("equivalent input not served from cache: calls %d, out %v", ct.calls, out)
+	}
+	cachedCall(t, cache, ct, map[string]interface{}{"q": "b"})
+	if ct.calls != 2 {
+		t.Fatalf("different input served from cache: calls %d", ct.calls)
+	}
+	if s := cache.Stats(); s.Hits != 1 || s.Misses != 2 || s.Entries != 2 {
+		t.Fatalf("stats = %+v", s)
+	}
+
+	failing := &countingTool{err: errors.New("boom")}
+	cachedCall(t, cache, failing, "x")
+	cachedCall(t, cache, failing, "x")
+	if failing.calls != 2 {
+		t.Fatalf("error result was cached: calls %d", failing.calls)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	t.Run("ttl", func(t *testing.T) {
+		cache := NewResultCache(ResultCacheConfig{})
+		ct := &countingTool{policy: CachePolicy{TTL: 20 * time.Millisecond}}
+		cachedCall(t, cache, ct, "x")
+		time.Sleep(30 * time.Millisecond)
+		cachedCall(t, cache, ct, "x")
+		if ct.calls != 2 {
+			t.Fatalf("expired entry served: calls %d", ct.calls)
+		}
+	})
+
+	t.Run("file change", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "f.txt")
+		if err := os.WriteFile(file, []byte("v1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cache := NewResultCache(ResultCacheConfig{})
+		ct := &countingTool{policy: CachePolicy{TTL: -1, Files: []string{file}}}
+		cachedCall(t, cache, ct, "x")
+		cachedCall(t, cache, ct, "x")
+		if ct.calls != 1 {
+			t.Fatalf("unchanged file: calls %d", ct.calls)
+		}
+		if err := os.WriteFile(file, []byte("version 2"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cachedCall(t, cache, ct, "x")
+		if ct.calls != 2 {
+			t.Fatalf("changed file served from cache: calls %d", ct.calls)
+		}
+	})
+
+	t.Run("lru", func(t *testing.T) {
+		cache := NewResultCache(ResultCacheConfig{MaxEntries: 2})
+		ct := &countingTool{}
+		for _, in := range []string{"a", "b", "a", "c", "a", "b"} {
+			cachedCall(t, cache, ct, in)
+		}
+		// a、b、c 各执行一次，c 挤出了最久未使用的 b
+		if ct.calls != 4 {
+			t.Fatalf("calls = %d, want 4", ct.calls)
+		}
+	})
+}
+
+func TestCachePersistent(t *testing.T) {
+	dir := t.TempDir()
+	ct := &countingTool{policy: CachePolicy{Persistent: true}}
+	cachedCall(t, NewResultCache(ResultCacheConfig{Dir: dir}), ct, map[string]interface{}{"a": 1, "b": 2})
+
+	// 新的缓存实例从持久缓存读取，结果以文本形式返回
+	cache := NewResultCache(ResultCacheConfig{Dir: dir})
+	out, err := cachedCall(t, cache, ct, `{"b":2,"a":1}`)
+	if err != nil || ct.calls != 1 || out != "1" {
+		t.Fatalf("persistent hit: out %v, calls %d, %v", out, ct.calls, err)
+	}
+	if s := cache.Stats(); s.PersistentHits != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+
+	memOnly := &countingTool{}
+	cachedCall(t, NewResultCache(ResultCacheConfig{Dir: dir}), memOnly, "y")
+	cachedCall(t, NewResultCache(ResultCacheConfig{Dir: dir}), memOnly, "y")
+	if memOnly.calls != 2 {
+		t.Fatalf("non-persistent result was written to disk: calls %d", memOnly.calls)
+	}
+}
+
+// resultTool 返回固定结果的可缓存工具
+type resultTool struct {
+	calls  int
+	result interface{}
+}
+
+func (t *resultTool) Name() string { return "result" }
+
+func (t *resultTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	t.calls++
+	return t.result, nil
+}
+
+func (t *resultTool) CachePolicy(input interface{}) (CachePolicy, bool) {
+	return CachePolicy{Persistent: true}, true
+}
+
+func TestCacheResultForms(t *testing.T) {
+	call := func(cache *ResultCache, rt *resultTool) interface{} {
+		t.Helper()
+		h := CacheMiddleware(cache)(invokeTool)
+		out, err := h(context.Background(), &ToolCall{Name: rt.Name(), Tool: rt, Input: "https://example.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	// 状态码表示失败的响应不缓存
+	for _, result := range []interface{}{
+		HTTPResponse{StatusCode: 404, Status: "404 Not Found"},
+		WebResponse{StatusCode: 503},
+	} {
+		rt := &resultTool{result: result}
+		cache := NewResultCache(ResultCacheConfig{Dir: t.TempDir()})
+		call(cache, rt)
+		call(cache, rt)
+		if rt.calls != 2 || cache.Stats().Entries != 0 {
+			t.Fatalf("%T %v was cached", result, result)
+		}
+	}
+
+	// 写入持久缓存的结果在内存命中和持久缓存命中时都返回相同的文本
+	tests := []struct {
+		name   string
+		result interface{}
+		want   string
+	}{
+		{"web response", WebResponse{StatusCode: 200, Body: "hello", Headers: map[string]string{"A": "b"}}, "HTTP 200 OK\nhello"},
+		{"http response", HTTPResponse{StatusCode: 200, Status: "200 OK", Body: "hi"}, "HTTP 200 OK\nhi"},
+		{"struct", struct {
+			N int `json:"n"`
+		}{3}, "{\n  \"n\": 3\n}"},
+		{"bytes", []byte("raw"), "raw"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			rt := &resultTool{result: tt.result}
+			cache := NewResultCache(ResultCacheConfig{Dir: dir})
+			call(cache, rt)
+			if out := call(cache, rt); out != tt.want {
+				t.Fatalf("memory hit = %#v, want %q", out, tt.want)
+			}
+			if out := call(NewResultCache(ResultCacheConfig{Dir: dir}), rt); out != tt.want {
+				t.Fatalf("persistent hit = %#v, want %q", out, tt.want)
+			}
+			if rt.calls != 1 {
+				t.Fatalf("calls = %d", rt.calls)
+			}
+		})
+	}
+
+	// 没有持久缓存目录时内存中保留原始结果
+	rt := &resultTool{result: WebResponse{StatusCode: 200, Body: "x"}}
+	cache := NewResultCache(ResultCacheConfig{})
+	call(cache, rt)
+	if out, ok := call(cache, rt).(WebResponse); !ok || out.Body != "x" || rt.calls != 1 {
+		t.Fatalf("memory-only hit = %#v", out)
+	}
+}
@@ -0,0 +1,316 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// cdpMessage Chrome DevTools Protocol 消息
+type cdpMessage struct {
+	ID        int64           `json:"id,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Params    interface{}     `json:"params,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *cdpError       `json:"error,omitempty"`
+}
+
+// cdpError CDP 协议错误
+type cdpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+func (e *cdpError) Error() string {
+	if e.Data != "" {
+		return fmt.Sprintf("cdp error %d: %s (%s)", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("cdp error %d: %s", e.Code, e.Message)
+}
+
+// cdpOrigin 连接 DevTools 时使用的 Origin
+const cdpOrigin = "http://localhost"
+
+// cdpConn 基于 WebSocket 的 CDP 连接，所有标签页通过 flatten session 复用同一连接
+type cdpConn struct {
+	ws      *websocket.Conn
+	nextID  int64
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[int64]chan cdpMessage
+	closed  chan struct{}
+	err     error
+}
+
+// dialCDP 连接浏览器级别的 DevTools WebSocket 端点
+func dialCDP(ctx context.Context, wsURL string) (*cdpConn, error) {
+	cfg, err := websocket.NewConfig(wsURL, cdpOrigin)
+	if err != nil {
+		return nil, fmt.Errorf("invalid devtools url: %v", err)
+	}
+	dialer := &net.Dialer{}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	cfg.Dialer = dialer
+	ws, err := websocket.DialConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect devtools failed: %v", err)
+	}
+	// 截图等响应可能很大
+	ws.MaxPayloadBytes = 64 << 20
+
+	c := &cdpConn{
+		ws:      ws,
+		pending: make(map[int64]chan cdpMessage),
+		closed:  make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// readLoop 读取响应并分发给等待中的调用，事件消息直接丢弃
+func (c *cdpConn) readLoop() {
+	var err error
+	for {
+		var msg cdpMessage
+		if err = websocket.JSON.Receive(c.ws, &msg); err != nil {
+			break
+		}
+		if msg.ID == 0 {
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[msg.ID]
+		delete(c.pending, msg.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+
+	c.mu.Lock()
+	if err == io.EOF {
+		err = fmt.Errorf("devtools connection closed")
+	}
+	c.err = err
+	c.mu.Unlock()
+	close(c.closed)
+}
+
+// Call 发送 CDP 命令并等待结果，sessionID 为空时发送到浏览器本身
+func (c *cdpConn) Call(ctx context.Context, sessionID, method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan cdpMessage, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if params == nil {
+		params = struct{}{}
+	}
+	c.writeMu.Lock()
+	err := websocket.JSON.Send(c.ws, cdpMessage{ID: id, SessionID: sessionID, Method: method, Params: params})
+	c.writeMu.Unlock()
+	if err != nil {
+		c.forget(id)
+		return fmt.Errorf("send %s failed: %v", method, err)
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %w", method, msg.Error)
+		}
+		if result != nil && len(msg.Result) > 0 {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("decode %s result failed: %v", method, err)
+			}
+		}
+		return nil
+	case <-c.closed:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.err
+	case <-ctx.Done():
+		c.forget(id)
+		return ctx.Err()
+	}
+}
+
+func (c *cdpConn) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Close 关闭连接
+func (c *cdpConn) Close() error {
+	return c.ws.Close()
+}
+
+// chromeProcess 本地启动的无头浏览器进程
+type chromeProcess struct {
+	cmd     *exec.Cmd
+	dataDir string
+	wsURL   string
+}
+
+// defaultChromeNames 在 PATH 中查找浏览器时依次尝试的可执行文件名
+var defaultChromeNames = []string{
+	"chromium",
+	"chromium-browser",
+	"google-chrome",
+	"google-chrome-stable",
+	"headless_shell",
+	"chrome",
+}
+
+// findChrome 查找可用的 Chromium 可执行文件
+func findChrome(path string) (string, error) {
+	if path != "" {
+		return exec.LookPath(path)
+	}
+	for _, name := range defaultChromeNames {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("chromium executable not found, set tools.browser.chrome_path")
+}
+
+// launchChrome 启动浏览器并从 stderr 中解析 DevTools 地址
+func launchChrome(ctx context.Context, config BrowserConfig) (*chromeProcess, error) {
+	bin, err := findChrome(config.ChromePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dataDir, err := os.MkdirTemp("", "openmanus-chrome-")
+	if err != nil {
+		return nil, fmt.Errorf("create user data dir failed: %v", err)
+	}
+
+	args := []string{
+		"--remote-debugging-port=0",
+		// Chromium 111 起拒绝 Origin 不在允许列表中的 DevTools WebSocket 连接，与 dialCDP 的 Origin 一致
+		"--remote-allow-origins=" + cdpOrigin,
+		"--user-data-dir=" + dataDir,
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--disable-gpu",
+		"--disable-extensions",
+		"--disable-background-networking",
+		"--disable-sync",
+		"--mute-audio",
+		fmt.Sprintf("--window-size=%d,%d", config.WindowWidth, config.WindowHeight),
+	}
+	if config.Headless {
+		args = append(args, "--headless=new")
+	}
+	// 以 root 运行时 Chromium 必须关闭自身沙箱
+	if config.NoSandbox || os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args, config.ExtraArgs...)
+	args = append(args, "about:blank")
+
+	cmd := exec.Command(bin, args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return nil, fmt.Errorf("create stderr pipe failed: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		return nil, fmt.Errorf("start chromium failed: %v", err)
+	}
+
+	proc := &chromeProcess{cmd: cmd, dataDir: dataDir}
+	found := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		sent := false
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !sent && strings.HasPrefix(line, "DevTools listening on ") {
+				found <- strings.TrimSpace(strings.TrimPrefix(line, "DevTools listening on "))
+				sent = true
+			}
+		}
+		if !sent {
+			close(found)
+		}
+	}()
+
+	select {
+	case wsURL, ok := <-found:
+		if !ok {
+			proc.Close()
+			return nil, fmt.Errorf("chromium exited before devtools was ready")
+		}
+		proc.wsURL = wsURL
+		return proc, nil
+	case <-ctx.Done():
+		proc.Close()
+		return nil, fmt.Errorf("wait for chromium devtools: %v", ctx.Err())
+	}
+}
+
+// Close 结束浏览器进程并清理临时目录
+func (p *chromeProcess) Close() error {
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+	}
+	return os.RemoveAll(p.dataDir)
+}
+
+// resolveDevToolsURL 将 http://host:port 形式的远程调试地址解析为浏览器 WebSocket 地址
+func resolveDevToolsURL(ctx context.Context, remote string) (string, error) {
+	if strings.HasPrefix(remote, "ws://") || strings.HasPrefix(remote, "wss://") {
+		return remote, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(remote, "/")+"/json/version", nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid remote url: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("query devtools version failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var version struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return "", fmt.Errorf("decode devtools version failed: %v", err)
+	}
+	if version.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("devtools endpoint returned no websocket url")
+	}
+	return version.WebSocketDebuggerURL, nil
+}
+
+// waitInterval 轮询页面状态的间隔
+const waitInterval = 100 * time.Millisecond
@@ -0,0 +1,738 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 图表类型
+const (
+	ChartLine      = "line"
+	ChartBar       = "bar"
+	ChartScatter   = "scatter"
+	ChartPie       = "pie"
+	ChartHistogram = "histogram"
+)
+
+// 图表输出格式
+const (
+	ChartFormatSVG = "svg"
+	ChartFormatPNG = "png"
+)
+
+const (
+	defaultChartWidth  = 800
+	defaultChartHeight = 500
+	maxChartSize       = 4000
+	maxPieSlices       = 10
+	maxCategoryLabel   = 14
+)
+
+// stringList 兼容单个字符串和字符串数组两种写法
+type stringList []string
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != "" {
+			*l = stringList{s}
+		}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// ChartSpec 图表描述
+type ChartSpec struct {
+	// Type line、bar、scatter、pie 或 histogram
+	Type  string `json:"type"`
+	Title string `json:"title,omitempty"`
+	// X 横轴列；饼图为标签列；为空时折线图和柱状图使用行号
+	X string `json:"x,omitempty"`
+	// Y 数值列，可包含多个序列；为空时使用除 X 外的全部数值列
+	Y      stringList `json:"y,omitempty"`
+	XLabel string     `json:"x_label,omitempty"`
+	YLabel string     `json:"y_label,omitempty"`
+	// Aggregate 柱状图和饼图按 X 分组的聚合方式：sum、mean 或 count，默认 sum
+	Aggregate string `json:"aggregate,omitempty"`
+	// Bins 直方图分箱数，默认按 Sturges 规则计算
+	Bins   int `json:"bins,omitempty"`
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Formats 输出格式 svg、png，默认 svg
+	Formats stringList `json:"formats,omitempty"`
+}
+
+// chartSeries 一组数据点；类别横轴时 xs 为类别下标
+type chartSeries struct {
+	name string
+	xs   []float64
+	ys   []float64
+}
+
+// chart 已整理好数据、可绘制到任意画布的图表
+type chart struct {
+	spec   ChartSpec
+	width  float64
+	height float64
+	series []chartSeries
+	// categories 类别横轴标签（柱状图、类别折线图）或饼图扇区标签
+	categories []string
+	// edges 直方图分箱边界
+	edges []float64
+}
+
+// 配色（Tableau 10）
+var chartPalette = []color.RGBA{
+	{0x4e, 0x79, 0xa7, 0xff}, {0xf2, 0x8e, 0x2b, 0xff}, {0xe1, 0x57, 0x59, 0xff},
+	{0x76, 0xb7, 0xb2, 0xff}, {0x59, 0xa1, 0x4f, 0xff}, {0xed, 0xc9, 0x48, 0xff},
+	{0xb0, 0x7a, 0xa1, 0xff}, {0xff, 0x9d, 0xa7, 0xff}, {0x9c, 0x75, 0x5f, 0xff},
+	{0xba, 0xb0, 0xac, 0xff},
+}
+
+var (
+	chartTextColor = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	chartAxisColor = color.RGBA{0x66, 0x66, 0x66, 0xff}
+	chartGridColor = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+)
+
+// buildChart 根据图表描述从数据集中取出绘图数据
+func buildChart(ds *dataset, spec ChartSpec) (*chart, error) {
+	spec.Type = strings.ToLower(spec.Type)
+	if spec.Type == "" {
+		spec.Type = ChartLine
+	}
+	c := &chart{spec: spec, width: defaultChartWidth, height: defaultChartHeight}
+	if spec.Width > 0 {
+		c.width = math.Min(float64(spec.Width), maxChartSize)
+	}
+	if spec.Height > 0 {
+		c.height = math.Min(float64(spec.Height), maxChartSize)
+	}
+
+	var err error
+	switch spec.Type {
+	case ChartLine, ChartBar:
+		err = c.loadXY(ds)
+	case ChartScatter:
+		err = c.loadScatter(ds)
+	case ChartPie:
+		err = c.loadPie(ds)
+	case ChartHistogram:
+		err = c.loadHistogram(ds)
+	default:
+		return nil, fmt.Errorf("%w: unsupported chart type %q, use line, bar, scatter, pie or histogram", ErrInvalidArgs, spec.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.spec.Title == "" {
+		c.spec.Title = c.defaultTitle()
+	}
+	return c, nil
+}
+
+func (c *chart) defaultTitle() string {
+	names := make([]string, len(c.series))
+	for i, s := range c.series {
+		names[i] = s.name
+	}
+	title := strings.Join(names, ", ")
+	if c.spec.X != "" && c.spec.Type != ChartHistogram {
+		title += " by " + c.spec.X
+	}
+	return title
+}
+
+// valueColumns 返回 Y 列，未指定时为除 exclude 外的全部数值列
+func valueColumns(ds *dataset, names []string, exclude int) ([]int, error) {
+	cols, err := ds.numericColumns(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		filtered := cols[:0]
+		for _, col := range cols {
+			if col != exclude {
+				filtered = append(filtered, col)
+			}
+		}
+		cols = filtered
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%w: chart needs at least one numeric column", ErrInvalidArgs)
+	}
+	return cols, nil
+}
+
+// loadXY 折线图和柱状图：数值横轴按数值绘制，其余按类别绘制
+func (c *chart) loadXY(ds *dataset) error {
+	x := -1
+	if c.spec.X != "" {
+		var err error
+		if x, err = ds.column(c.spec.X); err != nil {
+			return err
+		}
+	}
+	cols, err := valueColumns(ds, c.spec.Y, x)
+	if err != nil {
+		return err
+	}
+
+	if c.spec.Type == ChartBar && x >= 0 {
+		return c.loadGrouped(ds, x, cols)
+	}
+	numericX := x >= 0 && c.spec.Type == ChartLine && ds.isNumeric(x)
+	var xs []float64
+	switch {
+	case numericX:
+		xs = ds.floats(x)
+	default:
+		xs = make([]float64, len(ds.rows))
+		c.categories = make([]string, len(ds.rows))
+		for i := range ds.rows {
+			xs[i] = float64(i)
+			if x >= 0 {
+				c.categories[i] = cellString(ds.cell(i, x))
+			} else {
+				c.categories[i] = strconv.Itoa(i + 1)
+			}
+		}
+	}
+	for _, col := range cols {
+		s := chartSeries{name: ds.columns[col]}
+		for i, y := range ds.floats(col) {
+			if !math.IsNaN(y) && !math.IsNaN(xs[i]) {
+				s.xs = append(s.xs, xs[i])
+				s.ys = append(s.ys, y)
+			}
+		}
+		if numericX {
+			sortSeries(&s)
+		}
+		c.series = append(c.series, s)
+	}
+	return nil
+}
+
+func sortSeries(s *chartSeries) {
+	index := make([]int, len(s.xs))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(a, b int) bool { return s.xs[index[a]] < s.xs[index[b]] })
+	xs, ys := make([]float64, len(index)), make([]float64, len(index))
+	for i, j := range index {
+		xs[i], ys[i] = s.xs[j], s.ys[j]
+	}
+	s.xs, s.ys = xs, ys
+}
+
+// groupBy 按标签列分组聚合，标签按首次出现顺序排列
+func groupBy(ds *dataset, label, value int, aggregate string) ([]string, []float64, error) {
+	var labels []string
+	index := make(map[string]int)
+	var sums, counts []float64
+	values := make([]float64, len(ds.rows))
+	if value >= 0 {
+		values = ds.floats(value)
+	}
+	for i := range ds.rows {
+		if math.IsNaN(values[i]) && aggregate != "count" {
+			continue
+		}
+		key := cellString(ds.cell(i, label))
+		if key == "" {
+			key = "(empty)"
+		}
+		j, ok := index[key]
+		if !ok {
+			j = len(labels)
+			index[key] = j
+			labels = append(labels, key)
+			sums = append(sums, 0)
+			counts = append(counts, 0)
+		}
+		if !math.IsNaN(values[i]) {
+			sums[j] += values[i]
+		}
+		counts[j]++
+	}
+	switch aggregate {
+	case "", "sum":
+		return labels, sums, nil
+	case "count":
+		return labels, counts, nil
+	case "mean", "avg":
+		for j := range sums {
+			sums[j] /= counts[j]
+		}
+		return labels, sums, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: aggregate must be sum, mean or count", ErrInvalidArgs)
+	}
+}
+
+// loadGrouped 柱状图按 X 分组聚合
+func (c *chart) loadGrouped(ds *dataset, x int, cols []int) error {
+	aggregate := strings.ToLower(c.spec.Aggregate)
+	for _, col := range cols {
+		labels, values, err := groupBy(ds, x, col, aggregate)
+		if err != nil {
+			return err
+		}
+		s := chartSeries{name: ds.columns[col]}
+		if aggregate == "count" {
+			s.name = "count"
+		}
+		if c.categories == nil {
+			c.categories = labels
+		}
+		// 各序列剔除的缺失行不同，按标签对齐
+		pos := make(map[string]int, len(c.categories))
+		for i, l := range c.categories {
+			pos[l] = i
+		}
+		for i, l := range labels {
+			j, ok := pos[l]
+			if !ok {
+				j = len(c.categories)
+				c.categories = append(c.categories, l)
+			}
+			s.xs = append(s.xs, float64(j))
+			s.ys = append(s.ys, values[i])
+		}
+		c.series = append(c.series, s)
+		if aggregate == "count" {
+			break
+		}
+	}
+	return nil
+}
+
+// loadScatter 散点图：X 为数值列，默认取第一个数值列
+func (c *chart) loadScatter(ds *dataset) error {
+	var x int
+	if c.spec.X != "" {
+		cols, err := ds.numericColumns([]string{c.spec.X})
+		if err != nil {
+			return err
+		}
+		x = cols[0]
+	} else {
+		cols, _ := ds.numericColumns(nil)
+		if len(cols) < 2 {
+			return fmt.Errorf("%w: scatter chart needs two numeric columns", ErrInvalidArgs)
+		}
+		x = cols[0]
+		c.spec.X = ds.columns[x]
+	}
+	cols, err := valueColumns(ds, c.spec.Y, x)
+	if err != nil {
+		return err
+	}
+	xs := ds.floats(x)
+	for _, col := range cols {
+		s := chartSeries{name: ds.columns[col]}
+		for i, y := range ds.floats(col) {
+			if !math.IsNaN(y) && !math.IsNaN(xs[i]) {
+				s.xs = append(s.xs, xs[i])
+				s.ys = append(s.ys, y)
+			}
+		}
+		c.series = append(c.series, s)
+	}
+	return nil
+}
+
+// loadPie 饼图：按标签列聚合数值，扇区过多时合并为 other
+func (c *chart) loadPie(ds *dataset) error {
+	label := -1
+	if c.spec.X != "" {
+		var err error
+		if label, err = ds.column(c.spec.X); err != nil {
+			return err
+		}
+	} else {
+		for i := range ds.columns {
+			if !ds.isNumeric(i) {
+				label = i
+				break
+			}
+		}
+		if label < 0 {
+			return fmt.Errorf("%w: pie chart needs a label column, set x", ErrInvalidArgs)
+		}
+		c.spec.X = ds.columns[label]
+	}
+	aggregate := strings.ToLower(c.spec.Aggregate)
+	value := -1
+	if aggregate != "count" {
+		cols, err := valueColumns(ds, c.spec.Y, label)
+		if err != nil {
+			return err
+		}
+		value = cols[0]
+	}
+	labels, values, err := groupBy(ds, label, value, aggregate)
+	if err != nil {
+		return err
+	}
+	for i, v := range values {
+		if v < 0 {
+			return fmt.Errorf("%w: pie chart values must not be negative (%s = %g)", ErrInvalidArgs, labels[i], v)
+		}
+	}
+
+	index := make([]int, len(labels))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(a, b int) bool { return values[index[a]] > values[index[b]] })
+	name := "count"
+	if value >= 0 {
+		name = ds.columns[value]
+	}
+	s := chartSeries{name: name}
+	for n, i := range index {
+		if n == maxPieSlices-1 && len(index) > maxPieSlices {
+			other := 0.0
+			for _, j := range index[n:] {
+				other += values[j]
+			}
+			c.categories = append(c.categories, "other")
+			s.ys = append(s.ys, other)
+			break
+		}
+		c.categories = append(c.categories, labels[i])
+		s.ys = append(s.ys, values[i])
+	}
+	c.series = []chartSeries{s}
+	return nil
+}
+
+// loadHistogram 直方图：等宽分箱统计频数
+func (c *chart) loadHistogram(ds *dataset) error {
+	names := []string(c.spec.Y)
+	if len(names) == 0 && c.spec.X != "" {
+		names = []string{c.spec.X}
+	}
+	if len(names) > 1 {
+		names = names[:1]
+	}
+	cols, err := valueColumns(ds, names, -1)
+	if err != nil {
+		return err
+	}
+	values := dropNaN(ds.floats(cols[0]))
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	bins := c.spec.Bins
+	if bins <= 0 {
+		bins = int(math.Ceil(math.Log2(float64(len(values))))) + 1
+	}
+	if bins > 200 {
+		bins = 200
+	}
+	if lo == hi {
+		lo, hi, bins = lo-0.5, hi+0.5, 1
+	}
+	width := (hi - lo) / float64(bins)
+	c.edges = make([]float64, bins+1)
+	for i := range c.edges {
+		c.edges[i] = lo + float64(i)*width
+	}
+	counts := make([]float64, bins)
+	for _, v := range values {
+		i := int((v - lo) / width)
+		if i >= bins {
+			i = bins - 1
+		}
+		counts[i]++
+	}
+	s := chartSeries{name: ds.columns[cols[0]], ys: counts}
+	for i := range counts {
+		s.xs = append(s.xs, (c.edges[i]+c.edges[i+1])/2)
+	}
+	c.series = []chartSeries{s}
+	if c.spec.XLabel == "" {
+		c.spec.XLabel = s.name
+	}
+	if c.spec.YLabel == "" {
+		c.spec.YLabel = "count"
+	}
+	return nil
+}
+
+// chartCanvas 绘图目标，坐标原点在左上角，角度从正上方开始顺时针计算
+type chartCanvas interface {
+	rect(x, y, w, h float64, fill color.RGBA)
+	line(x1, y1, x2, y2, width float64, stroke color.RGBA)
+	polyline(xs, ys []float64, width float64, stroke color.RGBA)
+	circle(cx, cy, r float64, fill color.RGBA)
+	wedge(cx, cy, r, start, end float64, fill color.RGBA)
+	// text 以 (x, y) 为基线位置绘制文字，anchor 为 start、middle 或 end
+	text(x, y float64, s string, size float64, anchor string, fill color.RGBA)
+	// vtext 逆时针旋转 90 度绘制文字，用于纵轴标题
+	vtext(x, y float64, s string, size float64, fill color.RGBA)
+}
+
+// draw 将图表绘制到画布上
+func (c *chart) draw(cv chartCanvas) {
+	cv.rect(0, 0, c.width, c.height, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	cv.text(c.width/2, 30, c.spec.Title, 16, "middle", chartTextColor)
+	if c.spec.Type == ChartPie {
+		c.drawPie(cv)
+		return
+	}
+
+	left, right, top, bottom := 70.0, c.width-20, 50.0, c.height-60
+	if len(c.series) > 1 {
+		right -= 130
+		c.drawLegend(cv, right+15, top)
+	}
+
+	// 纵轴范围：柱状图和直方图包含 0
+	ylo, yhi := math.Inf(1), math.Inf(-1)
+	for _, s := range c.series {
+		for _, y := range s.ys {
+			ylo, yhi = math.Min(ylo, y), math.Max(yhi, y)
+		}
+	}
+	if math.IsInf(ylo, 1) {
+		ylo, yhi = 0, 1
+	}
+	if c.spec.Type == ChartBar || c.spec.Type == ChartHistogram {
+		ylo, yhi = math.Min(ylo, 0), math.Max(yhi, 0)
+	}
+	ylo, yhi, yticks := niceTicks(ylo, yhi, 6)
+	py := func(v float64) float64 { return bottom - (v-ylo)/(yhi-ylo)*(bottom-top) }
+	yDecimals := tickDecimals(yticks)
+	for _, t := range yticks {
+		y := py(t)
+		cv.line(left, y, right, y, 1, chartGridColor)
+		cv.text(left-8, y+4, formatTick(t, yDecimals), 11, "end", chartAxisColor)
+	}
+
+	// 横轴：类别横轴使用等宽分段，数值横轴使用刻度
+	var px func(v float64) float64
+	band := 0.0
+	switch {
+	case c.edges != nil:
+		lo, hi := c.edges[0], c.edges[len(c.edges)-1]
+		px = func(v float64) float64 { return left + (v-lo)/(hi-lo)*(right-left) }
+		band = px(c.edges[1]) - px(c.edges[0])
+		step := int(math.Ceil(float64(len(c.edges)) / ((right - left) / 60)))
+		for i := 0; i < len(c.edges); i += step {
+			x := px(c.edges[i])
+			cv.line(x, bottom, x, bottom+5, 1, chartAxisColor)
+			cv.text(x, bottom+18, formatTick(roundFloat(c.edges[i]), -1), 11, "middle", chartAxisColor)
+		}
+	case c.categories != nil:
+		n := float64(len(c.categories))
+		band = (right - left) / math.Max(n, 1)
+		px = func(v float64) float64 { return left + (v+0.5)*band }
+		step := int(math.Ceil(n / ((right - left) / 70)))
+		for i := 0; i < len(c.categories); i += step {
+			cv.text(px(float64(i)), bottom+18, clipLabel(c.categories[i]), 11, "middle", chartAxisColor)
+		}
+	default:
+		xlo, xhi := math.Inf(1), math.Inf(-1)
+		for _, s := range c.series {
+			for _, x := range s.xs {
+				xlo, xhi = math.Min(xlo, x), math.Max(xhi, x)
+			}
+		}
+		if math.IsInf(xlo, 1) {
+			xlo, xhi = 0, 1
+		}
+		var xticks []float64
+		xlo, xhi, xticks = niceTicks(xlo, xhi, 8)
+		px = func(v float64) float64 { return left + (v-xlo)/(xhi-xlo)*(right-left) }
+		decimals := tickDecimals(xticks)
+		for _, t := range xticks {
+			x := px(t)
+			cv.line(x, top, x, bottom, 1, chartGridColor)
+			cv.text(x, bottom+18, formatTick(t, decimals), 11, "middle", chartAxisColor)
+		}
+	}
+
+	cv.line(left, bottom, right, bottom, 1, chartAxisColor)
+	cv.line(left, top, left, bottom, 1, chartAxisColor)
+	if c.spec.XLabel != "" || c.spec.X != "" {
+		label := c.spec.XLabel
+		if label == "" {
+			label = c.spec.X
+		}
+		cv.text((left+right)/2, c.height-18, label, 12, "middle", chartTextColor)
+	}
+	if c.spec.YLabel != "" {
+		cv.vtext(20, (top+bottom)/2, c.spec.YLabel, 12, chartTextColor)
+	} else if len(c.series) == 1 {
+		cv.vtext(20, (top+bottom)/2, c.series[0].name, 12, chartTextColor)
+	}
+
+	zero := py(math.Max(ylo, math.Min(0, yhi)))
+	for i, s := range c.series {
+		stroke := chartPalette[i%len(chartPalette)]
+		switch c.spec.Type {
+		case ChartLine:
+			xs, ys := make([]float64, len(s.xs)), make([]float64, len(s.ys))
+			for j := range s.xs {
+				xs[j], ys[j] = px(s.xs[j]), py(s.ys[j])
+			}
+			cv.polyline(xs, ys, 2, stroke)
+			if len(xs) <= 60 {
+				for j := range xs {
+					cv.circle(xs[j], ys[j], 3, stroke)
+				}
+			}
+		case ChartScatter:
+			for j := range s.xs {
+				cv.circle(px(s.xs[j]), py(s.ys[j]), 3.5, stroke)
+			}
+		case ChartBar:
+			// 多个序列在同一类别内并排
+			w := band * 0.8 / float64(len(c.series))
+			for j := range s.xs {
+				x := px(s.xs[j]) - band*0.4 + float64(i)*w
+				y := py(s.ys[j])
+				cv.rect(x, math.Min(y, zero), math.Max(w-1, 1), math.Abs(zero-y), stroke)
+			}
+		case ChartHistogram:
+			for j := range s.xs {
+				y := py(s.ys[j])
+				cv.rect(px(c.edges[j]), y, math.Max(band-1, 1), zero-y, stroke)
+			}
+		}
+	}
+}
+
+func (c *chart) drawLegend(cv chartCanvas, x, y float64) {
+	for i, s := range c.series {
+		cy := y + float64(i)*20
+		cv.rect(x, cy, 12, 12, chartPalette[i%len(chartPalette)])
+		cv.text(x+18, cy+10, clipLabel(s.name), 11, "start", chartTextColor)
+	}
+}
+
+func (c *chart) drawPie(cv chartCanvas) {
+	s := c.series[0]
+	total := 0.0
+	for _, v := range s.ys {
+		total += v
+	}
+	cx, cy := (c.width-160)/2, (c.height+30)/2
+	r := math.Min(cx-30, cy-50)
+	if total == 0 {
+		cv.text(cx, cy, "no data", 12, "middle", chartAxisColor)
+		return
+	}
+	angle := 0.0
+	for i, v := range s.ys {
+		sweep := v / total * 2 * math.Pi
+		fill := chartPalette[i%len(chartPalette)]
+		cv.wedge(cx, cy, r, angle, angle+sweep, fill)
+		// 较大的扇区内标注百分比
+		if sweep > 0.3 {
+			mid := angle + sweep/2
+			tx, ty := cx+math.Sin(mid)*r*0.65, cy-math.Cos(mid)*r*0.65
+			cv.text(tx, ty+4, fmt.Sprintf("%.1f%%", v/total*100), 11, "middle", color.RGBA{0xff, 0xff, 0xff, 0xff})
+		}
+		angle += sweep
+
+		ly := 60 + float64(i)*20
+		cv.rect(c.width-170, ly, 12, 12, fill)
+		cv.text(c.width-152, ly+10, fmt.Sprintf("%s (%s)", clipLabel(c.categories[i]), formatTick(roundFloat(v), -1)), 11, "start", chartTextColor)
+	}
+}
+
+// niceTicks 把范围扩展到“整齐”的刻度，返回新的范围和刻度值
+func niceTicks(lo, hi float64, count int) (float64, float64, []float64) {
+	if lo == hi {
+		if lo == 0 {
+			hi = 1
+		} else {
+			lo, hi = lo-math.Abs(lo)*0.1, hi+math.Abs(hi)*0.1
+		}
+	}
+	step := niceNumber((hi-lo)/float64(count-1), true)
+	lo = math.Floor(lo/step) * step
+	hi = math.Ceil(hi/step) * step
+	var ticks []float64
+	for t := lo; t <= hi+step/2; t += step {
+		ticks = append(ticks, roundFloat(t))
+	}
+	return lo, hi, ticks
+}
+
+func niceNumber(x float64, round bool) float64 {
+	exp := math.Floor(math.Log10(x))
+	f := x / math.Pow(10, exp)
+	var nf float64
+	switch {
+	case round && f < 1.5, !round && f <= 1:
+		nf = 1
+	case round && f < 3, !round && f <= 2:
+		nf = 2
+	case round && f < 7, !round && f <= 5:
+		nf = 5
+	default:
+		nf = 10
+	}
+	return nf * math.Pow(10, exp)
+}
+
+// tickDecimals 根据刻度间隔确定小数位数
+func tickDecimals(ticks []float64) int {
+	if len(ticks) < 2 {
+		return -1
+	}
+	step := math.Abs(ticks[1] - ticks[0])
+	if step == 0 {
+		return -1
+	}
+	d := int(math.Max(0, -math.Floor(math.Log10(step))))
+	if d > 6 {
+		return -1
+	}
+	return d
+}
+
+// formatTick 格式化刻度，数值很大时使用 k、M 等后缀
+func formatTick(v float64, decimals int) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e9:
+		return strconv.FormatFloat(v/1e9, 'g', 4, 64) + "B"
+	case abs >= 1e6:
+		return strconv.FormatFloat(v/1e6, 'g', 4, 64) + "M"
+	case abs >= 1e4:
+		return strconv.FormatFloat(v/1e3, 'g', 4, 64) + "k"
+	}
+	if decimals < 0 {
+		return strconv.FormatFloat(v, 'g', 6, 64)
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+func clipLabel(s string) string {
+	r := []rune(s)
+	if len(r) > maxCategoryLabel {
+		return string(r[:maxCategoryLabel-1]) + "…"
+	}
+	return s
+}
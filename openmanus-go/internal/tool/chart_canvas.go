@@ -0,0 +1,237 @@
+package tool
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// svgCanvas 生成 SVG 文本
+type svgCanvas struct {
+	sb strings.Builder
+}
+
+func newSVGCanvas(width, height float64) *svgCanvas {
+	c := &svgCanvas{}
+	fmt.Fprintf(&c.sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		width, height, width, height)
+	return c
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, fill color.RGBA) {
+	fmt.Fprintf(&c.sb, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`+"\n", x, y, w, h, svgColor(fill))
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2, width float64, stroke color.RGBA) {
+	fmt.Fprintf(&c.sb, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%g"/>`+"\n", x1, y1, x2, y2, svgColor(stroke), width)
+}
+
+func (c *svgCanvas) polyline(xs, ys []float64, width float64, stroke color.RGBA) {
+	points := make([]string, len(xs))
+	for i := range xs {
+		points[i] = fmt.Sprintf("%.2f,%.2f", xs[i], ys[i])
+	}
+	fmt.Fprintf(&c.sb, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%g" stroke-linejoin="round"/>`+"\n",
+		strings.Join(points, " "), svgColor(stroke), width)
+}
+
+func (c *svgCanvas) circle(cx, cy, r float64, fill color.RGBA) {
+	fmt.Fprintf(&c.sb, `<circle cx="%.2f" cy="%.2f" r="%g" fill="%s"/>`+"\n", cx, cy, r, svgColor(fill))
+}
+
+func (c *svgCanvas) wedge(cx, cy, r, start, end float64, fill color.RGBA) {
+	if end-start >= 2*math.Pi-1e-9 {
+		c.circle(cx, cy, r, fill)
+		return
+	}
+	large := 0
+	if end-start > math.Pi {
+		large = 1
+	}
+	fmt.Fprintf(&c.sb, `<path d="M%.2f,%.2f L%.2f,%.2f A%.2f,%.2f 0 %d 1 %.2f,%.2f Z" fill="%s" stroke="#ffffff" stroke-width="1"/>`+"\n",
+		cx, cy, cx+r*math.Sin(start), cy-r*math.Cos(start), r, r, large, cx+r*math.Sin(end), cy-r*math.Cos(end), svgColor(fill))
+}
+
+func (c *svgCanvas) text(x, y float64, s string, size float64, anchor string, fill color.RGBA) {
+	fmt.Fprintf(&c.sb, `<text x="%.2f" y="%.2f" font-size="%g" text-anchor="%s" fill="%s">%s</text>`+"\n",
+		x, y, size, anchor, svgColor(fill), html.EscapeString(s))
+}
+
+func (c *svgCanvas) vtext(x, y float64, s string, size float64, fill color.RGBA) {
+	fmt.Fprintf(&c.sb, `<text x="%.2f" y="%.2f" font-size="%g" text-anchor="middle" fill="%s" transform="rotate(-90 %.2f %.2f)">%s</text>`+"\n",
+		x, y, size, svgColor(fill), x, y, html.EscapeString(s))
+}
+
+func (c *svgCanvas) bytes() []byte {
+	return []byte(c.sb.String() + "</svg>\n")
+}
+
+// pngCanvas 光栅化绘制，边缘按像素覆盖率做抗锯齿；文字使用内置点阵字体，仅支持 ASCII 字符
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func newPNGCanvas(width, height float64) *pngCanvas {
+	return &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, int(math.Ceil(width)), int(math.Ceil(height))))}
+}
+
+// blend 以 alpha 覆盖率把颜色混合到像素上
+func (c *pngCanvas) blend(x, y int, col color.RGBA, alpha float64) {
+	if alpha <= 0 || !(image.Point{x, y}.In(c.img.Rect)) {
+		return
+	}
+	if alpha > 1 {
+		alpha = 1
+	}
+	i := c.img.PixOffset(x, y)
+	p := c.img.Pix[i : i+4 : i+4]
+	p[0] = uint8(float64(col.R)*alpha + float64(p[0])*(1-alpha))
+	p[1] = uint8(float64(col.G)*alpha + float64(p[1])*(1-alpha))
+	p[2] = uint8(float64(col.B)*alpha + float64(p[2])*(1-alpha))
+	p[3] = 0xff
+}
+
+// clampBounds 把浮点包围盒转换为图像内的整数范围
+func (c *pngCanvas) clampBounds(x0, y0, x1, y1 float64) (int, int, int, int) {
+	b := c.img.Rect
+	return max(int(math.Floor(x0)), b.Min.X), max(int(math.Floor(y0)), b.Min.Y),
+		min(int(math.Ceil(x1)), b.Max.X-1), min(int(math.Ceil(y1)), b.Max.Y-1)
+}
+
+func (c *pngCanvas) rect(x, y, w, h float64, fill color.RGBA) {
+	x0, y0, x1, y1 := c.clampBounds(x, y, x+w, y+h)
+	for py := y0; py <= y1; py++ {
+		cy := overlap(float64(py), y, y+h)
+		for px := x0; px <= x1; px++ {
+			c.blend(px, py, fill, cy*overlap(float64(px), x, x+w))
+		}
+	}
+}
+
+// overlap 像素 [p, p+1) 与区间 [a, b) 的重叠比例
+func overlap(p, a, b float64) float64 {
+	return math.Max(0, math.Min(p+1, b)-math.Max(p, a))
+}
+
+func (c *pngCanvas) line(x1, y1, x2, y2, width float64, stroke color.RGBA) {
+	half := width / 2
+	x0, y0, xm, ym := c.clampBounds(math.Min(x1, x2)-half-1, math.Min(y1, y2)-half-1, math.Max(x1, x2)+half+1, math.Max(y1, y2)+half+1)
+	dx, dy := x2-x1, y2-y1
+	length2 := dx*dx + dy*dy
+	for py := y0; py <= ym; py++ {
+		for px := x0; px <= xm; px++ {
+			// 像素中心到线段的距离
+			cx, cy := float64(px)+0.5, float64(py)+0.5
+			t := 0.0
+			if length2 > 0 {
+				t = math.Max(0, math.Min(1, ((cx-x1)*dx+(cy-y1)*dy)/length2))
+			}
+			d := math.Hypot(cx-(x1+t*dx), cy-(y1+t*dy))
+			c.blend(px, py, stroke, half+0.5-d)
+		}
+	}
+}
+
+func (c *pngCanvas) polyline(xs, ys []float64, width float64, stroke color.RGBA) {
+	for i := 1; i < len(xs); i++ {
+		c.line(xs[i-1], ys[i-1], xs[i], ys[i], width, stroke)
+	}
+}
+
+func (c *pngCanvas) circle(cx, cy, r float64, fill color.RGBA) {
+	c.wedge(cx, cy, r, 0, 2*math.Pi, fill)
+}
+
+func (c *pngCanvas) wedge(cx, cy, r, start, end float64, fill color.RGBA) {
+	x0, y0, x1, y1 := c.clampBounds(cx-r-1, cy-r-1, cx+r+1, cy+r+1)
+	full := end-start >= 2*math.Pi-1e-9
+	for py := y0; py <= y1; py++ {
+		for px := x0; px <= x1; px++ {
+			dx, dy := float64(px)+0.5-cx, float64(py)+0.5-cy
+			alpha := r + 0.5 - math.Hypot(dx, dy)
+			if alpha <= 0 {
+				continue
+			}
+			if !full {
+				// 从正上方开始顺时针的角度
+				a := math.Atan2(dx, -dy)
+				if a < 0 {
+					a += 2 * math.Pi
+				}
+				if a < start || a >= end {
+					continue
+				}
+			}
+			c.blend(px, py, fill, alpha)
+		}
+	}
+}
+
+func (c *pngCanvas) text(x, y float64, s string, size float64, anchor string, fill color.RGBA) {
+	d := &font.Drawer{Dst: c.img, Src: image.NewUniform(fill), Face: basicfont.Face7x13}
+	w := float64(d.MeasureString(s).Round())
+	switch anchor {
+	case "middle":
+		x -= w / 2
+	case "end":
+		x -= w
+	}
+	d.Dot = fixed.P(int(math.Round(x)), int(math.Round(y)))
+	d.DrawString(s)
+}
+
+func (c *pngCanvas) vtext(x, y float64, s string, size float64, fill color.RGBA) {
+	// 先水平绘制到临时图像，再逆时针旋转 90 度复制
+	face := basicfont.Face7x13
+	d := &font.Drawer{Face: face}
+	w := d.MeasureString(s).Round()
+	h := face.Height
+	tmp := image.NewRGBA(image.Rect(0, 0, w, h))
+	d.Dst, d.Src = tmp, image.NewUniform(fill)
+	d.Dot = fixed.P(0, face.Ascent)
+	d.DrawString(s)
+
+	ox, oy := int(math.Round(x))-h/2, int(math.Round(y))+w/2
+	for ty := 0; ty < h; ty++ {
+		for tx := 0; tx < w; tx++ {
+			if a := tmp.RGBAAt(tx, ty).A; a > 0 {
+				c.blend(ox+ty, oy-tx, fill, float64(a)/0xff)
+			}
+		}
+	}
+}
+
+func (c *pngCanvas) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderSVG 渲染为 SVG
+func (c *chart) renderSVG() []byte {
+	cv := newSVGCanvas(c.width, c.height)
+	c.draw(cv)
+	return cv.bytes()
+}
+
+// renderPNG 渲染为 PNG
+func (c *chart) renderPNG() ([]byte, error) {
+	cv := newPNGCanvas(c.width, c.height)
+	c.draw(cv)
+	return cv.bytes()
+}
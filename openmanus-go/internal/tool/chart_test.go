@@ -0,0 +1,169 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"image/png"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildChart(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       ChartSpec
+		title      string
+		categories []string
+		ys         [][]float64
+		edges      []float64
+	}{
+		{
+			name:  "line by row",
+			spec:  ChartSpec{Y: stringList{"y"}},
+			title: "y",
+			ys:    [][]float64{{2, 4, 5, 4, 5}},
+		},
+		{
+			name:       "bar sum",
+			spec:       ChartSpec{Type: "BAR", X: "g", Y: stringList{"y"}},
+			title:      "y by g",
+			categories: []string{"a", "b", "c"},
+			ys:         [][]float64{{12, 4, 4}},
+		},
+		{
+			name:       "bar mean",
+			spec:       ChartSpec{Type: ChartBar, X: "g", Y: stringList{"y"}, Aggregate: "mean", Title: "平均值"},
+			title:      "平均值",
+			categories: []string{"a", "b", "c"},
+			ys:         [][]float64{{4, 4, 4}},
+		},
+		{
+			name:  "scatter",
+			spec:  ChartSpec{Type: ChartScatter, X: "x", Y: stringList{"y"}},
+			title: "y by x",
+			ys:    [][]float64{{2, 4, 5, 4, 5}},
+		},
+		{
+			name:       "pie count",
+			spec:       ChartSpec{Type: ChartPie, X: "g", Aggregate: "count"},
+			title:      "count by g",
+			categories: []string{"a", "b", "c"},
+			ys:         [][]float64{{3, 1, 1}},
+		},
+		{
+			name:  "histogram",
+			spec:  ChartSpec{Type: ChartHistogram, Y: stringList{"y"}, Bins: 3},
+			title: "y",
+			ys:    [][]float64{{1, 0, 4}},
+			edges: []float64{2, 3, 4, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := buildChart(newTestDataset(t), tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(c.spec.Title, tt.title) {
+				t.Fatalf("title = %q, want %q", c.spec.Title, tt.title)
+			}
+			if tt.categories != nil && !reflect.DeepEqual(c.categories, tt.categories) {
+				t.Fatalf("categories = %v", c.categories)
+			}
+			var ys [][]float64
+			for _, s := range c.series {
+				ys = append(ys, s.ys)
+			}
+			if !reflect.DeepEqual(ys, tt.ys) {
+				t.Fatalf("series = %v, want %v", ys, tt.ys)
+			}
+			if tt.edges != nil && !reflect.DeepEqual(c.edges, tt.edges) {
+				t.Fatalf("edges = %v", c.edges)
+			}
+		})
+	}
+
+	for _, spec := range []ChartSpec{
+		{Type: "radar"},
+		{Type: ChartBar, X: "g", Y: stringList{"y"}, Aggregate: "median"},
+		{Type: ChartScatter, X: "g"},
+		{Type: ChartLine, Y: stringList{"g"}},
+	} {
+		if _, err := buildChart(newTestDataset(t), spec); !errors.Is(err, ErrInvalidArgs) {
+			t.Fatalf("spec %+v: %v", spec, err)
+		}
+	}
+}
+
+func TestChartRender(t *testing.T) {
+	for _, typ := range []string{ChartLine, ChartBar, ChartScatter, ChartPie, ChartHistogram} {
+		t.Run(typ, func(t *testing.T) {
+			spec := ChartSpec{Type: typ, X: "g", Y: stringList{"y"}, Title: "<y & x>", Width: 320, Height: 200}
+			if typ == ChartScatter || typ == ChartLine {
+				spec.X = "x"
+			}
+			c, err := buildChart(newTestDataset(t), spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// SVG 是合法的 XML，标题经过转义
+			svg := c.renderSVG()
+			dec := xml.NewDecoder(bytes.NewReader(svg))
+			var root string
+			var texts []string
+			for {
+				tok, err := dec.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("invalid svg: %v\n%s", err, svg)
+				}
+				switch tok := tok.(type) {
+				case xml.StartElement:
+					if root == "" {
+						root = tok.Name.Local
+					}
+				case xml.CharData:
+					texts = append(texts, string(tok))
+				}
+			}
+			if root != "svg" {
+				t.Fatalf("root element = %q", root)
+			}
+			if !strings.Contains(strings.Join(texts, "\n"), "<y & x>") {
+				t.Fatal("title missing from svg text")
+			}
+
+			data, err := c.renderPNG()
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 200 {
+				t.Fatalf("png size = %dx%d", b.Dx(), b.Dy())
+			}
+			// 背景为白色，绘制内容不是空白图
+			if r, g, b, _ := img.At(0, 0).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+				t.Fatalf("background = %v", img.At(0, 0))
+			}
+			colors := make(map[[3]uint32]bool)
+			for y := 0; y < 200; y += 4 {
+				for x := 0; x < 320; x += 4 {
+					r, g, b, _ := img.At(x, y).RGBA()
+					colors[[3]uint32{r, g, b}] = true
+				}
+			}
+			if len(colors) < 3 {
+				t.Fatalf("png has only %d colors", len(colors))
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AgentController 由智能体实现，供 terminate、ask_human 等工具改变智能体状态
+type AgentController interface {
+	// Pause 暂停智能体，等待外部输入
+	Pause() error
+	// Resume 恢复暂停的智能体
+	Resume() error
+	// Finish 记录最终结果并结束智能体
+	Finish(status, answer string) error
+}
+
+type agentControllerKey struct{}
+
+// WithAgentController 将智能体控制器放入上下文，工具执行时可通过 AgentControllerFrom 取回
+func WithAgentController(ctx context.Context, c AgentController) context.Context {
+	return context.WithValue(ctx, agentControllerKey{}, c)
+}
+
+// AgentControllerFrom 从上下文中获取智能体控制器
+func AgentControllerFrom(ctx context.Context) (AgentController, bool) {
+	c, ok := ctx.Value(agentControllerKey{}).(AgentController)
+	return c, ok
+}
+
+// 终止状态
+const (
+	TerminateSuccess = "success"
+	TerminateFailure = "failure"
+)
+
+// TerminateTool 结束当前任务的工具
+type TerminateTool struct {
+	name        string
+	description string
+}
+
+// NewTerminateTool 创建终止工具
+func NewTerminateTool() *TerminateTool {
+	return &TerminateTool{
+		name:        "terminate",
+		description: "任务完成或无法继续时调用，结束当前任务；status 为 success 或 failure，answer 为最终答案",
+	}
+}
+
+// Name 返回工具名称
+func (t *TerminateTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *TerminateTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *TerminateTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// TerminateInput 终止工具参数
+type TerminateInput struct {
+	Status string `json:"status"`
+	Answer string `json:"answer,omitempty"`
+	// FinalAnswer 兼容部分模型使用的字段名
+	FinalAnswer string `json:"final_answer,omitempty"`
+}
+
+// TerminateResult 终止结果
+type TerminateResult struct {
+	Status string `json:"status"`
+	Answer string `json:"answer"`
+}
+
+// String 返回最终答案，便于直接作为智能体输出
+func (r TerminateResult) String() string {
+	if r.Answer == "" {
+		return fmt.Sprintf("任务结束，状态: %s", r.Status)
+	}
+	return r.Answer
+}
+
+// Run 结束任务
+func (t *TerminateTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in TerminateInput
+	if s, ok := input.(string); ok {
+		in.Answer = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Answer == "" {
+		in.Answer = in.FinalAnswer
+	}
+	switch in.Status {
+	case "":
+		in.Status = TerminateSuccess
+	case TerminateSuccess, TerminateFailure:
+	default:
+		return nil, fmt.Errorf("%w: status must be success or failure", ErrInvalidArgs)
+	}
+
+	if c, ok := AgentControllerFrom(ctx); ok {
+		if err := c.Finish(in.Status, in.Answer); err != nil {
+			return nil, err
+		}
+	}
+	return TerminateResult{Status: in.Status, Answer: in.Answer}, nil
+}
+
+// 人工输入相关错误
+var (
+	ErrQuestionNotFound = errors.New("question not found or already answered")
+	ErrHumanTimeout     = errors.New("timed out waiting for human input")
+)
+
+// HumanQuestion 等待人工回答的问题
+type HumanQuestion struct {
+	ID       string    `json:"id"`
+	Question string    `json:"question"`
+	AskedAt  time.Time `json:"asked_at"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// HumanBroker 在智能体和人工输入端（命令行、HTTP）之间转发问题和回答
+type HumanBroker struct {
+	mu          sync.Mutex
+	seq         int
+	pending     map[string]*pendingQuestion
+	subscribers []func(HumanQuestion)
+}
+
+type pendingQuestion struct {
+	question HumanQuestion
+	answer   chan string
+	done     chan struct{}
+}
+
+// NewHumanBroker 创建人工输入中转
+func NewHumanBroker() *HumanBroker {
+	return &HumanBroker{
+		pending: make(map[string]*pendingQuestion),
+	}
+}
+
+// Subscribe 订阅新问题，回调在独立的 goroutine 中执行
+func (b *HumanBroker) Subscribe(fn func(HumanQuestion)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Ask 提出问题并等待回答，超时或上下文取消时返回错误
+func (b *HumanBroker) Ask(ctx context.Context, question string, timeout time.Duration) (string, error) {
+	b.mu.Lock()
+	b.seq++
+	now := time.Now()
+	p := &pendingQuestion{
+		question: HumanQuestion{
+			ID:       strconv.Itoa(b.seq),
+			Question: question,
+			AskedAt:  now,
+			Deadline: now.Add(timeout),
+		},
+		answer: make(chan string, 1),
+		done:   make(chan struct{}),
+	}
+	b.pending[p.question.ID] = p
+	subscribers := make([]func(HumanQuestion), len(b.subscribers))
+	copy(subscribers, b.subscribers)
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.pending, p.question.ID)
+		b.mu.Unlock()
+		close(p.done)
+	}()
+	for _, fn := range subscribers {
+		go fn(p.question)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case answer := <-p.answer:
+		return answer, nil
+	case <-timer.C:
+		return "", ErrHumanTimeout
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Answer 回答指定问题
+func (b *HumanBroker) Answer(id, answer string) error {
+	b.mu.Lock()
+	p, ok := b.pending[id]
+	if ok {
+		delete(b.pending, id)
+	}
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrQuestionNotFound, id)
+	}
+	p.answer <- answer
+	return nil
+}
+
+// Done 返回问题结束（已回答、超时或取消）时关闭的通道，输入端据此撤下过期的提示；
+// 问题不存在时返回已关闭的通道
+func (b *HumanBroker) Done(id string) <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if p, ok := b.pending[id]; ok {
+		return p.done
+	}
+	return closedChan
+}
+
+// Pending 按提问顺序返回等待回答的问题
+func (b *HumanBroker) Pending() []HumanQuestion {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	questions := make([]HumanQuestion, 0, len(b.pending))
+	for _, p := range b.pending {
+		questions = append(questions, p.question)
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].AskedAt.Before(questions[j].AskedAt)
+	})
+	return questions
+}
+
+// closedChan 已关闭的通道，用于已结束的请求
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+var (
+	defaultHumanBroker     *HumanBroker
+	defaultHumanBrokerOnce sync.Once
+)
+
+// DefaultHumanBroker 返回进程内共享的人工输入中转，命令行和 HTTP 服务都通过它回答问题
+func DefaultHumanBroker() *HumanBroker {
+	defaultHumanBrokerOnce.Do(func() {
+		defaultHumanBroker = NewHumanBroker()
+	})
+	return defaultHumanBroker
+}
+
+// 默认等待人工回答的时间
+const defaultAskHumanTimeout = 5 * time.Minute
+
+// AskHumanTool 向用户提问并等待回答的工具
+type AskHumanTool struct {
+	name        string
+	description string
+	broker      *HumanBroker
+	timeout     time.Duration
+}
+
+// NewAskHumanTool 创建提问工具，broker 为空时使用默认中转
+func NewAskHumanTool(broker *HumanBroker, timeout time.Duration) *AskHumanTool {
+	if broker == nil {
+		broker = DefaultHumanBroker()
+	}
+	if timeout <= 0 {
+		timeout = defaultAskHumanTimeout
+	}
+	return &AskHumanTool{
+		name:        "ask_human",
+		description: "向用户提问并等待回答，用于澄清需求或确认操作；等待期间智能体处于暂停状态",
+		broker:      broker,
+		timeout:     timeout,
+	}
+}
+
+// Name 返回工具名称
+func (t *AskHumanTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *AskHumanTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *AskHumanTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// AskHumanInput 提问参数
+type AskHumanInput struct {
+	Question string `json:"question"`
+	// Timeout 等待秒数，不超过工具配置的上限
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// AskHumanResult 提问结果
+type AskHumanResult struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+}
+
+// String 返回回答，超时时给出提示
+func (r AskHumanResult) String() string {
+	if r.TimedOut {
+		return "用户在限定时间内没有回答，请根据已有信息自行判断后继续"
+	}
+	return r.Answer
+}
+
+// Run 提问并等待回答；智能体在等待期间被暂停，回答或超时后恢复
+func (t *AskHumanTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in AskHumanInput
+	if s, ok := input.(string); ok {
+		in.Question = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	in.Question = strings.TrimSpace(in.Question)
+	if in.Question == "" {
+		return nil, ErrInvalidArgs
+	}
+	timeout := t.timeout
+	if in.Timeout > 0 && time.Duration(in.Timeout)*time.Second < timeout {
+		timeout = time.Duration(in.Timeout) * time.Second
+	}
+
+	// 智能体未处于运行状态时（例如直接调用 Act）无法暂停，此时仅等待回答
+	c, ok := AgentControllerFrom(ctx)
+	paused := ok && c.Pause() == nil
+
+	answer, err := t.broker.Ask(ctx, in.Question, timeout)
+
+	if paused {
+		if resumeErr := c.Resume(); resumeErr != nil && err == nil {
+			err = resumeErr
+		}
+	}
+	if errors.Is(err, ErrHumanTimeout) {
+		return AskHumanResult{Question: in.Question, TimedOut: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return AskHumanResult{Question: in.Question, Answer: answer}, nil
+}
@@ -0,0 +1,259 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testController 记录状态变化的智能体控制器
+type testController struct {
+	mu     sync.Mutex
+	events []string
+	status string
+	answer string
+}
+
+func (c *testController) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+func (c *testController) Pause() error  { c.record("pause"); return nil }
+func (c *testController) Resume() error { c.record("resume"); return nil }
+
+func (c *testController) Finish(status, answer string) error {
+	c.record("finish")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status, c.answer = status, answer
+	return nil
+}
+
+// Events 返回记录的状态变化
+func (c *testController) Events() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.events...)
+}
+
+// waitPending 等待问题出现在待回答列表中
+func waitPending(t *testing.T, b *HumanBroker) HumanQuestion {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if pending := b.Pending(); len(pending) > 0 {
+			return pending[0]
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("question not pending")
+	return HumanQuestion{}
+}
+
+func TestHumanBrokerAnswer(t *testing.T) {
+	b := NewHumanBroker()
+	asked := make(chan HumanQuestion, 1)
+	b.Subscribe(func(q HumanQuestion) { asked <- q })
+
+	answer := make(chan string, 1)
+	go func() {
+		a, err := b.Ask(context.Background(), "继续吗？", time.Minute)
+		if err != nil {
+			a = "error: " + err.Error()
+		}
+		answer <- a
+	}()
+
+	q := <-asked
+	if q.Question != "继续吗？" || q.ID == "" || !q.Deadline.After(q.AskedAt) {
+		t.Fatalf("question = %+v", q)
+	}
+	done := b.Done(q.ID)
+	if pending := b.Pending(); len(pending) != 1 || pending[0].ID != q.ID {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if err := b.Answer(q.ID, "是"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-answer; got != "是" {
+		t.Fatalf("answer = %q", got)
+	}
+	<-done
+	// 已回答的问题不能再次回答，也不再等待
+	if err := b.Answer(q.ID, "否"); !errors.Is(err, ErrQuestionNotFound) {
+		t.Fatalf("second answer: %v", err)
+	}
+	if pending := b.Pending(); len(pending) != 0 {
+		t.Fatalf("pending after answer = %+v", pending)
+	}
+	select {
+	case <-b.Done(q.ID):
+	default:
+		t.Fatal("Done of an answered question is not closed")
+	}
+}
+
+func TestHumanBrokerWithdraw(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+		wantErr error
+	}{
+		{"timeout", 20 * time.Millisecond, false, ErrHumanTimeout},
+		{"context canceled", time.Minute, true, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewHumanBroker()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errc := make(chan error, 1)
+			go func() {
+				_, err := b.Ask(ctx, "还在吗？", tt.timeout)
+				errc <- err
+			}()
+
+			q := waitPending(t, b)
+			done := b.Done(q.ID)
+			if tt.cancel {
+				cancel()
+			}
+			if err := <-errc; !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			// 提问结束后输入端撤下提示，迟到的回答被拒绝
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("Done not closed after the question was withdrawn")
+			}
+			if err := b.Answer(q.ID, "迟到的回答"); !errors.Is(err, ErrQuestionNotFound) {
+				t.Fatalf("late answer: %v", err)
+			}
+			if pending := b.Pending(); len(pending) != 0 {
+				t.Fatalf("pending = %+v", pending)
+			}
+		})
+	}
+}
+
+func TestHumanBrokerPendingOrder(t *testing.T) {
+	b := NewHumanBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, q := range []string{"first", "second", "third"} {
+		go b.Ask(ctx, q, time.Minute)
+		for len(b.Pending()) == 0 || b.Pending()[len(b.Pending())-1].Question != q {
+			time.Sleep(time.Millisecond)
+		}
+		// 保证提问时间不同
+		time.Sleep(2 * time.Millisecond)
+	}
+	var got []string
+	for _, q := range b.Pending() {
+		got = append(got, q.Question)
+	}
+	if len(got) != 3 || got[0] != "first" || got[1] != "second" || got[2] != "third" {
+		t.Fatalf("pending order = %v", got)
+	}
+}
+
+func TestAskHumanTool(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		answer   bool
+		want     AskHumanResult
+		wantText string
+	}{
+		{
+			name:     "answered",
+			input:    map[string]interface{}{"question": " 用哪个分支？ "},
+			answer:   true,
+			want:     AskHumanResult{Question: "用哪个分支？", Answer: "main"},
+			wantText: "main",
+		},
+		{
+			name:     "timed out",
+			input:    map[string]interface{}{"question": "用哪个分支？", "timeout": 1},
+			want:     AskHumanResult{Question: "用哪个分支？", TimedOut: true},
+			wantText: "用户在限定时间内没有回答，请根据已有信息自行判断后继续",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewHumanBroker()
+			if tt.answer {
+				b.Subscribe(func(q HumanQuestion) { b.Answer(q.ID, "main") })
+			}
+			// 工具配置的等待上限短于参数中的 timeout
+			at := NewAskHumanTool(b, 30*time.Millisecond)
+			c := &testController{}
+			out, err := at.Run(WithAgentController(context.Background(), c), tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := out.(AskHumanResult)
+			if res != tt.want || res.String() != tt.wantText {
+				t.Fatalf("result = %+v (%q)", res, res.String())
+			}
+			// 等待期间暂停智能体，结束后恢复
+			if events := c.Events(); len(events) != 2 || events[0] != "pause" || events[1] != "resume" {
+				t.Fatalf("controller events = %v", events)
+			}
+		})
+	}
+
+	at := NewAskHumanTool(NewHumanBroker(), time.Minute)
+	if _, err := at.Run(context.Background(), map[string]interface{}{"question": "  "}); !errors.Is(err, ErrInvalidArgs) {
+		t.Fatalf("empty question: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := at.Run(ctx, "问题"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled context: %v", err)
+	}
+}
+
+func TestTerminateTool(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   interface{}
+		want    TerminateResult
+		wantErr error
+	}{
+		{"default status", map[string]interface{}{"answer": "done"}, TerminateResult{Status: TerminateSuccess, Answer: "done"}, nil},
+		{"failure", map[string]interface{}{"status": "failure", "final_answer": "blocked"}, TerminateResult{Status: TerminateFailure, Answer: "blocked"}, nil},
+		{"string input", "result", TerminateResult{Status: TerminateSuccess, Answer: "result"}, nil},
+		{"invalid status", map[string]interface{}{"status": "maybe"}, TerminateResult{}, ErrInvalidArgs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &testController{}
+			out, err := NewTerminateTool().Run(WithAgentController(context.Background(), c), tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if len(c.Events()) != 0 {
+					t.Fatal("invalid terminate finished the agent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.(TerminateResult) != tt.want {
+				t.Fatalf("result = %+v", out)
+			}
+			if c.status != tt.want.Status || c.answer != tt.want.Answer {
+				t.Fatalf("controller finished with %q, %q", c.status, c.answer)
+			}
+		})
+	}
+}
@@ -0,0 +1,570 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// 爬取的默认限制
+const (
+	defaultCrawlMaxDepth    = 2
+	defaultCrawlMaxPages    = 20
+	defaultCrawlMaxBytes    = 2 * 1024 * 1024
+	defaultCrawlMaxPageText = 5000
+	defaultCrawlDelay       = time.Second
+	defaultCrawlUserAgent   = "OpenManus-Go/1.0"
+	// robots.txt 读取的上限
+	maxRobotsBytes = 512 * 1024
+)
+
+// 爬取范围
+const (
+	CrawlScopeDomain = "domain" // 起始主机及其子域名
+	CrawlScopePrefix = "prefix" // 以指定前缀开头的地址
+)
+
+// CrawlConfig 爬取工具配置，调用参数不能超过其中的上限
+type CrawlConfig struct {
+	MaxDepth int
+	MaxPages int
+	// MaxBytes 一次爬取下载的响应体总字节数
+	MaxBytes int
+	// MaxPageText 每个页面返回的正文字节数
+	MaxPageText int
+	// Delay 同一主机两次请求的最小间隔，robots.txt 的 Crawl-delay 更大时使用后者
+	Delay     time.Duration
+	UserAgent string
+}
+
+var (
+	defaultCrawlConfig = CrawlConfig{
+		MaxDepth:    defaultCrawlMaxDepth,
+		MaxPages:    defaultCrawlMaxPages,
+		MaxBytes:    defaultCrawlMaxBytes,
+		MaxPageText: defaultCrawlMaxPageText,
+		Delay:       defaultCrawlDelay,
+		UserAgent:   defaultCrawlUserAgent,
+	}
+	defaultCrawlConfigMu sync.RWMutex
+)
+
+// SetDefaultCrawlConfig 设置爬取工具默认配置
+func SetDefaultCrawlConfig(config CrawlConfig) {
+	defaultCrawlConfigMu.Lock()
+	defer defaultCrawlConfigMu.Unlock()
+	defaultCrawlConfig = config
+}
+
+// DefaultCrawlConfig 返回爬取工具默认配置
+func DefaultCrawlConfig() CrawlConfig {
+	defaultCrawlConfigMu.RLock()
+	defer defaultCrawlConfigMu.RUnlock()
+	return defaultCrawlConfig
+}
+
+// CrawlTool 从起始页面出发，在同一域名或前缀内按广度优先跟随链接，返回每个页面的正文
+type CrawlTool struct {
+	name        string
+	description string
+	scraper     *WebScraperTool
+	config      CrawlConfig
+}
+
+// NewCrawlTool 创建爬取工具，scraper 为 nil 时使用 NewWebScraperTool
+func NewCrawlTool(scraper *WebScraperTool, config CrawlConfig) *CrawlTool {
+	def := CrawlConfig{
+		MaxPages:    defaultCrawlMaxPages,
+		MaxBytes:    defaultCrawlMaxBytes,
+		MaxPageText: defaultCrawlMaxPageText,
+		UserAgent:   defaultCrawlUserAgent,
+	}
+	if config.MaxDepth < 0 {
+		config.MaxDepth = 0
+	}
+	if config.MaxPages <= 0 {
+		config.MaxPages = def.MaxPages
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = def.MaxBytes
+	}
+	if config.MaxPageText <= 0 {
+		config.MaxPageText = def.MaxPageText
+	}
+	if config.Delay < 0 {
+		config.Delay = 0
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = def.UserAgent
+	}
+	if scraper == nil {
+		scraper = NewWebScraperTool()
+	}
+	return &CrawlTool{
+		name: "web_crawl",
+		description: fmt.Sprintf("爬取网站：从 url 出发跟随同一域名（scope=domain）或前缀（scope=prefix，prefix 默认为起始地址所在目录）内的链接，"+
+			"遵守 robots.txt，返回每个页面的标题和正文；参数：url、scope、prefix、max_depth（最大 %d）、max_pages（最大 %d）、max_bytes",
+			config.MaxDepth, config.MaxPages),
+		scraper: scraper,
+		config:  config,
+	}
+}
+
+func (t *CrawlTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *CrawlTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *CrawlTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// CrawlInput 爬取参数，为 0 的限制使用配置中的上限
+type CrawlInput struct {
+	URL      string `json:"url"`
+	Scope    string `json:"scope,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	MaxDepth int    `json:"max_depth,omitempty"`
+	MaxPages int    `json:"max_pages,omitempty"`
+	MaxBytes int    `json:"max_bytes,omitempty"`
+}
+
+// CrawlPage 一个已抓取的页面
+type CrawlPage struct {
+	URL    string `json:"url"`
+	Title  string `json:"title,omitempty"`
+	Depth  int    `json:"depth"`
+	Status int    `json:"status,omitempty"`
+	Text   string `json:"text,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// CrawlResult 爬取结果
+type CrawlResult struct {
+	Start string      `json:"start"`
+	Pages []CrawlPage `json:"pages"`
+	// Skipped 被 robots.txt 禁止抓取的地址
+	Skipped []string `json:"skipped,omitempty"`
+	// Bytes 下载的响应体总字节数
+	Bytes int `json:"bytes"`
+	// StopReason 结束原因：done、max_pages、max_bytes 或上下文取消的原因
+	StopReason string `json:"stop_reason"`
+}
+
+// String 按页面输出标题和正文
+func (r CrawlResult) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "爬取 %s：%d 个页面，%d 字节，结束原因 %s\n", r.Start, len(r.Pages), r.Bytes, r.StopReason)
+	for _, p := range r.Pages {
+		fmt.Fprintf(&sb, "\n## %s (depth %d)\n", p.URL, p.Depth)
+		if p.Title != "" {
+			fmt.Fprintf(&sb, "标题: %s\n", p.Title)
+		}
+		if p.Error != "" {
+			fmt.Fprintf(&sb, "错误: %s\n", p.Error)
+			continue
+		}
+		sb.WriteString(p.Text)
+		sb.WriteByte('\n')
+	}
+	if len(r.Skipped) > 0 {
+		fmt.Fprintf(&sb, "\nrobots.txt 禁止抓取: %s\n", strings.Join(r.Skipped, ", "))
+	}
+	return sb.String()
+}
+
+// crawlItem 等待抓取的地址
+type crawlItem struct {
+	url   string
+	depth int
+}
+
+// crawler 一次爬取的状态
+type crawler struct {
+	tool     *CrawlTool
+	start    *url.URL
+	scope    string
+	prefix   string
+	maxDepth int
+	maxPages int
+	maxBytes int
+
+	queue []crawlItem
+	// seen 已入队或已抓取的规范化地址
+	seen map[string]bool
+	// fetched 已返回页面的规范地址，用于按 canonical 去重
+	fetched   map[string]bool
+	robots    map[string]*robotsRules
+	lastFetch map[string]time.Time
+	result    CrawlResult
+}
+
+// Run 执行爬取；上下文取消时返回已抓取的页面
+func (t *CrawlTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in CrawlInput
+	if s, ok := input.(string); ok {
+		in.URL = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	c, err := t.newCrawler(in)
+	if err != nil {
+		return nil, err
+	}
+	c.run(ctx)
+	return c.result, nil
+}
+
+// newCrawler 校验参数并确定爬取范围
+func (t *CrawlTool) newCrawler(in CrawlInput) (*crawler, error) {
+	start, err := normalizeCrawlURL(in.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid url %q", ErrInvalidArgs, in.URL)
+	}
+	c := &crawler{
+		tool:      t,
+		start:     start,
+		scope:     strings.ToLower(in.Scope),
+		maxDepth:  clampCrawlLimit(in.MaxDepth, t.config.MaxDepth),
+		maxPages:  clampCrawlLimit(in.MaxPages, t.config.MaxPages),
+		maxBytes:  clampCrawlLimit(in.MaxBytes, t.config.MaxBytes),
+		seen:      make(map[string]bool),
+		fetched:   make(map[string]bool),
+		robots:    make(map[string]*robotsRules),
+		lastFetch: make(map[string]time.Time),
+		result:    CrawlResult{Start: start.String()},
+	}
+	switch c.scope {
+	case "", CrawlScopeDomain:
+		c.scope = CrawlScopeDomain
+	case CrawlScopePrefix:
+		c.prefix = in.Prefix
+		if c.prefix == "" {
+			// 默认为起始地址所在目录
+			dir := *start
+			dir.RawQuery = ""
+			dir.Path = dir.Path[:strings.LastIndex(dir.Path, "/")+1]
+			c.prefix = dir.String()
+		}
+		if !strings.HasPrefix(start.String(), c.prefix) {
+			return nil, fmt.Errorf("%w: url %s is outside prefix %s", ErrInvalidArgs, start, c.prefix)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported scope %q, use domain or prefix", ErrInvalidArgs, in.Scope)
+	}
+	return c, nil
+}
+
+// clampCrawlLimit 参数为 0 或超过上限时使用上限
+func clampCrawlLimit(v, limit int) int {
+	if v <= 0 || v > limit {
+		return limit
+	}
+	return v
+}
+
+func (c *crawler) run(ctx context.Context) {
+	c.enqueue(c.start.String(), 0)
+	c.result.StopReason = "done"
+	for len(c.queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			c.result.StopReason = err.Error()
+			return
+		}
+		if len(c.result.Pages) >= c.maxPages {
+			c.result.StopReason = "max_pages"
+			return
+		}
+		if c.result.Bytes >= c.maxBytes {
+			c.result.StopReason = "max_bytes"
+			return
+		}
+		item := c.queue[0]
+		c.queue = c.queue[1:]
+		c.visit(ctx, item)
+	}
+	if err := ctx.Err(); err != nil {
+		c.result.StopReason = err.Error()
+	}
+}
+
+// enqueue 把范围内且未见过的地址加入队列
+func (c *crawler) enqueue(raw string, depth int) {
+	u, err := normalizeCrawlURL(raw)
+	if err != nil || !c.inScope(u) {
+		return
+	}
+	key := u.String()
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.queue = append(c.queue, crawlItem{url: key, depth: depth})
+}
+
+// inScope 判断地址是否在爬取范围内
+func (c *crawler) inScope(u *url.URL) bool {
+	if c.scope == CrawlScopePrefix {
+		return strings.HasPrefix(u.String(), c.prefix)
+	}
+	host, root := u.Hostname(), c.start.Hostname()
+	return host == root || strings.HasSuffix(host, "."+root)
+}
+
+// visit 抓取一个页面，记录正文并把链接加入队列
+func (c *crawler) visit(ctx context.Context, item crawlItem) {
+	u, _ := url.Parse(item.url)
+	rules := c.robotsFor(ctx, u)
+	if !rules.allowed(u.RequestURI()) {
+		c.result.Skipped = append(c.result.Skipped, item.url)
+		return
+	}
+	if err := c.wait(ctx, u.Host, rules.delay); err != nil {
+		return
+	}
+
+	page := CrawlPage{URL: item.url, Depth: item.depth}
+	resp, err := c.fetch(ctx, item.url, c.maxBytes-c.result.Bytes)
+	if resp == nil {
+		if ctx.Err() != nil {
+			return
+		}
+		page.Error = err.Error()
+		c.result.Pages = append(c.result.Pages, page)
+		return
+	}
+	body, _ := resp.Body.(string)
+	c.result.Bytes += len(body)
+	page.Status = resp.StatusCode
+	if err != nil {
+		page.Error = err.Error()
+		c.result.Pages = append(c.result.Pages, page)
+		return
+	}
+
+	// 跟随重定向后以最终地址去重
+	if final, ok := resp.Metadata["url"].(string); ok && final != item.url {
+		fu, err := normalizeCrawlURL(final)
+		if err != nil || !c.inScope(fu) || c.fetched[fu.String()] {
+			return
+		}
+		page.URL = fu.String()
+		c.seen[page.URL] = true
+	}
+	base, _ := url.Parse(page.URL)
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Headers["Content-Type"])
+	var links []string
+	follow := true
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml" || (mediaType == "" && strings.Contains(strings.ToLower(body[:min(len(body), 512)]), "<html")):
+		info := extractCrawlLinks([]byte(body), base)
+		if info.canonical != "" {
+			if cu, err := normalizeCrawlURL(info.canonical); err == nil && cu.String() != page.URL {
+				if c.fetched[cu.String()] {
+					return
+				}
+				c.fetched[cu.String()] = true
+				c.seen[cu.String()] = true
+			}
+		}
+		follow = !info.nofollow
+		links = info.links
+		if info.noindex {
+			// 不返回正文，但仍可跟随链接
+			c.fetched[page.URL] = true
+			c.follow(links, item.depth, follow)
+			return
+		}
+		doc, err := parseHTML([]byte(body))
+		if err != nil {
+			page.Error = err.Error()
+			break
+		}
+		page.Title, _ = doc.metadata["title"].(string)
+		page.Text = doc.sections[0].text
+	case strings.HasPrefix(mediaType, "text/"):
+		page.Text = tidyText(body)
+	default:
+		page.Error = fmt.Sprintf("unsupported content type %q", resp.Headers["Content-Type"])
+	}
+	if len(page.Text) > c.tool.config.MaxPageText {
+		page.Text = truncateOutput(page.Text, c.tool.config.MaxPageText)
+	}
+	c.fetched[page.URL] = true
+	c.result.Pages = append(c.result.Pages, page)
+	c.follow(links, item.depth, follow)
+}
+
+// follow 未达到最大深度时把链接加入队列
+func (c *crawler) follow(links []string, depth int, follow bool) {
+	if !follow || depth >= c.maxDepth {
+		return
+	}
+	for _, l := range links {
+		c.enqueue(l, depth+1)
+	}
+}
+
+// fetch 通过 WebScraperTool 发送 GET 请求，读取不超过 maxBytes 字节
+func (c *crawler) fetch(ctx context.Context, target string, maxBytes int) (*WebResponse, error) {
+	out, err := c.tool.scraper.Run(ctx, map[string]interface{}{
+		"method":  MethodGet,
+		"url":     target,
+		"headers": map[string]interface{}{"User-Agent": c.tool.config.UserAgent},
+		"options": map[string]interface{}{"max_bytes": maxBytes},
+	})
+	resp, ok := out.(WebResponse)
+	if !ok {
+		return nil, err
+	}
+	return &resp, err
+}
+
+// robotsFor 获取并缓存主机的 robots.txt：不存在（4xx）时允许全部，无法获取（网络错误或 5xx）时不抓取该主机
+func (c *crawler) robotsFor(ctx context.Context, u *url.URL) *robotsRules {
+	if r, ok := c.robots[u.Host]; ok {
+		return r
+	}
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+	resp, _ := c.fetch(ctx, robotsURL, maxRobotsBytes)
+	c.lastFetch[u.Host] = time.Now()
+	var r *robotsRules
+	switch {
+	case resp == nil || resp.StatusCode >= 500:
+		r = &robotsRules{disallowAll: true}
+	case resp.StatusCode >= 400:
+		r = &robotsRules{}
+	default:
+		body, _ := resp.Body.(string)
+		r = parseRobots(body, c.tool.config.UserAgent)
+	}
+	if r.delay < c.tool.config.Delay {
+		r.delay = c.tool.config.Delay
+	}
+	// 上下文取消导致的失败不缓存
+	if ctx.Err() == nil {
+		c.robots[u.Host] = r
+	}
+	return r
+}
+
+// wait 等待到与同一主机上次请求的间隔不小于 delay
+func (c *crawler) wait(ctx context.Context, host string, delay time.Duration) error {
+	if last, ok := c.lastFetch[host]; ok {
+		if d := time.Until(last.Add(delay)); d > 0 {
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	c.lastFetch[host] = time.Now()
+	return nil
+}
+
+// normalizeCrawlURL 规范化地址：去掉片段和默认端口，主机名小写，空路径改为 "/"
+func normalizeCrawlURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("unsupported url %q", raw)
+	}
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.User = nil
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" {
+		u.Path, u.RawPath = "/", ""
+	}
+	return u, nil
+}
+
+// crawlLinks 页面中与爬取相关的信息
+type crawlLinks struct {
+	links     []string
+	canonical string
+	noindex   bool
+	nofollow  bool
+}
+
+// extractCrawlLinks 提取页面链接（按 <base> 解析为绝对地址）、canonical 地址和 meta robots 指令
+func extractCrawlLinks(data []byte, pageURL *url.URL) crawlLinks {
+	var info crawlLinks
+	root, err := html.Parse(strings.NewReader(decodeDocumentText(data)))
+	if err != nil {
+		return info
+	}
+	base := pageURL
+	var hrefs []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "base":
+				if href := htmlAttr(n, "href"); href != "" {
+					if b, err := pageURL.Parse(href); err == nil {
+						base = b
+					}
+				}
+			case "a", "area":
+				rel := strings.ToLower(htmlAttr(n, "rel"))
+				if href := htmlAttr(n, "href"); href != "" && !strings.Contains(rel, "nofollow") {
+					hrefs = append(hrefs, href)
+				}
+			case "link":
+				if strings.EqualFold(htmlAttr(n, "rel"), "canonical") {
+					info.canonical = htmlAttr(n, "href")
+				}
+			case "meta":
+				if strings.EqualFold(htmlAttr(n, "name"), "robots") {
+					content := strings.ToLower(htmlAttr(n, "content"))
+					info.noindex = info.noindex || strings.Contains(content, "noindex") || strings.Contains(content, "none")
+					info.nofollow = info.nofollow || strings.Contains(content, "nofollow") || strings.Contains(content, "none")
+				}
+			}
+		}
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			walk(ch)
+		}
+	}
+	walk(root)
+	// <base> 可能出现在链接之后，统一在遍历完成后解析
+	for _, h := range hrefs {
+		if u, err := base.Parse(h); err == nil {
+			info.links = append(info.links, u.String())
+		}
+	}
+	if info.canonical != "" {
+		if u, err := base.Parse(info.canonical); err == nil {
+			info.canonical = u.String()
+		}
+	}
+	return info
+}
@@ -0,0 +1,267 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSitePages 测试站点的页面及其链接
+var testSitePages = map[string][]string{
+	"/":               {"/docs/a.html", "/docs/b.html", "/private/x.html", "/other.html", "http://example.invalid/"},
+	"/docs/a.html":    {"/docs/c.html"},
+	"/docs/b.html":    {"/"},
+	"/docs/c.html":    {"/docs/d.html"},
+	"/docs/d.html":    nil,
+	"/private/x.html": nil,
+	"/other.html":     nil,
+}
+
+// testSite 本地测试站点，记录收到的请求路径
+type testSite struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+// newTestSite 启动测试站点，robots.txt 返回 robotsStatus 和 robots
+func newTestSite(t *testing.T, robotsStatus int, robots string) *testSite {
+	t.Helper()
+	s := &testSite{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.Path)
+		s.mu.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(robotsStatus)
+			fmt.Fprint(w, robots)
+			return
+		}
+		links, ok := testSitePages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><head><title>%s</title></head><body><p>page %s</p>", r.URL.Path, r.URL.Path)
+		for _, l := range links {
+			fmt.Fprintf(w, `<a href="%s">link</a>`, l)
+		}
+		fmt.Fprint(w, "</body></html>")
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Requests 返回收到的请求路径
+func (s *testSite) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// newTestCrawlTool 创建允许访问本机地址的爬取工具
+func newTestCrawlTool(config CrawlConfig) *CrawlTool {
+	scraper := &WebScraperTool{client: NewHTTPClient(HostPolicy{}, 5*time.Second)}
+	return NewCrawlTool(scraper, config)
+}
+
+// crawlPaths 去掉页面地址中的站点前缀
+func crawlPaths(site string, urls []string) []string {
+	var paths []string
+	for _, u := range urls {
+		paths = append(paths, strings.TrimPrefix(u, site))
+	}
+	return paths
+}
+
+func TestCrawl(t *testing.T) {
+	const robots = "User-agent: *\nDisallow: /private/\n"
+	tests := []struct {
+		name         string
+		robotsStatus int
+		input        func(site string) map[string]interface{}
+		pages        []string
+		skipped      []string
+		stop         string
+	}{
+		{
+			name:         "domain depth 1",
+			robotsStatus: http.StatusOK,
+			input:        func(site string) map[string]interface{} { return map[string]interface{}{"url": site, "max_depth": 1} },
+			pages:        []string{"/", "/docs/a.html", "/docs/b.html", "/other.html"},
+			skipped:      []string{"/private/x.html"},
+			stop:         "done",
+		},
+		{
+			name:         "domain depth 2",
+			robotsStatus: http.StatusOK,
+			input:        func(site string) map[string]interface{} { return map[string]interface{}{"url": site, "max_depth": 2} },
+			pages:        []string{"/", "/docs/a.html", "/docs/b.html", "/other.html", "/docs/c.html"},
+			skipped:      []string{"/private/x.html"},
+			stop:         "done",
+		},
+		{
+			name:         "max depth above limit",
+			robotsStatus: http.StatusOK,
+			input: func(site string) map[string]interface{} {
+				return map[string]interface{}{"url": site + "/docs/a.html", "max_depth": 5}
+			},
+			pages: []string{"/docs/a.html", "/docs/c.html", "/docs/d.html"},
+			stop:  "done",
+		},
+		{
+			name:         "max pages",
+			robotsStatus: http.StatusOK,
+			input:        func(site string) map[string]interface{} { return map[string]interface{}{"url": site, "max_pages": 2} },
+			pages:        []string{"/", "/docs/a.html"},
+			stop:         "max_pages",
+		},
+		{
+			name:         "default prefix is start directory",
+			robotsStatus: http.StatusOK,
+			input: func(site string) map[string]interface{} {
+				return map[string]interface{}{"url": site + "/docs/b.html", "scope": CrawlScopePrefix}
+			},
+			pages: []string{"/docs/b.html"},
+			stop:  "done",
+		},
+		{
+			name:         "explicit prefix",
+			robotsStatus: http.StatusOK,
+			input: func(site string) map[string]interface{} {
+				return map[string]interface{}{"url": site + "/docs/a.html", "scope": CrawlScopePrefix, "prefix": site + "/docs/"}
+			},
+			pages: []string{"/docs/a.html", "/docs/c.html", "/docs/d.html"},
+			stop:  "done",
+		},
+		{
+			name:         "missing robots allows all",
+			robotsStatus: http.StatusNotFound,
+			input:        func(site string) map[string]interface{} { return map[string]interface{}{"url": site, "max_depth": 1} },
+			pages:        []string{"/", "/docs/a.html", "/docs/b.html", "/private/x.html", "/other.html"},
+			stop:         "done",
+		},
+		{
+			name:         "robots server error disallows host",
+			robotsStatus: http.StatusServiceUnavailable,
+			input:        func(site string) map[string]interface{} { return map[string]interface{}{"url": site} },
+			skipped:      []string{"/"},
+			stop:         "done",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := newTestSite(t, tt.robotsStatus, robots)
+			// 参数超过配置的上限时使用上限
+			ct := newTestCrawlTool(CrawlConfig{MaxDepth: 2, MaxPages: 10})
+			out, err := ct.Run(context.Background(), tt.input(site.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := out.(CrawlResult)
+			var urls []string
+			for _, p := range res.Pages {
+				if p.Error != "" {
+					t.Fatalf("page %s: %s", p.URL, p.Error)
+				}
+				urls = append(urls, p.URL)
+			}
+			if got := crawlPaths(site.URL, urls); !reflect.DeepEqual(got, tt.pages) {
+				t.Fatalf("pages = %v, want %v", got, tt.pages)
+			}
+			if got := crawlPaths(site.URL, res.Skipped); !reflect.DeepEqual(got, tt.skipped) {
+				t.Fatalf("skipped = %v, want %v", got, tt.skipped)
+			}
+			if res.StopReason != tt.stop {
+				t.Fatalf("stop reason = %q, want %q", res.StopReason, tt.stop)
+			}
+			// 被 robots.txt 禁止的页面不会被请求
+			for _, p := range site.Requests() {
+				for _, s := range tt.skipped {
+					if p == s {
+						t.Fatalf("skipped page %s was requested", p)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCrawlPageContent(t *testing.T) {
+	site := newTestSite(t, http.StatusNotFound, "")
+	out, err := newTestCrawlTool(CrawlConfig{}).Run(context.Background(), site.URL+"/other.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := out.(CrawlResult)
+	if len(res.Pages) != 1 {
+		t.Fatalf("pages = %+v", res.Pages)
+	}
+	p := res.Pages[0]
+	if p.Title != "/other.html" || !strings.Contains(p.Text, "page /other.html") || p.Status != http.StatusOK || p.Depth != 0 {
+		t.Fatalf("page = %+v", p)
+	}
+	if res.Bytes == 0 {
+		t.Fatal("bytes not counted")
+	}
+}
+
+func TestCrawlInvalidInput(t *testing.T) {
+	ct := newTestCrawlTool(CrawlConfig{})
+	tests := []struct {
+		name  string
+		input map[string]interface{}
+	}{
+		{"missing url", map[string]interface{}{}},
+		{"unsupported scheme", map[string]interface{}{"url": "ftp://example.com/"}},
+		{"unknown scope", map[string]interface{}{"url": "http://example.com/", "scope": "site"}},
+		{"start outside prefix", map[string]interface{}{"url": "http://example.com/a/", "scope": CrawlScopePrefix, "prefix": "http://example.com/b/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ct.Run(context.Background(), tt.input); !errors.Is(err, ErrInvalidArgs) {
+				t.Fatalf("got %v, want ErrInvalidArgs", err)
+			}
+		})
+	}
+}
+
+func TestCrawlDelay(t *testing.T) {
+	const delay = 150 * time.Millisecond
+	site := newTestSite(t, http.StatusOK, "User-agent: *\nCrawl-delay: 0.15\n")
+	// 配置的间隔更小，使用 robots.txt 的 Crawl-delay
+	ct := newTestCrawlTool(CrawlConfig{MaxDepth: 1, Delay: 10 * time.Millisecond})
+
+	start := time.Now()
+	out, err := ct.Run(context.Background(), map[string]interface{}{"url": site.URL, "max_pages": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(out.(CrawlResult).Pages); n != 3 {
+		t.Fatalf("pages = %d", n)
+	}
+	// robots.txt 之后的三次请求各等待一个间隔
+	if elapsed := time.Since(start); elapsed < 3*delay {
+		t.Fatalf("elapsed %v, want at least %v", elapsed, 3*delay)
+	}
+
+	// 取消上下文时返回已抓取的页面
+	ctx, cancel := context.WithTimeout(context.Background(), delay/2)
+	defer cancel()
+	out, err = newTestCrawlTool(CrawlConfig{MaxDepth: 1, Delay: time.Hour}).Run(ctx, map[string]interface{}{"url": site.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := out.(CrawlResult); len(res.Pages) != 0 || res.StopReason != context.DeadlineExceeded.Error() {
+		t.Fatalf("cancelled crawl = %+v", res)
+	}
+}
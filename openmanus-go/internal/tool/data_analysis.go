@@ -22,11 +22,33 @@ func (d *DataAnalysisTool) Name() string {
 	return "DataAnalysisTool"
 }
 
+// Description 返回工具描述
+func (d *DataAnalysisTool) Description() string {
+	return "对 CSV/JSON 表格数据做统计分析：analyze 描述统计、correlate 相关矩阵、regression 线性回归、cluster k-means 聚类、forecast 移动平均/指数平滑预测、visualize 绘图；data 为内联数据，path 为工作区内的数据文件；charts 指定 line、bar、scatter、pie、histogram 图表，生成的 SVG/PNG 和 HTML 报告保存到工作区并返回路径"
+}
+
+// Execute 执行工具功能
+func (d *DataAnalysisTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return d.Run(ctx, args)
+}
+
 // DataAnalysisInput 数据分析工具输入
 type DataAnalysisInput struct {
-	Operation string                 `json:"operation"`
-	Data      interface{}            `json:"data"`
-	Options   map[string]interface{} `json:"options,omitempty"`
+	Operation string      `json:"operation"`
+	Data      interface{} `json:"data"`
+	// Path 工作区内的 CSV/TSV/JSON/JSONL 文件，设置后忽略 Data
+	Path string `json:"path,omitempty"`
+	// Format 数据格式，为空时根据扩展名或内容判断
+	Format  string                 `json:"format,omitempty"`
+	Options map[string]interface{} `json:"options,omitempty"`
+	// Charts 需要生成的图表，任一分析操作都可附带；visualize 未指定时绘制全部数值列的折线图
+	Charts []ChartSpec `json:"charts,omitempty"`
+	// OutputDir 图表和报告的保存目录（相对工作区），默认 charts
+	OutputDir string `json:"output_dir,omitempty"`
+	// Name 图表和报告的文件名前缀，默认由操作名和时间生成
+	Name string `json:"name,omitempty"`
+	// Title HTML 报告标题
+	Title string `json:"title,omitempty"`
 }
 
 // DataAnalysisOutput 数据分析工具输出
@@ -36,6 +58,15 @@ type DataAnalysisOutput struct {
 	Visualization map[string]interface{} `json:"visualization,omitempty"`
 }
 
+// String 以缩进 JSON 输出，便于智能体阅读
+func (o DataAnalysisOutput) String() string {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", o.Result)
+	}
+	return string(data)
+}
+
 func (d *DataAnalysisTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
 	// 解析输入
 	var daInput DataAnalysisInput
@@ -63,6 +94,18 @@ func (d *DataAnalysisTool) Run(ctx context.Context, input interface{}) (interfac
 		return nil, fmt.Errorf("unsupported input type: %T", input)
 	}
 
+	switch daInput.Operation {
+	case "", "basic_analysis", "describe":
+		daInput.Operation = OpAnalyze
+	}
+	switch daInput.Operation {
+	case OpAnalyze, OpCorrelate, OpRegression, OpCluster, OpForecast, OpVisualize:
+		return d.runNative(daInput)
+	}
+	if d.client == nil {
+		return nil, fmt.Errorf("%w: %s requires the python analysis service", ErrInvalidOperation, daInput.Operation)
+	}
+
 	// 调用 Python 服务
 	result, err := d.client.Call(ctx, "analyze", daInput)
 	if err != nil {
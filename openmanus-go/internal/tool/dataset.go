@@ -0,0 +1,388 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 数据文件大小上限
+const maxDatasetSize = 100 * 1024 * 1024
+
+// dataset 表格数据，单元格保留原始值（字符串、数字、布尔或 nil）
+type dataset struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// loadDataset 从工作区文件或内联数据构造数据集，返回数据来源描述
+func loadDataset(ws *Workspace, path, format string, data interface{}) (*dataset, string, error) {
+	if path == "" {
+		ds, err := parseDatasetValue(data, format)
+		return ds, "inline", err
+	}
+	ws, err := workspaceOrDefault(ws)
+	if err != nil {
+		return nil, "", err
+	}
+	full, err := ws.Resolve(path)
+	if err != nil {
+		return nil, "", err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.Size() > maxDatasetSize {
+		return nil, "", fmt.Errorf("file too large to analyze: %d bytes", info.Size())
+	}
+	content, err := os.ReadFile(full)
+	if err != nil {
+		return nil, "", err
+	}
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(full)), ".")
+	}
+	ds, err := parseDatasetText(string(content), format)
+	return ds, ws.Rel(full), err
+}
+
+// parseDatasetValue 解析内联数据：CSV/JSON 文本、记录数组、二维数组、数值数组或按列组织的对象
+func parseDatasetValue(data interface{}, format string) (*dataset, error) {
+	switch v := data.(type) {
+	case nil:
+		return nil, fmt.Errorf("%w: data or path is required", ErrInvalidArgs)
+	case string:
+		return parseDatasetText(v, format)
+	case []interface{}:
+		return datasetFromSlice(v)
+	case map[string]interface{}:
+		if ds, ok := datasetFromColumns(v); ok {
+			return ds, nil
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data: %v", err)
+		}
+		return parseDatasetText(string(raw), "json")
+	default:
+		return nil, fmt.Errorf("%w: unsupported data type %T", ErrInvalidArgs, data)
+	}
+}
+
+// parseDatasetText 解析 CSV、TSV、JSON 或 JSON Lines 文本，format 为空时根据内容判断
+func parseDatasetText(text, format string) (*dataset, error) {
+	text = strings.TrimPrefix(text, "\ufeff")
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return nil, fmt.Errorf("%w: data is empty", ErrInvalidArgs)
+	}
+	format = strings.ToLower(format)
+	if format == "" {
+		switch {
+		case trimmed[0] == '[' || trimmed[0] == '{':
+			format = "json"
+		case strings.Contains(firstLine(trimmed), "\t") && !strings.Contains(firstLine(trimmed), ","):
+			format = "tsv"
+		default:
+			format = "csv"
+		}
+	}
+
+	var columns []string
+	var rows [][]interface{}
+	switch format {
+	case "csv", "txt":
+		var err error
+		if columns, rows, err = parseCSVRecords(strings.NewReader(text), ',', true); err != nil {
+			return nil, err
+		}
+	case "tsv":
+		var err error
+		if columns, rows, err = parseCSVRecords(strings.NewReader(text), '\t', true); err != nil {
+			return nil, err
+		}
+	case "json", "jsonl", "ndjson":
+		records, err := parseJSONRecords([]byte(trimmed))
+		if err != nil {
+			// 不是对象数组时尝试按二维数组或数值数组解析
+			var items []interface{}
+			if json.Unmarshal([]byte(trimmed), &items) != nil {
+				return nil, err
+			}
+			return datasetFromSlice(items)
+		}
+		columns, rows = jsonRecordRows(records)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q, use csv, tsv, json or jsonl", ErrInvalidArgs, format)
+	}
+	if len(columns) == 0 || len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no rows found in data", ErrInvalidArgs)
+	}
+	return &dataset{columns: columns, rows: rows}, nil
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// datasetFromSlice 由记录数组、二维数组或标量数组构造数据集
+func datasetFromSlice(items []interface{}) (*dataset, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: no rows found in data", ErrInvalidArgs)
+	}
+	switch items[0].(type) {
+	case map[string]interface{}:
+		raw, err := json.Marshal(items)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data: %v", err)
+		}
+		records, err := parseJSONRecords(raw)
+		if err != nil {
+			return nil, err
+		}
+		columns, rows := jsonRecordRows(records)
+		return &dataset{columns: columns, rows: rows}, nil
+	case []interface{}:
+		var rows [][]interface{}
+		width := 0
+		for i, item := range items {
+			row, ok := item.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: row %d is not an array", ErrInvalidArgs, i)
+			}
+			if len(row) > width {
+				width = len(row)
+			}
+			rows = append(rows, row)
+		}
+		// 首行全部是非数值字符串时作为表头
+		var columns []string
+		if len(rows) > 1 && isHeaderRow(rows[0]) {
+			for _, v := range rows[0] {
+				columns = append(columns, v.(string))
+			}
+			rows = rows[1:]
+		}
+		for i := len(columns); i < width; i++ {
+			columns = append(columns, fmt.Sprintf("column%d", i+1))
+		}
+		return &dataset{columns: columns, rows: rows}, nil
+	default:
+		rows := make([][]interface{}, len(items))
+		for i, v := range items {
+			rows[i] = []interface{}{v}
+		}
+		return &dataset{columns: []string{"value"}, rows: rows}, nil
+	}
+}
+
+func isHeaderRow(row []interface{}) bool {
+	for _, v := range row {
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return false
+		}
+		if _, missing, numeric := cellFloat(s); missing || numeric {
+			return false
+		}
+	}
+	return len(row) > 0
+}
+
+// datasetFromColumns 由 {"列名": [值...]} 形式的对象构造数据集，各列长度必须一致
+func datasetFromColumns(m map[string]interface{}) (*dataset, bool) {
+	if len(m) == 0 {
+		return nil, false
+	}
+	columns := make([]string, 0, len(m))
+	length := -1
+	for name, v := range m {
+		values, ok := v.([]interface{})
+		if !ok || (length >= 0 && len(values) != length) {
+			return nil, false
+		}
+		length = len(values)
+		columns = append(columns, name)
+	}
+	if length == 0 {
+		return nil, false
+	}
+	sort.Strings(columns)
+	rows := make([][]interface{}, length)
+	for i := range rows {
+		row := make([]interface{}, len(columns))
+		for j, name := range columns {
+			row[j] = m[name].([]interface{})[i]
+		}
+		rows[i] = row
+	}
+	return &dataset{columns: columns, rows: rows}, true
+}
+
+// cellFloat 将单元格转换为数值；空值和 NA 等标记视为缺失
+func cellFloat(v interface{}) (f float64, missing bool, ok bool) {
+	switch x := v.(type) {
+	case nil:
+		return 0, true, false
+	case float64:
+		return x, math.IsNaN(x), !math.IsNaN(x)
+	case float32:
+		return float64(x), false, true
+	case int:
+		return float64(x), false, true
+	case int64:
+		return float64(x), false, true
+	case json.Number:
+		f, err := x.Float64()
+		return f, false, err == nil
+	case string:
+		s := strings.TrimSpace(x)
+		switch strings.ToLower(s) {
+		case "", "na", "n/a", "nan", "null", "none", "-":
+			return 0, true, false
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return 0, false, false
+		}
+		return f, false, true
+	default:
+		return 0, false, false
+	}
+}
+
+// cellString 单元格的文本形式，缺失值返回空字符串
+func cellString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(x)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case map[string]interface{}, []interface{}:
+		raw, _ := json.Marshal(x)
+		return string(raw)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func (d *dataset) cell(row, col int) interface{} {
+	if col < len(d.rows[row]) {
+		return d.rows[row][col]
+	}
+	return nil
+}
+
+// column 返回列下标
+func (d *dataset) column(name string) (int, error) {
+	for i, c := range d.columns {
+		if c == name {
+			return i, nil
+		}
+	}
+	// 列名大小写不敏感的兜底匹配
+	for i, c := range d.columns {
+		if strings.EqualFold(c, name) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: column %q not found, available columns: %s", ErrInvalidArgs, name, strings.Join(d.columns, ", "))
+}
+
+// isNumeric 列中至少有一个数值且所有非缺失值均为数值
+func (d *dataset) isNumeric(col int) bool {
+	found := false
+	for i := range d.rows {
+		_, missing, ok := cellFloat(d.cell(i, col))
+		if missing {
+			continue
+		}
+		if !ok {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// floats 返回列的数值，缺失值为 NaN
+func (d *dataset) floats(col int) []float64 {
+	values := make([]float64, len(d.rows))
+	for i := range d.rows {
+		f, _, ok := cellFloat(d.cell(i, col))
+		if !ok {
+			f = math.NaN()
+		}
+		values[i] = f
+	}
+	return values
+}
+
+// numericColumns 校验并返回数值列下标，names 为空时返回全部数值列
+func (d *dataset) numericColumns(names []string) ([]int, error) {
+	var cols []int
+	if len(names) == 0 {
+		for i := range d.columns {
+			if d.isNumeric(i) {
+				cols = append(cols, i)
+			}
+		}
+		return cols, nil
+	}
+	for _, name := range names {
+		i, err := d.column(name)
+		if err != nil {
+			return nil, err
+		}
+		if !d.isNumeric(i) {
+			return nil, fmt.Errorf("%w: column %q is not numeric", ErrInvalidArgs, d.columns[i])
+		}
+		cols = append(cols, i)
+	}
+	return cols, nil
+}
+
+// matrix 返回所选列均不缺失的行下标及对应数值矩阵
+func (d *dataset) matrix(cols []int) ([]int, [][]float64) {
+	series := make([][]float64, len(cols))
+	for j, c := range cols {
+		series[j] = d.floats(c)
+	}
+	var index []int
+	var m [][]float64
+	for i := range d.rows {
+		row := make([]float64, len(cols))
+		complete := true
+		for j := range cols {
+			row[j] = series[j][i]
+			if math.IsNaN(row[j]) {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			index = append(index, i)
+			m = append(m, row)
+		}
+	}
+	return index, m
+}
+
+func (d *dataset) columnNames(cols []int) []string {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = d.columns[c]
+	}
+	return names
+}
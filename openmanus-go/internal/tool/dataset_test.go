@@ -0,0 +1,90 @@
+package tool
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseDataset(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    interface{}
+		format  string
+		columns []string
+		x       []float64
+	}{
+		{"csv", "x,y\n1,2\n3,4\n", "", []string{"x", "y"}, []float64{1, 3}},
+		{"csv with bom", "\ufeffx,y\n1,2\n3,4\n", "csv", []string{"x", "y"}, []float64{1, 3}},
+		{"tsv detected", "x\ty\n1\t2\n3\t4\n", "", []string{"x", "y"}, []float64{1, 3}},
+		{"json records", `[{"x": 1, "y": 2}, {"x": 3, "y": 4}]`, "", []string{"x", "y"}, []float64{1, 3}},
+		{"json wrapped", `{"data": [{"x": 1, "y": 2}, {"x": 3, "y": 4}]}`, "json", []string{"x", "y"}, []float64{1, 3}},
+		{"json lines", "{\"x\": 1, \"y\": 2}\n{\"x\": 3, \"y\": 4}\n", "jsonl", []string{"x", "y"}, []float64{1, 3}},
+		{"json matrix", `[["x", "y"], [1, 2], [3, 4]]`, "json", []string{"x", "y"}, []float64{1, 3}},
+		{"records value", []interface{}{
+			map[string]interface{}{"x": 1.0, "y": 2.0},
+			map[string]interface{}{"x": 3.0, "y": 4.0},
+		}, "", []string{"x", "y"}, []float64{1, 3}},
+		{"matrix without header", []interface{}{[]interface{}{1.0, 2.0}, []interface{}{3.0, 4.0}}, "", []string{"column1", "column2"}, []float64{1, 3}},
+		{"scalar array", []interface{}{1.0, 3.0}, "", []string{"value"}, []float64{1, 3}},
+		{"columns object", map[string]interface{}{"y": []interface{}{2.0, 4.0}, "x": []interface{}{1.0, 3.0}}, "", []string{"x", "y"}, []float64{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, err := parseDatasetValue(tt.data, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ds.columns, tt.columns) {
+				t.Fatalf("columns = %v, want %v", ds.columns, tt.columns)
+			}
+			if got := ds.floats(0); !reflect.DeepEqual(got, tt.x) {
+				t.Fatalf("first column = %v, want %v", got, tt.x)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		name   string
+		data   interface{}
+		format string
+	}{
+		{"nil", nil, ""},
+		{"empty", "  \n", ""},
+		{"header only", "x,y\n", "csv"},
+		{"unknown format", "x\n1\n", "parquet"},
+		{"row not an array", []interface{}{[]interface{}{1.0}, 2.0}, ""},
+		{"unsupported type", 42, ""},
+	} {
+		t.Run("invalid "+tt.name, func(t *testing.T) {
+			if _, err := parseDatasetValue(tt.data, tt.format); !errors.Is(err, ErrInvalidArgs) {
+				t.Fatalf("got %v, want ErrInvalidArgs", err)
+			}
+		})
+	}
+}
+
+func TestDatasetColumns(t *testing.T) {
+	ds, err := parseDatasetText("id,score,name\n1,2.5,a\n2,NA,b\n3,,c\n4,n/a,\n", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 缺失值不影响数值列判断
+	if !ds.isNumeric(0) || !ds.isNumeric(1) || ds.isNumeric(2) {
+		t.Fatal("numeric detection mismatch")
+	}
+	cols, err := ds.numericColumns(nil)
+	if err != nil || !reflect.DeepEqual(cols, []int{0, 1}) {
+		t.Fatalf("numeric columns = %v, %v", cols, err)
+	}
+	if _, err := ds.numericColumns([]string{"name"}); !errors.Is(err, ErrInvalidArgs) {
+		t.Fatalf("non-numeric column: %v", err)
+	}
+	if i, err := ds.column("SCORE"); err != nil || i != 1 {
+		t.Fatalf("case-insensitive column = %d, %v", i, err)
+	}
+	index, m := ds.matrix([]int{0, 1})
+	if !reflect.DeepEqual(index, []int{0}) || !reflect.DeepEqual(m, [][]float64{{1, 2.5}}) {
+		t.Fatalf("matrix = %v, %v", index, m)
+	}
+}
@@ -0,0 +1,220 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 统一 diff 默认的上下文行数
+const defaultDiffContext = 3
+
+// diffEdit 单行编辑操作，kind 为 ' '、'-' 或 '+'
+type diffEdit struct {
+	kind byte
+	line string
+}
+
+// splitLines 按行切分文本，每行保留换行符，最后一行可能没有换行符
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines 计算两组行之间的最短编辑序列（Myers 算法）
+func diffLines(a, b []string) []diffEdit {
+	// 先去掉公共前缀和后缀，缩小搜索范围
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]diffEdit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, diffEdit{' ', line})
+	}
+	edits = append(edits, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, diffEdit{' ', line})
+	}
+	return edits
+}
+
+// myersDiff Myers O(ND) 差分，trace 只保存每轮实际用到的对角线
+func myersDiff(a, b []string) []diffEdit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// 从终点回溯得到逆序的编辑序列
+	var reversed []diffEdit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		snap := trace[d]
+		at := func(k int) int { return snap[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, diffEdit{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, diffEdit{'+', b[y]})
+		} else {
+			x--
+			reversed = append(reversed, diffEdit{'-', a[x]})
+		}
+	}
+
+	edits := make([]diffEdit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+// unifiedDiff 生成统一 diff 文本，返回新增和删除的行数；内容相同时返回空字符串
+func unifiedDiff(oldName, newName, oldText, newText string, context int) (string, int, int) {
+	if context < 0 {
+		context = defaultDiffContext
+	}
+	edits := diffLines(splitLines(oldText), splitLines(newText))
+
+	added, deleted := 0, 0
+	var changes []int
+	for i, e := range edits {
+		switch e.kind {
+		case '+':
+			added++
+			changes = append(changes, i)
+		case '-':
+			deleted++
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return "", 0, 0
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	// 相邻变更之间的公共行不超过 2*context 时合并为一个 hunk
+	for i := 0; i < len(changes); {
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context+1 {
+			j++
+		}
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[j] + context + 1
+		if end > len(edits) {
+			end = len(edits)
+		}
+		writeHunk(&sb, edits, start, end)
+		i = j + 1
+	}
+	return sb.String(), added, deleted
+}
+
+// writeHunk 输出 edits[start:end] 对应的 hunk
+func writeHunk(sb *strings.Builder, edits []diffEdit, start, end int) {
+	oldLine, newLine := 1, 1
+	for _, e := range edits[:start] {
+		if e.kind != '+' {
+			oldLine++
+		}
+		if e.kind != '-' {
+			newLine++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, e := range edits[start:end] {
+		if e.kind != '+' {
+			oldCount++
+		}
+		if e.kind != '-' {
+			newCount++
+		}
+	}
+	// 空范围的起始行号指向其前一行
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+	for _, e := range edits[start:end] {
+		sb.WriteByte(e.kind)
+		sb.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,514 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 文档读取的默认限制
+const (
+	maxDocumentSize       = 100 * 1024 * 1024
+	maxDocumentChars      = 5 * 1024 * 1024
+	defaultChunkSize      = 4000
+	defaultChunkOverlap   = 200
+	maxChunksPerCall      = 20
+	documentCacheCapacity = 8
+)
+
+// DocumentTool 文档读取工具，提取 PDF、DOCX、XLSX/CSV、HTML 和 Markdown 的文本
+type DocumentTool struct {
+	name        string
+	description string
+	workspace   *Workspace
+
+	mu    sync.Mutex
+	cache map[string]*cachedDocument
+}
+
+// cachedDocument 按文件大小和修改时间缓存解析结果，分块翻页时不必重复解析
+type cachedDocument struct {
+	size     int64
+	modTime  time.Time
+	loadedAt time.Time
+	doc      *parsedDocument
+}
+
+// NewDocumentTool 创建文档读取工具，ws 为空时使用默认工作区
+func NewDocumentTool(ws *Workspace) *DocumentTool {
+	return &DocumentTool{
+		name:        "read_document",
+		description: "读取工作区中的 PDF、DOCX、XLSX、CSV、HTML、Markdown 文档并返回纯文本，保留分页和工作表边界；长文档按 chunk_size 分块，使用 chunk 参数翻页",
+		workspace:   ws,
+		cache:       make(map[string]*cachedDocument),
+	}
+}
+
+// Name 返回工具名称
+func (t *DocumentTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *DocumentTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *DocumentTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// DocumentInput 文档读取参数
+type DocumentInput struct {
+	Path string `json:"path"`
+	// Format 文档格式，为空时根据扩展名判断
+	Format string `json:"format,omitempty"`
+	// Pages 页码范围，例如 "1-3,5"，仅对 PDF 和 DOCX 生效
+	Pages string `json:"pages,omitempty"`
+	// Sheets 只读取指定的工作表
+	Sheets stringList `json:"sheets,omitempty"`
+	// ChunkSize 每块的字符数
+	ChunkSize int `json:"chunk_size,omitempty"`
+	// ChunkOverlap 相邻分块重叠的字符数
+	ChunkOverlap *int `json:"chunk_overlap,omitempty"`
+	// Chunk 从第几块开始返回（从 0 开始）
+	Chunk int `json:"chunk,omitempty"`
+	// MaxChunks 本次最多返回的块数
+	MaxChunks int `json:"max_chunks,omitempty"`
+}
+
+// DocumentSection 文档分段信息
+type DocumentSection struct {
+	Kind  string `json:"kind"`
+	Index int    `json:"index"`
+	Name  string `json:"name,omitempty"`
+	Chars int    `json:"chars"`
+}
+
+// label 分段的可读名称，例如 page 3、sheet Sales
+func (s DocumentSection) label() string {
+	switch {
+	case s.Kind == SectionSheet && s.Name != "":
+		return "sheet " + s.Name
+	case s.Kind == SectionDocument:
+		return ""
+	default:
+		return fmt.Sprintf("%s %d", s.Kind, s.Index)
+	}
+}
+
+// DocumentChunk 文本分块
+type DocumentChunk struct {
+	Index int `json:"index"`
+	// Location 分块覆盖的页或工作表，例如 "page 2-3"
+	Location string `json:"location,omitempty"`
+	Text     string `json:"text"`
+}
+
+// DocumentResult 文档读取结果
+type DocumentResult struct {
+	Path        string                 `json:"path"`
+	Format      string                 `json:"format"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Sections    []DocumentSection      `json:"sections"`
+	TotalChars  int                    `json:"total_chars"`
+	TotalChunks int                    `json:"total_chunks"`
+	Chunks      []DocumentChunk        `json:"chunks"`
+	// Truncated 文本超过上限被截断
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// String 以文本形式返回分块内容，末尾提示如何继续读取
+func (r DocumentResult) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%s, %d sections, %d chars)\n", r.Path, r.Format, len(r.Sections), r.TotalChars)
+	keys := make([]string, 0, len(r.Metadata))
+	for k := range r.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "%s: %v\n", k, r.Metadata[k])
+	}
+	for _, c := range r.Chunks {
+		fmt.Fprintf(&sb, "\n--- chunk %d/%d", c.Index+1, r.TotalChunks)
+		if c.Location != "" {
+			fmt.Fprintf(&sb, " (%s)", c.Location)
+		}
+		sb.WriteString(" ---\n")
+		sb.WriteString(c.Text)
+		sb.WriteString("\n")
+	}
+	if n := len(r.Chunks); n > 0 && r.Chunks[n-1].Index+1 < r.TotalChunks {
+		fmt.Fprintf(&sb, "\n[还有 %d 块未读取，使用 chunk=%d 继续]\n", r.TotalChunks-r.Chunks[n-1].Index-1, r.Chunks[n-1].Index+1)
+	}
+	if r.Truncated {
+		sb.WriteString("[文档文本超过上限，已截断]\n")
+	}
+	return sb.String()
+}
+
+// Run 读取文档
+// CachePolicy 文档读取的结果在文件修改前可以复用
+func (t *DocumentTool) CachePolicy(input interface{}) (CachePolicy, bool) {
+	var in DocumentInput
+	if s, ok := input.(string); ok {
+		in.Path = s
+	} else if err := decodeArgs(input, &in); err != nil || in.Path == "" {
+		return CachePolicy{}, false
+	}
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return CachePolicy{}, false
+	}
+	full, err := ws.Resolve(in.Path)
+	if err != nil {
+		return CachePolicy{}, false
+	}
+	return CachePolicy{TTL: -1, Files: []string{full}}, true
+}
+
+func (t *DocumentTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in DocumentInput
+	if s, ok := input.(string); ok {
+		in.Path = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Path == "" {
+		return nil, ErrInvalidArgs
+	}
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return nil, err
+	}
+	full, err := ws.Resolve(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := t.load(full, in.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := selectSections(doc.sections, in)
+	if err != nil {
+		return nil, err
+	}
+	text, starts, truncated := joinSections(sections)
+
+	size := in.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	overlap := defaultChunkOverlap
+	if in.ChunkOverlap != nil {
+		overlap = *in.ChunkOverlap
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+	spans := chunkText(text, size, overlap)
+	if in.Chunk < 0 || (in.Chunk >= len(spans) && len(spans) > 0) {
+		return nil, fmt.Errorf("%w: chunk %d out of range, document has %d chunks", ErrInvalidArgs, in.Chunk, len(spans))
+	}
+	count := in.MaxChunks
+	if count <= 0 {
+		count = 1
+	}
+	if count > maxChunksPerCall {
+		count = maxChunksPerCall
+	}
+
+	result := DocumentResult{
+		Path:        ws.Rel(full),
+		Format:      doc.format,
+		Metadata:    doc.metadata,
+		TotalChars:  utf8.RuneCountInString(text),
+		TotalChunks: len(spans),
+		Truncated:   truncated,
+	}
+	for _, s := range sections {
+		result.Sections = append(result.Sections, DocumentSection{Kind: s.kind, Index: s.index, Name: s.name, Chars: utf8.RuneCountInString(s.text)})
+	}
+	runes := []rune(text)
+	for i := in.Chunk; i < len(spans) && i < in.Chunk+count; i++ {
+		span := spans[i]
+		result.Chunks = append(result.Chunks, DocumentChunk{
+			Index:    i,
+			Location: chunkLocation(result.Sections, starts, span),
+			Text:     strings.TrimRight(string(runes[span[0]:span[1]]), " \t\n"),
+		})
+	}
+	return result, nil
+}
+
+// load 解析文档，文件未修改时使用缓存
+func (t *DocumentTool) load(full, format string) (*parsedDocument, error) {
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s is a directory", ErrInvalidArgs, full)
+	}
+	if info.Size() > maxDocumentSize {
+		return nil, fmt.Errorf("document too large: %d bytes", info.Size())
+	}
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(full)), ".")
+	}
+	key := full + "\x00" + format
+
+	t.mu.Lock()
+	if c, ok := t.cache[key]; ok && c.size == info.Size() && c.modTime.Equal(info.ModTime()) {
+		t.mu.Unlock()
+		return c.doc, nil
+	}
+	t.mu.Unlock()
+
+	doc, err := parseDocument(full, format)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.cache) >= documentCacheCapacity {
+		// 淘汰最早加载的文档
+		var oldest string
+		for k, c := range t.cache {
+			if oldest == "" || c.loadedAt.Before(t.cache[oldest].loadedAt) {
+				oldest = k
+			}
+		}
+		delete(t.cache, oldest)
+	}
+	t.cache[key] = &cachedDocument{size: info.Size(), modTime: info.ModTime(), loadedAt: time.Now(), doc: doc}
+	return doc, nil
+}
+
+// parseDocument 根据格式选择解析器
+func parseDocument(full, format string) (*parsedDocument, error) {
+	switch format {
+	case "pdf":
+		return parsePDF(full)
+	case "docx":
+		return parseDOCX(full)
+	case "xlsx", "xlsm":
+		return parseXLSX(full)
+	}
+
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "csv":
+		return parseDelimited(data, filepath.Base(full), "csv"), nil
+	case "tsv":
+		return parseDelimited(data, filepath.Base(full), "tsv"), nil
+	case "html", "htm", "xhtml":
+		return parseHTML(data)
+	case "md", "markdown":
+		return parseMarkdown(data, "markdown"), nil
+	case "txt", "text", "rst", "log":
+		return parseMarkdown(data, "text"), nil
+	case "doc", "xls", "ppt":
+		return nil, fmt.Errorf("%w: legacy binary office format %q is not supported, convert it to %sx first", ErrInvalidArgs, format, format)
+	default:
+		return nil, fmt.Errorf("%w: unsupported document format %q, use pdf, docx, xlsx, csv, tsv, html, md or txt", ErrInvalidArgs, format)
+	}
+}
+
+// selectSections 按页码范围和工作表名称筛选分段
+func selectSections(sections []docSection, in DocumentInput) ([]docSection, error) {
+	if in.Pages == "" && len(in.Sheets) == 0 {
+		return sections, nil
+	}
+	var pages map[int]bool
+	if in.Pages != "" {
+		var err error
+		if pages, err = parsePageRanges(in.Pages); err != nil {
+			return nil, err
+		}
+	}
+	var selected []docSection
+	for _, s := range sections {
+		switch {
+		case s.kind == SectionPage && pages != nil:
+			if !pages[s.index] {
+				continue
+			}
+		case s.kind == SectionSheet && len(in.Sheets) > 0:
+			found := false
+			for _, name := range in.Sheets {
+				if strings.EqualFold(name, s.name) || name == strconv.Itoa(s.index) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		selected = append(selected, s)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: no pages or sheets match the selection", ErrInvalidArgs)
+	}
+	return selected, nil
+}
+
+// parsePageRanges 解析 "1-3,5" 形式的页码范围
+func parsePageRanges(spec string) (map[int]bool, error) {
+	pages := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("%w: invalid page range %q", ErrInvalidArgs, part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil || end < start {
+				return nil, fmt.Errorf("%w: invalid page range %q", ErrInvalidArgs, part)
+			}
+		}
+		if end-start > 100000 {
+			return nil, fmt.Errorf("%w: page range %q is too large", ErrInvalidArgs, part)
+		}
+		for p := start; p <= end; p++ {
+			pages[p] = true
+		}
+	}
+	return pages, nil
+}
+
+// joinSections 拼接分段文本，多个分段之间插入边界标记；返回各分段在文本中的起始字符位置
+func joinSections(sections []docSection) (string, []int, bool) {
+	var sb strings.Builder
+	starts := make([]int, len(sections))
+	chars := 0
+	truncated := false
+	for i, s := range sections {
+		starts[i] = chars
+		if len(sections) > 1 {
+			label := DocumentSection{Kind: s.kind, Index: s.index, Name: s.name}.label()
+			if label == "" {
+				label = s.kind
+			}
+			marker := fmt.Sprintf("=== %s ===\n", strings.ToUpper(label[:1])+label[1:])
+			if i > 0 {
+				marker = "\n" + marker
+			}
+			sb.WriteString(marker)
+			chars += utf8.RuneCountInString(marker)
+		}
+		text := s.text
+		if n := utf8.RuneCountInString(text); chars+n > maxDocumentChars {
+			text = string([]rune(text)[:max(maxDocumentChars-chars, 0)])
+			truncated = true
+		}
+		sb.WriteString(text)
+		chars += utf8.RuneCountInString(text)
+		if truncated {
+			starts = starts[:i+1]
+			break
+		}
+	}
+	return sb.String(), starts, truncated
+}
+
+// chunkText 按字符数切分文本，尽量在段落、换行、句末或空白处断开；返回各块的 [起, 止) 字符位置
+func chunkText(text string, size, overlap int) [][2]int {
+	runes := []rune(text)
+	n := len(runes)
+	if n == 0 {
+		return [][2]int{{0, 0}}
+	}
+	var spans [][2]int
+	for start := 0; start < n; {
+		end := start + size
+		if end >= n {
+			spans = append(spans, [2]int{start, n})
+			break
+		}
+		end = chunkBreak(runes, start+size/2, end)
+		spans = append(spans, [2]int{start, end})
+		// 重叠部分从单词边界开始
+		next := end - overlap
+		for next < end && next > start && !unicode.IsSpace(runes[next-1]) {
+			next++
+		}
+		if next <= start || next >= end {
+			next = end
+		}
+		start = next
+	}
+	return spans
+}
+
+// chunkBreak 在 [lo, hi] 中从后向前寻找最合适的断点
+func chunkBreak(runes []rune, lo, hi int) int {
+	for i := hi; i > lo; i-- {
+		if runes[i-1] == '\n' && i >= 2 && runes[i-2] == '\n' {
+			return i
+		}
+	}
+	for i := hi; i > lo; i-- {
+		if runes[i-1] == '\n' {
+			return i
+		}
+	}
+	for i := hi; i > lo; i-- {
+		switch runes[i-1] {
+		case '.', '!', '?', '。', '！', '？', '；', ';':
+			if i == len(runes) || unicode.IsSpace(runes[i]) || runes[i-1] > unicode.MaxLatin1 {
+				return i
+			}
+		}
+	}
+	for i := hi; i > lo; i-- {
+		if unicode.IsSpace(runes[i-1]) {
+			return i
+		}
+	}
+	return hi
+}
+
+// chunkLocation 根据分段起始位置计算分块覆盖的页或工作表
+func chunkLocation(sections []DocumentSection, starts []int, span [2]int) string {
+	first, last := -1, -1
+	for i := range starts {
+		if starts[i] < span[1] && (i+1 == len(starts) || starts[i+1] > span[0]) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 || sections[first].Kind == SectionDocument {
+		return ""
+	}
+	if first == last {
+		return sections[first].label()
+	}
+	if sections[first].Kind == SectionPage && sections[last].Kind == SectionPage {
+		return fmt.Sprintf("page %d-%d", sections[first].Index, sections[last].Index)
+	}
+	return sections[first].label() + " - " + sections[last].label()
+}
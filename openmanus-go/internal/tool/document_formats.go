@@ -0,0 +1,739 @@
+package tool
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"golang.org/x/net/html"
+)
+
+// 压缩包内单个 XML 文件的大小上限，防止解压炸弹
+const maxDocumentEntrySize = 64 * 1024 * 1024
+
+// 分段类型
+const (
+	SectionPage     = "page"
+	SectionSheet    = "sheet"
+	SectionDocument = "document"
+)
+
+// docSection 文档中的一页、一个工作表或整篇文档
+type docSection struct {
+	kind  string
+	index int
+	name  string
+	text  string
+}
+
+// parsedDocument 解析后的文档
+type parsedDocument struct {
+	format   string
+	metadata map[string]interface{}
+	sections []docSection
+}
+
+// parsePDF 按页提取 PDF 文本
+func parsePDF(p string) (doc *parsedDocument, err error) {
+	// 解析库在遇到损坏的文件时会 panic
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("parse pdf failed: %v", r)
+		}
+	}()
+
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := pdf.NewReader(file, info.Size())
+	if err != nil {
+		if errors.Is(err, pdf.ErrInvalidPassword) {
+			return nil, fmt.Errorf("pdf is encrypted: %v", err)
+		}
+		return nil, fmt.Errorf("parse pdf failed: %v", err)
+	}
+
+	doc = &parsedDocument{format: "pdf", metadata: make(map[string]interface{})}
+	infoDict := reader.Trailer().Key("Info")
+	for _, key := range []string{"Title", "Author", "Subject", "Keywords", "Creator", "Producer", "CreationDate", "ModDate"} {
+		if v := strings.TrimSpace(infoDict.Key(key).Text()); v != "" {
+			doc.metadata[strings.ToLower(key)] = v
+		}
+	}
+
+	pages := reader.NumPage()
+	doc.metadata["pages"] = pages
+	for i := 1; i <= pages; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		doc.sections = append(doc.sections, docSection{kind: SectionPage, index: i, text: pdfPageText(page)})
+	}
+	return doc, nil
+}
+
+// pdfPageText 按内容流顺序拼接字形：纵向位置变化时换行，横向间距较大时补空格
+func pdfPageText(page pdf.Page) (text string) {
+	defer func() {
+		if recover() != nil {
+			text = ""
+		}
+	}()
+	var sb strings.Builder
+	glyphs := page.Content().Text
+	var prev *pdf.Text
+	for i := range glyphs {
+		t := &glyphs[i]
+		if prev != nil {
+			size := math.Max(prev.FontSize, 1)
+			switch {
+			case math.Abs(t.Y-prev.Y) > size*0.5:
+				sb.WriteByte('\n')
+			case t.X-(prev.X+prev.W) > size*0.15:
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(t.S)
+		prev = t
+	}
+	return sb.String()
+}
+
+// readZipEntry 读取压缩包中的文件，超过大小上限时返回错误
+func readZipEntry(zr *zip.Reader, name string) ([]byte, error) {
+	name = strings.TrimPrefix(name, "/")
+	for _, f := range zr.File {
+		if f.Name != name && !strings.EqualFold(f.Name, name) {
+			continue
+		}
+		if f.UncompressedSize64 > maxDocumentEntrySize {
+			return nil, fmt.Errorf("%s is too large: %d bytes", name, f.UncompressedSize64)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(io.LimitReader(rc, maxDocumentEntrySize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxDocumentEntrySize {
+			return nil, fmt.Errorf("%s is too large", name)
+		}
+		return data, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+// xmlAttr 按本地名称读取属性
+func xmlAttr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// officeCoreMetadata 读取 docProps/core.xml 中的标题、作者等信息
+func officeCoreMetadata(zr *zip.Reader, metadata map[string]interface{}) {
+	data, err := readZipEntry(zr, "docProps/core.xml")
+	if err != nil {
+		return
+	}
+	fields := map[string]string{
+		"title": "title", "subject": "subject", "creator": "author", "keywords": "keywords",
+		"description": "description", "lastModifiedBy": "last_modified_by", "created": "created", "modified": "modified",
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var current string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			current = fields[t.Name.Local]
+		case xml.CharData:
+			if v := strings.TrimSpace(string(t)); current != "" && v != "" {
+				metadata[current] = v
+			}
+		case xml.EndElement:
+			current = ""
+		}
+	}
+}
+
+var headingStyleRe = regexp.MustCompile(`(?i)^heading\s*([1-6])$`)
+
+// parseDOCX 提取 Word 文档正文；分页依据显式分页符和 Word 保存时记录的分页位置
+func parseDOCX(p string) (*parsedDocument, error) {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, fmt.Errorf("parse docx failed: %v", err)
+	}
+	defer zr.Close()
+	data, err := readZipEntry(&zr.Reader, "word/document.xml")
+	if err != nil {
+		return nil, fmt.Errorf("parse docx failed: word/document.xml: %v", err)
+	}
+
+	doc := &parsedDocument{format: "docx", metadata: make(map[string]interface{})}
+	officeCoreMetadata(&zr.Reader, doc.metadata)
+
+	var pages []string
+	var page, para strings.Builder
+	prefix := ""
+	inText := false
+	cellDepth, rowCells := 0, 0
+	pageBreak := func() {
+		// 同一位置的显式分页符和渲染分页记录只算一次
+		if strings.TrimSpace(page.String()+para.String()) == "" {
+			return
+		}
+		page.WriteString(para.String())
+		para.Reset()
+		pages = append(pages, page.String())
+		page.Reset()
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse docx failed: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				prefix = ""
+			case "pStyle":
+				if m := headingStyleRe.FindStringSubmatch(xmlAttr(t, "val")); m != nil {
+					level, _ := strconv.Atoi(m[1])
+					prefix = strings.Repeat("#", level) + " "
+				} else if strings.EqualFold(xmlAttr(t, "val"), "Title") {
+					prefix = "# "
+				}
+			case "numPr":
+				if prefix == "" {
+					prefix = "- "
+				}
+			case "t":
+				inText = true
+			case "tab":
+				para.WriteByte('\t')
+			case "br", "cr":
+				if xmlAttr(t, "type") == "page" {
+					pageBreak()
+				} else {
+					para.WriteByte('\n')
+				}
+			case "lastRenderedPageBreak":
+				pageBreak()
+			case "tr":
+				rowCells = 0
+			case "tc":
+				if rowCells > 0 {
+					page.WriteString("| ")
+				}
+				cellDepth++
+			}
+		case xml.CharData:
+			if inText {
+				if para.Len() == 0 {
+					para.WriteString(prefix)
+				}
+				para.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				// 单元格内的段落以空格分隔，单元格之间以 | 分隔
+				if cellDepth > 0 {
+					para.WriteByte(' ')
+				} else {
+					para.WriteByte('\n')
+				}
+				page.WriteString(para.String())
+				para.Reset()
+			case "tc":
+				cellDepth--
+				rowCells++
+			case "tr":
+				page.WriteString("\n")
+			case "tbl":
+				page.WriteString("\n")
+			}
+		}
+	}
+	page.WriteString(para.String())
+	if strings.TrimSpace(page.String()) != "" || len(pages) == 0 {
+		pages = append(pages, page.String())
+	}
+	for i, text := range pages {
+		doc.sections = append(doc.sections, docSection{kind: SectionPage, index: i + 1, text: tidyText(text)})
+	}
+	doc.metadata["pages"] = len(pages)
+	return doc, nil
+}
+
+// parseXLSX 将每个工作表转换为 CSV 文本；日期按 Excel 序列号输出
+func parseXLSX(p string) (*parsedDocument, error) {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, fmt.Errorf("parse xlsx failed: %v", err)
+	}
+	defer zr.Close()
+
+	doc := &parsedDocument{format: "xlsx", metadata: make(map[string]interface{})}
+	officeCoreMetadata(&zr.Reader, doc.metadata)
+
+	sheets, err := xlsxSheets(&zr.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := xlsxSharedStrings(&zr.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for i, sheet := range sheets {
+		data, err := readZipEntry(&zr.Reader, sheet.target)
+		if err != nil {
+			return nil, fmt.Errorf("parse xlsx failed: sheet %q: %v", sheet.name, err)
+		}
+		rows, err := xlsxRows(data, shared)
+		if err != nil {
+			return nil, fmt.Errorf("parse xlsx failed: sheet %q: %v", sheet.name, err)
+		}
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.WriteAll(rows)
+		doc.sections = append(doc.sections, docSection{kind: SectionSheet, index: i + 1, name: sheet.name, text: strings.TrimRight(buf.String(), "\n")})
+		names = append(names, sheet.name)
+	}
+	doc.metadata["sheets"] = names
+	return doc, nil
+}
+
+type xlsxSheet struct {
+	name   string
+	target string
+}
+
+// xlsxSheets 按工作簿中的顺序返回工作表名称和对应的 XML 路径
+func xlsxSheets(zr *zip.Reader) ([]xlsxSheet, error) {
+	rels := make(map[string]string)
+	if data, err := readZipEntry(zr, "xl/_rels/workbook.xml.rels"); err == nil {
+		dec := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				break
+			}
+			if el, ok := tok.(xml.StartElement); ok && el.Name.Local == "Relationship" {
+				target := xmlAttr(el, "Target")
+				if !strings.HasPrefix(target, "/") {
+					target = path.Join("xl", target)
+				}
+				rels[xmlAttr(el, "Id")] = target
+			}
+		}
+	}
+
+	data, err := readZipEntry(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, fmt.Errorf("parse xlsx failed: xl/workbook.xml: %v", err)
+	}
+	var sheets []xlsxSheet
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse xlsx failed: %v", err)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok || el.Name.Local != "sheet" {
+			continue
+		}
+		sheet := xlsxSheet{name: xmlAttr(el, "name")}
+		// r:id 属性的本地名称为 id，sheetId 不能作为路径依据
+		for _, a := range el.Attr {
+			if a.Name.Local == "id" && a.Name.Space != "" {
+				sheet.target = rels[a.Value]
+			}
+		}
+		if sheet.target == "" {
+			sheet.target = fmt.Sprintf("xl/worksheets/sheet%d.xml", len(sheets)+1)
+		}
+		sheets = append(sheets, sheet)
+	}
+	return sheets, nil
+}
+
+// xlsxSharedStrings 读取共享字符串表，忽略注音文本
+func xlsxSharedStrings(zr *zip.Reader) ([]string, error) {
+	data, err := readZipEntry(zr, "xl/sharedStrings.xml")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse xlsx failed: %v", err)
+	}
+	var strs []string
+	var sb strings.Builder
+	inText, inPhonetic := false, false
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse xlsx failed: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				sb.Reset()
+			case "t":
+				inText = true
+			case "rPh":
+				inPhonetic = true
+			}
+		case xml.CharData:
+			if inText && !inPhonetic {
+				sb.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				strs = append(strs, sb.String())
+			case "t":
+				inText = false
+			case "rPh":
+				inPhonetic = false
+			}
+		}
+	}
+	return strs, nil
+}
+
+// xlsxRows 读取工作表单元格，按单元格引用补齐空列
+func xlsxRows(data []byte, shared []string) ([][]string, error) {
+	var rows [][]string
+	var row []string
+	var cellType, cellRef string
+	var value strings.Builder
+	inValue := false
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = nil
+				// 跳过的空行用空记录补齐
+				if r, err := strconv.Atoi(xmlAttr(t, "r")); err == nil {
+					for len(rows) < r-1 {
+						rows = append(rows, nil)
+					}
+				}
+			case "c":
+				cellType, cellRef = xmlAttr(t, "t"), xmlAttr(t, "r")
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				v := value.String()
+				switch cellType {
+				case "s":
+					if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < len(shared) {
+						v = shared[i]
+					}
+				case "b":
+					if v == "1" {
+						v = "TRUE"
+					} else {
+						v = "FALSE"
+					}
+				}
+				if col := columnIndex(cellRef); col >= 0 {
+					for len(row) < col {
+						row = append(row, "")
+					}
+				}
+				row = append(row, v)
+			case "row":
+				rows = append(rows, row)
+			}
+		}
+	}
+	// 去掉末尾的空行
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	for i := range rows {
+		if rows[i] == nil {
+			rows[i] = []string{}
+		}
+	}
+	return rows, nil
+}
+
+// columnIndex 将单元格引用（如 AB12）转换为从 0 开始的列号
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
+
+// decodeDocumentText 按探测到的编码把文本转换为 UTF-8
+func decodeDocumentText(data []byte) string {
+	name := detectEncoding(data[:min(len(data), sniffSize)])
+	enc, err := lookupEncoding(name)
+	if err != nil || enc == nil {
+		return string(data)
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// html 中不输出内容的元素
+var skippedHTMLElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true, "head": true, "iframe": true,
+}
+
+// html 中独占一行的元素
+var blockHTMLElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "header": true, "footer": true, "main": true,
+	"nav": true, "aside": true, "ul": true, "ol": true, "li": true, "table": true, "tr": true, "br": true,
+	"hr": true, "pre": true, "blockquote": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "dl": true, "dt": true, "dd": true, "figure": true, "figcaption": true, "form": true,
+}
+
+// html 中之后空一行的段落级元素
+var paragraphHTMLElements = map[string]bool{
+	"p": true, "ul": true, "ol": true, "table": true, "pre": true, "blockquote": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "dl": true, "figure": true,
+}
+
+// parseHTML 提取 HTML 正文：标题转换为 Markdown 标题，列表项加 "- "，表格单元格以 | 分隔
+func parseHTML(data []byte) (*parsedDocument, error) {
+	root, err := html.Parse(strings.NewReader(decodeDocumentText(data)))
+	if err != nil {
+		return nil, fmt.Errorf("parse html failed: %v", err)
+	}
+	doc := &parsedDocument{format: "html", metadata: make(map[string]interface{})}
+	var sb strings.Builder
+	var walk func(n *html.Node, pre bool)
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.TextNode:
+			text := n.Data
+			if !pre {
+				text = strings.Join(strings.Fields(text), " ")
+				if text == "" {
+					return
+				}
+				if strings.TrimLeft(n.Data, " \t\r\n") != n.Data && !htmlEndsWith(&sb, " \n") {
+					sb.WriteByte(' ')
+				}
+				sb.WriteString(text)
+				if strings.TrimRight(n.Data, " \t\r\n") != n.Data {
+					sb.WriteByte(' ')
+				}
+				return
+			}
+			sb.WriteString(text)
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "title":
+				if n.FirstChild != nil {
+					doc.metadata["title"] = strings.TrimSpace(n.FirstChild.Data)
+				}
+				return
+			case "meta":
+				name := strings.ToLower(htmlAttr(n, "name"))
+				if name == "description" || name == "author" || name == "keywords" {
+					doc.metadata[name] = htmlAttr(n, "content")
+				}
+				return
+			}
+			if skippedHTMLElements[n.Data] {
+				// head 中只取 title 和 meta
+				if n.Data == "head" {
+					for c := n.FirstChild; c != nil; c = c.NextSibling {
+						if c.Type == html.ElementNode && (c.Data == "title" || c.Data == "meta") {
+							walk(c, pre)
+						}
+					}
+				}
+				return
+			}
+			block := blockHTMLElements[n.Data]
+			if block && !htmlEndsWith(&sb, "\n") {
+				sb.WriteByte('\n')
+			}
+			switch n.Data {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				sb.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+			case "li":
+				sb.WriteString("- ")
+			case "pre":
+				pre = true
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c, pre)
+			}
+			switch n.Data {
+			case "td", "th":
+				if n.NextSibling != nil {
+					sb.WriteString(" | ")
+				}
+			}
+			if block {
+				if !htmlEndsWith(&sb, "\n") {
+					sb.WriteByte('\n')
+				}
+				// 段落级元素之后空一行
+				if paragraphHTMLElements[n.Data] {
+					sb.WriteByte('\n')
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, pre)
+		}
+	}
+	walk(root, false)
+	doc.sections = []docSection{{kind: SectionDocument, index: 1, text: tidyText(sb.String())}}
+	return doc, nil
+}
+
+// htmlEndsWith 判断已输出文本是否以 chars 中的任一字符结尾，开头视为已换行
+func htmlEndsWith(sb *strings.Builder, chars string) bool {
+	s := sb.String()
+	return s == "" || strings.ContainsRune(chars, rune(s[len(s)-1]))
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+var frontMatterRe = regexp.MustCompile(`(?s)\A---\r?\n(.*?)\r?\n---\r?\n`)
+
+// parseMarkdown 读取 Markdown 或纯文本，解析 YAML front matter 中的简单键值作为元数据
+func parseMarkdown(data []byte, format string) *parsedDocument {
+	text := decodeDocumentText(data)
+	doc := &parsedDocument{format: format, metadata: make(map[string]interface{})}
+	if format == "markdown" {
+		if m := frontMatterRe.FindStringSubmatch(text); m != nil {
+			for _, line := range strings.Split(m[1], "\n") {
+				key, value, ok := strings.Cut(line, ":")
+				if ok && !strings.HasPrefix(line, " ") {
+					doc.metadata[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+				}
+			}
+			text = text[len(m[0]):]
+		}
+		if _, ok := doc.metadata["title"]; !ok {
+			for _, line := range strings.Split(text, "\n") {
+				if strings.HasPrefix(line, "# ") {
+					doc.metadata["title"] = strings.TrimSpace(line[2:])
+					break
+				}
+			}
+		}
+	}
+	doc.sections = []docSection{{kind: SectionDocument, index: 1, text: strings.ReplaceAll(text, "\r\n", "\n")}}
+	return doc
+}
+
+// parseDelimited 读取 CSV/TSV 文件，作为单个工作表
+func parseDelimited(data []byte, name, format string) *parsedDocument {
+	text := strings.TrimPrefix(decodeDocumentText(data), "\ufeff")
+	return &parsedDocument{
+		format:   format,
+		metadata: make(map[string]interface{}),
+		sections: []docSection{{kind: SectionSheet, index: 1, name: name, text: strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")}},
+	}
+}
+
+var blankLinesRe = regexp.MustCompile(`\n{3,}`)
+
+// tidyText 去掉行尾空白并压缩连续空行
+func tidyText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+		lines[i] = strings.TrimLeft(lines[i], " ")
+	}
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
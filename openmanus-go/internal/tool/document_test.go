@@ -0,0 +1,366 @@
+package tool
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// newTestDocumentWorkspace 创建包含 testdata/documents 中样例文档的工作区
+func newTestDocumentWorkspace(t *testing.T) *Workspace {
+	t.Helper()
+	ws := newTestWorkspace(t)
+	entries, err := os.ReadDir(filepath.Join("testdata", "documents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join("testdata", "documents", e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, ws.Root(), e.Name(), string(data))
+	}
+	return ws
+}
+
+func runDocument(t *testing.T, dt *DocumentTool, args map[string]interface{}) (DocumentResult, error) {
+	t.Helper()
+	out, err := dt.Run(context.Background(), args)
+	if err != nil {
+		return DocumentResult{}, err
+	}
+	return out.(DocumentResult), nil
+}
+
+func TestParseDocumentFixtures(t *testing.T) {
+	tests := []struct {
+		file     string
+		format   string
+		metadata map[string]interface{}
+		sections []docSection
+	}{
+		{
+			file:     "report.pdf",
+			format:   "pdf",
+			metadata: map[string]interface{}{"title": "Quarterly Report", "author": "Finance Team", "pages": 2},
+			sections: []docSection{
+				{kind: SectionPage, index: 1, text: "Quarterly Report\nRevenue grew in Q3."},
+				{kind: SectionPage, index: 2, text: "Second page text.\nEnd of report."},
+			},
+		},
+		{
+			file:     "plan.docx",
+			format:   "docx",
+			metadata: map[string]interface{}{"title": "Project Plan", "author": "Finance Team", "created": "2024-01-02T03:04:05Z", "pages": 2},
+			sections: []docSection{
+				{kind: SectionPage, index: 1, text: "# Project Plan\n## Goals\nShip the & first release.\n- Write tests\nTab\tstop\nName | Owner\nParser | Ann"},
+				{kind: SectionPage, index: 2, text: "# Appendix\nSecond page body."},
+			},
+		},
+		{
+			file:     "budget.xlsx",
+			format:   "xlsx",
+			metadata: map[string]interface{}{"title": "Budget", "author": "Finance Team", "created": "2024-01-02T03:04:05Z", "sheets": []string{"Sales", "Notes"}},
+			sections: []docSection{
+				// 工作表按工作簿顺序排列，空列和空行保留位置，注音文本被忽略
+				{kind: SectionSheet, index: 1, name: "Sales", text: "Region,Amount,,Active\nNorth,1200.5,,TRUE\n\n\"South, East\",300,,FALSE"},
+				{kind: SectionSheet, index: 2, name: "Notes", text: "Reviewed"},
+			},
+		},
+		{
+			file:     "article.html",
+			format:   "html",
+			metadata: map[string]interface{}{"title": "Release Notes", "description": "What changed"},
+			sections: []docSection{
+				{kind: SectionDocument, index: 1, text: "# Version 2.0\n\nNew features and fixes.\n\n- Faster search\n- Charts\n\nModule | Status\nsearch | done\n\nline one\nindented"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			doc, err := parseDocument(filepath.Join("testdata", "documents", tt.file), strings.TrimPrefix(filepath.Ext(tt.file), "."))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.format != tt.format {
+				t.Fatalf("format = %q", doc.format)
+			}
+			if !reflect.DeepEqual(doc.metadata, tt.metadata) {
+				t.Fatalf("metadata = %v, want %v", doc.metadata, tt.metadata)
+			}
+			if !reflect.DeepEqual(doc.sections, tt.sections) {
+				t.Fatalf("sections =\n%+v\nwant\n%+v", doc.sections, tt.sections)
+			}
+		})
+	}
+}
+
+func TestParseDocumentText(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "notes.md", "---\ntitle: \"Notes\"\ntags:\n  - a\n---\n# Heading\r\nbody\n")
+	writeTestFile(t, dir, "data.csv", "\ufeffa,b\r\n1,2\r\n")
+	writeTestFile(t, dir, "broken.pdf", "%PDF-1.4 not really")
+
+	doc, err := parseDocument(filepath.Join(dir, "notes.md"), "md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.metadata, map[string]interface{}{"title": "Notes", "tags": ""}) || doc.sections[0].text != "# Heading\nbody\n" {
+		t.Fatalf("markdown = %v %q", doc.metadata, doc.sections[0].text)
+	}
+	doc, err = parseDocument(filepath.Join(dir, "data.csv"), "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := doc.sections[0]; s.kind != SectionSheet || s.name != "data.csv" || s.text != "a,b\n1,2" {
+		t.Fatalf("csv section = %+v", s)
+	}
+
+	if _, err := parseDocument(filepath.Join(dir, "broken.pdf"), "pdf"); err == nil {
+		t.Fatal("broken pdf parsed")
+	}
+	for _, format := range []string{"doc", "pages"} {
+		if _, err := parseDocument(filepath.Join(dir, "notes.md"), format); !errors.Is(err, ErrInvalidArgs) {
+			t.Fatalf("format %q: %v", format, err)
+		}
+	}
+}
+
+func TestDocumentToolSelection(t *testing.T) {
+	dt := NewDocumentTool(newTestDocumentWorkspace(t))
+	tests := []struct {
+		name     string
+		args     map[string]interface{}
+		sections []DocumentSection
+		text     string
+		location string
+	}{
+		{
+			name: "all pages with markers",
+			args: map[string]interface{}{"path": "report.pdf"},
+			sections: []DocumentSection{
+				{Kind: SectionPage, Index: 1, Chars: 36},
+				{Kind: SectionPage, Index: 2, Chars: 32},
+			},
+			text:     "=== Page 1 ===\nQuarterly Report\nRevenue grew in Q3.\n=== Page 2 ===\nSecond page text.\nEnd of report.",
+			location: "page 1-2",
+		},
+		{
+			name:     "page range",
+			args:     map[string]interface{}{"path": "plan.docx", "pages": "2-5"},
+			sections: []DocumentSection{{Kind: SectionPage, Index: 2, Chars: 28}},
+			text:     "# Appendix\nSecond page body.",
+			location: "page 2",
+		},
+		{
+			name:     "sheet by name",
+			args:     map[string]interface{}{"path": "budget.xlsx", "sheets": "notes"},
+			sections: []DocumentSection{{Kind: SectionSheet, Index: 2, Name: "Notes", Chars: 8}},
+			text:     "Reviewed",
+			location: "sheet Notes",
+		},
+		{
+			name:     "sheet by index",
+			args:     map[string]interface{}{"path": "budget.xlsx", "sheets": []interface{}{"1"}},
+			sections: []DocumentSection{{Kind: SectionSheet, Index: 1, Name: "Sales", Chars: 66}},
+			text:     "Region,Amount,,Active\nNorth,1200.5,,TRUE\n\n\"South, East\",300,,FALSE",
+			location: "sheet Sales",
+		},
+		{
+			name:     "format override",
+			args:     map[string]interface{}{"path": "article.html", "format": "txt"},
+			sections: []DocumentSection{{Kind: SectionDocument, Index: 1, Chars: 515}},
+			text:     "<!DOCTYPE html>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := runDocument(t, dt, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res.Sections, tt.sections) {
+				t.Fatalf("sections = %+v", res.Sections)
+			}
+			if res.TotalChunks != 1 || len(res.Chunks) != 1 {
+				t.Fatalf("chunks = %d/%d", len(res.Chunks), res.TotalChunks)
+			}
+			if c := res.Chunks[0]; !strings.HasPrefix(c.Text, tt.text) || c.Location != tt.location {
+				t.Fatalf("chunk = %+v", c)
+			}
+		})
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"path": "report.pdf", "pages": "3"},
+		{"path": "report.pdf", "pages": "2-1"},
+		{"path": "budget.xlsx", "sheets": "Missing"},
+		{"path": "report.pdf", "chunk": 1},
+		{"path": "."},
+		{},
+	} {
+		if _, err := runDocument(t, dt, args); !errors.Is(err, ErrInvalidArgs) {
+			t.Fatalf("args %v: %v", args, err)
+		}
+	}
+}
+
+func TestDocumentToolChunks(t *testing.T) {
+	ws := newTestWorkspace(t)
+	var paragraphs []string
+	for i := 0; i < 10; i++ {
+		paragraphs = append(paragraphs, strings.Repeat("word ", 19)+"end.")
+	}
+	writeTestFile(t, ws.Root(), "long.txt", strings.Join(paragraphs, "\n\n"))
+	dt := NewDocumentTool(ws)
+
+	res, err := runDocument(t, dt, map[string]interface{}{"path": "long.txt", "chunk_size": 250, "chunk_overlap": 0, "max_chunks": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每块在段落边界断开，正好包含两个段落
+	if res.TotalChars != 1008 || res.TotalChunks != 5 || len(res.Chunks) != 2 {
+		t.Fatalf("chars = %d, chunks = %d/%d", res.TotalChars, len(res.Chunks), res.TotalChunks)
+	}
+	want := paragraphs[0] + "\n\n" + paragraphs[1]
+	if res.Chunks[0].Text != want || res.Chunks[1].Index != 1 {
+		t.Fatalf("chunks = %+v", res.Chunks)
+	}
+	if !strings.Contains(res.String(), "[还有 3 块未读取，使用 chunk=2 继续]") {
+		t.Fatalf("missing continuation hint:\n%s", res.String())
+	}
+
+	// 翻到最后一块，不再提示继续
+	res, err = runDocument(t, dt, map[string]interface{}{"path": "long.txt", "chunk_size": 250, "chunk_overlap": 0, "chunk": 4, "max_chunks": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Chunks) != 1 || res.Chunks[0].Text != paragraphs[8]+"\n\n"+paragraphs[9] || strings.Contains(res.String(), "还有") {
+		t.Fatalf("last chunk = %+v", res.Chunks)
+	}
+
+	// 重叠部分从单词边界开始
+	res, err = runDocument(t, dt, map[string]interface{}{"path": "long.txt", "chunk_size": 250, "chunk_overlap": 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalChunks != 5 {
+		t.Fatalf("chunks with overlap = %d", res.TotalChunks)
+	}
+	res, err = runDocument(t, dt, map[string]interface{}{"path": "long.txt", "chunk_size": 250, "chunk_overlap": 12, "chunk": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(res.Chunks[0].Text, "word end.\n\n") {
+		t.Fatalf("overlapping chunk = %q", res.Chunks[0].Text)
+	}
+}
+
+func TestDocumentToolCache(t *testing.T) {
+	ws := newTestWorkspace(t)
+	writeTestFile(t, ws.Root(), "a.md", "# First\n")
+	dt := NewDocumentTool(ws)
+	if res, err := runDocument(t, dt, map[string]interface{}{"path": "a.md"}); err != nil || res.Metadata["title"] != "First" {
+		t.Fatalf("first read = %+v, %v", res, err)
+	}
+	// 文件大小变化后重新解析
+	writeTestFile(t, ws.Root(), "a.md", "# Second title\n")
+	if res, err := runDocument(t, dt, map[string]interface{}{"path": "a.md"}); err != nil || res.Metadata["title"] != "Second title" {
+		t.Fatalf("after change = %+v, %v", res, err)
+	}
+}
+
+func TestDocumentLimits(t *testing.T) {
+	// 超过大小上限的文件不解析
+	ws := newTestWorkspace(t)
+	f, err := os.Create(filepath.Join(ws.Root(), "huge.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(maxDocumentSize + 1); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := runDocument(t, NewDocumentTool(ws), map[string]interface{}{"path": "huge.txt"}); err == nil || !strings.Contains(err.Error(), "document too large") {
+		t.Fatalf("huge file: %v", err)
+	}
+
+	// 文本超过字符上限时截断，截断后的分段不再出现
+	sections := []docSection{
+		{kind: SectionPage, index: 1, text: strings.Repeat("页", maxDocumentChars)},
+		{kind: SectionPage, index: 2, text: "never shown"},
+	}
+	text, starts, truncated := joinSections(sections)
+	if !truncated || utf8.RuneCountInString(text) != maxDocumentChars || len(starts) != 1 || strings.Contains(text, "never shown") {
+		t.Fatalf("truncated = %v, chars = %d, starts = %v", truncated, utf8.RuneCountInString(text), starts)
+	}
+
+	// 解压后超过上限的压缩包条目被拒绝
+	bomb := filepath.Join(t.TempDir(), "bomb.docx")
+	out, err := os.Create(bomb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk := make([]byte, 1<<20)
+	for written := 0; written <= maxDocumentEntrySize; written += len(chunk) {
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	if _, err := parseDocument(bomb, "docx"); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("zip bomb: %v", err)
+	}
+}
+
+func TestParsePageRanges(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []int
+		wantErr bool
+	}{
+		{"1", []int{1}, false},
+		{" 1-3, 5 ,", []int{1, 2, 3, 5}, false},
+		{"2-2", []int{2}, false},
+		{"0", nil, true},
+		{"3-1", nil, true},
+		{"a-b", nil, true},
+		{"1-200000", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			pages, err := parsePageRanges(tt.spec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidArgs) {
+					t.Fatalf("got %v, want ErrInvalidArgs", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := make(map[int]bool)
+			for _, p := range tt.want {
+				want[p] = true
+			}
+			if !reflect.DeepEqual(pages, want) {
+				t.Fatalf("pages = %v", pages)
+			}
+		})
+	}
+}
@@ -6,22 +6,48 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path/filepath"
+	"sync"
 	"time"
 )
 
 // FileTool 文件工具
 type FileTool struct {
-	basePath string
+	basePath  string
+	workspace *Workspace
+	once      sync.Once
+	wsErr     error
 }
 
-// NewFileTool 创建文件工具
+// NewFileTool 创建文件工具，basePath 作为工作区根目录并使用默认访问规则
 func NewFileTool(basePath string) *FileTool {
 	return &FileTool{
 		basePath: basePath,
 	}
 }
 
+// NewFileToolWithWorkspace 使用指定工作区创建文件工具
+func NewFileToolWithWorkspace(ws *Workspace) *FileTool {
+	return &FileTool{
+		basePath:  ws.Root(),
+		workspace: ws,
+	}
+}
+
+// getWorkspace 获取工作区，未指定 basePath 时使用默认工作区
+func (f *FileTool) getWorkspace() (*Workspace, error) {
+	f.once.Do(func() {
+		if f.workspace != nil {
+			return
+		}
+		if f.basePath == "" {
+			f.workspace, f.wsErr = GetDefaultWorkspace()
+			return
+		}
+		f.workspace, f.wsErr = NewWorkspace(DefaultWorkspaceConfig(f.basePath))
+	})
+	return f.workspace, f.wsErr
+}
+
 func (f *FileTool) Name() string {
 	return "FileTool"
 }
@@ -43,6 +69,52 @@ type FileResult struct {
 	Error    string                 `json:"error,omitempty"`
 }
 
+// CachePolicy read 操作的结果在文件修改前可以复用
+func (f *FileTool) CachePolicy(input interface{}) (CachePolicy, bool) {
+	var op FileOperation
+	switch v := input.(type) {
+	case string:
+		op = FileOperation{Action: "read", Path: v}
+	case map[string]interface{}:
+		if err := decodeArgs(v, &op); err != nil {
+			return CachePolicy{}, false
+		}
+	default:
+		return CachePolicy{}, false
+	}
+	if op.Action != "read" {
+		return CachePolicy{}, false
+	}
+	ws, err := f.getWorkspace()
+	if err != nil {
+		return CachePolicy{}, false
+	}
+	fullPath, err := ws.Resolve(op.Path)
+	if err != nil {
+		return CachePolicy{}, false
+	}
+	return CachePolicy{TTL: -1, Files: []string{fullPath}}, true
+}
+
+// ExclusiveKeys 同一文件上的操作依次执行
+func (f *FileTool) ExclusiveKeys(input interface{}) []string {
+	var op FileOperation
+	switch v := input.(type) {
+	case string:
+		op.Path = v
+	case map[string]interface{}:
+		if err := decodeArgs(v, &op); err != nil {
+			return nil
+		}
+	}
+	ws, err := f.getWorkspace()
+	if err != nil {
+		return nil
+	}
+	target, _ := op.Options["target"].(string)
+	return fileKeys(ws, op.Path, target)
+}
+
 func (f *FileTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
 	// 解析输入
 	var op FileOperation
@@ -66,25 +138,23 @@ func (f *FileTool) Run(ctx context.Context, input interface{}) (interface{}, err
 		return nil, fmt.Errorf("unsupported input type: %T", input)
 	}
 
-	// 构建完整路径
-	fullPath := filepath.Join(f.basePath, op.Path)
+	ws, err := f.getWorkspace()
+	if err != nil {
+		return nil, err
+	}
 
 	// 执行操作
 	var result FileResult
 	switch op.Action {
 	case "read":
-		content, err := ioutil.ReadFile(fullPath)
+		fullPath, err := ws.Resolve(op.Path)
 		if err != nil {
-			return nil, fmt.Errorf("read file failed: %v", err)
+			return nil, err
 		}
-		result = FileResult{
-			Success: true,
-			Path:    fullPath,
-			Content: string(content),
-			Metadata: map[string]interface{}{
-				"size": len(content),
-				"time": time.Now(),
-			},
+		// 支持行范围、字节范围、head/tail 和分页读取，见 ReadOptions
+		result, err = readFileRich(fullPath, op.Options)
+		if err != nil {
+			return nil, err
 		}
 
 	case "write":
@@ -98,8 +168,9 @@ func (f *FileTool) Run(ctx context.Context, input interface{}) (interface{}, err
 			content, _ = json.Marshal(v)
 		}
 
-		if err := ioutil.WriteFile(fullPath, content, os.ModePerm); err != nil {
-			return nil, fmt.Errorf("write file failed: %v", err)
+		fullPath, err := ws.WriteFile(op.Path, content)
+		if err != nil {
+			return nil, fmt.Errorf("write file failed: %w", err)
 		}
 		result = FileResult{
 			Success: true,
@@ -111,18 +182,25 @@ func (f *FileTool) Run(ctx context.Context, input interface{}) (interface{}, err
 		}
 
 	case "delete":
-		if err := os.Remove(fullPath); err != nil {
-			return nil, fmt.Errorf("delete file failed: %v", err)
+		// 软删除，文件移动到回收站
+		trashPath, err := ws.Remove(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("delete file failed: %w", err)
 		}
 		result = FileResult{
 			Success: true,
-			Path:    fullPath,
+			Path:    op.Path,
 			Metadata: map[string]interface{}{
-				"time": time.Now(),
+				"trash_path": trashPath,
+				"time":       time.Now(),
 			},
 		}
 
 	case "list":
+		fullPath, err := ws.Resolve(op.Path)
+		if err != nil {
+			return nil, err
+		}
 		files, err := ioutil.ReadDir(fullPath)
 		if err != nil {
 			return nil, fmt.Errorf("list directory failed: %v", err)
@@ -148,7 +226,11 @@ func (f *FileTool) Run(ctx context.Context, input interface{}) (interface{}, err
 		}
 
 	case "mkdir":
-		if err := os.MkdirAll(fullPath, os.ModePerm); err != nil {
+		fullPath, err := ws.ResolveWrite(op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(fullPath, DefaultDirMode); err != nil {
 			return nil, fmt.Errorf("create directory failed: %v", err)
 		}
 		result = FileResult{
@@ -164,7 +246,14 @@ func (f *FileTool) Run(ctx context.Context, input interface{}) (interface{}, err
 		if !ok {
 			return nil, fmt.Errorf("target path not specified")
 		}
-		targetPath := filepath.Join(f.basePath, target)
+		fullPath, err := ws.ResolveWrite(op.Path)
+		if err != nil {
+			return nil, err
+		}
+		targetPath, err := ws.ResolveWrite(target)
+		if err != nil {
+			return nil, err
+		}
 		if err := os.Rename(fullPath, targetPath); err != nil {
 			return nil, fmt.Errorf("move file failed: %v", err)
 		}
@@ -181,13 +270,17 @@ func (f *FileTool) Run(ctx context.Context, input interface{}) (interface{}, err
 		if !ok {
 			return nil, fmt.Errorf("target path not specified")
 		}
-		targetPath := filepath.Join(f.basePath, target)
+		fullPath, err := ws.Resolve(op.Path)
+		if err != nil {
+			return nil, err
+		}
 		content, err := ioutil.ReadFile(fullPath)
 		if err != nil {
 			return nil, fmt.Errorf("read source file failed: %v", err)
 		}
-		if err := ioutil.WriteFile(targetPath, content, os.ModePerm); err != nil {
-			return nil, fmt.Errorf("write target file failed: %v", err)
+		targetPath, err := ws.WriteFile(target, content)
+		if err != nil {
+			return nil, fmt.Errorf("write target file failed: %w", err)
 		}
 		result = FileResult{
 			Success: true,
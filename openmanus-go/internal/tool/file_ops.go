@@ -10,9 +10,10 @@ import (
 type FileOpsTool struct {
 	name        string
 	description string
+	workspace   *Workspace
 }
 
-// NewFileOpsTool 创建文件操作工具
+// NewFileOpsTool 创建文件操作工具，路径限制在默认工作区内
 func NewFileOpsTool() *FileOpsTool {
 	return &FileOpsTool{
 		name:        "file_ops",
@@ -20,6 +21,21 @@ func NewFileOpsTool() *FileOpsTool {
 	}
 }
 
+// NewFileOpsToolWithWorkspace 使用指定工作区创建文件操作工具
+func NewFileOpsToolWithWorkspace(ws *Workspace) *FileOpsTool {
+	t := NewFileOpsTool()
+	t.workspace = ws
+	return t
+}
+
+// getWorkspace 获取工作区
+func (t *FileOpsTool) getWorkspace() (*Workspace, error) {
+	if t.workspace != nil {
+		return t.workspace, nil
+	}
+	return GetDefaultWorkspace()
+}
+
 // Name 返回工具名称
 func (t *FileOpsTool) Name() string {
 	return t.name
@@ -63,6 +79,35 @@ func (t *FileOpsTool) Run(ctx context.Context, input interface{}) (interface{},
 	}
 }
 
+// CachePolicy read 操作的结果在文件修改前可以复用
+func (t *FileOpsTool) CachePolicy(input interface{}) (CachePolicy, bool) {
+	args, _ := input.(map[string]interface{})
+	path, _ := args["path"].(string)
+	if op, _ := args["operation"].(string); op != "read" || path == "" {
+		return CachePolicy{}, false
+	}
+	ws, err := t.getWorkspace()
+	if err != nil {
+		return CachePolicy{}, false
+	}
+	fullPath, err := ws.Resolve(path)
+	if err != nil {
+		return CachePolicy{}, false
+	}
+	return CachePolicy{TTL: -1, Files: []string{fullPath}}, true
+}
+
+// ExclusiveKeys 同一文件上的操作依次执行
+func (t *FileOpsTool) ExclusiveKeys(input interface{}) []string {
+	args, _ := input.(map[string]interface{})
+	path, _ := args["path"].(string)
+	ws, err := t.getWorkspace()
+	if err != nil {
+		return nil
+	}
+	return fileKeys(ws, path)
+}
+
 // readFile 读取文件
 func (t *FileOpsTool) readFile(args map[string]interface{}) (interface{}, error) {
 	path, ok := args["path"].(string)
@@ -70,7 +115,16 @@ func (t *FileOpsTool) readFile(args map[string]interface{}) (interface{}, error)
 		return nil, ErrInvalidArgs
 	}
 
-	data, err := ioutil.ReadFile(path)
+	ws, err := t.getWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	fullPath, err := ws.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(fullPath)
 	if err != nil {
 		return nil, err
 	}
@@ -90,10 +144,13 @@ func (t *FileOpsTool) writeFile(args map[string]interface{}) (interface{}, error
 		return nil, ErrInvalidArgs
 	}
 
-	err := ioutil.WriteFile(path, []byte(content), 0644)
+	ws, err := t.getWorkspace()
 	if err != nil {
 		return nil, err
 	}
+	if _, err := ws.WriteFile(path, []byte(content)); err != nil {
+		return nil, err
+	}
 
 	return "success", nil
 }
@@ -105,10 +162,17 @@ func (t *FileOpsTool) createDir(args map[string]interface{}) (interface{}, error
 		return nil, ErrInvalidArgs
 	}
 
-	err := os.MkdirAll(path, 0755)
+	ws, err := t.getWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	fullPath, err := ws.ResolveWrite(path)
 	if err != nil {
 		return nil, err
 	}
+	if err := os.MkdirAll(fullPath, DefaultDirMode); err != nil {
+		return nil, err
+	}
 
 	return "success", nil
 }
@@ -120,7 +184,16 @@ func (t *FileOpsTool) listDir(args map[string]interface{}) (interface{}, error)
 		return nil, ErrInvalidArgs
 	}
 
-	files, err := ioutil.ReadDir(path)
+	ws, err := t.getWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	fullPath, err := ws.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(fullPath)
 	if err != nil {
 		return nil, err
 	}
@@ -139,17 +212,24 @@ func (t *FileOpsTool) listDir(args map[string]interface{}) (interface{}, error)
 	return result, nil
 }
 
-// deleteFile 删除文件或目录
+// deleteFile 删除文件或目录（移动到工作区回收站）
 func (t *FileOpsTool) deleteFile(args map[string]interface{}) (interface{}, error) {
 	path, ok := args["path"].(string)
 	if !ok {
 		return nil, ErrInvalidArgs
 	}
 
-	err := os.RemoveAll(path)
+	ws, err := t.getWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	trashPath, err := ws.Remove(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return "success", nil
+	return map[string]interface{}{
+		"status":     "success",
+		"trash_path": trashPath,
+	}, nil
 }
@@ -0,0 +1,553 @@
+package tool
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// 读取相关的默认限制
+const (
+	defaultReadPageSize = 64 * 1024
+	maxReadBytes        = 256 * 1024
+	maxDecodeBytes      = 16 * 1024 * 1024
+	sniffSize           = 8 * 1024
+	defaultHexDumpBytes = 512
+)
+
+// ErrStalePageToken 分页令牌对应的文件已被修改
+var ErrStalePageToken = errors.New("file changed since page token was issued")
+
+// ReadOptions 读取选项，来自 FileOperation.Options
+type ReadOptions struct {
+	StartLine int    `json:"start_line,omitempty"` // 起始行（从 1 开始，含）
+	EndLine   int    `json:"end_line,omitempty"`   // 结束行（含）
+	Offset    int64  `json:"offset,omitempty"`     // 起始字节偏移
+	Limit     int64  `json:"limit,omitempty"`      // 读取字节数
+	Head      int    `json:"head,omitempty"`       // 读取前 N 行
+	Tail      int    `json:"tail,omitempty"`       // 读取后 N 行
+	PageSize  int64  `json:"page_size,omitempty"`  // 分页大小（字节）
+	PageToken string `json:"page_token,omitempty"` // 上一页返回的令牌
+	Encoding  string `json:"encoding,omitempty"`   // 强制指定编码
+	Hex       bool   `json:"hex,omitempty"`        // 以十六进制查看
+}
+
+// pageToken 分页令牌，绑定文件大小和修改时间
+type pageToken struct {
+	Path    string `json:"p"`
+	Offset  int64  `json:"o"`
+	Line    int    `json:"l"`
+	Size    int64  `json:"s"`
+	ModTime int64  `json:"m"`
+}
+
+// encodePageToken 编码分页令牌
+func encodePageToken(tok pageToken) string {
+	data, _ := json.Marshal(tok)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePageToken 解码分页令牌
+func decodePageToken(s string) (pageToken, error) {
+	var tok pageToken
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return tok, fmt.Errorf("invalid page token: %v", err)
+	}
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return tok, fmt.Errorf("invalid page token: %v", err)
+	}
+	return tok, nil
+}
+
+// parseReadOptions 将 map 形式的选项转换为 ReadOptions
+func parseReadOptions(options map[string]interface{}) (ReadOptions, error) {
+	var opts ReadOptions
+	if len(options) == 0 {
+		return opts, nil
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return opts, fmt.Errorf("invalid read options: %v", err)
+	}
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return opts, fmt.Errorf("invalid read options: %v", err)
+	}
+	return opts, nil
+}
+
+// readFileRich 按选项读取文件：支持行范围、字节范围、head/tail、二进制检测、编码识别和分页
+func readFileRich(fullPath string, options map[string]interface{}) (FileResult, error) {
+	opts, err := parseReadOptions(options)
+	if err != nil {
+		return FileResult{}, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return FileResult{}, fmt.Errorf("read file failed: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return FileResult{}, fmt.Errorf("read file failed: %v", err)
+	}
+	if info.IsDir() {
+		return FileResult{}, fmt.Errorf("read file failed: %s is a directory", fullPath)
+	}
+
+	sample := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return FileResult{}, fmt.Errorf("read file failed: %v", err)
+	}
+	sample = sample[:n]
+
+	metadata := map[string]interface{}{
+		"size":     info.Size(),
+		"mod_time": info.ModTime(),
+		"time":     time.Now(),
+	}
+
+	encName := strings.ToLower(opts.Encoding)
+	if encName == "" {
+		if isBinary(sample) {
+			encName = "binary"
+		} else {
+			encName = detectEncoding(sample)
+		}
+	}
+	metadata["encoding"] = encName
+
+	if encName == "binary" || opts.Hex {
+		content, err := binaryView(file, info, sample, opts, metadata)
+		if err != nil {
+			return FileResult{}, err
+		}
+		return FileResult{Success: true, Path: fullPath, Content: content, Metadata: metadata}, nil
+	}
+
+	enc, err := lookupEncoding(encName)
+	if err != nil {
+		return FileResult{}, err
+	}
+
+	// UTF-16 无法按字节查找换行，先整体解码为 UTF-8 再处理
+	var src io.ReadSeeker = file
+	size := info.Size()
+	if strings.HasPrefix(encName, "utf-16") {
+		if size > maxDecodeBytes {
+			return FileResult{}, fmt.Errorf("file too large to decode as %s: %d bytes", encName, size)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return FileResult{}, err
+		}
+		decoded, err := io.ReadAll(enc.NewDecoder().Reader(file))
+		if err != nil {
+			return FileResult{}, fmt.Errorf("decode %s failed: %v", encName, err)
+		}
+		src = bytes.NewReader(decoded)
+		size = int64(len(decoded))
+		enc = nil
+	}
+
+	var raw []byte
+	switch {
+	case opts.PageToken != "":
+		raw, err = readPage(src, fullPath, info, size, opts, metadata)
+	case opts.Tail > 0:
+		raw, err = readTail(src, size, opts.Tail, metadata)
+	case opts.Head > 0 || opts.StartLine > 0 || opts.EndLine > 0:
+		start, end := opts.StartLine, opts.EndLine
+		if opts.Head > 0 {
+			start, end = 1, opts.Head
+		}
+		raw, err = readLines(src, start, end, metadata)
+	case opts.Offset > 0 || opts.Limit > 0:
+		raw, err = readRange(src, size, opts.Offset, opts.Limit, metadata)
+	case size > maxReadBytes:
+		// 大文件没有指定范围时从头分页读取
+		opts.PageSize = pageSizeOrDefault(opts.PageSize)
+		raw, err = readPageAt(src, fullPath, info, size, 0, 1, opts.PageSize, metadata)
+	default:
+		if _, err = src.Seek(0, io.SeekStart); err == nil {
+			raw, err = io.ReadAll(src)
+		}
+	}
+	if err != nil {
+		return FileResult{}, err
+	}
+
+	content := string(raw)
+	if enc != nil {
+		decoded, err := enc.NewDecoder().Bytes(raw)
+		if err != nil {
+			return FileResult{}, fmt.Errorf("decode %s failed: %v", encName, err)
+		}
+		content = string(decoded)
+	}
+	metadata["bytes_read"] = len(raw)
+
+	return FileResult{Success: true, Path: fullPath, Content: content, Metadata: metadata}, nil
+}
+
+// pageSizeOrDefault 返回有效的分页大小
+func pageSizeOrDefault(size int64) int64 {
+	if size <= 0 {
+		return defaultReadPageSize
+	}
+	if size > maxReadBytes {
+		return maxReadBytes
+	}
+	return size
+}
+
+// readPage 根据令牌读取下一页
+func readPage(src io.ReadSeeker, fullPath string, info os.FileInfo, size int64, opts ReadOptions, metadata map[string]interface{}) ([]byte, error) {
+	tok, err := decodePageToken(opts.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	if tok.Path != fullPath {
+		return nil, fmt.Errorf("page token was issued for %s", tok.Path)
+	}
+	if tok.Size != info.Size() || tok.ModTime != info.ModTime().UnixNano() {
+		return nil, ErrStalePageToken
+	}
+	return readPageAt(src, fullPath, info, size, tok.Offset, tok.Line, pageSizeOrDefault(opts.PageSize), metadata)
+}
+
+// readPageAt 从 offset 读取一页，尽量在换行处截断，必要时返回下一页令牌
+func readPageAt(src io.ReadSeeker, fullPath string, info os.FileInfo, size, offset int64, line int, pageSize int64, metadata map[string]interface{}) ([]byte, error) {
+	if offset > size {
+		offset = size
+	}
+	if _, err := src.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, pageSize)
+	n, err := io.ReadFull(src, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	next := offset + int64(n)
+	if next < size {
+		// 不在行中间截断，单行超过一页时按 UTF-8 字符边界截断
+		if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+			buf = buf[:i+1]
+		} else {
+			buf = trimIncompleteRune(buf)
+		}
+		next = offset + int64(len(buf))
+	}
+
+	metadata["offset"] = offset
+	metadata["start_line"] = line
+	lines := bytes.Count(buf, []byte{'\n'})
+	metadata["end_line"] = line + lines - 1
+	if len(buf) > 0 && buf[len(buf)-1] != '\n' {
+		metadata["end_line"] = line + lines
+	}
+	if next < size {
+		metadata["truncated"] = true
+		metadata["next_page_token"] = encodePageToken(pageToken{
+			Path:    fullPath,
+			Offset:  next,
+			Line:    line + lines,
+			Size:    info.Size(),
+			ModTime: info.ModTime().UnixNano(),
+		})
+	}
+	return buf, nil
+}
+
+// readLines 读取 [start, end] 行，end 为 0 表示读到文件末尾（受 maxReadBytes 限制）
+func readLines(src io.ReadSeeker, start, end int, metadata map[string]interface{}) ([]byte, error) {
+	if start <= 0 {
+		start = 1
+	}
+	if end > 0 && end < start {
+		return nil, fmt.Errorf("invalid line range: %d-%d", start, end)
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(src)
+	var out bytes.Buffer
+	lineNo := 0
+	lastLine := 0
+	truncated := false
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNo++
+			if lineNo >= start && (end == 0 || lineNo <= end) {
+				if out.Len()+len(line) > maxReadBytes {
+					truncated = true
+					break
+				}
+				out.Write(line)
+				lastLine = lineNo
+			}
+		}
+		if err == io.EOF || (end > 0 && lineNo >= end) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	metadata["start_line"] = start
+	metadata["end_line"] = lastLine
+	if truncated {
+		metadata["truncated"] = true
+	} else if end == 0 || lineNo < end {
+		// 读到了文件末尾，行数即总行数
+		metadata["total_lines"] = lineNo
+	}
+	return out.Bytes(), nil
+}
+
+// readTail 从文件末尾向前读取最后 n 行
+func readTail(src io.ReadSeeker, size int64, n int, metadata map[string]interface{}) ([]byte, error) {
+	const block = 16 * 1024
+	var data []byte
+	pos := size
+	for pos > 0 {
+		step := int64(block)
+		if pos < step {
+			step = pos
+		}
+		pos -= step
+		if _, err := src.Seek(pos, io.SeekStart); err != nil {
+			return nil, err
+		}
+		chunk := make([]byte, step)
+		if _, err := io.ReadFull(src, chunk); err != nil {
+			return nil, err
+		}
+		data = append(chunk, data...)
+
+		// 末尾的换行不算作新的一行
+		count := bytes.Count(bytes.TrimSuffix(data, []byte{'\n'}), []byte{'\n'})
+		if count >= n || int64(len(data)) > maxReadBytes {
+			break
+		}
+	}
+
+	trimmed := bytes.TrimSuffix(data, []byte{'\n'})
+	lines := bytes.Split(trimmed, []byte{'\n'})
+	truncated := false
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	} else if pos > 0 {
+		// 超过读取上限，第一行可能不完整
+		lines = lines[1:]
+		truncated = true
+	}
+	out := bytes.Join(lines, []byte{'\n'})
+	if len(data) > len(trimmed) {
+		out = append(out, '\n')
+	}
+	metadata["tail"] = len(lines)
+	if truncated {
+		metadata["truncated"] = true
+	}
+	return out, nil
+}
+
+// readRange 按字节范围读取
+func readRange(src io.ReadSeeker, size, offset, limit int64, metadata map[string]interface{}) ([]byte, error) {
+	if offset < 0 || offset > size {
+		return nil, fmt.Errorf("offset %d out of range (size %d)", offset, size)
+	}
+	if limit <= 0 || limit > maxReadBytes {
+		limit = maxReadBytes
+	}
+	if _, err := src.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, limit)
+	n, err := io.ReadFull(src, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	metadata["offset"] = offset
+	if offset+int64(n) < size {
+		metadata["truncated"] = true
+		metadata["next_offset"] = offset + int64(n)
+	}
+	return buf[:n], nil
+}
+
+// binaryView 二进制文件只返回摘要和十六进制视图
+func binaryView(file *os.File, info os.FileInfo, sample []byte, opts ReadOptions, metadata map[string]interface{}) (string, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultHexDumpBytes
+	}
+	if limit > 64*1024 {
+		limit = 64 * 1024
+	}
+	if _, err := file.Seek(opts.Offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	buf := make([]byte, limit)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	metadata["binary"] = true
+	metadata["mime_type"] = http.DetectContentType(sample)
+	metadata["sha256"] = hex.EncodeToString(hash.Sum(nil))
+	metadata["offset"] = opts.Offset
+	if opts.Offset+int64(n) < info.Size() {
+		metadata["truncated"] = true
+		metadata["next_offset"] = opts.Offset + int64(n)
+	}
+
+	return fmt.Sprintf("binary file, %d bytes, %s\n%s", info.Size(), metadata["mime_type"], hexDumpAt(buf[:n], opts.Offset)), nil
+}
+
+// hexDumpAt 生成带实际偏移量的十六进制视图
+func hexDumpAt(data []byte, offset int64) string {
+	var sb strings.Builder
+	for i := 0; i < len(data); i += 16 {
+		end := i + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		line := data[i:end]
+		fmt.Fprintf(&sb, "%08x  ", offset+int64(i))
+		for j := 0; j < 16; j++ {
+			if j < len(line) {
+				fmt.Fprintf(&sb, "%02x ", line[j])
+			} else {
+				sb.WriteString("   ")
+			}
+			if j == 7 {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(" |")
+		for _, b := range line {
+			if b >= 0x20 && b < 0x7f {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	return sb.String()
+}
+
+// isBinary 根据样本判断是否为二进制内容
+func isBinary(sample []byte) bool {
+	if len(sample) == 0 {
+		return false
+	}
+	// 带 BOM 的 UTF-16 文本包含大量 0 字节，需要先排除
+	if bytes.HasPrefix(sample, []byte{0xFF, 0xFE}) || bytes.HasPrefix(sample, []byte{0xFE, 0xFF}) {
+		return false
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	if utf8.Valid(trimIncompleteRune(sample)) {
+		return false
+	}
+	control := 0
+	for _, b := range sample {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' && b != 0x1b {
+			control++
+		}
+	}
+	return control*10 > len(sample)
+}
+
+// detectEncoding 识别文本编码：BOM、UTF-8、GB18030，最后回退到 Latin-1
+func detectEncoding(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8-bom"
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	}
+	if utf8.Valid(trimIncompleteRune(sample)) {
+		return "utf-8"
+	}
+	if decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(sample); err == nil && !bytes.ContainsRune(decoded, utf8.RuneError) {
+		return "gb18030"
+	}
+	return "latin-1"
+}
+
+// lookupEncoding 根据名称返回解码器，UTF-8 返回 nil
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	switch name {
+	case "utf-8", "utf8":
+		return nil, nil
+	case "utf-8-bom":
+		return unicode.UTF8BOM, nil
+	case "utf-16le":
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), nil
+	case "utf-16be", "utf-16":
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM), nil
+	case "gb18030", "gbk", "gb2312":
+		return simplifiedchinese.GB18030, nil
+	case "latin-1", "latin1", "iso-8859-1":
+		return charmap.ISO8859_1, nil
+	case "windows-1252", "cp1252":
+		return charmap.Windows1252, nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", name)
+	}
+}
+
+// trimIncompleteRune 去掉末尾被截断的 UTF-8 字符
+func trimIncompleteRune(b []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		r := b[len(b)-i]
+		if !utf8.RuneStart(r) {
+			continue
+		}
+		if !utf8.FullRune(b[len(b)-i:]) {
+			return b[:len(b)-i]
+		}
+		break
+	}
+	return b
+}
@@ -0,0 +1,717 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrGitOperationNotAllowed 被安全策略拒绝的 git 操作
+var ErrGitOperationNotAllowed = errors.New("git operation not allowed")
+
+// GitConfig git 工具配置
+type GitConfig struct {
+	// AllowHistoryRewrite 允许 amend、强制删除分支、丢弃工作区修改等改写历史的操作
+	AllowHistoryRewrite bool
+	// AuthorName 和 AuthorEmail 用于提交，留空时使用仓库配置
+	AuthorName  string
+	AuthorEmail string
+	// EnableHooks 是否执行仓库中的 git hooks，默认禁用
+	EnableHooks bool
+	Timeout     time.Duration
+	MaxOutput   int
+}
+
+// GitTool 本地 git 仓库操作工具
+type GitTool struct {
+	name        string
+	description string
+	config      GitConfig
+	workspace   *Workspace
+}
+
+// NewGitTool 创建 git 工具，ws 为空时使用默认工作区
+func NewGitTool(config GitConfig, ws *Workspace) *GitTool {
+	if config.Timeout <= 0 {
+		config.Timeout = 60 * time.Second
+	}
+	if config.MaxOutput <= 0 {
+		config.MaxOutput = 100 * 1024
+	}
+	return &GitTool{
+		name:        "git",
+		description: "本地 git 仓库工具，支持 status、diff、log、show、branch、checkout、add、commit、stash、apply，不支持远程操作",
+		config:      config,
+		workspace:   ws,
+	}
+}
+
+// Name 返回工具名称
+func (t *GitTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *GitTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *GitTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// GitInput git 操作参数
+type GitInput struct {
+	Operation  string   `json:"operation"`
+	Repo       string   `json:"repo,omitempty"`
+	Paths      []string `json:"paths,omitempty"`
+	Ref        string   `json:"ref,omitempty"`
+	Base       string   `json:"base,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	StartPoint string   `json:"start_point,omitempty"`
+	Action     string   `json:"action,omitempty"`
+	Message    string   `json:"message,omitempty"`
+	Patch      string   `json:"patch,omitempty"`
+	Staged     bool     `json:"staged,omitempty"`
+	Create     bool     `json:"create,omitempty"`
+	All        bool     `json:"all,omitempty"`
+	Amend      bool     `json:"amend,omitempty"`
+	Force      bool     `json:"force,omitempty"`
+	Check      bool     `json:"check,omitempty"`
+	Index      bool     `json:"index,omitempty"`
+	MaxCount   int      `json:"max_count,omitempty"`
+}
+
+// GitResult git 操作结果
+type GitResult struct {
+	Operation string                 `json:"operation"`
+	Success   bool                   `json:"success"`
+	Output    string                 `json:"output,omitempty"`
+	Data      interface{}            `json:"data,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// GitFileStatus 文件状态
+type GitFileStatus struct {
+	Path     string `json:"path"`
+	OrigPath string `json:"orig_path,omitempty"`
+	Index    string `json:"index"`
+	Worktree string `json:"worktree"`
+}
+
+// GitStatus 仓库状态
+type GitStatus struct {
+	Branch   string          `json:"branch"`
+	Upstream string          `json:"upstream,omitempty"`
+	Ahead    int             `json:"ahead,omitempty"`
+	Behind   int             `json:"behind,omitempty"`
+	Clean    bool            `json:"clean"`
+	Files    []GitFileStatus `json:"files"`
+}
+
+// GitCommit 提交信息
+type GitCommit struct {
+	Hash    string `json:"hash"`
+	Author  string `json:"author"`
+	Email   string `json:"email"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+}
+
+// GitFileStat 文件改动统计
+type GitFileStat struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// GitBranch 分支信息
+type GitBranch struct {
+	Name    string `json:"name"`
+	Commit  string `json:"commit"`
+	Current bool   `json:"current"`
+}
+
+// 预定义的 git 操作
+const (
+	GitOpStatus   = "status"
+	GitOpDiff     = "diff"
+	GitOpLog      = "log"
+	GitOpShow     = "show"
+	GitOpBranch   = "branch"
+	GitOpCheckout = "checkout"
+	GitOpAdd      = "add"
+	GitOpCommit   = "commit"
+	GitOpStash    = "stash"
+	GitOpApply    = "apply"
+)
+
+// ExclusiveKeys 同一仓库上的操作依次执行，避免争用 index.lock
+func (t *GitTool) ExclusiveKeys(input interface{}) []string {
+	var in GitInput
+	if m, ok := input.(map[string]interface{}); ok {
+		decodeArgs(m, &in)
+	}
+	if in.Repo == "" {
+		in.Repo = "."
+	}
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return []string{"git"}
+	}
+	repo, err := ws.Resolve(in.Repo)
+	if err != nil {
+		return []string{"git"}
+	}
+	return []string{"git:" + repo}
+}
+
+// Run 执行 git 操作
+func (t *GitTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in GitInput
+	if s, ok := input.(string); ok {
+		in.Operation = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Repo == "" {
+		in.Repo = "."
+	}
+
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := ws.Resolve(in.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateGitArgs(in); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.config.Timeout)
+	defer cancel()
+
+	var data interface{}
+	var output string
+	switch in.Operation {
+	case GitOpStatus:
+		data, err = t.status(ctx, repo)
+	case GitOpDiff:
+		output, data, err = t.diff(ctx, repo, in)
+	case GitOpLog:
+		data, err = t.log(ctx, repo, in)
+	case GitOpShow:
+		output, data, err = t.show(ctx, repo, in)
+	case GitOpBranch:
+		output, data, err = t.branch(ctx, repo, in)
+	case GitOpCheckout:
+		output, err = t.checkout(ctx, repo, in)
+	case GitOpAdd:
+		output, err = t.add(ctx, repo, in)
+	case GitOpCommit:
+		output, data, err = t.commit(ctx, repo, in)
+	case GitOpStash:
+		output, data, err = t.stash(ctx, repo, in)
+	case GitOpApply:
+		output, data, err = t.apply(ctx, repo, in)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrGitOperationNotAllowed, in.Operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return GitResult{
+		Operation: in.Operation,
+		Success:   true,
+		Output:    output,
+		Data:      data,
+		Metadata: map[string]interface{}{
+			"repo": ws.Rel(repo),
+			"time": time.Now(),
+		},
+	}, nil
+}
+
+// validateGitArgs 拒绝以 "-" 开头的引用和名称，防止参数注入
+func validateGitArgs(in GitInput) error {
+	for _, v := range []string{in.Ref, in.Base, in.Name, in.StartPoint} {
+		if strings.HasPrefix(v, "-") {
+			return fmt.Errorf("invalid git argument: %s", v)
+		}
+	}
+	return nil
+}
+
+// requireRewrite 检查是否允许改写历史的操作
+func (t *GitTool) requireRewrite(what string) error {
+	if !t.config.AllowHistoryRewrite {
+		return fmt.Errorf("%w: %s requires tools.git.allow_history_rewrite", ErrGitOperationNotAllowed, what)
+	}
+	return nil
+}
+
+// git 在仓库目录中执行 git 命令
+func (t *GitTool) git(ctx context.Context, repo string, stdin []byte, args ...string) (string, error) {
+	base := []string{"-C", repo, "--no-pager", "-c", "color.ui=false", "-c", "core.quotepath=false"}
+	if !t.config.EnableHooks {
+		base = append(base, "-c", "core.hooksPath="+os.DevNull)
+	}
+	if t.config.AuthorName != "" {
+		base = append(base, "-c", "user.name="+t.config.AuthorName)
+	}
+	if t.config.AuthorEmail != "" {
+		base = append(base, "-c", "user.email="+t.config.AuthorEmail)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append(base, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C", "GIT_EDITOR=true")
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		return stdout.String(), fmt.Errorf("git %s failed: %v: %s", args[0], err, msg)
+	}
+	return stdout.String(), nil
+}
+
+// truncate 截断过长输出
+func (t *GitTool) truncate(s string) string {
+	if len(s) <= t.config.MaxOutput {
+		return s
+	}
+	return s[:t.config.MaxOutput] + fmt.Sprintf("\n[truncated %d bytes]", len(s)-t.config.MaxOutput)
+}
+
+// status 解析 porcelain v1 格式的状态
+func (t *GitTool) status(ctx context.Context, repo string) (*GitStatus, error) {
+	out, err := t.git(ctx, repo, nil, "status", "--porcelain=v1", "--branch", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	status := &GitStatus{Files: make([]GitFileStatus, 0)}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if entry == "" {
+			continue
+		}
+		if strings.HasPrefix(entry, "## ") {
+			parseBranchHeader(strings.TrimPrefix(entry, "## "), status)
+			continue
+		}
+		if len(entry) < 4 {
+			continue
+		}
+		file := GitFileStatus{
+			Index:    string(entry[0]),
+			Worktree: string(entry[1]),
+			Path:     entry[3:],
+		}
+		// 重命名和复制的下一项是原路径
+		if (entry[0] == 'R' || entry[0] == 'C') && i+1 < len(entries) {
+			file.OrigPath = entries[i+1]
+			i++
+		}
+		status.Files = append(status.Files, file)
+	}
+	status.Clean = len(status.Files) == 0
+	return status, nil
+}
+
+// parseBranchHeader 解析 "## main...origin/main [ahead 1, behind 2]"
+func parseBranchHeader(header string, status *GitStatus) {
+	if i := strings.Index(header, " ["); i >= 0 {
+		counts := strings.TrimSuffix(header[i+2:], "]")
+		header = header[:i]
+		for _, part := range strings.Split(counts, ", ") {
+			fields := strings.Fields(part)
+			if len(fields) != 2 {
+				continue
+			}
+			n, _ := strconv.Atoi(fields[1])
+			switch fields[0] {
+			case "ahead":
+				status.Ahead = n
+			case "behind":
+				status.Behind = n
+			}
+		}
+	}
+	header = strings.TrimPrefix(header, "No commits yet on ")
+	if i := strings.Index(header, "..."); i >= 0 {
+		status.Branch = header[:i]
+		status.Upstream = header[i+3:]
+	} else {
+		status.Branch = header
+	}
+}
+
+// diffArgs 构造 diff 的公共参数
+func diffArgs(in GitInput) []string {
+	var args []string
+	if in.Staged {
+		args = append(args, "--cached")
+	}
+	if in.Base != "" {
+		args = append(args, in.Base)
+	}
+	if in.Ref != "" {
+		args = append(args, in.Ref)
+	}
+	args = append(args, "--")
+	return append(args, in.Paths...)
+}
+
+// diff 返回补丁文本和每个文件的改动统计
+func (t *GitTool) diff(ctx context.Context, repo string, in GitInput) (string, []GitFileStat, error) {
+	numstat, err := t.git(ctx, repo, nil, append([]string{"diff", "--numstat", "-z"}, diffArgs(in)...)...)
+	if err != nil {
+		return "", nil, err
+	}
+	patch, err := t.git(ctx, repo, nil, append([]string{"diff"}, diffArgs(in)...)...)
+	if err != nil {
+		return "", nil, err
+	}
+	return t.truncate(patch), parseNumstat(numstat), nil
+}
+
+// parseNumstat 解析 --numstat -z 输出
+func parseNumstat(out string) []GitFileStat {
+	stats := make([]GitFileStat, 0)
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		fields := strings.SplitN(strings.TrimLeft(entries[i], "\n"), "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		stat := GitFileStat{Path: fields[2]}
+		// 重命名时路径为空，随后两项为原路径和新路径
+		if stat.Path == "" && i+2 < len(entries) {
+			stat.Path = entries[i+2]
+			i += 2
+		}
+		if fields[0] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Added, _ = strconv.Atoi(fields[0])
+			stat.Deleted, _ = strconv.Atoi(fields[1])
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// gitLogFormat 提交信息格式，字段以 0x1f 分隔，记录以 0x1e 分隔
+const gitLogFormat = "--format=%H%x1f%an%x1f%ae%x1f%aI%x1f%s%x1e"
+
+// parseCommits 解析 gitLogFormat 输出
+func parseCommits(out string) []GitCommit {
+	commits := make([]GitCommit, 0)
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		commits = append(commits, GitCommit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    fields[3],
+			Subject: fields[4],
+		})
+	}
+	return commits
+}
+
+// log 返回提交历史
+func (t *GitTool) log(ctx context.Context, repo string, in GitInput) ([]GitCommit, error) {
+	if in.MaxCount <= 0 {
+		in.MaxCount = 20
+	}
+	args := []string{"log", gitLogFormat, fmt.Sprintf("--max-count=%d", in.MaxCount)}
+	if in.Ref != "" {
+		args = append(args, in.Ref)
+	}
+	args = append(args, "--")
+	args = append(args, in.Paths...)
+	out, err := t.git(ctx, repo, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommits(out), nil
+}
+
+// show 返回提交信息和补丁
+func (t *GitTool) show(ctx context.Context, repo string, in GitInput) (string, interface{}, error) {
+	ref := in.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	meta, err := t.git(ctx, repo, nil, "show", "-s", gitLogFormat, ref, "--")
+	if err != nil {
+		return "", nil, err
+	}
+	commits := parseCommits(meta)
+	if len(commits) == 0 {
+		return "", nil, fmt.Errorf("commit %s not found", ref)
+	}
+	numstat, err := t.git(ctx, repo, nil, "show", "--format=", "--numstat", "-z", ref, "--")
+	if err != nil {
+		return "", nil, err
+	}
+	args := append([]string{"show", "--format=", "--patch", ref, "--"}, in.Paths...)
+	patch, err := t.git(ctx, repo, nil, args...)
+	if err != nil {
+		return "", nil, err
+	}
+	return t.truncate(patch), map[string]interface{}{
+		"commit": commits[0],
+		"files":  parseNumstat(numstat),
+	}, nil
+}
+
+// branch 列出、创建或删除分支
+func (t *GitTool) branch(ctx context.Context, repo string, in GitInput) (string, interface{}, error) {
+	switch in.Action {
+	case "", "list":
+		out, err := t.git(ctx, repo, nil, "branch", "--list", "--format=%(refname:short)%1f%(objectname:short)%1f%(HEAD)")
+		if err != nil {
+			return "", nil, err
+		}
+		branches := make([]GitBranch, 0)
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			fields := strings.Split(line, "\x1f")
+			if len(fields) != 3 {
+				continue
+			}
+			branches = append(branches, GitBranch{Name: fields[0], Commit: fields[1], Current: fields[2] == "*"})
+		}
+		return "", branches, nil
+	case "create":
+		if in.Name == "" {
+			return "", nil, ErrInvalidArgs
+		}
+		args := []string{"branch", in.Name}
+		if in.StartPoint != "" {
+			args = append(args, in.StartPoint)
+		}
+		out, err := t.git(ctx, repo, nil, args...)
+		return out, nil, err
+	case "delete":
+		if in.Name == "" {
+			return "", nil, ErrInvalidArgs
+		}
+		flag := "-d"
+		if in.Force {
+			// 强制删除未合并的分支会丢失提交
+			if err := t.requireRewrite("force branch delete"); err != nil {
+				return "", nil, err
+			}
+			flag = "-D"
+		}
+		out, err := t.git(ctx, repo, nil, "branch", flag, in.Name)
+		return out, nil, err
+	default:
+		return "", nil, fmt.Errorf("unsupported branch action: %s", in.Action)
+	}
+}
+
+// checkout 切换分支；指定 paths 时恢复文件，会丢弃工作区修改
+func (t *GitTool) checkout(ctx context.Context, repo string, in GitInput) (string, error) {
+	if len(in.Paths) > 0 {
+		if err := t.requireRewrite("discarding working tree changes"); err != nil {
+			return "", err
+		}
+		ref := in.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		out, err := t.git(ctx, repo, nil, append([]string{"checkout", ref, "--"}, in.Paths...)...)
+		return out, err
+	}
+
+	target := in.Ref
+	if in.Create {
+		target = in.Name
+	}
+	if target == "" {
+		return "", ErrInvalidArgs
+	}
+	args := []string{"checkout"}
+	if in.Force {
+		if err := t.requireRewrite("force checkout"); err != nil {
+			return "", err
+		}
+		args = append(args, "--force")
+	}
+	if in.Create {
+		args = append(args, "-b", in.Name)
+		if in.StartPoint != "" {
+			args = append(args, in.StartPoint)
+		}
+	} else {
+		// 与文件同名的引用会被当作路径恢复，丢弃工作区修改
+		if err := t.verifyCommit(ctx, repo, in.Ref); err != nil {
+			return "", err
+		}
+		args = append(args, in.Ref)
+	}
+	// git checkout 将进度信息写到 stderr
+	out, err := t.git(ctx, repo, nil, append(args, "--")...)
+	return out, err
+}
+
+// verifyCommit 检查引用是否指向提交
+func (t *GitTool) verifyCommit(ctx context.Context, repo, ref string) error {
+	if _, err := t.git(ctx, repo, nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return fmt.Errorf("%w: %s is not a commit", ErrInvalidArgs, ref)
+	}
+	return nil
+}
+
+// add 暂存文件
+func (t *GitTool) add(ctx context.Context, repo string, in GitInput) (string, error) {
+	if in.All {
+		return t.git(ctx, repo, nil, "add", "--all")
+	}
+	if len(in.Paths) == 0 {
+		return "", ErrInvalidArgs
+	}
+	return t.git(ctx, repo, nil, append([]string{"add", "--"}, in.Paths...)...)
+}
+
+// commit 提交暂存的修改，返回新提交
+func (t *GitTool) commit(ctx context.Context, repo string, in GitInput) (string, interface{}, error) {
+	if in.Message == "" && !in.Amend {
+		return "", nil, ErrInvalidArgs
+	}
+	args := []string{"commit", "--file=-"}
+	if in.Amend {
+		if err := t.requireRewrite("commit --amend"); err != nil {
+			return "", nil, err
+		}
+		args = append(args, "--amend")
+		if in.Message == "" {
+			args = []string{"commit", "--amend", "--no-edit"}
+		}
+	}
+	if in.All {
+		args = append(args, "--all")
+	}
+	var stdin []byte
+	if in.Message != "" {
+		stdin = []byte(in.Message)
+	}
+	out, err := t.git(ctx, repo, stdin, args...)
+	if err != nil {
+		return "", nil, err
+	}
+	meta, err := t.git(ctx, repo, nil, "show", "-s", gitLogFormat, "HEAD", "--")
+	if err != nil {
+		return "", nil, err
+	}
+	commits := parseCommits(meta)
+	if len(commits) == 0 {
+		return out, nil, nil
+	}
+	return out, commits[0], nil
+}
+
+// stash 管理暂存栈：push、pop、apply、list、drop
+func (t *GitTool) stash(ctx context.Context, repo string, in GitInput) (string, interface{}, error) {
+	switch in.Action {
+	case "", "push":
+		args := []string{"stash", "push", "--include-untracked"}
+		if in.Message != "" {
+			args = append(args, "--message", in.Message)
+		}
+		if len(in.Paths) > 0 {
+			args = append(args, "--")
+			args = append(args, in.Paths...)
+		}
+		out, err := t.git(ctx, repo, nil, args...)
+		return out, nil, err
+	case "pop", "apply":
+		args := []string{"stash", in.Action}
+		if in.Ref != "" {
+			args = append(args, in.Ref)
+		}
+		out, err := t.git(ctx, repo, nil, args...)
+		return out, nil, err
+	case "list":
+		out, err := t.git(ctx, repo, nil, "stash", "list", "--format=%gd%x1f%s")
+		if err != nil {
+			return "", nil, err
+		}
+		entries := make([]map[string]string, 0)
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			fields := strings.SplitN(line, "\x1f", 2)
+			if len(fields) != 2 {
+				continue
+			}
+			entries = append(entries, map[string]string{"ref": fields[0], "message": fields[1]})
+		}
+		return "", entries, nil
+	case "drop":
+		// 丢弃的 stash 无法恢复
+		if err := t.requireRewrite("stash drop"); err != nil {
+			return "", nil, err
+		}
+		args := []string{"stash", "drop"}
+		if in.Ref != "" {
+			args = append(args, in.Ref)
+		}
+		out, err := t.git(ctx, repo, nil, args...)
+		return out, nil, err
+	default:
+		return "", nil, fmt.Errorf("unsupported stash action: %s", in.Action)
+	}
+}
+
+// apply 应用补丁，check 为 true 时只检查能否应用
+func (t *GitTool) apply(ctx context.Context, repo string, in GitInput) (string, interface{}, error) {
+	if in.Patch == "" {
+		return "", nil, ErrInvalidArgs
+	}
+	patch := []byte(in.Patch)
+	if !bytes.HasSuffix(patch, []byte("\n")) {
+		patch = append(patch, '\n')
+	}
+
+	base := []string{"apply", "--whitespace=nowarn", "--recount"}
+	if in.Index {
+		base = append(base, "--index")
+	}
+	numstat, err := t.git(ctx, repo, patch, append(base, "--check", "--numstat", "-z", "-")...)
+	if err != nil {
+		return "", nil, err
+	}
+	files := parseNumstat(numstat)
+	if in.Check {
+		return "patch applies cleanly", files, nil
+	}
+	out, err := t.git(ctx, repo, patch, append(base, "-")...)
+	if err != nil {
+		return "", nil, err
+	}
+	return out, files, nil
+}
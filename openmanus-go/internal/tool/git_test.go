@@ -0,0 +1,248 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestGitRepo 在临时工作区中初始化仓库并提交一个文件；找不到 git 时跳过
+func newTestGitRepo(t *testing.T, config GitConfig) (*GitTool, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	ws := newTestWorkspace(t)
+	config.AuthorName = "Test"
+	config.AuthorEmail = "test@example.com"
+	gt := NewGitTool(config, ws)
+
+	ctx := context.Background()
+	root := ws.Root()
+	if _, err := gt.git(ctx, root, nil, "init", "-q", "-b", "main"); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, root, "a.txt", "one\ntwo\n")
+	if _, err := gt.git(ctx, root, nil, "add", "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gt.git(ctx, root, []byte("initial"), "commit", "-q", "--file=-"); err != nil {
+		t.Fatal(err)
+	}
+	return gt, root
+}
+
+func writeTestFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runGit(t *testing.T, gt *GitTool, args map[string]interface{}) (GitResult, error) {
+	t.Helper()
+	out, err := gt.Run(context.Background(), args)
+	if err != nil {
+		return GitResult{}, err
+	}
+	return out.(GitResult), nil
+}
+
+func TestParseBranchHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		want   GitStatus
+	}{
+		{"main", GitStatus{Branch: "main"}},
+		{"main...origin/main", GitStatus{Branch: "main", Upstream: "origin/main"}},
+		{"main...origin/main [ahead 2]", GitStatus{Branch: "main", Upstream: "origin/main", Ahead: 2}},
+		{"dev...origin/dev [ahead 1, behind 3]", GitStatus{Branch: "dev", Upstream: "origin/dev", Ahead: 1, Behind: 3}},
+		{"No commits yet on main", GitStatus{Branch: "main"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			var got GitStatus
+			parseBranchHeader(tt.header, &got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumstat(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want []GitFileStat
+	}{
+		{"empty", "", []GitFileStat{}},
+		{"modified", "3\t1\ta.txt\x00", []GitFileStat{{Path: "a.txt", Added: 3, Deleted: 1}}},
+		{"binary", "-\t-\timg.png\x00", []GitFileStat{{Path: "img.png", Binary: true}}},
+		{"rename", "0\t0\t\x00old.txt\x00new.txt\x00", []GitFileStat{{Path: "new.txt"}}},
+		{
+			"multiple",
+			"1\t0\ta.txt\x002\t2\tdir/b.txt\x00",
+			[]GitFileStat{{Path: "a.txt", Added: 1}, {Path: "dir/b.txt", Added: 2, Deleted: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNumstat(tt.out); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommits(t *testing.T) {
+	out := "abc\x1fAlice\x1fa@example.com\x1f2024-01-02T03:04:05Z\x1ffirst\x1e\n" +
+		"def\x1fBob\x1fb@example.com\x1f2024-01-03T03:04:05Z\x1fsecond\x1e\n"
+	got := parseCommits(out)
+	if len(got) != 2 || got[0].Hash != "abc" || got[1].Author != "Bob" || got[1].Subject != "second" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestGitRejectsOptionLikeArgs(t *testing.T) {
+	gt, _ := newTestGitRepo(t, GitConfig{AllowHistoryRewrite: true})
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+	}{
+		{"ref", map[string]interface{}{"operation": GitOpCheckout, "ref": "--orphan=x"}},
+		{"base", map[string]interface{}{"operation": GitOpDiff, "base": "--output=/tmp/x"}},
+		{"name", map[string]interface{}{"operation": GitOpBranch, "action": "create", "name": "-f"}},
+		{"start point", map[string]interface{}{"operation": GitOpBranch, "action": "create", "name": "x", "start_point": "-h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runGit(t, gt, tt.args)
+			if err == nil || !strings.Contains(err.Error(), "invalid git argument") {
+				t.Fatalf("got %v, want invalid git argument", err)
+			}
+		})
+	}
+}
+
+func TestGitHistoryRewriteGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		without error
+		with    error
+	}{
+		{"force branch delete", map[string]interface{}{"operation": GitOpBranch, "action": "delete", "name": "feature", "force": true}, ErrGitOperationNotAllowed, nil},
+		{"force checkout", map[string]interface{}{"operation": GitOpCheckout, "ref": "feature", "force": true}, ErrGitOperationNotAllowed, nil},
+		{"checkout paths", map[string]interface{}{"operation": GitOpCheckout, "paths": []interface{}{"a.txt"}}, ErrGitOperationNotAllowed, nil},
+		{"amend", map[string]interface{}{"operation": GitOpCommit, "amend": true, "message": "rewritten"}, ErrGitOperationNotAllowed, nil},
+		// 与文件同名的引用不能绕过检查恢复文件
+		{"checkout ref naming a file", map[string]interface{}{"operation": GitOpCheckout, "ref": "a.txt"}, ErrInvalidArgs, ErrInvalidArgs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gt, root := newTestGitRepo(t, GitConfig{})
+			if _, err := gt.git(context.Background(), root, nil, "branch", "feature"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := runGit(t, gt, tt.args); !errors.Is(err, tt.without) {
+				t.Fatalf("without allow_history_rewrite: got %v, want %v", err, tt.without)
+			}
+
+			// 修改工作区，被拒绝的操作不能丢弃修改
+			writeTestFile(t, root, "a.txt", "changed\n")
+			gt.config.AllowHistoryRewrite = true
+			_, err := runGit(t, gt, tt.args)
+			if tt.with == nil && err != nil {
+				t.Fatalf("with allow_history_rewrite: %v", err)
+			}
+			if tt.with != nil {
+				if !errors.Is(err, tt.with) {
+					t.Fatalf("with allow_history_rewrite: got %v, want %v", err, tt.with)
+				}
+				if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "changed\n" {
+					t.Fatalf("working tree change discarded: %q", data)
+				}
+			}
+		})
+	}
+}
+
+func TestGitAmendRewritesHead(t *testing.T) {
+	gt, _ := newTestGitRepo(t, GitConfig{AllowHistoryRewrite: true})
+	res, err := runGit(t, gt, map[string]interface{}{"operation": GitOpCommit, "amend": true, "message": "rewritten"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := res.Data.(GitCommit); c.Subject != "rewritten" {
+		t.Fatalf("HEAD subject = %q", c.Subject)
+	}
+	log, err := runGit(t, gt, map[string]interface{}{"operation": GitOpLog})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commits := log.Data.([]GitCommit); len(commits) != 1 {
+		t.Fatalf("amend should not add a commit: %+v", commits)
+	}
+}
+
+func TestGitStatusAndDiff(t *testing.T) {
+	gt, root := newTestGitRepo(t, GitConfig{})
+
+	res, err := runGit(t, gt, map[string]interface{}{"operation": GitOpStatus})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := res.Data.(*GitStatus); !st.Clean || st.Branch != "main" {
+		t.Fatalf("clean status = %+v", st)
+	}
+
+	writeTestFile(t, root, "a.txt", "one\nTWO\nthree\n")
+	writeTestFile(t, root, "dir/new.txt", "new\n")
+	if _, err := gt.git(context.Background(), root, nil, "mv", "a.txt", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = runGit(t, gt, map[string]interface{}{"operation": GitOpStatus})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := res.Data.(*GitStatus)
+	want := []GitFileStatus{
+		{Path: "b.txt", OrigPath: "a.txt", Index: "R", Worktree: "M"},
+		{Path: "dir/new.txt", Index: "?", Worktree: "?"},
+	}
+	if st.Clean || !reflect.DeepEqual(st.Files, want) {
+		t.Fatalf("status files = %+v", st.Files)
+	}
+
+	res, err = runGit(t, gt, map[string]interface{}{"operation": GitOpDiff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := res.Data.([]GitFileStat)
+	if !reflect.DeepEqual(stats, []GitFileStat{{Path: "b.txt", Added: 2, Deleted: 1}}) {
+		t.Fatalf("diff stats = %+v", stats)
+	}
+	if !strings.Contains(res.Output, "+TWO") {
+		t.Fatalf("diff output = %q", res.Output)
+	}
+
+	res, err = runGit(t, gt, map[string]interface{}{"operation": GitOpDiff, "staged": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats = res.Data.([]GitFileStat)
+	if !reflect.DeepEqual(stats, []GitFileStat{{Path: "b.txt"}}) {
+		t.Fatalf("staged diff stats = %+v", stats)
+	}
+}
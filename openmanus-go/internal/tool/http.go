@@ -0,0 +1,380 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP 工具的默认限制
+const (
+	defaultHTTPTimeout     = 30 * time.Second
+	defaultHTTPMaxResponse = 100000
+)
+
+// 认证方式
+const (
+	HTTPAuthNone   = "none"
+	HTTPAuthBearer = "bearer"
+	HTTPAuthBasic  = "basic"
+	HTTPAuthAPIKey = "api_key"
+)
+
+// HTTPAuth 请求使用的认证信息；字符串中的 ${VAR} 从环境变量展开
+type HTTPAuth struct {
+	Type     string
+	Token    string
+	Username string
+	Password string
+	// Name、In 用于 api_key：参数名和位置（header、query、cookie）
+	Name string
+	In   string
+}
+
+// apply 把认证信息附加到请求，查询参数写入 query
+func (a HTTPAuth) apply(req *http.Request, query url.Values) error {
+	switch strings.ToLower(a.Type) {
+	case "", HTTPAuthNone:
+	case HTTPAuthBearer:
+		req.Header.Set("Authorization", "Bearer "+os.ExpandEnv(a.Token))
+	case HTTPAuthBasic:
+		req.SetBasicAuth(os.ExpandEnv(a.Username), os.ExpandEnv(a.Password))
+	case HTTPAuthAPIKey:
+		if a.Name == "" {
+			return fmt.Errorf("%w: api_key auth needs a parameter name", ErrInvalidArgs)
+		}
+		key := os.ExpandEnv(a.Token)
+		switch a.In {
+		case "query":
+			query.Set(a.Name, key)
+		case "cookie":
+			req.AddCookie(&http.Cookie{Name: a.Name, Value: key})
+		default:
+			req.Header.Set(a.Name, key)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported auth type %q, use bearer, basic or api_key", ErrInvalidArgs, a.Type)
+	}
+	return nil
+}
+
+// headerName api_key 放在请求头中时的头名称，重定向到其他主机时需要移除
+func (a HTTPAuth) headerName() string {
+	if strings.ToLower(a.Type) == HTTPAuthAPIKey && a.In != "query" && a.In != "cookie" {
+		return a.Name
+	}
+	return ""
+}
+
+// HTTPAuthProfile 命名的认证配置，模型只能通过名称引用，密钥不进入提示词
+type HTTPAuthProfile struct {
+	HTTPAuth
+	// Hosts 允许使用该配置的主机名通配，为空时不限制
+	Hosts []string
+}
+
+// HTTPConfig HTTP 工具配置
+type HTTPConfig struct {
+	Hosts HostPolicy
+	// Auth 按名称引用的认证配置
+	Auth map[string]HTTPAuthProfile
+	// Headers 每个请求附加的请求头，例如 User-Agent
+	Headers     map[string]string
+	Timeout     time.Duration
+	MaxResponse int
+}
+
+var (
+	defaultHTTPConfig   = HTTPConfig{Hosts: HostPolicy{BlockPrivate: true}}
+	defaultHTTPConfigMu sync.RWMutex
+)
+
+// SetDefaultHTTPConfig 设置 HTTP 工具默认配置，WebScraperTool、APITool 同样使用其中的主机限制
+func SetDefaultHTTPConfig(config HTTPConfig) {
+	defaultHTTPConfigMu.Lock()
+	defer defaultHTTPConfigMu.Unlock()
+	defaultHTTPConfig = config
+}
+
+// DefaultHTTPConfig 返回 HTTP 工具默认配置
+func DefaultHTTPConfig() HTTPConfig {
+	defaultHTTPConfigMu.RLock()
+	defer defaultHTTPConfigMu.RUnlock()
+	return defaultHTTPConfig
+}
+
+// HTTPTool 发送 HTTP 请求，受主机限制，通过命名配置认证
+type HTTPTool struct {
+	name        string
+	description string
+	config      HTTPConfig
+	client      *http.Client
+}
+
+// NewHTTPTool 创建 HTTP 请求工具
+func NewHTTPTool(config HTTPConfig) *HTTPTool {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultHTTPTimeout
+	}
+	if config.MaxResponse <= 0 {
+		config.MaxResponse = defaultHTTPMaxResponse
+	}
+	t := &HTTPTool{
+		name:   "http_request",
+		config: config,
+		client: NewHTTPClient(config.Hosts, config.Timeout),
+	}
+	t.description = "发送 HTTP 请求（GET、POST、PUT、PATCH、DELETE、HEAD、OPTIONS），参数：method、url、headers、query、json（JSON 请求体）或 body（文本请求体）、auth（认证配置名）、max_response"
+	if names := t.authNames(); len(names) > 0 {
+		t.description += "；可用的认证配置：" + strings.Join(names, ", ")
+	}
+	return t
+}
+
+func (t *HTTPTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *HTTPTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *HTTPTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+func (t *HTTPTool) authNames() []string {
+	names := make([]string, 0, len(t.config.Auth))
+	for name := range t.config.Auth {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HTTPInput 请求参数
+type HTTPInput struct {
+	Method  string                 `json:"method"`
+	URL     string                 `json:"url"`
+	Headers map[string]string      `json:"headers,omitempty"`
+	Query   map[string]interface{} `json:"query,omitempty"`
+	// JSON 作为 JSON 编码的请求体
+	JSON interface{} `json:"json,omitempty"`
+	// Body 原样发送的请求体
+	Body string `json:"body,omitempty"`
+	// Auth 认证配置名
+	Auth        string `json:"auth,omitempty"`
+	MaxResponse int    `json:"max_response,omitempty"`
+}
+
+// HTTPResponse 请求结果；非 2xx 状态同样作为结果返回
+type HTTPResponse struct {
+	URL         string            `json:"url"`
+	StatusCode  int               `json:"status_code"`
+	Status      string            `json:"status"`
+	ContentType string            `json:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body"`
+	// Truncated 响应体超过上限被截断
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// String 返回状态行和响应体
+func (r HTTPResponse) String() string {
+	return fmt.Sprintf("HTTP %s\n%s", r.Status, r.Body)
+}
+
+// Cacheable 状态码表示失败的响应不缓存
+func (r HTTPResponse) Cacheable() bool {
+	return r.StatusCode < 400
+}
+
+var httpMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// 响应中返回给模型的头
+var httpResponseHeaders = []string{"Content-Type", "Content-Length", "Location", "Last-Modified", "ETag", "Retry-After", "Link"}
+
+// Run 发送请求
+func (t *HTTPTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in HTTPInput
+	if s, ok := input.(string); ok {
+		in.URL = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	req, err := t.buildRequest(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %v", req.Method, in.URL, err)
+	}
+	defer resp.Body.Close()
+
+	limit := t.config.MaxResponse
+	if in.MaxResponse > 0 && in.MaxResponse < limit {
+		limit = in.MaxResponse
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	result := HTTPResponse{
+		URL:         resp.Request.URL.String(),
+		StatusCode:  resp.StatusCode,
+		Status:      resp.Status,
+		ContentType: resp.Header.Get("Content-Type"),
+		Headers:     make(map[string]string),
+	}
+	for _, h := range httpResponseHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			result.Headers[h] = v
+		}
+	}
+	truncated := len(data) > limit
+	if truncated {
+		data = data[:limit]
+	}
+	switch {
+	case isBinary(data[:min(len(data), 8000)]):
+		result.Body = fmt.Sprintf("[binary content, %s]", result.ContentType)
+	case truncated:
+		// 统计剩余字节数用于提示
+		rest, _ := io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024*1024))
+		kept := trimIncompleteRune(data)
+		result.Truncated = true
+		result.Body = fmt.Sprintf("%s\n...[truncated %d bytes]", kept, rest+1+int64(len(data)-len(kept)))
+	default:
+		result.Body = string(data)
+	}
+	return result, nil
+}
+
+// buildRequest 检查主机和认证配置并组装请求
+func (t *HTTPTool) buildRequest(ctx context.Context, in HTTPInput) (*http.Request, error) {
+	method := strings.ToUpper(in.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !httpMethods[method] {
+		return nil, fmt.Errorf("%w: unsupported method %q", ErrInvalidArgs, in.Method)
+	}
+	u, err := url.Parse(in.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%w: invalid url %q", ErrInvalidArgs, in.URL)
+	}
+	if err := t.config.Hosts.CheckURL(u); err != nil {
+		return nil, err
+	}
+
+	var auth HTTPAuth
+	if in.Auth != "" {
+		profile, ok := t.config.Auth[in.Auth]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown auth profile %q, available: %s", ErrInvalidArgs, in.Auth, strings.Join(t.authNames(), ", "))
+		}
+		if len(profile.Hosts) > 0 && !matchHostRules(profile.Hosts, strings.ToLower(u.Hostname())) {
+			return nil, fmt.Errorf("%w: auth profile %q cannot be used for %s", ErrHostBlocked, in.Auth, u.Hostname())
+		}
+		auth = profile.HTTPAuth
+	}
+
+	var body io.Reader
+	contentType := ""
+	switch {
+	case in.JSON != nil && in.Body != "":
+		return nil, fmt.Errorf("%w: use either json or body", ErrInvalidArgs)
+	case in.JSON != nil:
+		// 模型可能把 JSON 作为字符串传入
+		data, ok := []byte(nil), false
+		if s, isString := in.JSON.(string); isString && json.Valid([]byte(s)) {
+			data, ok = []byte(s), true
+		}
+		if !ok {
+			if data, err = json.Marshal(in.JSON); err != nil {
+				return nil, fmt.Errorf("%w: encode json body: %v", ErrInvalidArgs, err)
+			}
+		}
+		body = bytes.NewReader(data)
+		contentType = "application/json"
+	case in.Body != "":
+		body = strings.NewReader(in.Body)
+	}
+
+	query := u.Query()
+	for k, v := range in.Query {
+		for _, s := range paramValues(v) {
+			query.Add(k, s)
+		}
+	}
+	// 重定向到其他主机时移除放在自定义请求头中的密钥
+	if name := auth.headerName(); name != "" {
+		ctx = withSensitiveHeaders(ctx, name)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.config.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	for k, v := range in.Headers {
+		req.Header.Set(k, v)
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if err := auth.apply(req, query); err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = query.Encode()
+	return req, nil
+}
+
+// CachePolicy 没有请求体的 GET 请求可以缓存；带认证的结果不写入持久缓存
+func (t *HTTPTool) CachePolicy(input interface{}) (CachePolicy, bool) {
+	var in HTTPInput
+	if s, ok := input.(string); ok {
+		in.URL = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return CachePolicy{}, false
+	}
+	method := strings.ToUpper(in.Method)
+	if (method != "" && method != http.MethodGet) || in.JSON != nil || in.Body != "" {
+		return CachePolicy{}, false
+	}
+	return CachePolicy{Persistent: in.Auth == ""}, true
+}
+
+type sensitiveHeadersKey struct{}
+
+// withSensitiveHeaders 标记重定向到其他主机时需要移除的请求头
+func withSensitiveHeaders(ctx context.Context, names ...string) context.Context {
+	return context.WithValue(ctx, sensitiveHeadersKey{}, names)
+}
+
+// stripSensitiveHeaders 重定向到其他主机时移除标记的请求头
+func stripSensitiveHeaders(req *http.Request, via []*http.Request) {
+	names, _ := req.Context().Value(sensitiveHeadersKey{}).([]string)
+	if len(names) == 0 || len(via) == 0 || req.URL.Host == via[0].URL.Host {
+		return
+	}
+	for _, name := range names {
+		req.Header.Del(name)
+	}
+}
@@ -0,0 +1,201 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrHostBlocked 目标主机不在允许列表中、在禁止列表中或解析到内网地址
+var ErrHostBlocked = errors.New("host blocked by policy")
+
+// 最多跟随的重定向次数
+const maxHTTPRedirects = 10
+
+// HostPolicy 出站 HTTP 请求的主机限制
+type HostPolicy struct {
+	// Allow 允许的主机名通配或 CIDR，为空时允许所有主机
+	Allow []string
+	// Deny 禁止的主机名通配或 CIDR，优先于 Allow
+	Deny []string
+	// BlockPrivate 禁止连接回环、内网、链路本地（含云元数据服务）等地址
+	BlockPrivate bool
+}
+
+// CheckURL 检查 URL 的协议和主机名；主机名解析后的地址在建立连接时再检查
+func (p HostPolicy) CheckURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrHostBlocked, u.Scheme)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrHostBlocked)
+	}
+	if matchHostRules(p.Deny, host) {
+		return fmt.Errorf("%w: %s is denied", ErrHostBlocked, host)
+	}
+	if len(p.Allow) > 0 && !matchHostRules(p.Allow, host) {
+		return fmt.Errorf("%w: %s is not in the allow list", ErrHostBlocked, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIP(host, ip)
+	}
+	if p.BlockPrivate && (host == "localhost" || strings.HasSuffix(host, ".localhost")) && !p.allowsPrivate(host) {
+		return fmt.Errorf("%w: %s is a local address", ErrHostBlocked, host)
+	}
+	return nil
+}
+
+// allowsPrivate 主机名被 Allow 显式列出时允许解析到内网地址，通配 "*" 除外
+func (p HostPolicy) allowsPrivate(host string) bool {
+	for _, r := range p.Allow {
+		if r != "*" && matchHostRules([]string{r}, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIP 检查实际连接的地址，host 为请求中的主机名
+func (p HostPolicy) checkIP(host string, ip net.IP) error {
+	if matchIPRules(p.Deny, ip) {
+		return fmt.Errorf("%w: %s (%s) is denied", ErrHostBlocked, host, ip)
+	}
+	if p.BlockPrivate && isPrivateIP(ip) && !matchIPRules(p.Allow, ip) && !p.allowsPrivate(host) {
+		return fmt.Errorf("%w: %s resolves to private address %s", ErrHostBlocked, host, ip)
+	}
+	return nil
+}
+
+// matchHostRules 主机名是否匹配任一通配；CIDR 规则匹配 IP 形式的主机名
+func matchHostRules(rules []string, host string) bool {
+	ip := net.ParseIP(host)
+	for _, r := range rules {
+		r = strings.ToLower(strings.TrimSpace(r))
+		if _, cidr, err := net.ParseCIDR(r); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(r, host); ok {
+			return true
+		}
+		// "*.example.com" 同时匹配 example.com
+		if strings.HasPrefix(r, "*.") && host == r[2:] {
+			return true
+		}
+	}
+	return false
+}
+
+// matchIPRules 地址是否在任一 CIDR 或 IP 规则中
+func matchIPRules(rules []string, ip net.IP) bool {
+	for _, r := range rules {
+		r = strings.TrimSpace(r)
+		if _, cidr, err := net.ParseCIDR(r); err == nil {
+			if cidr.Contains(ip) {
+				return true
+			}
+		} else if rip := net.ParseIP(r); rip != nil && rip.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// 共享地址空间（运营商 NAT）和 IPv4 映射以外的特殊地址段
+var extraPrivateNets = []*net.IPNet{
+	mustCIDR("100.64.0.0/10"),
+	mustCIDR("198.18.0.0/15"),
+	mustCIDR("0.0.0.0/8"),
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// isPrivateIP 回环、内网、链路本地、未指定和组播地址
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, n := range extraPrivateNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewHTTPClient 创建受主机策略限制的 HTTP 客户端：每次重定向前检查 URL，
+// 建立连接时检查解析出的地址，防止通过 DNS 指向内网绕过限制；不使用环境变量中的代理
+func NewHTTPClient(policy HostPolicy, timeout time.Duration) *http.Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			host = strings.ToLower(strings.TrimSuffix(host, "."))
+			// Control 在域名解析之后、建立连接之前执行，检查的是实际连接的地址
+			dialer := &net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+				Control: func(network, address string, _ syscall.RawConn) error {
+					ipStr, _, err := net.SplitHostPort(address)
+					if err != nil {
+						return err
+					}
+					ip := net.ParseIP(ipStr)
+					if ip == nil {
+						return fmt.Errorf("%w: unexpected address %s", ErrHostBlocked, address)
+					}
+					return policy.checkIP(host, ip)
+				},
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &guardedTransport{policy: policy, next: transport},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxHTTPRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxHTTPRedirects)
+			}
+			stripSensitiveHeaders(req, via)
+			return policy.CheckURL(req.URL)
+		},
+	}
+}
+
+// guardedTransport 在发送前检查 URL，覆盖直接构造的请求和重定向
+type guardedTransport struct {
+	policy HostPolicy
+	next   http.RoundTripper
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,207 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		private bool
+	}{
+		{"127.0.0.1", true},
+		{"127.8.8.8", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"172.31.255.255", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"198.18.0.1", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"::", true},
+		{"fe80::1", true},
+		{"fc00::1", true},
+		{"fd12:3456::1", true},
+		{"ff02::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"100.128.0.1", false},
+		{"93.184.216.34", false},
+		{"2606:4700::1111", false},
+		{"::ffff:8.8.8.8", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("bad test ip %s", tt.ip)
+			}
+			if got := isPrivateIP(ip); got != tt.private {
+				t.Fatalf("isPrivateIP = %v, want %v", got, tt.private)
+			}
+		})
+	}
+}
+
+func TestHostPolicyCheckURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  HostPolicy
+		url     string
+		blocked bool
+	}{
+		{"public", HostPolicy{BlockPrivate: true}, "https://example.com/x", false},
+		{"scheme", HostPolicy{}, "file:///etc/passwd", true},
+		{"ftp", HostPolicy{}, "ftp://example.com/", true},
+		{"missing host", HostPolicy{}, "http:///x", true},
+		{"loopback ip", HostPolicy{BlockPrivate: true}, "http://127.0.0.1:8080/", true},
+		{"loopback allowed without block", HostPolicy{}, "http://127.0.0.1/", false},
+		{"metadata", HostPolicy{BlockPrivate: true}, "http://169.254.169.254/latest/meta-data", true},
+		{"ipv6 loopback", HostPolicy{BlockPrivate: true}, "http://[::1]/", true},
+		{"mapped ipv4", HostPolicy{BlockPrivate: true}, "http://[::ffff:127.0.0.1]/", true},
+		{"localhost", HostPolicy{BlockPrivate: true}, "http://localhost/", true},
+		{"localhost trailing dot", HostPolicy{BlockPrivate: true}, "http://LOCALHOST./", true},
+		{"sub localhost", HostPolicy{BlockPrivate: true}, "http://app.localhost/", true},
+		{"explicitly allowed private host", HostPolicy{BlockPrivate: true, Allow: []string{"localhost"}}, "http://localhost/", false},
+		{"allowed private cidr", HostPolicy{BlockPrivate: true, Allow: []string{"10.0.0.0/8"}}, "http://10.1.2.3/", false},
+		{"wildcard allow keeps private blocked", HostPolicy{BlockPrivate: true, Allow: []string{"*"}}, "http://127.0.0.1/", true},
+		{"deny wildcard", HostPolicy{Deny: []string{"*.internal"}}, "http://db.internal/", true},
+		{"deny wildcard apex", HostPolicy{Deny: []string{"*.internal"}}, "http://internal/", true},
+		{"deny beats allow", HostPolicy{Allow: []string{"*.example.com"}, Deny: []string{"admin.example.com"}}, "https://admin.example.com/", true},
+		{"allow list", HostPolicy{Allow: []string{"*.example.com"}}, "https://api.example.com/", false},
+		{"not in allow list", HostPolicy{Allow: []string{"*.example.com"}}, "https://example.org/", true},
+		{"deny cidr", HostPolicy{Deny: []string{"203.0.113.0/24"}}, "http://203.0.113.5/", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tt.policy.CheckURL(u)
+			if tt.blocked != (err != nil) {
+				t.Fatalf("CheckURL = %v, want blocked %v", err, tt.blocked)
+			}
+			if err != nil && !errors.Is(err, ErrHostBlocked) {
+				t.Fatalf("got %v, want ErrHostBlocked", err)
+			}
+		})
+	}
+}
+
+func TestHTTPClientBlocksPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	type guardCase struct {
+		name    string
+		policy  HostPolicy
+		url     string
+		blocked bool
+	}
+	tests := []guardCase{
+		{"ip literal", HostPolicy{BlockPrivate: true}, srv.URL, true},
+		{"localhost", HostPolicy{BlockPrivate: true, Allow: []string{"*"}}, "http://localhost:" + port, true},
+		{"allowed by cidr", HostPolicy{BlockPrivate: true, Allow: []string{"127.0.0.0/8"}}, srv.URL, false},
+		{"no restriction", HostPolicy{}, srv.URL, false},
+	}
+	// 本机主机名通过 URL 检查，解析到回环地址后在建立连接前被拦截
+	if host, err := os.Hostname(); err == nil && !strings.Contains(host, "localhost") {
+		if ips, err := net.LookupIP(host); err == nil && len(ips) > 0 && isPrivateIP(ips[0]) {
+			tests = append(tests, guardCase{"name resolving to private address", HostPolicy{BlockPrivate: true}, "http://" + net.JoinHostPort(host, port), true})
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewHTTPClient(tt.policy, 5*time.Second).Get(tt.url)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if tt.blocked != (err != nil) {
+				t.Fatalf("Get = %v, want blocked %v", err, tt.blocked)
+			}
+			if err != nil && !errors.Is(err, ErrHostBlocked) {
+				t.Fatalf("got %v, want ErrHostBlocked", err)
+			}
+		})
+	}
+}
+
+func TestHTTPClientRedirects(t *testing.T) {
+	seen := make(chan string, 4)
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- r.Header.Get("X-Api-Key")
+	}))
+	defer other.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/cross":
+			http.Redirect(w, r, other.URL+"/final", http.StatusFound)
+		case "/metadata":
+			http.Redirect(w, r, "http://169.254.169.254/latest", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			seen <- r.Header.Get("X-Api-Key")
+		}
+	}))
+	defer origin.Close()
+
+	get := func(policy HostPolicy, path string) error {
+		ctx := withSensitiveHeaders(context.Background(), "X-Api-Key")
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, origin.URL+path, nil)
+		req.Header.Set("X-Api-Key", "secret")
+		resp, err := NewHTTPClient(policy, 5*time.Second).Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"/same", "secret"},
+		{"/cross", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if err := get(HostPolicy{}, tt.path); err != nil {
+				t.Fatal(err)
+			}
+			if got := <-seen; got != tt.key {
+				t.Fatalf("X-Api-Key after redirect = %q, want %q", got, tt.key)
+			}
+		})
+	}
+
+	// 重定向目标同样受主机策略限制
+	policy := HostPolicy{BlockPrivate: true, Allow: []string{"127.0.0.1"}}
+	if err := get(policy, "/metadata"); !errors.Is(err, ErrHostBlocked) {
+		t.Fatalf("redirect to metadata endpoint: %v", err)
+	}
+	if err := get(HostPolicy{}, "/loop"); err == nil {
+		t.Fatal("redirect loop should stop")
+	}
+}
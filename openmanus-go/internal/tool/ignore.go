@@ -0,0 +1,205 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// alwaysIgnoredDirs 搜索时总是跳过的目录
+var alwaysIgnoredDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	"node_modules": true,
+}
+
+// ignoreRule 单条 .gitignore 规则
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreMatcher 按目录加载并缓存 .gitignore 规则
+type ignoreMatcher struct {
+	ws    *Workspace
+	mu    sync.Mutex
+	rules map[string][]ignoreRule
+}
+
+// newIgnoreMatcher 创建忽略规则匹配器
+func newIgnoreMatcher(ws *Workspace) *ignoreMatcher {
+	return &ignoreMatcher{
+		ws:    ws,
+		rules: make(map[string][]ignoreRule),
+	}
+}
+
+// load 读取目录下的 .gitignore，dir 为相对工作区的路径
+func (m *ignoreMatcher) load(dir string) []ignoreRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	file, err := os.Open(filepath.Join(m.ws.Root(), filepath.FromSlash(dir), ".gitignore"))
+	if err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+				rules = append(rules, rule)
+			}
+		}
+		file.Close()
+	}
+	m.rules[dir] = rules
+	return rules
+}
+
+// parseIgnoreRule 解析一行 .gitignore
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	line = strings.TrimPrefix(line, `\`)
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.HasPrefix(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	} else if strings.Contains(line, "/") && !strings.HasPrefix(line, "**/") {
+		// 中间含有 "/" 的规则相对 .gitignore 所在目录
+		rule.anchored = true
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.pattern = line
+	return rule, true
+}
+
+// Ignored 判断相对工作区的路径是否被忽略，规则按目录由浅到深依次应用，后出现的规则优先
+func (m *ignoreMatcher) Ignored(rel string, isDir bool) bool {
+	if rel == "." || rel == "" {
+		return false
+	}
+	if isDir && alwaysIgnoredDirs[path.Base(rel)] {
+		return true
+	}
+	if m.ws.trashDir != "" && matchPathRule(m.ws.trashDir, rel) {
+		return true
+	}
+	for _, pattern := range m.ws.deny {
+		if matchPathRule(pattern, rel) {
+			return true
+		}
+	}
+
+	ignored := false
+	dir := "."
+	parts := strings.Split(rel, "/")
+	for i := 0; i < len(parts); i++ {
+		sub := strings.Join(parts[i:], "/")
+		for _, rule := range m.load(dir) {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.matches(sub) {
+				ignored = !rule.negate
+			}
+		}
+		dir = path.Join(dir, parts[i])
+	}
+	return ignored
+}
+
+// matches 判断规则是否匹配相对 .gitignore 所在目录的路径
+func (r ignoreRule) matches(sub string) bool {
+	if r.anchored {
+		return matchGlob(r.pattern, sub)
+	}
+	return matchGlob(r.pattern, path.Base(sub))
+}
+
+// matchGlob 支持 "**" 的 glob 匹配，"**" 匹配任意层级目录
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
+
+// walkWorkspace 遍历工作区中的目录，跳过被忽略的文件和目录；fn 收到相对工作区的路径
+func walkWorkspace(ctx context.Context, ws *Workspace, start string, fn func(rel string, d fs.DirEntry) error) error {
+	root, err := ws.Resolve(start)
+	if err != nil {
+		return err
+	}
+	matcher := newIgnoreMatcher(ws)
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 无权限等错误跳过该项继续遍历
+			if d != nil && d.IsDir() && p != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel := ws.Rel(p)
+		if p != root && matcher.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// 不跟随指向工作区外部的符号链接
+		if d.Type()&fs.ModeSymlink != 0 {
+			if _, err := ws.Resolve(rel); err != nil {
+				return nil
+			}
+		}
+		return fn(rel, d)
+	})
+}
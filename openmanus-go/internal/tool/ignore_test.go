@@ -0,0 +1,103 @@
+package tool
+
+import (
+	"testing"
+)
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+		want ignoreRule
+	}{
+		{"", false, ignoreRule{}},
+		{"# comment", false, ignoreRule{}},
+		{"/", false, ignoreRule{}},
+		{"*.log  ", true, ignoreRule{pattern: "*.log"}},
+		{"!keep.log", true, ignoreRule{pattern: "keep.log", negate: true}},
+		{`\!literal`, true, ignoreRule{pattern: "!literal"}},
+		{"out/", true, ignoreRule{pattern: "out", dirOnly: true}},
+		{"/build", true, ignoreRule{pattern: "build", anchored: true}},
+		{"docs/*.tmp", true, ignoreRule{pattern: "docs/*.tmp", anchored: true}},
+		{"**/cache", true, ignoreRule{pattern: "**/cache"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseIgnoreRule(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Fatalf("parseIgnoreRule(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	ws := newTestWorkspace(t)
+	writeTestFile(t, ws.Root(), ".gitignore", "*.log\n!keep.log\n/build\ndocs/*.tmp\nout/\n**/cache\n")
+	writeTestFile(t, ws.Root(), "sub/.gitignore", "*.txt\n!important.txt\n/local\n")
+	m := newIgnoreMatcher(ws)
+
+	tests := []struct {
+		name    string
+		rel     string
+		isDir   bool
+		ignored bool
+	}{
+		{"root", ".", true, false},
+		{"unanchored pattern", "a.log", false, true},
+		{"unanchored pattern in subdir", "sub/deep/x.log", false, true},
+		{"negation", "keep.log", false, false},
+		{"negation in subdir", "sub/keep.log", false, false},
+		{"anchored at root", "build", true, true},
+		{"anchored not in subdir", "sub/build", true, false},
+		{"pattern with slash", "docs/a.tmp", false, true},
+		{"pattern with slash is anchored", "sub/docs/a.tmp", false, false},
+		{"dir only matches dir", "out", true, true},
+		{"dir only skips file", "out", false, false},
+		{"double star", "a/b/cache", true, true},
+		{"nested gitignore", "sub/a.txt", false, true},
+		{"nested gitignore scope", "a.txt", false, false},
+		{"nested negation", "sub/important.txt", false, false},
+		{"nested anchored", "sub/local", true, true},
+		{"nested anchored not deeper", "sub/deep/local", true, false},
+		{"always ignored dir", ".git", true, true},
+		{"node_modules", "web/node_modules", true, true},
+		{"deny", ".env", false, true},
+		{"deny glob", "keys/server.pem", false, true},
+		{"trash", ".trash/x", false, true},
+		{"plain file", "main.go", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Ignored(tt.rel, tt.isDir); got != tt.ignored {
+				t.Fatalf("Ignored(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.ignored)
+			}
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "a/main.go", false},
+		{"**/*.go", "a/b/main.go", true},
+		{"**/*.go", "main.go", true},
+		{"a/**", "a/b/c", true},
+		{"a/**/c", "a/c", true},
+		{"a/**/c", "a/b/d/c", true},
+		{"a/**/c", "a/b/d", false},
+		{"a/?.txt", "a/b.txt", true},
+		{"a/[bc].txt", "a/d.txt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if got := matchGlob(tt.pattern, tt.name); got != tt.match {
+				t.Fatalf("matchGlob = %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
@@ -28,6 +28,23 @@ func RegisterDefaultTools() {
 	// 注册字符串处理工具
 	reg.Register(NewStrReplaceTool())
 
+	// 注册补丁工具
+	reg.Register(NewPatchTool(nil))
+
+	// 注册代码检索工具
+	reg.Register(NewGlobTool(nil))
+	reg.Register(NewGrepTool(nil))
+	reg.Register(NewGoSymbolTool(nil))
+
+	// 注册文档读取工具
+	reg.Register(NewDocumentTool(nil))
+
+	// 注册 HTTP 请求工具
+	reg.Register(NewHTTPTool(DefaultHTTPConfig()))
+
+	// 注册网站爬取工具
+	reg.Register(NewCrawlTool(nil, DefaultCrawlConfig()))
+
 	// 注册 Python 执行工具
 	// 注意：Python 执行工具需要 Python 服务 URL
 	// 这里暂时不注册，等待 Python 服务配置完成后再注册
@@ -40,7 +57,17 @@ func ExecuteTool(ctx context.Context, toolName string, args map[string]interface
 	if !ok {
 		return nil, ErrInvalidOperation
 	}
-	return tool.Execute(ctx, args)
+	return wrapTool(toolName, registryTool{tool}, DefaultMiddleware()).Run(ctx, args)
+}
+
+// registryTool 把 ITool 适配为 Tool，使注册表中的工具同样经过默认中间件
+type registryTool struct {
+	ITool
+}
+
+func (t registryTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	args, _ := input.(map[string]interface{})
+	return t.Execute(ctx, args)
 }
 
 // ListTools 列出所有已注册的工具
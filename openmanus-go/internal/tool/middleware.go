@@ -0,0 +1,354 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 中间件产生的错误
+var (
+	ErrToolTimeout = errors.New("tool timed out")
+	ErrToolPanic   = errors.New("tool panicked")
+)
+
+// ToolCall 一次工具调用
+type ToolCall struct {
+	// Name 工具在集合中注册的名称
+	Name  string
+	Tool  Tool
+	Input interface{}
+}
+
+// ToolHandler 执行工具调用
+type ToolHandler func(ctx context.Context, call *ToolCall) (interface{}, error)
+
+// ToolMiddleware 包装 ToolHandler，在调用前后附加通用逻辑
+type ToolMiddleware func(next ToolHandler) ToolHandler
+
+var (
+	defaultMiddleware   []ToolMiddleware
+	defaultMiddlewareMu sync.RWMutex
+)
+
+// SetDefaultMiddleware 设置新建工具集合默认使用的中间件
+func SetDefaultMiddleware(mw ...ToolMiddleware) {
+	defaultMiddlewareMu.Lock()
+	defer defaultMiddlewareMu.Unlock()
+	defaultMiddleware = mw
+}
+
+// DefaultMiddleware 返回默认中间件
+func DefaultMiddleware() []ToolMiddleware {
+	defaultMiddlewareMu.RLock()
+	defer defaultMiddlewareMu.RUnlock()
+	return append([]ToolMiddleware(nil), defaultMiddleware...)
+}
+
+// chainMiddleware 组合中间件，mw[0] 在最外层
+func chainMiddleware(mw []ToolMiddleware, h ToolHandler) ToolHandler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+func invokeTool(ctx context.Context, call *ToolCall) (interface{}, error) {
+	return call.Tool.Run(ctx, call.Input)
+}
+
+// middlewareTool 经过中间件链调用的工具
+type middlewareTool struct {
+	name    string
+	tool    Tool
+	handler ToolHandler
+}
+
+func wrapTool(name string, tool Tool, mw []ToolMiddleware) Tool {
+	if len(mw) == 0 {
+		return tool
+	}
+	return &middlewareTool{name: name, tool: tool, handler: chainMiddleware(mw, invokeTool)}
+}
+
+func (t *middlewareTool) Name() string {
+	return t.tool.Name()
+}
+
+func (t *middlewareTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	return t.handler(ctx, &ToolCall{Name: t.name, Tool: t.tool, Input: input})
+}
+
+// UnwrapTool 返回中间件包装下的原始工具，用于类型断言
+func UnwrapTool(t Tool) Tool {
+	if w, ok := t.(*middlewareTool); ok {
+		return w.tool
+	}
+	return t
+}
+
+// MiddlewareConfig 标准中间件配置
+type MiddlewareConfig struct {
+	// Timeout 默认调用超时，0 表示不限制
+	Timeout time.Duration
+	// Timeouts 按工具名覆盖超时，0 表示不限制
+	Timeouts map[string]time.Duration
+	// MaxOutput 输出的最大字节数，0 表示不限制
+	MaxOutput int
+	Logger    *logrus.Logger
+	Metrics   *ToolMetrics
+	// Policy 调用前的权限检查，为空时不检查
+	Policy *PolicyEngine
+}
+
+// StandardMiddleware 返回标准中间件链：日志、指标、权限策略、超时、输出截断、panic 恢复（由外到内）；
+// 策略在超时之外，等待审批的时间不计入调用超时
+func StandardMiddleware(config MiddlewareConfig) []ToolMiddleware {
+	mw := []ToolMiddleware{LoggingMiddleware(config.Logger)}
+	if config.Metrics != nil {
+		mw = append(mw, MetricsMiddleware(config.Metrics))
+	}
+	if config.Policy != nil {
+		mw = append(mw, PolicyMiddleware(config.Policy))
+	}
+	mw = append(mw, TimeoutMiddleware(config.Timeout, config.Timeouts))
+	if config.MaxOutput > 0 {
+		mw = append(mw, TruncateMiddleware(config.MaxOutput))
+	}
+	return append(mw, RecoverMiddleware())
+}
+
+// LoggingMiddleware 记录每次调用的工具名、耗时、输出大小和错误
+func LoggingMiddleware(logger *logrus.Logger) ToolMiddleware {
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (interface{}, error) {
+			start := time.Now()
+			out, err := next(ctx, call)
+			entry := logger.WithFields(logrus.Fields{
+				"tool":        call.Name,
+				"duration_ms": time.Since(start).Milliseconds(),
+			})
+			if err != nil {
+				entry.WithError(err).Warn("tool call failed")
+			} else {
+				entry.WithField("output_bytes", outputSize(out)).Debug("tool call finished")
+			}
+			return out, err
+		}
+	}
+}
+
+// TimeoutMiddleware 限制调用时长；工具不响应 ctx 取消时调用方仍按时返回，工具在后台结束
+func TimeoutMiddleware(timeout time.Duration, perTool map[string]time.Duration) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (interface{}, error) {
+			// 配置文件中的键会被转换为小写
+			d := timeout
+			if v, ok := perTool[call.Name]; ok {
+				d = v
+			} else if v, ok := perTool[strings.ToLower(call.Name)]; ok {
+				d = v
+			}
+			if d <= 0 {
+				return next(ctx, call)
+			}
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			type result struct {
+				out interface{}
+				err error
+			}
+			done := make(chan result, 1)
+			go func() {
+				out, err := next(ctx, call)
+				done <- result{out, err}
+			}()
+			select {
+			case r := <-done:
+				if r.err != nil && ctx.Err() == context.DeadlineExceeded {
+					return r.out, fmt.Errorf("%w: %s after %s: %v", ErrToolTimeout, call.Name, d, r.err)
+				}
+				return r.out, r.err
+			case <-ctx.Done():
+				if ctx.Err() == context.DeadlineExceeded {
+					return nil, fmt.Errorf("%w: %s after %s", ErrToolTimeout, call.Name, d)
+				}
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
+
+// RecoverMiddleware 把工具中的 panic 转换为错误
+func RecoverMiddleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (out interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logrus.WithField("tool", call.Name).Errorf("tool panicked: %v\n%s", r, debug.Stack())
+					out, err = nil, fmt.Errorf("%w: %s: %v", ErrToolPanic, call.Name, r)
+				}
+			}()
+			return next(ctx, call)
+		}
+	}
+}
+
+// TruncateMiddleware 截断超过 maxBytes 的输出，末尾附加 "[truncated N bytes]"；
+// 非字符串结果超长时转换为截断后的文本
+func TruncateMiddleware(maxBytes int) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (interface{}, error) {
+			out, err := next(ctx, call)
+			if out == nil {
+				return out, err
+			}
+			var text string
+			switch v := out.(type) {
+			case string:
+				if len(v) <= maxBytes {
+					return out, err
+				}
+				text = v
+			case []byte:
+				if len(v) <= maxBytes {
+					return out, err
+				}
+				text = string(v)
+			default:
+				text = fmt.Sprintf("%v", out)
+				if len(text) <= maxBytes {
+					return out, err
+				}
+			}
+			return truncateOutput(text, maxBytes), err
+		}
+	}
+}
+
+// truncateOutput 按字节截断，不切断 UTF-8 字符
+func truncateOutput(s string, maxBytes int) string {
+	cut := maxBytes
+	for cut > 0 && cut < len(s) && s[cut]&0xc0 == 0x80 {
+		cut--
+	}
+	return fmt.Sprintf("%s\n...[truncated %d bytes]", s[:cut], len(s)-cut)
+}
+
+// outputSize 估算输出大小，结构化结果按字符串形式计算
+func outputSize(out interface{}) int {
+	switch v := out.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	default:
+		return len(fmt.Sprintf("%v", v))
+	}
+}
+
+// ToolStats 单个工具的调用统计
+type ToolStats struct {
+	Calls     int64         `json:"calls"`
+	Errors    int64         `json:"errors"`
+	Timeouts  int64         `json:"timeouts"`
+	Panics    int64         `json:"panics"`
+	Total     time.Duration `json:"total"`
+	Max       time.Duration `json:"max"`
+	LastError string        `json:"last_error,omitempty"`
+}
+
+// Average 平均耗时
+func (s ToolStats) Average() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Calls)
+}
+
+// ToolMetrics 按工具名汇总调用次数、错误和耗时
+type ToolMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*ToolStats
+}
+
+// NewToolMetrics 创建指标收集器
+func NewToolMetrics() *ToolMetrics {
+	return &ToolMetrics{stats: make(map[string]*ToolStats)}
+}
+
+func (m *ToolMetrics) record(name string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.stats[name]
+	if !ok {
+		s = &ToolStats{}
+		m.stats[name] = s
+	}
+	s.Calls++
+	s.Total += d
+	s.Max = max(s.Max, d)
+	if err != nil {
+		s.Errors++
+		s.LastError = err.Error()
+		switch {
+		case errors.Is(err, ErrToolTimeout):
+			s.Timeouts++
+		case errors.Is(err, ErrToolPanic):
+			s.Panics++
+		}
+	}
+}
+
+// Snapshot 返回当前统计的副本
+func (m *ToolMetrics) Snapshot() map[string]ToolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]ToolStats, len(m.stats))
+	for name, s := range m.stats {
+		out[name] = *s
+	}
+	return out
+}
+
+// String 按工具名输出统计表
+func (m *ToolMetrics) String() string {
+	snapshot := m.Snapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		s := snapshot[name]
+		fmt.Fprintf(&sb, "%-20s calls=%d errors=%d timeouts=%d panics=%d avg=%s max=%s\n",
+			name, s.Calls, s.Errors, s.Timeouts, s.Panics, s.Average().Round(time.Millisecond), s.Max.Round(time.Millisecond))
+	}
+	return sb.String()
+}
+
+// MetricsMiddleware 把每次调用计入 metrics
+func MetricsMiddleware(metrics *ToolMetrics) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (interface{}, error) {
+			start := time.Now()
+			out, err := next(ctx, call)
+			metrics.record(call.Name, time.Since(start), err)
+			return out, err
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// stubTool 由函数实现的工具
+type stubTool struct {
+	name string
+	run  func(ctx context.Context, input interface{}) (interface{}, error)
+}
+
+func (t *stubTool) Name() string { return t.name }
+
+func (t *stubTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	return t.run(ctx, input)
+}
+
+// sleepTool 等待 d 或 ctx 取消；ignoreCtx 时不响应取消
+func sleepTool(name string, d time.Duration, ignoreCtx bool) *stubTool {
+	return &stubTool{name: name, run: func(ctx context.Context, input interface{}) (interface{}, error) {
+		if ignoreCtx {
+			time.Sleep(d)
+			return "done", nil
+		}
+		select {
+		case <-time.After(d):
+			return "done", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}}
+}
+
+func panicTool(name string) *stubTool {
+	return &stubTool{name: name, run: func(ctx context.Context, input interface{}) (interface{}, error) {
+		panic("kaboom")
+	}}
+}
+
+func textTool(name, text string) *stubTool {
+	return &stubTool{name: name, run: func(ctx context.Context, input interface{}) (interface{}, error) {
+		return text, nil
+	}}
+}
+
+func callWith(mw []ToolMiddleware, tool Tool) (interface{}, error) {
+	return wrapTool(tool.Name(), tool, mw).Run(context.Background(), nil)
+}
+
+func TestChainMiddlewareOrder(t *testing.T) {
+	var trace []string
+	record := func(name string) ToolMiddleware {
+		return func(next ToolHandler) ToolHandler {
+			return func(ctx context.Context, call *ToolCall) (interface{}, error) {
+				trace = append(trace, name+" in")
+				out, err := next(ctx, call)
+				trace = append(trace, name+" out")
+				return out, err
+			}
+		}
+	}
+	tc := NewToolCollection()
+	tc.Use(record("a"), record("b"))
+	tc.Use(record("c"))
+	tc.Register("text", textTool("text", "ok"))
+	tool, err := tc.Get("text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := tool.Run(context.Background(), nil); err != nil || out != "ok" {
+		t.Fatalf("Run = %v, %v", out, err)
+	}
+	want := "a in,b in,c in,c out,b out,a out"
+	if got := strings.Join(trace, ","); got != want {
+		t.Fatalf("trace = %s, want %s", got, want)
+	}
+	if tool.Name() != "text" || UnwrapTool(tool).(*stubTool).name != "text" {
+		t.Fatal("wrapped tool does not expose the original")
+	}
+}
+
+func TestStandardMiddleware(t *testing.T) {
+	if n := len(StandardMiddleware(MiddlewareConfig{})); n != 3 {
+		t.Fatalf("minimal chain has %d middleware, want logging, timeout, recover", n)
+	}
+
+	var logs bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logs)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(logrus.DebugLevel)
+
+	// 审批在 100ms 后通过，超过调用超时
+	broker := NewApprovalBroker()
+	broker.Subscribe(func(req ApprovalRequest) {
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			broker.Decide(req.ID, Approval{Approved: true, By: "alice"})
+		}()
+	})
+	policy := newTestPolicy(t, PolicyConfig{
+		Approver: broker,
+		Rules: []PolicyRule{
+			{Name: "ask", Tool: "asked", Action: PolicyAsk},
+			{Name: "deny", Tool: "denied", Action: PolicyDeny},
+		},
+	})
+	metrics := NewToolMetrics()
+	mw := StandardMiddleware(MiddlewareConfig{
+		Timeout:   50 * time.Millisecond,
+		MaxOutput: 8,
+		Logger:    logger,
+		Metrics:   metrics,
+		Policy:    policy,
+	})
+	if len(mw) != 6 {
+		t.Fatalf("full chain has %d middleware", len(mw))
+	}
+
+	// 策略在超时之外：等待审批的时间不计入超时
+	if out, err := callWith(mw, textTool("asked", "ok")); err != nil || out != "ok" {
+		t.Fatalf("approved call = %v, %v", out, err)
+	}
+	// 策略拒绝时不调用工具，拒绝计入指标
+	called := false
+	denied := &stubTool{name: "denied", run: func(ctx context.Context, input interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}}
+	if _, err := callWith(mw, denied); !errors.Is(err, ErrPolicyDenied) || called {
+		t.Fatalf("denied call = %v, tool called %v", err, called)
+	}
+	if _, err := callWith(mw, sleepTool("slow", time.Second, false)); !errors.Is(err, ErrToolTimeout) {
+		t.Fatalf("slow call = %v", err)
+	}
+	// panic 恢复在最内层：超时中间件的 goroutine 中的 panic 同样被恢复
+	if _, err := callWith(mw, panicTool("panics")); !errors.Is(err, ErrToolPanic) {
+		t.Fatalf("panicking call = %v", err)
+	}
+	if out, err := callWith(mw, textTool("long", "0123456789")); err != nil || out != "01234567\n...[truncated 2 bytes]" {
+		t.Fatalf("long output = %q, %v", out, err)
+	}
+
+	// 指标在策略、超时和恢复之外，记录各自的错误类型
+	stats := metrics.Snapshot()
+	if s := stats["denied"]; s.Calls != 1 || s.Errors != 1 || !strings.Contains(s.LastError, "policy") {
+		t.Fatalf("denied stats = %+v", s)
+	}
+	if s := stats["slow"]; s.Timeouts != 1 || s.Errors != 1 {
+		t.Fatalf("slow stats = %+v", s)
+	}
+	if s := stats["panics"]; s.Panics != 1 || s.Errors != 1 {
+		t.Fatalf("panics stats = %+v", s)
+	}
+	if s := stats["asked"]; s.Calls != 1 || s.Errors != 0 || s.Total < 100*time.Millisecond {
+		t.Fatalf("asked stats = %+v", s)
+	}
+
+	// 日志在最外层，每次调用一条记录
+	entries := make(map[string]map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		if name, ok := entry["tool"].(string); ok && entry["duration_ms"] != nil {
+			entries[name] = entry
+		}
+	}
+	if len(entries) != 5 {
+		t.Fatalf("logged calls = %v", entries)
+	}
+	if e := entries["panics"]; e["level"] != "warning" || !strings.Contains(e["error"].(string), "tool panicked") {
+		t.Fatalf("panic log = %v", e)
+	}
+	if e := entries["long"]; e["level"] != "debug" || e["output_bytes"] != float64(len("01234567\n...[truncated 2 bytes]")) {
+		t.Fatalf("truncated output log = %v", e)
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	mw := []ToolMiddleware{TimeoutMiddleware(50*time.Millisecond, map[string]time.Duration{
+		"ask_human": 0,
+		"build":     300 * time.Millisecond,
+		"fast":      10 * time.Millisecond,
+	})}
+	tests := []struct {
+		name    string
+		tool    Tool
+		timeout string
+	}{
+		{"default timeout", sleepTool("shell", time.Second, false), "50ms"},
+		{"tool ignoring ctx", sleepTool("shell", time.Second, true), "50ms"},
+		// 0 表示不限制
+		{"no limit", sleepTool("ask_human", 150*time.Millisecond, false), ""},
+		{"longer override", sleepTool("build", 150*time.Millisecond, false), ""},
+		{"shorter override", sleepTool("fast", 30*time.Millisecond, false), "10ms"},
+		// 配置文件中的键为小写
+		{"override case-insensitive", sleepTool("Build", 150*time.Millisecond, false), ""},
+		{"within default", sleepTool("shell", 10*time.Millisecond, false), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			out, err := callWith(mw, tt.tool)
+			if tt.timeout == "" {
+				if err != nil || out != "done" {
+					t.Fatalf("got %v, %v", out, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrToolTimeout) || !strings.Contains(err.Error(), "after "+tt.timeout) {
+				t.Fatalf("got %v, want timeout after %s", err, tt.timeout)
+			}
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Fatalf("timeout returned after %s", elapsed)
+			}
+		})
+	}
+
+	// 调用方取消不算超时
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := wrapTool("shell", sleepTool("shell", time.Second, false), mw).Run(ctx, nil)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrToolTimeout) {
+		t.Fatalf("canceled call = %v", err)
+	}
+}
+
+func TestTruncateMiddleware(t *testing.T) {
+	mw := []ToolMiddleware{TruncateMiddleware(10)}
+	type result struct{ Text string }
+	tests := []struct {
+		name string
+		out  interface{}
+		want interface{}
+	}{
+		{"short string", "short", "short"},
+		{"exact limit", "0123456789", "0123456789"},
+		{"long string", "0123456789abcdef", "0123456789\n...[truncated 6 bytes]"},
+		// 不切断多字节字符
+		{"utf-8 boundary", "12345678你好", "12345678\n...[truncated 6 bytes]"},
+		{"bytes", []byte("0123456789ab"), "0123456789\n...[truncated 2 bytes]"},
+		{"short struct", result{"ab"}, result{"ab"}},
+		{"long struct", result{"0123456789"}, "{012345678\n...[truncated 2 bytes]"},
+		{"nil", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &stubTool{name: "out", run: func(ctx context.Context, input interface{}) (interface{}, error) {
+				return tt.out, errors.New("partial")
+			}}
+			out, err := callWith(mw, tool)
+			if err == nil || err.Error() != "partial" {
+				t.Fatalf("error not passed through: %v", err)
+			}
+			if out != tt.want {
+				t.Fatalf("output = %#v, want %#v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	out, err := callWith([]ToolMiddleware{RecoverMiddleware()}, panicTool("bad"))
+	if out != nil || !errors.Is(err, ErrToolPanic) || err.Error() != "tool panicked: bad: kaboom" {
+		t.Fatalf("got %v, %v", out, err)
+	}
+	if out, err := callWith([]ToolMiddleware{RecoverMiddleware()}, textTool("ok", "fine")); err != nil || out != "fine" {
+		t.Fatalf("got %v, %v", out, err)
+	}
+}
@@ -0,0 +1,578 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPI 工具的默认限制
+const (
+	defaultOpenAPITimeout     = 30 * time.Second
+	defaultOpenAPIMaxResponse = 20000
+)
+
+// 认证方式
+const (
+	OpenAPIAuthNone   = HTTPAuthNone
+	OpenAPIAuthBearer = HTTPAuthBearer
+	OpenAPIAuthBasic  = HTTPAuthBasic
+	OpenAPIAuthAPIKey = HTTPAuthAPIKey
+)
+
+// OpenAPIAuth 调用服务时使用的认证信息；api_key 的 Name、In 为空时取规范中 apiKey 安全方案的定义
+type OpenAPIAuth = HTTPAuth
+
+// OpenAPIServiceConfig 一个 OpenAPI 服务的配置
+type OpenAPIServiceConfig struct {
+	// Name 服务名，作为工具名前缀
+	Name string
+	// Spec 规范文件路径或 http(s) 地址
+	Spec string
+	// BaseURL 覆盖规范中的 servers
+	BaseURL string
+	// Operations 只生成匹配的操作，支持 operationId、tag 或 "GET /path" 形式的通配
+	Operations []string
+	// Exclude 跳过匹配的操作
+	Exclude     []string
+	Headers     map[string]string
+	Auth        OpenAPIAuth
+	Timeout     time.Duration
+	MaxResponse int
+}
+
+// OpenAPITool 由 OpenAPI 操作生成的工具，每个操作对应一个工具
+type OpenAPITool struct {
+	name        string
+	description string
+	method      string
+	path        string
+	baseURL     string
+	params      []openAPIParameter
+	body        *openAPIRequestBody
+	bodyType    string
+	apiKey      *openAPISecurityScheme
+	service     OpenAPIServiceConfig
+	client      *http.Client
+}
+
+// LoadOpenAPITools 读取服务的 OpenAPI 规范，为每个操作生成一个工具
+func LoadOpenAPITools(ctx context.Context, service OpenAPIServiceConfig) ([]*OpenAPITool, error) {
+	if service.Spec == "" {
+		return nil, fmt.Errorf("%w: openapi service %q has no spec", ErrInvalidArgs, service.Name)
+	}
+	doc, err := loadOpenAPISpec(ctx, service.Spec)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := openAPIBaseURL(service, doc)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := doc.operations()
+	if err != nil {
+		return nil, fmt.Errorf("openapi spec %s: %v", service.Spec, err)
+	}
+	if service.Timeout <= 0 {
+		service.Timeout = defaultOpenAPITimeout
+	}
+	if service.MaxResponse <= 0 {
+		service.MaxResponse = defaultOpenAPIMaxResponse
+	}
+	client := &http.Client{Timeout: service.Timeout}
+
+	var tools []*OpenAPITool
+	seen := make(map[string]int)
+	for _, r := range routes {
+		if !service.selects(r) {
+			continue
+		}
+		name := operationToolName(service.Name, r)
+		if n := seen[name]; n > 0 {
+			suffix := "_" + strconv.Itoa(n+1)
+			name = name[:min(len(name), 64-len(suffix))] + suffix
+		}
+		seen[name]++
+
+		t := &OpenAPITool{
+			name:    name,
+			method:  r.method,
+			path:    r.path,
+			baseURL: baseURL,
+			params:  r.op.Parameters,
+			body:    r.op.RequestBody,
+			service: service,
+			client:  client,
+		}
+		if t.body != nil {
+			t.bodyType = requestBodyType(t.body)
+		}
+		t.apiKey = doc.apiKeyScheme(r.op)
+		t.description = t.describe(r.op)
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// RegisterOpenAPITools 加载多个服务的工具并注册到工具集合，返回注册的工具名
+func RegisterOpenAPITools(ctx context.Context, tc *ToolCollection, services []OpenAPIServiceConfig) ([]string, error) {
+	var names []string
+	for _, s := range services {
+		tools, err := LoadOpenAPITools(ctx, s)
+		if err != nil {
+			return names, fmt.Errorf("openapi service %q: %w", s.Name, err)
+		}
+		for _, t := range tools {
+			if err := tc.Register(t.Name(), t); err != nil {
+				return names, err
+			}
+			names = append(names, t.Name())
+		}
+	}
+	return names, nil
+}
+
+// openAPIBaseURL 确定服务地址；规范中的相对地址相对于规范的下载地址
+func openAPIBaseURL(service OpenAPIServiceConfig, doc *openAPIDocument) (string, error) {
+	base := service.BaseURL
+	if base == "" {
+		base = doc.serverURL()
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid base url %q: %v", base, err)
+	}
+	if !u.IsAbs() {
+		spec, err := url.Parse(service.Spec)
+		if err != nil || !spec.IsAbs() || service.BaseURL != "" {
+			return "", fmt.Errorf("%w: openapi service %q needs an absolute base_url", ErrInvalidArgs, service.Name)
+		}
+		u = spec.ResolveReference(u)
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// selects 判断操作是否在配置的范围内；默认跳过已废弃的操作
+func (s OpenAPIServiceConfig) selects(r openAPIRoute) bool {
+	match := func(patterns []string) bool {
+		keys := append([]string{r.op.OperationID, r.method + " " + r.path}, r.op.Tags...)
+		for _, p := range patterns {
+			for _, k := range keys {
+				if ok, _ := path.Match(p, k); ok && k != "" {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if match(s.Exclude) {
+		return false
+	}
+	if len(s.Operations) > 0 {
+		return match(s.Operations)
+	}
+	return !r.op.Deprecated
+}
+
+// apiKeyScheme 返回操作（或全局）要求的 apiKey 安全方案
+func (d *openAPIDocument) apiKeyScheme(op openAPIOperation) *openAPISecurityScheme {
+	reqs := d.Security
+	if op.Security != nil {
+		reqs = *op.Security
+	}
+	var names []string
+	for _, req := range reqs {
+		for name := range req {
+			names = append(names, name)
+		}
+	}
+	// 操作未声明安全要求时，退回到组件中定义的第一个 apiKey 方案
+	if len(names) == 0 {
+		for name := range d.Components.SecuritySchemes {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if s, ok := d.Components.SecuritySchemes[name]; ok && s.Type == "apiKey" {
+			return &s
+		}
+	}
+	return nil
+}
+
+// requestBodyType 选择请求体的内容类型，优先 JSON
+func requestBodyType(body *openAPIRequestBody) string {
+	types := make([]string, 0, len(body.Content))
+	for ct := range body.Content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+	for _, ct := range types {
+		if ct == "application/json" || strings.HasSuffix(ct, "+json") {
+			return ct
+		}
+	}
+	for _, ct := range types {
+		if ct == "application/x-www-form-urlencoded" {
+			return ct
+		}
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return "application/json"
+}
+
+// describe 生成工具描述，附带参数摘要
+func (t *OpenAPITool) describe(op openAPIOperation) string {
+	var sb strings.Builder
+	summary := strings.TrimSpace(op.Summary)
+	if summary == "" {
+		summary = strings.TrimSpace(op.Description)
+	}
+	if len(summary) > 300 {
+		summary = summary[:300] + "..."
+	}
+	fmt.Fprintf(&sb, "%s %s", t.method, t.path)
+	if summary != "" {
+		sb.WriteString(": " + summary)
+	}
+	var params []string
+	for _, p := range t.params {
+		s := fmt.Sprintf("%s (%s", p.Name, p.In)
+		if typ, ok := p.Schema["type"].(string); ok {
+			s += ", " + typ
+		}
+		if p.Required || p.In == "path" {
+			s += ", required"
+		}
+		params = append(params, s+")")
+	}
+	if t.body != nil {
+		s := "body (" + t.bodyType
+		if t.body.Required {
+			s += ", required"
+		}
+		params = append(params, s+")")
+	}
+	if len(params) > 0 {
+		sb.WriteString("。参数: " + strings.Join(params, ", "))
+	}
+	return sb.String()
+}
+
+// Name 返回工具名称
+func (t *OpenAPITool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *OpenAPITool) Description() string {
+	return t.description
+}
+
+// Parameters 返回工具参数的 JSON Schema；请求体放在 body 字段中
+func (t *OpenAPITool) Parameters() map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	for _, p := range t.params {
+		schema, _ := toJSONSchema(p.Schema).(map[string]interface{})
+		if schema == nil {
+			schema = map[string]interface{}{"type": "string"}
+		}
+		if p.Description != "" {
+			schema["description"] = p.Description
+		}
+		props[p.Name] = schema
+		if p.Required || p.In == "path" {
+			required = append(required, p.Name)
+		}
+	}
+	if t.body != nil {
+		schema, _ := toJSONSchema(t.body.Content[t.bodyType].Schema).(map[string]interface{})
+		if schema == nil {
+			schema = map[string]interface{}{}
+		}
+		if t.body.Description != "" {
+			schema["description"] = t.body.Description
+		}
+		props["body"] = schema
+		if t.body.Required {
+			required = append(required, "body")
+		}
+	}
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// Execute 执行工具功能
+func (t *OpenAPITool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// OpenAPIResponse 接口响应
+type OpenAPIResponse struct {
+	StatusCode  int    `json:"status_code"`
+	Status      string `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+	// Truncated 响应体超过上限被截断
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// String 返回状态行和响应体
+func (r OpenAPIResponse) String() string {
+	return fmt.Sprintf("HTTP %s\n%s", r.Status, r.Body)
+}
+
+// Run 调用接口
+func (t *OpenAPITool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	args, ok := input.(map[string]interface{})
+	if !ok {
+		if input != nil {
+			return nil, ErrInvalidArgs
+		}
+		args = map[string]interface{}{}
+	}
+	req, err := t.buildRequest(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %v", t.method, t.path, err)
+	}
+	defer resp.Body.Close()
+
+	limit := t.service.MaxResponse
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	result := OpenAPIResponse{
+		StatusCode:  resp.StatusCode,
+		Status:      resp.Status,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if len(data) > limit {
+		// 统计剩余字节数用于提示
+		rest, _ := io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024*1024))
+		data = data[:limit]
+		result.Truncated = true
+		result.Body = fmt.Sprintf("%s\n...[truncated %d bytes]", data, rest+1)
+	} else {
+		result.Body = string(data)
+	}
+	return result, nil
+}
+
+// buildRequest 根据参数位置组装请求
+func (t *OpenAPITool) buildRequest(ctx context.Context, args map[string]interface{}) (*http.Request, error) {
+	p := t.path
+	query := url.Values{}
+	header := http.Header{}
+	var cookies []*http.Cookie
+
+	for _, param := range t.params {
+		v, ok := args[param.Name]
+		if !ok || v == nil {
+			if param.Required || param.In == "path" {
+				return nil, fmt.Errorf("%w: missing required %s parameter %q", ErrInvalidArgs, param.In, param.Name)
+			}
+			continue
+		}
+		switch param.In {
+		case "path":
+			p = strings.ReplaceAll(p, "{"+param.Name+"}", url.PathEscape(strings.Join(paramValues(v), ",")))
+		case "query":
+			addQueryParam(query, param, v)
+		case "header":
+			switch http.CanonicalHeaderKey(param.Name) {
+			case "Accept", "Content-Type", "Authorization":
+				// OpenAPI 规定这些头由请求体和安全方案决定
+			default:
+				header.Set(param.Name, strings.Join(paramValues(v), ","))
+			}
+		case "cookie":
+			cookies = append(cookies, &http.Cookie{Name: param.Name, Value: strings.Join(paramValues(v), ",")})
+		}
+	}
+
+	var body io.Reader
+	if t.body != nil {
+		if v, ok := args["body"]; ok && v != nil {
+			data, contentType, err := encodeRequestBody(t.bodyType, v)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(data)
+			header.Set("Content-Type", contentType)
+		} else if t.body.Required {
+			return nil, fmt.Errorf("%w: missing required request body", ErrInvalidArgs)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, t.method, t.baseURL+p, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.service.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json, */*;q=0.8")
+	}
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	if err := t.authorize(req, query); err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = query.Encode()
+	return req, nil
+}
+
+// authorize 按配置附加认证信息
+func (t *OpenAPITool) authorize(req *http.Request, query url.Values) error {
+	auth := t.service.Auth
+	if strings.ToLower(auth.Type) == OpenAPIAuthAPIKey && t.apiKey != nil {
+		if auth.Name == "" {
+			auth.Name = t.apiKey.Name
+		}
+		if auth.In == "" {
+			auth.In = t.apiKey.In
+		}
+	}
+	if err := auth.apply(req, query); err != nil {
+		return fmt.Errorf("openapi service %q: %w", t.service.Name, err)
+	}
+	return nil
+}
+
+// addQueryParam 按 style/explode 编码查询参数，默认 form 风格展开数组
+func addQueryParam(query url.Values, param openAPIParameter, v interface{}) {
+	if obj, ok := v.(map[string]interface{}); ok && param.Style == "deepObject" {
+		for k, item := range obj {
+			for _, s := range paramValues(item) {
+				query.Add(fmt.Sprintf("%s[%s]", param.Name, k), s)
+			}
+		}
+		return
+	}
+	values := paramValues(v)
+	explode := param.Explode == nil || *param.Explode
+	switch {
+	case param.Style == "spaceDelimited":
+		query.Add(param.Name, strings.Join(values, " "))
+	case param.Style == "pipeDelimited":
+		query.Add(param.Name, strings.Join(values, "|"))
+	case explode:
+		for _, s := range values {
+			query.Add(param.Name, s)
+		}
+	default:
+		query.Add(param.Name, strings.Join(values, ","))
+	}
+}
+
+// paramValues 把参数值转换为字符串列表；对象编码为 JSON
+func paramValues(v interface{}) []string {
+	switch t := v.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(t))
+		for _, item := range t {
+			values = append(values, paramValues(item)...)
+		}
+		return values
+	case []string:
+		return t
+	case string:
+		return []string{t}
+	case float64:
+		return []string{strconv.FormatFloat(t, 'f', -1, 64)}
+	case bool:
+		return []string{strconv.FormatBool(t)}
+	case nil:
+		return nil
+	case map[string]interface{}:
+		data, _ := json.Marshal(t)
+		return []string{string(data)}
+	default:
+		return []string{fmt.Sprint(t)}
+	}
+}
+
+// encodeRequestBody 按内容类型编码请求体，返回实际的 Content-Type
+func encodeRequestBody(contentType string, v interface{}) ([]byte, string, error) {
+	switch {
+	case contentType == "application/json" || strings.HasSuffix(contentType, "+json"):
+		// 模型可能把 JSON 作为字符串传入
+		if s, ok := v.(string); ok && json.Valid([]byte(s)) {
+			return []byte(s), contentType, nil
+		}
+		data, err := json.Marshal(v)
+		return data, contentType, err
+	case contentType == "application/x-www-form-urlencoded":
+		if s, ok := v.(string); ok {
+			return []byte(s), contentType, nil
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("%w: form body must be an object", ErrInvalidArgs)
+		}
+		form := url.Values{}
+		for k, item := range obj {
+			for _, s := range paramValues(item) {
+				form.Add(k, s)
+			}
+		}
+		return []byte(form.Encode()), contentType, nil
+	case contentType == "multipart/form-data":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("%w: multipart body must be an object", ErrInvalidArgs)
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		for _, k := range keys {
+			for _, s := range paramValues(obj[k]) {
+				if err := w.WriteField(k, s); err != nil {
+					return nil, "", err
+				}
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), w.FormDataContentType(), nil
+	default:
+		if s, ok := v.(string); ok {
+			return []byte(s), contentType, nil
+		}
+		data, err := json.Marshal(v)
+		return data, contentType, err
+	}
+}
@@ -0,0 +1,363 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPI 规范文件的大小上限
+const maxOpenAPISpecSize = 20 * 1024 * 1024
+
+// $ref 展开的最大嵌套深度
+const maxOpenAPIRefDepth = 32
+
+// openAPIMethods 支持的 HTTP 方法，按生成顺序排列
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIDocument 展开 $ref 之后的 OpenAPI 3 文档中用到的部分
+type openAPIDocument struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Servers    []openAPIServer                       `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Security   []map[string][]string                 `json:"security"`
+	Components struct {
+		SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+	} `json:"components"`
+}
+
+type openAPIServer struct {
+	URL       string `json:"url"`
+	Variables map[string]struct {
+		Default string `json:"default"`
+	} `json:"variables"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+	Name   string `json:"name"`
+	In     string `json:"in"`
+}
+
+type openAPIOperation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary"`
+	Description string                 `json:"description"`
+	Tags        []string               `json:"tags"`
+	Deprecated  bool                   `json:"deprecated"`
+	Parameters  []openAPIParameter     `json:"parameters"`
+	RequestBody *openAPIRequestBody    `json:"requestBody"`
+	Security    *[]map[string][]string `json:"security"`
+}
+
+type openAPIParameter struct {
+	Name        string                 `json:"name"`
+	In          string                 `json:"in"`
+	Description string                 `json:"description"`
+	Required    bool                   `json:"required"`
+	Style       string                 `json:"style"`
+	Explode     *bool                  `json:"explode"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Content     map[string]struct {
+		Schema map[string]interface{} `json:"schema"`
+	} `json:"content"`
+}
+
+// loadOpenAPISpec 读取本地文件或 http(s) 地址上的 OpenAPI 规范（JSON 或 YAML），展开本地 $ref
+func loadOpenAPISpec(ctx context.Context, location string) (*openAPIDocument, error) {
+	data, err := readOpenAPISpec(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse openapi spec %s failed: %v", location, err)
+	}
+	root, ok := normalizeYAML(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("parse openapi spec %s failed: not an object", location)
+	}
+	if v, _ := root["openapi"].(string); !strings.HasPrefix(v, "3.") {
+		return nil, fmt.Errorf("%s: only OpenAPI 3.x specs are supported", location)
+	}
+
+	resolved := resolveOpenAPIRefs(root, root, nil)
+	data, err = json.Marshal(resolved)
+	if err != nil {
+		return nil, err
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi spec %s failed: %v", location, err)
+	}
+	return &doc, nil
+}
+
+func readOpenAPISpec(ctx context.Context, location string) ([]byte, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("fetch openapi spec failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetch openapi spec failed: %s", resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxOpenAPISpecSize))
+	}
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxOpenAPISpecSize {
+		return nil, fmt.Errorf("openapi spec too large: %d bytes", info.Size())
+	}
+	return os.ReadFile(location)
+}
+
+// normalizeYAML 把 YAML 解码出的 map[interface{}]interface{} 等结构转换为 JSON 兼容的结构
+func normalizeYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, item := range t {
+			t[k] = normalizeYAML(item)
+		}
+		return t
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			m[fmt.Sprint(k)] = normalizeYAML(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range t {
+			t[i] = normalizeYAML(item)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// resolveOpenAPIRefs 展开文档内的 $ref（#/...）；stack 为正在展开的引用链，
+// 递归引用和外部引用替换为描述性对象
+func resolveOpenAPIRefs(node interface{}, root map[string]interface{}, stack []string) interface{} {
+	switch t := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := t["$ref"].(string); ok {
+			for _, r := range stack {
+				if r == ref {
+					return map[string]interface{}{"type": "object", "description": "recursive reference " + ref}
+				}
+			}
+			if len(stack) >= maxOpenAPIRefDepth {
+				return map[string]interface{}{"type": "object", "description": "reference too deep: " + ref}
+			}
+			target, err := lookupOpenAPIRef(root, ref)
+			if err != nil {
+				return map[string]interface{}{"description": err.Error()}
+			}
+			resolved := resolveOpenAPIRefs(target, root, append(stack[:len(stack):len(stack)], ref))
+			// $ref 旁边的 description 等字段覆盖引用目标（OpenAPI 3.1）
+			if m, ok := resolved.(map[string]interface{}); ok && len(t) > 1 {
+				merged := make(map[string]interface{}, len(m)+len(t))
+				for k, v := range m {
+					merged[k] = v
+				}
+				for k, v := range t {
+					if k != "$ref" {
+						merged[k] = resolveOpenAPIRefs(v, root, stack)
+					}
+				}
+				return merged
+			}
+			return resolved
+		}
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = resolveOpenAPIRefs(v, root, stack)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = resolveOpenAPIRefs(v, root, stack)
+		}
+		return s
+	default:
+		return node
+	}
+}
+
+// lookupOpenAPIRef 按 JSON Pointer 查找引用目标
+func lookupOpenAPIRef(root map[string]interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("external reference %s is not supported", ref)
+	}
+	var cur interface{} = root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			part = unescaped
+		}
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+	}
+	return cur, nil
+}
+
+// serverURL 返回第一个 server 的地址，变量使用默认值替换
+func (d *openAPIDocument) serverURL() string {
+	if len(d.Servers) == 0 {
+		return ""
+	}
+	s := d.Servers[0]
+	u := s.URL
+	for name, v := range s.Variables {
+		u = strings.ReplaceAll(u, "{"+name+"}", v.Default)
+	}
+	return u
+}
+
+// operations 按路径和方法排序返回所有操作
+func (d *openAPIDocument) operations() ([]openAPIRoute, error) {
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var routes []openAPIRoute
+	for _, p := range paths {
+		item := d.Paths[p]
+		// 路径级参数由各操作继承
+		var shared []openAPIParameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("path %s: %v", p, err)
+			}
+		}
+		for _, method := range openAPIMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op openAPIOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", strings.ToUpper(method), p, err)
+			}
+			op.Parameters = mergeOpenAPIParameters(shared, op.Parameters)
+			routes = append(routes, openAPIRoute{method: strings.ToUpper(method), path: p, op: op})
+		}
+	}
+	return routes, nil
+}
+
+type openAPIRoute struct {
+	method string
+	path   string
+	op     openAPIOperation
+}
+
+// mergeOpenAPIParameters 合并路径级和操作级参数，同名同位置时操作级优先
+func mergeOpenAPIParameters(shared, own []openAPIParameter) []openAPIParameter {
+	merged := append([]openAPIParameter(nil), own...)
+	for _, s := range shared {
+		found := false
+		for _, o := range own {
+			if o.Name == s.Name && o.In == s.In {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+var openAPINameRe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// operationToolName 生成工具名称：服务名_operationId，没有 operationId 时使用方法和路径
+func operationToolName(service string, r openAPIRoute) string {
+	base := r.op.OperationID
+	if base == "" {
+		base = strings.ToLower(r.method) + "_" + r.path
+	}
+	name := strings.Trim(openAPINameRe.ReplaceAllString(base, "_"), "_")
+	if service != "" {
+		name = strings.Trim(openAPINameRe.ReplaceAllString(service, "_"), "_") + "_" + name
+	}
+	// 多数模型的函数名长度上限为 64
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// toJSONSchema 把 OpenAPI schema 转换为 JSON Schema，去掉仅用于文档的字段
+func toJSONSchema(schema interface{}) interface{} {
+	switch t := schema.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			switch k {
+			case "xml", "externalDocs", "example", "discriminator", "readOnly", "writeOnly", "deprecated", "nullable":
+				continue
+			}
+			// properties 的键是属性名，不做过滤
+			if props, ok := v.(map[string]interface{}); ok && (k == "properties" || k == "patternProperties") {
+				converted := make(map[string]interface{}, len(props))
+				for name, prop := range props {
+					converted[name] = toJSONSchema(prop)
+				}
+				out[k] = converted
+				continue
+			}
+			out[k] = toJSONSchema(v)
+		}
+		if nullable, _ := t["nullable"].(bool); nullable {
+			if typ, ok := t["type"].(string); ok {
+				out["type"] = []interface{}{typ, "null"}
+			}
+		}
+		return out
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = toJSONSchema(v)
+		}
+		return s
+	default:
+		return schema
+	}
+}
@@ -0,0 +1,353 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testOpenAPISpec = `openapi: 3.0.3
+info:
+  title: Pets
+  version: "1.0"
+servers:
+  - url: /api
+security:
+  - key: []
+components:
+  securitySchemes:
+    key:
+      type: apiKey
+      name: X-API-Key
+      in: header
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+      summary: List pets
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+        - name: tags
+          in: query
+          explode: false
+          schema:
+            type: array
+            items:
+              type: string
+    post:
+      operationId: createPet
+      tags: [pets]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: getPet
+      tags: [pets]
+    delete:
+      operationId: deletePet
+      tags: [admin]
+  /admin/stats:
+    get:
+      operationId: getStats
+      tags: [admin]
+  /legacy:
+    get:
+      operationId: oldThing
+      deprecated: true
+  /big:
+    get:
+      operationId: bigResponse
+`
+
+// openAPITestServer 提供规范并记录收到的接口请求
+type openAPITestServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	last *http.Request
+	body string
+}
+
+func newOpenAPITestServer(t *testing.T) *openAPITestServer {
+	t.Helper()
+	s := &openAPITestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/openapi.yaml" {
+			io.WriteString(w, testOpenAPISpec)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.last, s.body = r, string(data)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/big" {
+			io.WriteString(w, strings.Repeat("x", 100))
+			return
+		}
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// request 返回最近一次接口请求及其请求体
+func (s *openAPITestServer) request() (*http.Request, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last, s.body
+}
+
+func loadTestOpenAPITools(t *testing.T, service OpenAPIServiceConfig) map[string]*OpenAPITool {
+	t.Helper()
+	tools, err := LoadOpenAPITools(context.Background(), service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*OpenAPITool)
+	for _, tool := range tools {
+		byName[tool.Name()] = tool
+	}
+	return byName
+}
+
+func TestLoadOpenAPIToolsSelection(t *testing.T) {
+	srv := newOpenAPITestServer(t)
+	tests := []struct {
+		name       string
+		operations []string
+		exclude    []string
+		want       []string
+	}{
+		{
+			name: "all but deprecated",
+			want: []string{"pets_getStats", "pets_bigResponse", "pets_listPets", "pets_createPet", "pets_getPet", "pets_deletePet"},
+		},
+		{name: "operation id", operations: []string{"getPet"}, want: []string{"pets_getPet"}},
+		{name: "operation id glob", operations: []string{"get*"}, want: []string{"pets_getStats", "pets_getPet"}},
+		{name: "tag", operations: []string{"pets"}, want: []string{"pets_listPets", "pets_createPet", "pets_getPet"}},
+		{name: "method and path", operations: []string{"GET /pets"}, want: []string{"pets_listPets"}},
+		{name: "method and path glob", operations: []string{"* /pets/*"}, want: []string{"pets_getPet", "pets_deletePet"}},
+		{name: "deprecated when selected", operations: []string{"oldThing"}, want: []string{"pets_oldThing"}},
+		{name: "exclude tag", exclude: []string{"admin"}, want: []string{"pets_bigResponse", "pets_listPets", "pets_createPet", "pets_getPet"}},
+		{name: "exclude wins", operations: []string{"pets"}, exclude: []string{"POST /*"}, want: []string{"pets_listPets", "pets_getPet"}},
+		{name: "no match", operations: []string{"missing"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools, err := LoadOpenAPITools(context.Background(), OpenAPIServiceConfig{
+				Name:       "pets",
+				Spec:       srv.URL + "/openapi.yaml",
+				Operations: tt.operations,
+				Exclude:    tt.exclude,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, tool := range tools {
+				names = append(names, tool.Name())
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Fatalf("tools = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAPIToolSchema(t *testing.T) {
+	srv := newOpenAPITestServer(t)
+	tools := loadTestOpenAPITools(t, OpenAPIServiceConfig{Name: "pets", Spec: srv.URL + "/openapi.yaml"})
+
+	create := tools["pets_createPet"]
+	params := create.Parameters()
+	if !reflect.DeepEqual(params["required"], []string{"body"}) {
+		t.Fatalf("required = %v", params["required"])
+	}
+	// $ref 已展开为请求体的 schema
+	body := params["properties"].(map[string]interface{})["body"].(map[string]interface{})
+	if body["type"] != "object" || !reflect.DeepEqual(body["required"], []interface{}{"name"}) {
+		t.Fatalf("body schema = %v", body)
+	}
+	if got := tools["pets_getPet"].Description(); got != "GET /pets/{id}。参数: id (path, string, required)" {
+		t.Fatalf("description = %q", got)
+	}
+	if got := tools["pets_listPets"].Description(); !strings.HasPrefix(got, "GET /pets: List pets。参数: limit (query, integer)") {
+		t.Fatalf("description = %q", got)
+	}
+}
+
+func TestOpenAPIToolRun(t *testing.T) {
+	t.Setenv("TEST_OPENAPI_KEY", "secret-key")
+	t.Setenv("TEST_OPENAPI_TRACE", "trace-1")
+	srv := newOpenAPITestServer(t)
+	tools := loadTestOpenAPITools(t, OpenAPIServiceConfig{
+		Name:    "pets",
+		Spec:    srv.URL + "/openapi.yaml",
+		Headers: map[string]string{"X-Trace": "${TEST_OPENAPI_TRACE}"},
+		// api_key 的名称和位置取自规范中的安全方案
+		Auth: OpenAPIAuth{Type: OpenAPIAuthAPIKey, Token: "${TEST_OPENAPI_KEY}"},
+	})
+	ctx := context.Background()
+
+	out, err := tools["pets_getPet"].Run(ctx, map[string]interface{}{"id": "a b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := out.(OpenAPIResponse); res.StatusCode != http.StatusOK || res.Body != `{"ok":true}` || res.ContentType != "application/json" || res.Truncated {
+		t.Fatalf("response = %+v", res)
+	}
+	req, _ := srv.request()
+	if req.Method != http.MethodGet || req.URL.EscapedPath() != "/api/pets/a%20b" {
+		t.Fatalf("request = %s %s", req.Method, req.URL.EscapedPath())
+	}
+	if req.Header.Get("X-API-Key") != "secret-key" || req.Header.Get("X-Trace") != "trace-1" {
+		t.Fatalf("headers = %v", req.Header)
+	}
+
+	if _, err := tools["pets_listPets"].Run(ctx, map[string]interface{}{"limit": 5.0, "tags": []interface{}{"a", "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	req, _ = srv.request()
+	if got := req.URL.Query(); got.Get("limit") != "5" || !reflect.DeepEqual(got["tags"], []string{"a,b"}) {
+		t.Fatalf("query = %v", got)
+	}
+
+	if _, err := tools["pets_createPet"].Run(ctx, map[string]interface{}{"body": map[string]interface{}{"name": "Rex"}}); err != nil {
+		t.Fatal(err)
+	}
+	req, body := srv.request()
+	if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" || body != `{"name":"Rex"}` {
+		t.Fatalf("request = %s %q %q", req.Method, req.Header.Get("Content-Type"), body)
+	}
+
+	for name, args := range map[string]map[string]interface{}{
+		"pets_getPet":    {},
+		"pets_createPet": {},
+	} {
+		if _, err := tools[name].Run(ctx, args); !errors.Is(err, ErrInvalidArgs) {
+			t.Fatalf("%s without required input: %v", name, err)
+		}
+	}
+}
+
+func TestOpenAPIToolAuth(t *testing.T) {
+	t.Setenv("TEST_OPENAPI_TOKEN", "tok")
+	t.Setenv("TEST_OPENAPI_USER", "alice")
+	srv := newOpenAPITestServer(t)
+	tests := []struct {
+		name  string
+		auth  OpenAPIAuth
+		check func(r *http.Request) bool
+	}{
+		{
+			name:  "bearer",
+			auth:  OpenAPIAuth{Type: OpenAPIAuthBearer, Token: "${TEST_OPENAPI_TOKEN}"},
+			check: func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer tok" },
+		},
+		{
+			name: "basic",
+			auth: OpenAPIAuth{Type: OpenAPIAuthBasic, Username: "$TEST_OPENAPI_USER", Password: "${TEST_OPENAPI_TOKEN}"},
+			check: func(r *http.Request) bool {
+				user, pass, ok := r.BasicAuth()
+				return ok && user == "alice" && pass == "tok"
+			},
+		},
+		{
+			name: "api key in query overrides scheme",
+			auth: OpenAPIAuth{Type: OpenAPIAuthAPIKey, Token: "${TEST_OPENAPI_TOKEN}", Name: "key", In: "query"},
+			check: func(r *http.Request) bool {
+				return r.URL.Query().Get("key") == "tok" && r.Header.Get("X-API-Key") == ""
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools := loadTestOpenAPITools(t, OpenAPIServiceConfig{
+				Name:       "pets",
+				Spec:       srv.URL + "/openapi.yaml",
+				Operations: []string{"getStats"},
+				Auth:       tt.auth,
+			})
+			if _, err := tools["pets_getStats"].Run(context.Background(), nil); err != nil {
+				t.Fatal(err)
+			}
+			if req, _ := srv.request(); !tt.check(req) {
+				t.Fatalf("request headers = %v, query = %v", req.Header, req.URL.Query())
+			}
+		})
+	}
+}
+
+func TestOpenAPIToolMaxResponse(t *testing.T) {
+	srv := newOpenAPITestServer(t)
+	tools := loadTestOpenAPITools(t, OpenAPIServiceConfig{Name: "pets", Spec: srv.URL + "/openapi.yaml", MaxResponse: 10})
+
+	out, err := tools["pets_bigResponse"].Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := out.(OpenAPIResponse)
+	if !res.Truncated || res.Body != "xxxxxxxxxx\n...[truncated 90 bytes]" {
+		t.Fatalf("response = %+v", res)
+	}
+	// 恰好等于上限时不截断
+	tools = loadTestOpenAPITools(t, OpenAPIServiceConfig{Name: "pets", Spec: srv.URL + "/openapi.yaml", MaxResponse: len(`{"ok":true}`)})
+	out, err = tools["pets_getStats"].Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := out.(OpenAPIResponse); res.Truncated || res.Body != `{"ok":true}` {
+		t.Fatalf("response = %+v", res)
+	}
+}
+
+func TestOpenAPIBaseURL(t *testing.T) {
+	srv := newOpenAPITestServer(t)
+	spec := filepath.Join(t.TempDir(), "openapi.yaml")
+	writeTestFile(t, filepath.Dir(spec), "openapi.yaml", testOpenAPISpec)
+
+	// 本地规范中的相对地址无法解析，需要配置 base_url
+	if _, err := LoadOpenAPITools(context.Background(), OpenAPIServiceConfig{Name: "pets", Spec: spec}); !errors.Is(err, ErrInvalidArgs) {
+		t.Fatalf("relative server in local spec: %v", err)
+	}
+	tools := loadTestOpenAPITools(t, OpenAPIServiceConfig{Name: "pets", Spec: spec, BaseURL: srv.URL + "/api/", Operations: []string{"getStats"}})
+	if _, err := tools["pets_getStats"].Run(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if req, _ := srv.request(); req.URL.Path != "/api/admin/stats" {
+		t.Fatalf("path = %s", req.URL.Path)
+	}
+
+	writeTestFile(t, filepath.Dir(spec), "swagger.yaml", "swagger: \"2.0\"\n")
+	if _, err := LoadOpenAPITools(context.Background(), OpenAPIServiceConfig{Name: "old", Spec: filepath.Join(filepath.Dir(spec), "swagger.yaml")}); err == nil {
+		t.Fatal("swagger 2.0 spec accepted")
+	}
+}
@@ -0,0 +1,825 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 补丁应用默认参数
+const (
+	defaultPatchFuzz    = 2
+	patchReportContext  = 3
+	devNull             = "/dev/null"
+	patchActionModify   = "modify"
+	patchActionCreate   = "create"
+	patchActionDelete   = "delete"
+	patchHunkApplied    = "applied"
+	patchHunkFailed     = "failed"
+	patchHunkAlreadyIn  = "already_applied"
+	maxPatchReportLines = 40
+)
+
+// ErrInvalidPatch 无法解析的补丁
+var ErrInvalidPatch = errors.New("invalid patch")
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`)
+
+// PatchTool 统一 diff 补丁工具
+type PatchTool struct {
+	name        string
+	description string
+	workspace   *Workspace
+}
+
+// NewPatchTool 创建补丁工具，ws 为空时使用默认工作区
+func NewPatchTool(ws *Workspace) *PatchTool {
+	return &PatchTool{
+		name:        "patch",
+		description: "应用统一 diff 补丁（支持多文件、偏移和模糊匹配、dry-run），或生成工作区文件与其上一保存版本之间的 diff",
+		workspace:   ws,
+	}
+}
+
+// Name 返回工具名称
+func (t *PatchTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *PatchTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *PatchTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// ExclusiveKeys 补丁涉及的文件与文件工具使用相同的资源标识，同一文件上的修改依次执行
+func (t *PatchTool) ExclusiveKeys(input interface{}) []string {
+	var in PatchInput
+	if s, ok := input.(string); ok {
+		in.Patch = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil
+	}
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return nil
+	}
+	if in.Operation == PatchOpDiff {
+		return fileKeys(ws, in.Path, in.Other)
+	}
+	return fileKeys(ws, patchTargets(in.Patch, in.Strip)...)
+}
+
+// PatchInput 补丁工具参数
+type PatchInput struct {
+	Operation string `json:"operation"`
+	// apply 参数
+	Patch            string `json:"patch,omitempty"`
+	DryRun           bool   `json:"dry_run,omitempty"`
+	Fuzz             *int   `json:"fuzz,omitempty"`
+	Strip            *int   `json:"strip,omitempty"`
+	IgnoreWhitespace bool   `json:"ignore_whitespace,omitempty"`
+	// Partial 为 true 时部分 hunk 失败仍写入成功的部分，默认全部成功才写入
+	Partial bool `json:"partial,omitempty"`
+	// diff 参数：Path 与 Other 比较，未指定 Other 时与上一保存版本比较
+	Path    string `json:"path,omitempty"`
+	Other   string `json:"other,omitempty"`
+	Context *int   `json:"context,omitempty"`
+}
+
+// PatchHunkResult 单个 hunk 的应用结果
+type PatchHunkResult struct {
+	Index  int    `json:"index"`
+	Header string `json:"header"`
+	Status string `json:"status"`
+	// Line 实际应用位置（1 起始），Offset 为相对补丁声明位置的偏移
+	Line   int    `json:"line,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+	Fuzz   int    `json:"fuzz,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Expected 和 Actual 仅在失败时给出：补丁期望的原始行和文件中对应位置附近的实际行
+	Expected []string `json:"expected,omitempty"`
+	Actual   []string `json:"actual,omitempty"`
+}
+
+// PatchFileResult 单个文件的应用结果
+type PatchFileResult struct {
+	Path         string            `json:"path"`
+	Action       string            `json:"action"`
+	HunksApplied int               `json:"hunks_applied"`
+	HunksFailed  int               `json:"hunks_failed"`
+	Written      bool              `json:"written"`
+	TrashPath    string            `json:"trash_path,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	Hunks        []PatchHunkResult `json:"hunks"`
+}
+
+// PatchResult 补丁应用结果
+type PatchResult struct {
+	Success bool              `json:"success"`
+	DryRun  bool              `json:"dry_run,omitempty"`
+	Files   []PatchFileResult `json:"files"`
+	Summary string            `json:"summary"`
+}
+
+// PatchDiffResult diff 生成结果
+type PatchDiffResult struct {
+	Path    string `json:"path"`
+	Against string `json:"against"`
+	Diff    string `json:"diff"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Changed bool   `json:"changed"`
+}
+
+// 预定义的补丁操作
+const (
+	PatchOpApply = "apply"
+	PatchOpDiff  = "diff"
+)
+
+// Run 执行补丁操作
+func (t *PatchTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in PatchInput
+	if s, ok := input.(string); ok {
+		in.Patch = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Operation == "" {
+		in.Operation = PatchOpApply
+	}
+
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	switch in.Operation {
+	case PatchOpApply:
+		if in.Patch == "" {
+			return nil, ErrInvalidArgs
+		}
+		return t.apply(ws, in)
+	case PatchOpDiff:
+		if in.Path == "" {
+			return nil, ErrInvalidArgs
+		}
+		return t.diff(ws, in)
+	default:
+		return nil, ErrInvalidOperation
+	}
+}
+
+// filePatch 单个文件的补丁
+type filePatch struct {
+	oldName string
+	newName string
+	hunks   []*patchHunk
+}
+
+// patchHunk 补丁中的一个 hunk，行内容保留换行符
+type patchHunk struct {
+	header   string
+	oldStart int
+	newStart int
+	lines    []diffEdit
+}
+
+// oldLines 返回 hunk 期望的原始行（上下文行和删除行）
+func (h *patchHunk) oldLines() []string {
+	var out []string
+	for _, e := range h.lines {
+		if e.kind != '+' {
+			out = append(out, e.line)
+		}
+	}
+	return out
+}
+
+// newLines 返回 hunk 应用后的行（上下文行和新增行）
+func (h *patchHunk) newLines() []string {
+	var out []string
+	for _, e := range h.lines {
+		if e.kind != '-' {
+			out = append(out, e.line)
+		}
+	}
+	return out
+}
+
+// parsePatch 解析统一 diff，支持 git diff 输出和多文件补丁
+func parsePatch(text string) ([]*filePatch, error) {
+	var patches []*filePatch
+	var cur *filePatch
+	var hunk *patchHunk
+	oldLeft, newLeft := 0, 0
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		// hunk 体内按声明的行数读取，避免把以 "---" 开头的删除行误认为文件头
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			var kind byte = ' '
+			body := ""
+			switch {
+			case line == "":
+				// 部分编辑器会去掉空上下文行的前导空格
+			case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+				kind, body = line[0], line[1:]
+			case strings.HasPrefix(line, `\`):
+				trimLastNewline(hunk)
+				continue
+			default:
+				return nil, fmt.Errorf("%w: line %d: unexpected line in hunk %q", ErrInvalidPatch, lineNo, hunk.header)
+			}
+			if kind != '+' {
+				oldLeft--
+			}
+			if kind != '-' {
+				newLeft--
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("%w: line %d: hunk %q is longer than its header", ErrInvalidPatch, lineNo, hunk.header)
+			}
+			hunk.lines = append(hunk.lines, diffEdit{kind, body + "\n"})
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, `\`):
+			if hunk != nil {
+				trimLastNewline(hunk)
+			}
+		case strings.HasPrefix(line, "--- "):
+			cur = &filePatch{oldName: parsePatchName(line[4:])}
+			hunk = nil
+		case strings.HasPrefix(line, "+++ "):
+			if cur == nil || cur.newName != "" {
+				return nil, fmt.Errorf("%w: line %d: '+++' without '---'", ErrInvalidPatch, lineNo)
+			}
+			cur.newName = parsePatchName(line[4:])
+			patches = append(patches, cur)
+		case strings.HasPrefix(line, "@@"):
+			if cur == nil || cur.newName == "" {
+				return nil, fmt.Errorf("%w: line %d: hunk without file header", ErrInvalidPatch, lineNo)
+			}
+			m := hunkHeaderRe.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("%w: line %d: malformed hunk header %q", ErrInvalidPatch, lineNo, line)
+			}
+			hunk = &patchHunk{header: line}
+			hunk.oldStart, _ = strconv.Atoi(m[1])
+			hunk.newStart, _ = strconv.Atoi(m[3])
+			oldLeft, newLeft = 1, 1
+			if m[2] != "" {
+				oldLeft, _ = strconv.Atoi(m[2])
+			}
+			if m[4] != "" {
+				newLeft, _ = strconv.Atoi(m[4])
+			}
+			cur.hunks = append(cur.hunks, hunk)
+		case strings.HasPrefix(line, "GIT binary patch"), strings.HasPrefix(line, "Binary files "):
+			return nil, fmt.Errorf("%w: binary patches are not supported", ErrInvalidPatch)
+		default:
+			// diff --git、index、mode 等元信息以及补丁前后的说明文字
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+		return nil, fmt.Errorf("%w: hunk %q is truncated", ErrInvalidPatch, hunk.header)
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("%w: no file headers found", ErrInvalidPatch)
+	}
+	for _, fp := range patches {
+		if len(fp.hunks) == 0 {
+			return nil, fmt.Errorf("%w: no hunks for %s", ErrInvalidPatch, fp.newName)
+		}
+	}
+	return patches, nil
+}
+
+// trimLastNewline 处理 "\ No newline at end of file"
+func trimLastNewline(h *patchHunk) {
+	if n := len(h.lines); n > 0 {
+		h.lines[n-1].line = strings.TrimSuffix(h.lines[n-1].line, "\n")
+	}
+}
+
+// parsePatchName 去掉文件头中的时间戳和引号
+func parsePatchName(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	return s
+}
+
+// stripPath 去掉路径的前 n 级目录
+func stripPath(name string, n int) string {
+	for i := 0; i < n; i++ {
+		idx := strings.IndexByte(name, '/')
+		if idx < 0 {
+			break
+		}
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// targetPath 确定补丁作用的文件和操作类型；strip 为 nil 时自动识别 git 的 a/ b/ 前缀
+func (fp *filePatch) targetPath(strip *int) (string, string) {
+	level := 0
+	if strip != nil {
+		level = *strip
+	} else if (fp.oldName == devNull || strings.HasPrefix(fp.oldName, "a/")) &&
+		(fp.newName == devNull || strings.HasPrefix(fp.newName, "b/")) {
+		level = 1
+	}
+	switch {
+	case fp.oldName == devNull:
+		return stripPath(fp.newName, level), patchActionCreate
+	case fp.newName == devNull:
+		return stripPath(fp.oldName, level), patchActionDelete
+	default:
+		return stripPath(fp.newName, level), patchActionModify
+	}
+}
+
+// patchTargets 返回补丁作用的文件路径（相对工作区）；补丁无法完整解析时（例如 git 二进制补丁）
+// 退回到只读取 "---" 和 "+++" 文件头
+func patchTargets(text string, strip *int) []string {
+	patches, err := parsePatch(text)
+	if err != nil {
+		patches = nil
+		var cur *filePatch
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			switch {
+			case strings.HasPrefix(line, "--- "):
+				cur = &filePatch{oldName: parsePatchName(line[4:])}
+			case strings.HasPrefix(line, "+++ ") && cur != nil:
+				cur.newName = parsePatchName(line[4:])
+				patches = append(patches, cur)
+				cur = nil
+			}
+		}
+	}
+	paths := make([]string, 0, len(patches))
+	for _, fp := range patches {
+		p, _ := fp.targetPath(strip)
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// apply 解析并应用补丁，默认所有文件的所有 hunk 都成功才写入
+func (t *PatchTool) apply(ws *Workspace, in PatchInput) (interface{}, error) {
+	patches, err := parsePatch(in.Patch)
+	if err != nil {
+		return nil, err
+	}
+	fuzz := defaultPatchFuzz
+	if in.Fuzz != nil && *in.Fuzz >= 0 {
+		fuzz = *in.Fuzz
+	}
+	// 同一文件的多个片段会基于同一原始内容计算、互相覆盖，直接拒绝
+	seen := make(map[string]bool, len(patches))
+	for _, fp := range patches {
+		path, _ := fp.targetPath(in.Strip)
+		key := filepath.Clean(path)
+		if full, err := ws.Resolve(path); err == nil {
+			key = full
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: multiple sections for %s, combine them into one", ErrInvalidPatch, path)
+		}
+		seen[key] = true
+	}
+
+	type pending struct {
+		result   *PatchFileResult
+		original string
+		content  string
+	}
+	result := PatchResult{Success: true, DryRun: in.DryRun, Files: make([]PatchFileResult, len(patches))}
+	var writes []pending
+
+	for i, fp := range patches {
+		path, action := fp.targetPath(in.Strip)
+		fr := &result.Files[i]
+		*fr = PatchFileResult{Path: path, Action: action, Hunks: make([]PatchHunkResult, 0, len(fp.hunks))}
+
+		original, err := readPatchTarget(ws, path, action)
+		if err != nil {
+			fr.Error = err.Error()
+			fr.HunksFailed = len(fp.hunks)
+			result.Success = false
+			continue
+		}
+
+		lines, hunks := applyHunks(splitLines(original), fp.hunks, fuzz, in.IgnoreWhitespace)
+		fr.Hunks = hunks
+		for _, h := range hunks {
+			if h.Status == patchHunkFailed {
+				fr.HunksFailed++
+			} else {
+				fr.HunksApplied++
+			}
+		}
+		content := strings.Join(lines, "")
+		if action == patchActionDelete && fr.HunksFailed == 0 && content != "" {
+			fr.Error = "file is not empty after applying deletion patch"
+			fr.HunksFailed = len(fp.hunks)
+			fr.HunksApplied = 0
+		}
+		if fr.HunksFailed > 0 {
+			result.Success = false
+		}
+		writes = append(writes, pending{result: fr, original: original, content: content})
+	}
+
+	if !in.DryRun && (result.Success || in.Partial) {
+		for _, w := range writes {
+			fr := w.result
+			// 内容未变化（例如所有 hunk 都已应用过）时不写入，避免覆盖上一保存版本
+			if fr.HunksApplied == 0 || (fr.Action != patchActionDelete && w.content == w.original) {
+				continue
+			}
+			if fr.Action == patchActionDelete {
+				if fr.HunksFailed > 0 {
+					continue
+				}
+				trashPath, err := ws.Remove(fr.Path)
+				if err != nil {
+					fr.Error = err.Error()
+					result.Success = false
+					continue
+				}
+				fr.TrashPath = trashPath
+			} else if _, err := ws.WriteFile(fr.Path, []byte(w.content)); err != nil {
+				fr.Error = err.Error()
+				result.Success = false
+				continue
+			}
+			fr.Written = true
+		}
+	}
+
+	result.Summary = patchSummary(result)
+	return result, nil
+}
+
+// readPatchTarget 读取补丁目标文件，新建文件要求目标不存在或为空
+func readPatchTarget(ws *Workspace, path, action string) (string, error) {
+	full, err := ws.ResolveWrite(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		if os.IsNotExist(err) && action == patchActionCreate {
+			return "", nil
+		}
+		return "", err
+	}
+	if action == patchActionCreate && len(data) > 0 {
+		return "", fmt.Errorf("file already exists: %s", path)
+	}
+	if isBinary(data[:min(len(data), sniffSize)]) {
+		return "", fmt.Errorf("cannot patch binary file: %s", path)
+	}
+	return string(data), nil
+}
+
+// applyHunks 依次应用 hunk，失败的 hunk 被跳过并记录附近的实际内容
+func applyHunks(lines []string, hunks []*patchHunk, fuzz int, ignoreSpace bool) ([]string, []PatchHunkResult) {
+	results := make([]PatchHunkResult, 0, len(hunks))
+	// delta 为已应用 hunk 造成的行数变化加上最近一次的偏移
+	delta := 0
+	// floor 之前的行已被前面的 hunk 处理，后续 hunk 不能与之重叠
+	floor := 0
+	crlf := usesCRLF(lines)
+
+	for i, h := range hunks {
+		res := PatchHunkResult{Index: i + 1, Header: h.header}
+		old := h.oldLines()
+		expected := h.oldStart - 1 + delta
+		if len(old) == 0 {
+			// 纯新增 hunk 的起始行号指向插入位置之前的一行
+			expected = h.oldStart + delta
+		}
+
+		pos, used, ok := -1, 0, false
+		for f := 0; f <= fuzz && !ok; f++ {
+			pos, ok = locateHunk(lines, h, f, expected, floor, ignoreSpace)
+			used = f
+		}
+		if !ok && alreadyApplied(lines, h, expected, floor, ignoreSpace) {
+			res.Status = patchHunkAlreadyIn
+			results = append(results, res)
+			continue
+		}
+		if !ok {
+			res.Status = patchHunkFailed
+			res.Error = "context does not match"
+			res.Expected = reportLines(old, 0)
+			res.Actual = surroundingLines(lines, expected, len(old))
+			results = append(results, res)
+			continue
+		}
+
+		// 模糊匹配时跳过的首尾上下文行保留文件中的原样
+		head, tail := fuzzTrim(h, used)
+		edits := h.lines[head : len(h.lines)-tail]
+		start := pos
+		var replacement []string
+		idx := start
+		for _, e := range edits {
+			switch e.kind {
+			case ' ':
+				replacement = append(replacement, lines[idx])
+				idx++
+			case '-':
+				idx++
+			case '+':
+				replacement = append(replacement, withLineEnding(e.line, crlf))
+			}
+		}
+		// 新增到文件末尾时，补齐原最后一行缺失的换行符
+		if start > 0 && start == len(lines) && len(replacement) > 0 && !strings.HasSuffix(lines[start-1], "\n") {
+			lines[start-1] += lineEnding(crlf)
+		}
+
+		merged := make([]string, 0, len(lines)-(idx-start)+len(replacement))
+		merged = append(merged, lines[:start]...)
+		merged = append(merged, replacement...)
+		merged = append(merged, lines[idx:]...)
+		lines = merged
+
+		res.Status = patchHunkApplied
+		res.Line = start + 1
+		res.Offset = start - head - expected
+		if len(old) == 0 {
+			res.Offset = start - expected
+		}
+		res.Fuzz = used
+		results = append(results, res)
+
+		delta += len(replacement) - (idx - start) + res.Offset
+		floor = start + len(replacement)
+	}
+	return lines, results
+}
+
+// fuzzTrim 返回模糊级别 f 下 hunk 首尾可忽略的上下文行数
+func fuzzTrim(h *patchHunk, f int) (int, int) {
+	head := 0
+	for head < f && head < len(h.lines) && h.lines[head].kind == ' ' {
+		head++
+	}
+	tail := 0
+	for tail < f && tail < len(h.lines)-head && h.lines[len(h.lines)-1-tail].kind == ' ' {
+		tail++
+	}
+	return head, tail
+}
+
+// locateHunk 从期望位置向两侧查找与 hunk 原始行匹配的位置
+func locateHunk(lines []string, h *patchHunk, f, expected, floor int, ignoreSpace bool) (int, bool) {
+	head, tail := fuzzTrim(h, f)
+	if f > 0 && head == 0 && tail == 0 {
+		return 0, false
+	}
+	var want []string
+	for _, e := range h.lines[head : len(h.lines)-tail] {
+		if e.kind != '+' {
+			want = append(want, e.line)
+		}
+	}
+	expected += head
+	if len(want) == 0 {
+		if expected < floor {
+			expected = floor
+		}
+		if expected > len(lines) {
+			expected = len(lines)
+		}
+		// 无上下文的 hunk 只能按声明位置应用
+		return expected, f == 0
+	}
+
+	last := len(lines) - len(want)
+	for dist := 0; ; dist++ {
+		before, after := expected-dist, expected+dist
+		if before < floor && after > last {
+			return 0, false
+		}
+		if after <= last && after >= floor && matchLinesAt(lines, want, after, ignoreSpace) {
+			return after, true
+		}
+		if dist > 0 && before >= floor && before <= last && matchLinesAt(lines, want, before, ignoreSpace) {
+			return before, true
+		}
+	}
+}
+
+// alreadyApplied 判断 hunk 的结果内容是否已存在于期望位置附近
+func alreadyApplied(lines []string, h *patchHunk, expected, floor int, ignoreSpace bool) bool {
+	added := false
+	for _, e := range h.lines {
+		if e.kind != ' ' {
+			added = true
+			break
+		}
+	}
+	want := h.newLines()
+	if !added || len(want) == 0 {
+		return false
+	}
+	reversed := &patchHunk{lines: make([]diffEdit, 0, len(want))}
+	for _, line := range want {
+		reversed.lines = append(reversed.lines, diffEdit{' ', line})
+	}
+	_, ok := locateHunk(lines, reversed, 0, expected, floor, ignoreSpace)
+	return ok
+}
+
+// matchLinesAt 比较文件从 pos 开始的行，忽略行尾换行符差异
+func matchLinesAt(lines, want []string, pos int, ignoreSpace bool) bool {
+	for i, w := range want {
+		if !sameLine(lines[pos+i], w, ignoreSpace) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameLine(a, b string, ignoreSpace bool) bool {
+	a = strings.TrimRight(a, "\r\n")
+	b = strings.TrimRight(b, "\r\n")
+	if ignoreSpace {
+		return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+	}
+	return a == b
+}
+
+// usesCRLF 判断文件是否以 CRLF 换行
+func usesCRLF(lines []string) bool {
+	crlf := 0
+	for _, line := range lines {
+		if strings.HasSuffix(line, "\r\n") {
+			crlf++
+		}
+	}
+	return crlf > 0 && crlf*2 >= len(lines)
+}
+
+func lineEnding(crlf bool) string {
+	if crlf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// withLineEnding 将补丁行的换行符转换为文件使用的换行符
+func withLineEnding(line string, crlf bool) string {
+	if crlf && strings.HasSuffix(line, "\n") && !strings.HasSuffix(line, "\r\n") {
+		return strings.TrimSuffix(line, "\n") + "\r\n"
+	}
+	return line
+}
+
+// surroundingLines 返回期望位置附近带行号的实际内容
+func surroundingLines(lines []string, pos, n int) []string {
+	start := pos - patchReportContext
+	if start < 0 {
+		start = 0
+	}
+	end := pos + n + patchReportContext
+	if end > len(lines) {
+		end = len(lines)
+	}
+	if start >= end {
+		return []string{fmt.Sprintf("(file has %d lines)", len(lines))}
+	}
+	return reportLines(lines[start:end], start+1)
+}
+
+// reportLines 格式化报告中的行，first 大于 0 时加上行号
+func reportLines(lines []string, first int) []string {
+	out := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if len(out) >= maxPatchReportLines {
+			out = append(out, fmt.Sprintf("... %d more lines", len(lines)-i))
+			break
+		}
+		line = clipLine(strings.TrimRight(line, "\r\n"))
+		if first > 0 {
+			line = fmt.Sprintf("%d: %s", first+i, line)
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// patchSummary 生成简要的结果说明
+func patchSummary(r PatchResult) string {
+	applied, failed, written := 0, 0, 0
+	for _, f := range r.Files {
+		applied += f.HunksApplied
+		failed += f.HunksFailed
+		if f.Written {
+			written++
+		}
+	}
+	switch {
+	case r.DryRun:
+		return fmt.Sprintf("dry run: %d hunks would apply, %d would fail", applied, failed)
+	case failed > 0 && written == 0:
+		return fmt.Sprintf("%d hunks failed, no files were changed", failed)
+	default:
+		return fmt.Sprintf("%d hunks applied, %d failed, %d files written", applied, failed, written)
+	}
+}
+
+// diff 生成文件与上一保存版本（或另一个文件）之间的统一 diff
+func (t *PatchTool) diff(ws *Workspace, in PatchInput) (interface{}, error) {
+	contextLines := defaultDiffContext
+	if in.Context != nil && *in.Context >= 0 {
+		contextLines = *in.Context
+	}
+
+	full, err := ws.Resolve(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	rel := ws.Rel(full)
+	current, err := os.ReadFile(full)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var previous []byte
+	against := in.Other
+	if in.Other != "" {
+		otherFull, err := ws.Resolve(in.Other)
+		if err != nil {
+			return nil, err
+		}
+		if previous, err = os.ReadFile(otherFull); err != nil {
+			return nil, err
+		}
+		against = ws.Rel(otherFull)
+	} else {
+		against = "last saved version"
+		previous, err = ws.LastVersion(in.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if os.IsNotExist(err) {
+			against = devNull
+		}
+	}
+	for _, data := range [][]byte{previous, current} {
+		if isBinary(data[:min(len(data), sniffSize)]) {
+			return nil, fmt.Errorf("cannot diff binary file: %s", in.Path)
+		}
+	}
+
+	oldName := "a/" + rel
+	if in.Other != "" {
+		oldName = "a/" + against
+	} else if against == devNull {
+		oldName = devNull
+	}
+	text, added, deleted := unifiedDiff(oldName, "b/"+rel, string(previous), string(current), contextLines)
+	return PatchDiffResult{
+		Path:    rel,
+		Against: against,
+		Diff:    text,
+		Added:   added,
+		Deleted: deleted,
+		Changed: text != "",
+	}, nil
+}
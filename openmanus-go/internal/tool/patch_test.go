@@ -0,0 +1,249 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		files   int
+		wantErr bool
+	}{
+		{"single", "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-x\n+y\n", 1, false},
+		{"multi", "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-x\n+y\n--- a/g\n+++ b/g\n@@ -1 +1 @@\n-x\n+y\n", 2, false},
+		{"dash line in hunk", "--- a/f\n+++ b/f\n@@ -1,2 +1 @@\n--- x\n keep\n", 1, false},
+		{"no newline marker", "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-x\n\\ No newline at end of file\n+y\n", 1, false},
+		{"no headers", "just text\n", 0, true},
+		{"hunk without header", "@@ -1 +1 @@\n-x\n+y\n", 0, true},
+		{"truncated hunk", "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n", 0, true},
+		{"too long", "--- a/f\n+++ b/f\n@@ -1,2 +1 @@\n-x\n+y\n+z\n", 0, true},
+		{"binary", "--- a/f\n+++ b/f\nGIT binary patch\n", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches, err := parsePatch(tt.patch)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPatch) {
+					t.Fatalf("got %v, want ErrInvalidPatch", err)
+				}
+				return
+			}
+			if err != nil || len(patches) != tt.files {
+				t.Fatalf("got %d files, %v", len(patches), err)
+			}
+		})
+	}
+}
+
+func TestPatchTargetPath(t *testing.T) {
+	one := 1
+	zero := 0
+	tests := []struct {
+		oldName, newName string
+		strip            *int
+		path, action     string
+	}{
+		{"a/dir/f.go", "b/dir/f.go", nil, "dir/f.go", patchActionModify},
+		{"dir/f.go", "dir/f.go", nil, "dir/f.go", patchActionModify},
+		{devNull, "b/new.go", nil, "new.go", patchActionCreate},
+		{"a/old.go", devNull, nil, "old.go", patchActionDelete},
+		{"x/f.go", "y/f.go", &one, "f.go", patchActionModify},
+		{"a/f.go", "b/f.go", &zero, "b/f.go", patchActionModify},
+	}
+	for _, tt := range tests {
+		t.Run(tt.newName, func(t *testing.T) {
+			fp := &filePatch{oldName: tt.oldName, newName: tt.newName}
+			path, action := fp.targetPath(tt.strip)
+			if path != tt.path || action != tt.action {
+				t.Fatalf("got %s %s, want %s %s", path, action, tt.path, tt.action)
+			}
+		})
+	}
+}
+
+func TestPatchApply(t *testing.T) {
+	const base = "a\nb\nc\nd\ne\nf\ng\n"
+	tests := []struct {
+		name    string
+		file    string
+		patch   string
+		want    string
+		success bool
+		status  string
+	}{
+		{
+			name:    "exact",
+			file:    base,
+			patch:   "--- a/f.txt\n+++ b/f.txt\n@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n",
+			want:    "a\nb\nC\nd\ne\nf\ng\n",
+			success: true,
+			status:  patchHunkApplied,
+		},
+		{
+			name:    "offset",
+			file:    "x\ny\n" + base,
+			patch:   "--- a/f.txt\n+++ b/f.txt\n@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n",
+			want:    "x\ny\na\nb\nC\nd\ne\nf\ng\n",
+			success: true,
+			status:  patchHunkApplied,
+		},
+		{
+			name:    "fuzz",
+			file:    base,
+			patch:   "--- a/f.txt\n+++ b/f.txt\n@@ -2,3 +2,3 @@\n B\n-c\n+C\n d\n",
+			want:    "a\nb\nC\nd\ne\nf\ng\n",
+			success: true,
+			status:  patchHunkApplied,
+		},
+		{
+			name:    "already applied",
+			file:    "a\nb\nC\nd\ne\nf\ng\n",
+			patch:   "--- a/f.txt\n+++ b/f.txt\n@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n",
+			want:    "a\nb\nC\nd\ne\nf\ng\n",
+			success: true,
+			status:  patchHunkAlreadyIn,
+		},
+		{
+			name:    "context mismatch",
+			file:    base,
+			patch:   "--- a/f.txt\n+++ b/f.txt\n@@ -2,3 +2,3 @@\n q\n-r\n+R\n s\n",
+			want:    base,
+			success: false,
+			status:  patchHunkFailed,
+		},
+		{
+			name:    "crlf",
+			file:    "a\r\nb\r\nc\r\n",
+			patch:   "--- a/f.txt\n+++ b/f.txt\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			want:    "a\r\nB\r\nc\r\n",
+			success: true,
+			status:  patchHunkApplied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := newTestWorkspace(t)
+			writeTestFile(t, ws.Root(), "f.txt", tt.file)
+			out, err := NewPatchTool(ws).Run(context.Background(), map[string]interface{}{"patch": tt.patch})
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := out.(PatchResult)
+			if res.Success != tt.success || res.Files[0].Hunks[0].Status != tt.status {
+				t.Fatalf("result = %+v", res)
+			}
+			data, _ := os.ReadFile(filepath.Join(ws.Root(), "f.txt"))
+			if string(data) != tt.want {
+				t.Fatalf("content = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchApplyCreateDeleteAndDryRun(t *testing.T) {
+	ws := newTestWorkspace(t)
+	root := ws.Root()
+	writeTestFile(t, root, "old.txt", "bye\n")
+	pt := NewPatchTool(ws)
+	patch := "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+hello\n" +
+		"--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n"
+
+	out, err := pt.Run(context.Background(), map[string]interface{}{"patch": patch, "dry_run": true})
+	if err != nil || !out.(PatchResult).Success {
+		t.Fatalf("dry run = %+v, %v", out, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new.txt")); !os.IsNotExist(err) {
+		t.Fatal("dry run wrote a file")
+	}
+
+	out, err = pt.Run(context.Background(), map[string]interface{}{"patch": patch})
+	if err != nil || !out.(PatchResult).Success {
+		t.Fatalf("apply = %+v, %v", out, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "new.txt")); string(data) != "hello\n" {
+		t.Fatalf("new.txt = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "old.txt")); !os.IsNotExist(err) {
+		t.Fatal("old.txt was not deleted")
+	}
+}
+
+func TestPatchApplyAllOrNothing(t *testing.T) {
+	ws := newTestWorkspace(t)
+	root := ws.Root()
+	writeTestFile(t, root, "a.txt", "a\n")
+	writeTestFile(t, root, "b.txt", "b\n")
+	patch := "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n" +
+		"--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-x\n+X\n"
+
+	out, err := NewPatchTool(ws).Run(context.Background(), map[string]interface{}{"patch": patch})
+	if err != nil || out.(PatchResult).Success {
+		t.Fatalf("apply = %+v, %v", out, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "a\n" {
+		t.Fatalf("a.txt changed without partial: %q", data)
+	}
+
+	out, err = NewPatchTool(ws).Run(context.Background(), map[string]interface{}{"patch": patch, "partial": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "A\n" {
+		t.Fatalf("a.txt with partial: %q", data)
+	}
+}
+
+func TestPatchApplyRejectsDuplicateTargets(t *testing.T) {
+	ws := newTestWorkspace(t)
+	writeTestFile(t, ws.Root(), "f.txt", "a\nb\n")
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"same name", "--- a/f.txt\n+++ b/f.txt\n@@ -1 +1 @@\n-a\n+A\n--- a/f.txt\n+++ b/f.txt\n@@ -2 +2 @@\n-b\n+B\n"},
+		{"equivalent name", "--- a/f.txt\n+++ b/f.txt\n@@ -1 +1 @@\n-a\n+A\n--- a/./f.txt\n+++ b/./f.txt\n@@ -2 +2 @@\n-b\n+B\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPatchTool(ws).Run(context.Background(), map[string]interface{}{"patch": tt.patch})
+			if !errors.Is(err, ErrInvalidPatch) {
+				t.Fatalf("got %v, want ErrInvalidPatch", err)
+			}
+			if data, _ := os.ReadFile(filepath.Join(ws.Root(), "f.txt")); string(data) != "a\nb\n" {
+				t.Fatalf("file changed: %q", data)
+			}
+		})
+	}
+}
+
+func TestPatchExclusiveKeys(t *testing.T) {
+	ws := newTestWorkspace(t)
+	pt := NewPatchTool(ws)
+	ft := NewFileToolWithWorkspace(ws)
+	patch := "--- a/src/a.go\n+++ b/src/a.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+x\n"
+
+	keys := pt.ExclusiveKeys(map[string]interface{}{"patch": patch})
+	want := append(ft.ExclusiveKeys(map[string]interface{}{"action": "write", "path": "src/a.go"}),
+		ft.ExclusiveKeys(map[string]interface{}{"action": "write", "path": "new.txt"})...)
+	if len(keys) != 2 || keys[0] != want[0] || keys[1] != want[1] {
+		t.Fatalf("keys = %v, want %v", keys, want)
+	}
+
+	if k := pt.ExclusiveKeys(patch); len(k) != 2 || k[0] != keys[0] {
+		t.Fatalf("string input keys = %v", k)
+	}
+	diff := pt.ExclusiveKeys(map[string]interface{}{"operation": PatchOpDiff, "path": "src/a.go"})
+	if len(diff) != 1 || diff[0] != want[0] {
+		t.Fatalf("diff keys = %v", diff)
+	}
+	if k := pt.ExclusiveKeys(map[string]interface{}{"patch": "--- a/../x\n+++ b/../x\n@@ -1 +1 @@\n-a\n+b\n"}); len(k) != 0 {
+		t.Fatalf("paths outside the workspace should be ignored: %v", k)
+	}
+}
@@ -0,0 +1,667 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PlanStepStatus 计划步骤状态
+type PlanStepStatus string
+
+// 预定义的步骤状态
+const (
+	StepNotStarted PlanStepStatus = "not_started"
+	StepInProgress PlanStepStatus = "in_progress"
+	StepCompleted  PlanStepStatus = "completed"
+	StepBlocked    PlanStepStatus = "blocked"
+)
+
+// 计划持久化文件名
+const planStoreFile = "plans.json"
+
+// 计划相关错误
+var (
+	ErrPlanNotFound          = errors.New("plan not found")
+	ErrNoActivePlan          = errors.New("no active plan, specify plan_id or set an active plan")
+	ErrInvalidStepTransition = errors.New("invalid step status transition")
+)
+
+// stepTransitions 允许的状态转换；已完成的步骤只能重新打开为进行中，阻塞的步骤需先解除阻塞才能完成
+var stepTransitions = map[PlanStepStatus][]PlanStepStatus{
+	StepNotStarted: {StepInProgress, StepCompleted, StepBlocked},
+	StepInProgress: {StepNotStarted, StepCompleted, StepBlocked},
+	StepBlocked:    {StepNotStarted, StepInProgress},
+	StepCompleted:  {StepInProgress},
+}
+
+var planIDRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// PlanStep 计划步骤
+type PlanStep struct {
+	Title     string         `json:"title"`
+	Status    PlanStepStatus `json:"status"`
+	Notes     string         `json:"notes,omitempty"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Plan 任务计划
+type Plan struct {
+	ID        string     `json:"plan_id"`
+	Title     string     `json:"title"`
+	Steps     []PlanStep `json:"steps"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// PlanProgress 计划进度统计
+type PlanProgress struct {
+	Total      int `json:"total"`
+	Completed  int `json:"completed"`
+	InProgress int `json:"in_progress"`
+	Blocked    int `json:"blocked"`
+	NotStarted int `json:"not_started"`
+}
+
+// Progress 统计各状态的步骤数量
+func (p *Plan) Progress() PlanProgress {
+	progress := PlanProgress{Total: len(p.Steps)}
+	for _, step := range p.Steps {
+		switch step.Status {
+		case StepCompleted:
+			progress.Completed++
+		case StepInProgress:
+			progress.InProgress++
+		case StepBlocked:
+			progress.Blocked++
+		default:
+			progress.NotStarted++
+		}
+	}
+	return progress
+}
+
+// Render 渲染计划的进度视图
+func (p *Plan) Render() string {
+	var sb strings.Builder
+	header := fmt.Sprintf("Plan: %s (ID: %s)", p.Title, p.ID)
+	sb.WriteString(header + "\n")
+	sb.WriteString(strings.Repeat("=", len(header)) + "\n\n")
+
+	progress := p.Progress()
+	percent := 0.0
+	if progress.Total > 0 {
+		percent = float64(progress.Completed) / float64(progress.Total) * 100
+	}
+	fmt.Fprintf(&sb, "Progress: %d/%d steps completed (%.1f%%)\n", progress.Completed, progress.Total, percent)
+	fmt.Fprintf(&sb, "Status: %d completed, %d in progress, %d blocked, %d not started\n\n",
+		progress.Completed, progress.InProgress, progress.Blocked, progress.NotStarted)
+
+	sb.WriteString("Steps:\n")
+	for i, step := range p.Steps {
+		fmt.Fprintf(&sb, "%d. %s %s\n", i, stepSymbol(step.Status), step.Title)
+		if step.Notes != "" {
+			fmt.Fprintf(&sb, "   Notes: %s\n", step.Notes)
+		}
+	}
+	return sb.String()
+}
+
+func stepSymbol(status PlanStepStatus) string {
+	switch status {
+	case StepInProgress:
+		return "[→]"
+	case StepCompleted:
+		return "[✓]"
+	case StepBlocked:
+		return "[!]"
+	default:
+		return "[ ]"
+	}
+}
+
+// validStepStatus 判断状态值是否合法
+func validStepStatus(status PlanStepStatus) bool {
+	_, ok := stepTransitions[status]
+	return ok
+}
+
+// canTransition 判断步骤状态能否从 from 转换到 to
+func canTransition(from, to PlanStepStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range stepTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanStore 计划存储，dir 非空时持久化到 dir/plans.json
+type PlanStore struct {
+	dir    string
+	mu     sync.Mutex
+	loaded bool
+	state  planState
+}
+
+// planState 存储中的计划和活动计划
+type planState struct {
+	plans  map[string]*Plan
+	active string
+}
+
+// planStoreData 持久化文件格式
+type planStoreData struct {
+	Active string  `json:"active,omitempty"`
+	Plans  []*Plan `json:"plans"`
+}
+
+// NewPlanStore 创建计划存储，dir 为空时仅保存在内存中
+func NewPlanStore(dir string) *PlanStore {
+	return &PlanStore{
+		dir:   dir,
+		state: planState{plans: make(map[string]*Plan)},
+	}
+}
+
+var (
+	sharedPlanStores   = make(map[string]*PlanStore)
+	sharedPlanStoresMu sync.Mutex
+)
+
+// SharedPlanStore 返回目录对应的共享计划存储；同一目录的多个存储会互相覆盖 plans.json，
+// 因此同一进程内的计划工具应通过它获取存储。dir 为空时返回独立的内存存储
+func SharedPlanStore(dir string) *PlanStore {
+	if dir == "" {
+		return NewPlanStore("")
+	}
+	key := dir
+	if abs, err := filepath.Abs(dir); err == nil {
+		key = abs
+	}
+	sharedPlanStoresMu.Lock()
+	defer sharedPlanStoresMu.Unlock()
+	store, ok := sharedPlanStores[key]
+	if !ok {
+		store = NewPlanStore(dir)
+		sharedPlanStores[key] = store
+	}
+	return store
+}
+
+// load 首次访问时读取持久化的计划，调用方需持有锁
+func (s *PlanStore) load() error {
+	if s.loaded || s.dir == "" {
+		s.loaded = true
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, planStoreFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.loaded = true
+			return nil
+		}
+		return fmt.Errorf("load plans failed: %v", err)
+	}
+	var stored planStoreData
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("load plans failed: %v", err)
+	}
+	for _, plan := range stored.Plans {
+		s.state.plans[plan.ID] = plan
+	}
+	if _, ok := s.state.plans[stored.Active]; ok {
+		s.state.active = stored.Active
+	}
+	s.loaded = true
+	return nil
+}
+
+// save 将所有计划写入磁盘，调用方需持有锁
+func (s *PlanStore) save(st planState) error {
+	if s.dir == "" {
+		return nil
+	}
+	stored := planStoreData{Active: st.active, Plans: st.sorted()}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, DefaultDirMode); err != nil {
+		return fmt.Errorf("create plan directory failed: %v", err)
+	}
+	return atomicWriteFile(filepath.Join(s.dir, planStoreFile), data)
+}
+
+// clone 深拷贝状态，修改副本不影响原状态
+func (st planState) clone() planState {
+	c := planState{plans: make(map[string]*Plan, len(st.plans)), active: st.active}
+	for id, plan := range st.plans {
+		c.plans[id] = copyPlan(plan)
+	}
+	return c
+}
+
+// sorted 按创建时间返回所有计划
+func (st planState) sorted() []*Plan {
+	plans := make([]*Plan, 0, len(st.plans))
+	for _, plan := range st.plans {
+		plans = append(plans, plan)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		if plans[i].CreatedAt.Equal(plans[j].CreatedAt) {
+			return plans[i].ID < plans[j].ID
+		}
+		return plans[i].CreatedAt.Before(plans[j].CreatedAt)
+	})
+	return plans
+}
+
+// update 在锁内加载计划并修改副本，保存成功后才替换内存中的状态；fn 返回错误时不保存
+func (s *PlanStore) update(fn func(st *planState) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	next := s.state.clone()
+	if err := fn(&next); err != nil {
+		return err
+	}
+	if err := s.save(next); err != nil {
+		return err
+	}
+	s.state = next
+	return nil
+}
+
+// view 在锁内只读访问计划
+func (s *PlanStore) view(fn func(st *planState) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	return fn(&s.state)
+}
+
+// resolve 返回指定计划，id 为空时返回当前活动计划
+func (st *planState) resolve(id string) (*Plan, error) {
+	if id == "" {
+		if st.active == "" {
+			return nil, ErrNoActivePlan
+		}
+		id = st.active
+	}
+	plan, ok := st.plans[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPlanNotFound, id)
+	}
+	return plan, nil
+}
+
+// copyPlan 返回计划的副本，避免调用方修改存储中的数据
+func copyPlan(p *Plan) *Plan {
+	c := *p
+	c.Steps = append([]PlanStep(nil), p.Steps...)
+	return &c
+}
+
+// Create 创建计划并设为活动计划，id 为空时自动生成
+func (s *PlanStore) Create(id, title string, steps []string) (*Plan, error) {
+	if title == "" || len(steps) == 0 {
+		return nil, ErrInvalidArgs
+	}
+	var created *Plan
+	err := s.update(func(st *planState) error {
+		if id == "" {
+			id = st.newID()
+		}
+		if !planIDRe.MatchString(id) {
+			return fmt.Errorf("invalid plan_id %q: use letters, digits, '_', '-' or '.'", id)
+		}
+		if _, ok := st.plans[id]; ok {
+			return fmt.Errorf("plan %q already exists, use update to modify it", id)
+		}
+		now := time.Now()
+		plan := &Plan{ID: id, Title: title, CreatedAt: now, UpdatedAt: now}
+		for _, step := range steps {
+			plan.Steps = append(plan.Steps, PlanStep{Title: step, Status: StepNotStarted, UpdatedAt: now})
+		}
+		st.plans[id] = plan
+		st.active = id
+		created = copyPlan(plan)
+		return nil
+	})
+	return created, err
+}
+
+// newID 生成未被占用的计划 ID
+func (st *planState) newID() string {
+	base := "plan_" + time.Now().Format("20060102_150405")
+	id := base
+	for i := 2; ; i++ {
+		if _, ok := st.plans[id]; !ok {
+			return id
+		}
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// Update 修改计划标题或步骤；与原步骤相同位置且内容相同的步骤保留状态和备注
+func (s *PlanStore) Update(id, title string, steps []string) (*Plan, error) {
+	var updated *Plan
+	err := s.update(func(st *planState) error {
+		plan, err := st.resolve(id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if title != "" {
+			plan.Title = title
+		}
+		if len(steps) > 0 {
+			newSteps := make([]PlanStep, len(steps))
+			for i, step := range steps {
+				if i < len(plan.Steps) && plan.Steps[i].Title == step {
+					newSteps[i] = plan.Steps[i]
+				} else {
+					newSteps[i] = PlanStep{Title: step, Status: StepNotStarted, UpdatedAt: now}
+				}
+			}
+			plan.Steps = newSteps
+		}
+		plan.UpdatedAt = now
+		updated = copyPlan(plan)
+		return nil
+	})
+	return updated, err
+}
+
+// MarkStep 修改步骤状态和备注，状态转换需符合 stepTransitions
+func (s *PlanStore) MarkStep(id string, index int, status PlanStepStatus, notes string) (*Plan, error) {
+	if status != "" && !validStepStatus(status) {
+		return nil, fmt.Errorf("%w: invalid step_status %q, valid statuses are not_started, in_progress, completed, blocked", ErrInvalidArgs, status)
+	}
+	var updated *Plan
+	err := s.update(func(st *planState) error {
+		plan, err := st.resolve(id)
+		if err != nil {
+			return err
+		}
+		if index < 0 || index >= len(plan.Steps) {
+			return fmt.Errorf("invalid step_index %d: valid indices range from 0 to %d", index, len(plan.Steps)-1)
+		}
+		step := &plan.Steps[index]
+		if status != "" {
+			if !canTransition(step.Status, status) {
+				return fmt.Errorf("%w: step %d is %s and cannot become %s", ErrInvalidStepTransition, index, step.Status, status)
+			}
+			step.Status = status
+		}
+		if notes != "" {
+			step.Notes = notes
+		}
+		now := time.Now()
+		step.UpdatedAt = now
+		plan.UpdatedAt = now
+		updated = copyPlan(plan)
+		return nil
+	})
+	return updated, err
+}
+
+// Get 获取计划，id 为空时返回活动计划
+func (s *PlanStore) Get(id string) (*Plan, error) {
+	var found *Plan
+	err := s.view(func(st *planState) error {
+		plan, err := st.resolve(id)
+		if err != nil {
+			return err
+		}
+		found = copyPlan(plan)
+		return nil
+	})
+	return found, err
+}
+
+// SetActive 设置活动计划
+func (s *PlanStore) SetActive(id string) (*Plan, error) {
+	if id == "" {
+		return nil, ErrInvalidArgs
+	}
+	var active *Plan
+	err := s.update(func(st *planState) error {
+		plan, err := st.resolve(id)
+		if err != nil {
+			return err
+		}
+		st.active = plan.ID
+		active = copyPlan(plan)
+		return nil
+	})
+	return active, err
+}
+
+// Delete 删除计划，删除活动计划时清空活动计划
+func (s *PlanStore) Delete(id string) error {
+	if id == "" {
+		return ErrInvalidArgs
+	}
+	return s.update(func(st *planState) error {
+		if _, err := st.resolve(id); err != nil {
+			return err
+		}
+		delete(st.plans, id)
+		if st.active == id {
+			st.active = ""
+		}
+		return nil
+	})
+}
+
+// List 按创建时间列出所有计划，并返回活动计划 ID
+func (s *PlanStore) List() ([]*Plan, string, error) {
+	var plans []*Plan
+	var active string
+	err := s.view(func(st *planState) error {
+		for _, plan := range st.sorted() {
+			plans = append(plans, copyPlan(plan))
+		}
+		active = st.active
+		return nil
+	})
+	return plans, active, err
+}
+
+// PlanningTool 计划管理工具
+type PlanningTool struct {
+	name        string
+	description string
+	store       *PlanStore
+}
+
+// NewPlanningTool 创建计划工具，使用 storeDir 对应的共享存储，storeDir 为空时计划只保存在内存中
+func NewPlanningTool(storeDir string) *PlanningTool {
+	return NewPlanningToolWithStore(SharedPlanStore(storeDir))
+}
+
+// NewPlanningToolWithStore 使用指定存储创建计划工具，便于多个智能体共享计划
+func NewPlanningToolWithStore(store *PlanStore) *PlanningTool {
+	return &PlanningTool{
+		name:        "planning",
+		description: "计划管理工具，支持 create、update、list、get、set_active、mark_step、delete，步骤状态为 not_started/in_progress/completed/blocked",
+		store:       store,
+	}
+}
+
+// Name 返回工具名称
+func (t *PlanningTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *PlanningTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *PlanningTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// Store 返回计划存储
+func (t *PlanningTool) Store() *PlanStore {
+	return t.store
+}
+
+// PlanningInput 计划工具参数
+type PlanningInput struct {
+	Command    string         `json:"command"`
+	Operation  string         `json:"operation,omitempty"`
+	PlanID     string         `json:"plan_id,omitempty"`
+	Title      string         `json:"title,omitempty"`
+	Steps      []string       `json:"steps,omitempty"`
+	StepIndex  *int           `json:"step_index,omitempty"`
+	StepStatus PlanStepStatus `json:"step_status,omitempty"`
+	StepNotes  string         `json:"step_notes,omitempty"`
+}
+
+// PlanSummary 计划列表项
+type PlanSummary struct {
+	ID       string       `json:"plan_id"`
+	Title    string       `json:"title"`
+	Active   bool         `json:"active"`
+	Progress PlanProgress `json:"progress"`
+}
+
+// PlanningResult 计划工具结果，Output 为渲染后的文本
+type PlanningResult struct {
+	Output string        `json:"output"`
+	Plan   *Plan         `json:"plan,omitempty"`
+	Plans  []PlanSummary `json:"plans,omitempty"`
+}
+
+// String 返回渲染后的文本，便于直接反馈给 LLM
+func (r PlanningResult) String() string {
+	return r.Output
+}
+
+// 预定义的计划命令
+const (
+	PlanCmdCreate    = "create"
+	PlanCmdUpdate    = "update"
+	PlanCmdList      = "list"
+	PlanCmdGet       = "get"
+	PlanCmdSetActive = "set_active"
+	PlanCmdMarkStep  = "mark_step"
+	PlanCmdDelete    = "delete"
+)
+
+// Run 执行计划命令
+func (t *PlanningTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in PlanningInput
+	if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	// 兼容其他工具使用的 operation 字段
+	if in.Command == "" {
+		in.Command = in.Operation
+	}
+
+	switch in.Command {
+	case PlanCmdCreate:
+		if in.Title == "" || len(in.Steps) == 0 {
+			return nil, fmt.Errorf("%w: create requires title and non-empty steps", ErrInvalidArgs)
+		}
+		plan, err := t.store.Create(in.PlanID, in.Title, in.Steps)
+		if err != nil {
+			return nil, err
+		}
+		return planResult(fmt.Sprintf("Plan created successfully with ID: %s", plan.ID), plan), nil
+	case PlanCmdUpdate:
+		if in.PlanID == "" {
+			return nil, fmt.Errorf("%w: update requires plan_id", ErrInvalidArgs)
+		}
+		plan, err := t.store.Update(in.PlanID, in.Title, in.Steps)
+		if err != nil {
+			return nil, err
+		}
+		return planResult(fmt.Sprintf("Plan updated successfully: %s", plan.ID), plan), nil
+	case PlanCmdList:
+		return t.list()
+	case PlanCmdGet:
+		plan, err := t.store.Get(in.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		return planResult("", plan), nil
+	case PlanCmdSetActive:
+		plan, err := t.store.SetActive(in.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		return planResult(fmt.Sprintf("Plan '%s' is now the active plan.", plan.ID), plan), nil
+	case PlanCmdMarkStep:
+		if in.StepIndex == nil {
+			return nil, fmt.Errorf("%w: mark_step requires step_index", ErrInvalidArgs)
+		}
+		plan, err := t.store.MarkStep(in.PlanID, *in.StepIndex, in.StepStatus, in.StepNotes)
+		if err != nil {
+			return nil, err
+		}
+		return planResult(fmt.Sprintf("Step %d updated in plan '%s'.", *in.StepIndex, plan.ID), plan), nil
+	case PlanCmdDelete:
+		if err := t.store.Delete(in.PlanID); err != nil {
+			return nil, err
+		}
+		return PlanningResult{Output: fmt.Sprintf("Plan '%s' has been deleted.", in.PlanID)}, nil
+	default:
+		return nil, ErrInvalidOperation
+	}
+}
+
+// list 列出所有计划及进度
+func (t *PlanningTool) list() (interface{}, error) {
+	plans, active, err := t.store.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return PlanningResult{Output: "No plans available. Create a plan with the 'create' command."}, nil
+	}
+	result := PlanningResult{Plans: make([]PlanSummary, 0, len(plans))}
+	var sb strings.Builder
+	sb.WriteString("Available plans:\n")
+	for _, plan := range plans {
+		summary := PlanSummary{ID: plan.ID, Title: plan.Title, Active: plan.ID == active, Progress: plan.Progress()}
+		result.Plans = append(result.Plans, summary)
+		marker := ""
+		if summary.Active {
+			marker = " (active)"
+		}
+		fmt.Fprintf(&sb, "• %s%s: %s - %d/%d steps completed\n", plan.ID, marker, plan.Title, summary.Progress.Completed, summary.Progress.Total)
+	}
+	result.Output = sb.String()
+	return result, nil
+}
+
+// planResult 构造带渲染视图的结果
+func planResult(message string, plan *Plan) PlanningResult {
+	output := plan.Render()
+	if message != "" {
+		output = message + "\n\n" + output
+	}
+	return PlanningResult{Output: output, Plan: plan}
+}
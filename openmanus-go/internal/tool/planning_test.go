@@ -0,0 +1,199 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanStepTransitions(t *testing.T) {
+	tests := []struct {
+		from    PlanStepStatus
+		to      PlanStepStatus
+		wantErr error
+	}{
+		{StepNotStarted, StepInProgress, nil},
+		{StepNotStarted, StepCompleted, nil},
+		{StepNotStarted, StepBlocked, nil},
+		{StepInProgress, StepNotStarted, nil},
+		{StepInProgress, StepCompleted, nil},
+		{StepInProgress, StepBlocked, nil},
+		{StepBlocked, StepNotStarted, nil},
+		{StepBlocked, StepInProgress, nil},
+		{StepBlocked, StepCompleted, ErrInvalidStepTransition},
+		{StepCompleted, StepInProgress, nil},
+		{StepCompleted, StepNotStarted, ErrInvalidStepTransition},
+		{StepCompleted, StepBlocked, ErrInvalidStepTransition},
+		{StepCompleted, StepCompleted, nil},
+		{StepNotStarted, "done", ErrInvalidArgs},
+		{StepNotStarted, "COMPLETED", ErrInvalidArgs},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			store := NewPlanStore("")
+			if _, err := store.Create("p", "plan", []string{"step"}); err != nil {
+				t.Fatal(err)
+			}
+			// 先经过合法路径到达起始状态
+			path := map[PlanStepStatus][]PlanStepStatus{
+				StepInProgress: {StepInProgress},
+				StepBlocked:    {StepBlocked},
+				StepCompleted:  {StepCompleted},
+			}[tt.from]
+			for _, status := range path {
+				if _, err := store.MarkStep("p", 0, status, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			plan, err := store.MarkStep("p", 0, tt.to, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && plan.Steps[0].Status != tt.to {
+				t.Fatalf("status = %s, want %s", plan.Steps[0].Status, tt.to)
+			}
+			if err != nil {
+				// 被拒绝的转换不修改步骤
+				got, _ := store.Get("p")
+				if got.Steps[0].Status != tt.from {
+					t.Fatalf("status changed to %s after rejected transition", got.Steps[0].Status)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanRender(t *testing.T) {
+	plan := &Plan{
+		ID:    "p1",
+		Title: "Ship it",
+		Steps: []PlanStep{
+			{Title: "design", Status: StepCompleted},
+			{Title: "build", Status: StepInProgress, Notes: "half done"},
+			{Title: "review", Status: StepBlocked},
+			{Title: "release", Status: StepNotStarted},
+		},
+	}
+	want := "Plan: Ship it (ID: p1)\n" +
+		"======================\n\n" +
+		"Progress: 1/4 steps completed (25.0%)\n" +
+		"Status: 1 completed, 1 in progress, 1 blocked, 1 not started\n\n" +
+		"Steps:\n" +
+		"0. [✓] design\n" +
+		"1. [→] build\n" +
+		"   Notes: half done\n" +
+		"2. [!] review\n" +
+		"3. [ ] release\n"
+	if got := plan.Render(); got != want {
+		t.Fatalf("Render() =\n%s\nwant\n%s", got, want)
+	}
+	if got := (&Plan{ID: "e", Title: "empty"}).Render(); !strings.Contains(got, "\nProgress: 0/0 steps completed (0.0%)\n") {
+		t.Fatalf("empty plan render:\n%s", got)
+	}
+}
+
+func TestPlanStoreReload(t *testing.T) {
+	dir := t.TempDir()
+	store := NewPlanStore(dir)
+	if _, err := store.Create("first", "First", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.MarkStep("first", 1, StepInProgress, "working"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create("second", "Second", []string{"c"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SetActive("first"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := NewPlanStore(dir)
+	plans, active, err := reloaded.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 2 || plans[0].ID != "first" || plans[1].ID != "second" || active != "first" {
+		t.Fatalf("reloaded plans = %+v, active = %q", plans, active)
+	}
+	step := plans[0].Steps[1]
+	if step.Status != StepInProgress || step.Notes != "working" {
+		t.Fatalf("reloaded step = %+v", step)
+	}
+	// 活动计划在重新加载后仍可省略 plan_id
+	if plan, err := reloaded.Get(""); err != nil || plan.ID != "first" {
+		t.Fatalf("Get active = %+v, %v", plan, err)
+	}
+
+	if err := reloaded.Delete("first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPlanStore(dir).Get(""); !errors.Is(err, ErrNoActivePlan) {
+		t.Fatalf("active plan after delete: %v", err)
+	}
+}
+
+func TestPlanStoreSaveFailureKeepsState(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "plans")
+	store := NewPlanStore(dir)
+	if _, err := store.Create("p", "plan", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 持久化目录被文件占用时保存失败
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.MarkStep("p", 0, StepCompleted, "done"); err == nil {
+		t.Fatal("save into a file path succeeded")
+	}
+	if _, err := store.Create("q", "other", []string{"b"}); err == nil {
+		t.Fatal("save into a file path succeeded")
+	}
+
+	plan, err := store.Get("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.ID != "p" || plan.Steps[0].Status != StepNotStarted || plan.Steps[0].Notes != "" {
+		t.Fatalf("failed save changed memory: %+v", plan)
+	}
+	if _, err := store.Get("q"); !errors.Is(err, ErrPlanNotFound) {
+		t.Fatalf("failed create is visible: %v", err)
+	}
+}
+
+func TestSharedPlanStore(t *testing.T) {
+	dir := t.TempDir()
+	if SharedPlanStore(dir) != SharedPlanStore(dir+string(filepath.Separator)+".") {
+		t.Fatal("same directory returned different stores")
+	}
+	if SharedPlanStore("") == SharedPlanStore("") {
+		t.Fatal("memory stores are shared")
+	}
+
+	// 两个智能体的计划工具写同一目录时不会互相覆盖
+	a, b := NewPlanningTool(dir), NewPlanningTool(dir)
+	ctx := context.Background()
+	if _, err := a.Run(ctx, map[string]interface{}{"command": "create", "plan_id": "from-a", "title": "A", "steps": []interface{}{"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Run(ctx, map[string]interface{}{"command": "create", "plan_id": "from-b", "title": "B", "steps": []interface{}{"y"}}); err != nil {
+		t.Fatal(err)
+	}
+	plans, _, err := NewPlanStore(dir).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("persisted plans = %+v", plans)
+	}
+}
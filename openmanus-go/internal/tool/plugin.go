@@ -0,0 +1,513 @@
+package tool
+
+// 外部工具插件协议
+//
+// 插件是任意语言编写的可执行程序，主机通过 stdin 发送请求、从 stdout 读取响应，
+// 每行一条 JSON-RPC 2.0 消息（UTF-8，以 \n 结尾）；stderr 只用于日志，进程退出时附在错误信息中。
+//
+//  1. initialize：进程启动后主机发送的第一个请求
+//     params: {"protocol_version": 1, "workspace": "/abs/workspace"}
+//     result: {"name": "...", "version": "...", "tools": [
+//     {"name": "...", "description": "...", "parameters": {JSON Schema}, "timeout": 秒（可选）}]}
+//  2. tools/call：调用工具
+//     params: {"name": "...", "arguments": {...}}
+//     result: {"content": 任意 JSON, "is_error": false}
+//     is_error 为 true 或返回 JSON-RPC error 时视为调用失败
+//  3. shutdown：通知，插件收到后应尽快退出；stdin 随后被关闭
+//
+// 插件从配置的目录中发现：目录下的可执行文件，或包含 plugin.json 清单的子目录，例如
+//
+//	{"name": "jira", "command": "python3", "args": ["main.py"], "env": {"JIRA_TOKEN": "${JIRA_TOKEN}"}, "timeout": 60}
+//
+// 进程崩溃后按退避间隔自动重启，短时间内崩溃次数过多则停用；调用超时会结束插件进程，
+// 避免超时的请求在插件中继续运行。
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PluginProtocolVersion 插件协议版本
+const PluginProtocolVersion = 1
+
+// 插件清单文件名
+const pluginManifestFile = "plugin.json"
+
+// 插件默认限制
+const (
+	defaultPluginTimeout      = 60 * time.Second
+	defaultPluginStartTimeout = 10 * time.Second
+	defaultPluginMaxRestarts  = 5
+	pluginRestartWindow       = time.Minute
+	pluginShutdownGrace       = 2 * time.Second
+	maxPluginRestartBackoff   = 30 * time.Second
+)
+
+// pluginRestartBackoff 崩溃后首次重启前的等待时间，重启失败时翻倍
+var pluginRestartBackoff = time.Second
+
+// PluginConfig 插件管理配置
+type PluginConfig struct {
+	// Dir 插件目录
+	Dir string
+	// Timeout 单次调用的默认超时
+	Timeout time.Duration
+	// StartTimeout 启动并完成 initialize 的超时
+	StartTimeout time.Duration
+	// MaxRestarts 一分钟内允许的最大重启次数，超过后停用插件
+	MaxRestarts int
+	// Workspace 传给插件的工作区根目录
+	Workspace string
+}
+
+// pluginManifest 插件清单
+type pluginManifest struct {
+	Name    string            `json:"name"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	// Timeout 调用超时（秒），覆盖全局配置
+	Timeout int `json:"timeout"`
+	// Dir 插件的工作目录
+	Dir string `json:"-"`
+}
+
+// pluginToolSpec 插件声明的工具
+type pluginToolSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+	Timeout     int                    `json:"timeout"`
+}
+
+// pluginInfo initialize 的返回结果
+type pluginInfo struct {
+	Name    string           `json:"name"`
+	Version string           `json:"version"`
+	Tools   []pluginToolSpec `json:"tools"`
+}
+
+// pluginCallResult tools/call 的返回结果
+type pluginCallResult struct {
+	Content json.RawMessage `json:"content"`
+	IsError bool            `json:"is_error"`
+}
+
+// PluginManager 发现、启动并监管插件进程
+type PluginManager struct {
+	config  PluginConfig
+	mu      sync.Mutex
+	plugins map[string]*pluginProcess
+	tools   []*PluginTool
+}
+
+// NewPluginManager 创建插件管理器
+func NewPluginManager(config PluginConfig) *PluginManager {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultPluginTimeout
+	}
+	if config.StartTimeout <= 0 {
+		config.StartTimeout = defaultPluginStartTimeout
+	}
+	if config.MaxRestarts <= 0 {
+		config.MaxRestarts = defaultPluginMaxRestarts
+	}
+	return &PluginManager{config: config, plugins: make(map[string]*pluginProcess)}
+}
+
+// Discover 扫描插件目录并启动所有插件；单个插件失败不影响其他插件，错误合并返回
+func (m *PluginManager) Discover(ctx context.Context) ([]*PluginTool, error) {
+	manifests, err := discoverPlugins(m.config.Dir)
+	var tools []*PluginTool
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, manifest := range manifests {
+		loaded, err := m.load(ctx, manifest)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		tools = append(tools, loaded...)
+	}
+	return tools, errors.Join(errs...)
+}
+
+// load 启动一个插件并返回它声明的工具；与已加载工具重名的工具被跳过
+func (m *PluginManager) load(ctx context.Context, manifest pluginManifest) ([]*PluginTool, error) {
+	m.mu.Lock()
+	if _, ok := m.plugins[manifest.Name]; ok {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("plugin %s: already loaded", manifest.Name)
+	}
+	m.mu.Unlock()
+
+	p := &pluginProcess{manifest: manifest, config: m.config, backoff: pluginRestartBackoff}
+	info, err := p.start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", manifest.Name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.plugins[manifest.Name] = p
+	existing := make(map[string]bool, len(m.tools))
+	for _, t := range m.tools {
+		existing[t.name] = true
+	}
+	var tools []*PluginTool
+	var errs []error
+	for _, spec := range info.Tools {
+		if spec.Name == "" || existing[spec.Name] {
+			errs = append(errs, fmt.Errorf("plugin %s: skip tool %q: empty or duplicate name", manifest.Name, spec.Name))
+			continue
+		}
+		existing[spec.Name] = true
+		timeout := m.config.Timeout
+		if manifest.Timeout > 0 {
+			timeout = time.Duration(manifest.Timeout) * time.Second
+		}
+		if spec.Timeout > 0 {
+			timeout = time.Duration(spec.Timeout) * time.Second
+		}
+		tools = append(tools, &PluginTool{
+			name:        spec.Name,
+			description: spec.Description,
+			parameters:  spec.Parameters,
+			timeout:     timeout,
+			plugin:      p,
+		})
+	}
+	m.tools = append(m.tools, tools...)
+	return tools, errors.Join(errs...)
+}
+
+// Tools 返回已加载的全部插件工具
+func (m *PluginManager) Tools() []*PluginTool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*PluginTool(nil), m.tools...)
+}
+
+// Register 把插件工具注册到工具集合，已存在的同名工具不会被覆盖
+func (m *PluginManager) Register(tc *ToolCollection) []string {
+	var names []string
+	for _, t := range m.Tools() {
+		if _, err := tc.Get(t.name); err == nil {
+			continue
+		}
+		if tc.Register(t.name, t) == nil {
+			names = append(names, t.name)
+		}
+	}
+	return names
+}
+
+// Close 停止所有插件
+func (m *PluginManager) Close() {
+	m.mu.Lock()
+	plugins := make([]*pluginProcess, 0, len(m.plugins))
+	for _, p := range m.plugins {
+		plugins = append(plugins, p)
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range plugins {
+		wg.Add(1)
+		go func(p *pluginProcess) {
+			defer wg.Done()
+			p.stop()
+		}(p)
+	}
+	wg.Wait()
+}
+
+// discoverPlugins 列出插件目录中的可执行文件和带清单的子目录；目录不存在时没有插件，
+// 清单有误的插件被跳过并返回错误
+func discoverPlugins(dir string) ([]pluginManifest, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read plugin dir failed: %v", err)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []pluginManifest
+	var errs []error
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		p := filepath.Join(abs, e.Name())
+		if e.IsDir() {
+			m, err := readPluginManifest(p)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			manifests = append(manifests, m)
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !isExecutable(info) {
+			continue
+		}
+		manifests = append(manifests, pluginManifest{
+			Name:    strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())),
+			Command: p,
+			Dir:     abs,
+		})
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Name < manifests[j].Name })
+	return manifests, errors.Join(errs...)
+}
+
+// readPluginManifest 读取子目录中的 plugin.json；相对路径的命令相对于插件目录
+func readPluginManifest(dir string) (pluginManifest, error) {
+	var m pluginManifest
+	data, err := os.ReadFile(filepath.Join(dir, pluginManifestFile))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("parse %s failed: %v", filepath.Join(dir, pluginManifestFile), err)
+	}
+	if m.Command == "" {
+		return m, fmt.Errorf("%s: command is required", filepath.Join(dir, pluginManifestFile))
+	}
+	if m.Name == "" {
+		m.Name = filepath.Base(dir)
+	}
+	if strings.ContainsRune(m.Command, '/') || strings.ContainsRune(m.Command, filepath.Separator) {
+		if !filepath.IsAbs(m.Command) {
+			m.Command = filepath.Join(dir, m.Command)
+		}
+	}
+	m.Dir = dir
+	return m, nil
+}
+
+func isExecutable(info os.FileInfo) bool {
+	if !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(info.Name()))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+	return info.Mode().Perm()&0111 != 0
+}
+
+// pluginProcess 监管一个插件进程：崩溃后退避重启，频繁崩溃时停用
+type pluginProcess struct {
+	manifest pluginManifest
+	config   PluginConfig
+	backoff  time.Duration
+
+	mu       sync.Mutex
+	conn     *pluginConn
+	restarts []time.Time
+	disabled error
+	stopped  bool
+}
+
+// start 启动进程并完成 initialize
+func (p *pluginProcess) start(ctx context.Context) (*pluginInfo, error) {
+	conn, err := startPluginConn(p.manifest, []string{"OPENMANUS_WORKSPACE=" + p.config.Workspace})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.config.StartTimeout)
+	defer cancel()
+	var info pluginInfo
+	err = conn.Call(ctx, "initialize", map[string]interface{}{
+		"protocol_version": PluginProtocolVersion,
+		"workspace":        p.config.Workspace,
+	}, &info)
+	if err != nil {
+		conn.Kill()
+		<-conn.closed
+		return nil, fmt.Errorf("initialize failed: %v", err)
+	}
+
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		conn.Close(pluginShutdownGrace)
+		return nil, fmt.Errorf("plugin %s is stopped", p.manifest.Name)
+	}
+	p.conn = conn
+	p.mu.Unlock()
+	go p.supervise(conn)
+	return &info, nil
+}
+
+// supervise 等待进程退出并按退避间隔重启
+func (p *pluginProcess) supervise(conn *pluginConn) {
+	backoff := p.backoff
+	<-conn.closed
+	for {
+		p.mu.Lock()
+		if p.stopped || p.conn != conn {
+			p.mu.Unlock()
+			return
+		}
+		// 清理超出窗口的重启记录
+		now := time.Now()
+		recent := p.restarts[:0]
+		for _, t := range p.restarts {
+			if now.Sub(t) < pluginRestartWindow {
+				recent = append(recent, t)
+			}
+		}
+		p.restarts = recent
+		if len(p.restarts) >= p.config.MaxRestarts {
+			p.disabled = fmt.Errorf("plugin %s restarted %d times within %s, disabled: %v", p.manifest.Name, len(p.restarts), pluginRestartWindow, conn.err)
+			p.conn = nil
+			p.mu.Unlock()
+			return
+		}
+		p.restarts = append(p.restarts, now)
+		p.mu.Unlock()
+
+		time.Sleep(backoff)
+		if _, err := p.start(context.Background()); err == nil {
+			return
+		}
+		backoff = min(backoff*2, maxPluginRestartBackoff)
+	}
+}
+
+// connection 返回运行中的连接
+func (p *pluginProcess) connection() (*pluginConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.disabled != nil {
+		return nil, p.disabled
+	}
+	if p.stopped {
+		return nil, fmt.Errorf("plugin %s is stopped", p.manifest.Name)
+	}
+	if p.conn == nil {
+		return nil, fmt.Errorf("plugin %s is restarting", p.manifest.Name)
+	}
+	select {
+	case <-p.conn.closed:
+		return nil, fmt.Errorf("plugin %s is restarting: %v", p.manifest.Name, p.conn.err)
+	default:
+	}
+	return p.conn, nil
+}
+
+// call 调用插件工具；超时时结束进程，由监管协程重启
+func (p *pluginProcess) call(ctx context.Context, name string, args interface{}, timeout time.Duration) (*pluginCallResult, error) {
+	conn, err := p.connection()
+	if err != nil {
+		return nil, err
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var result pluginCallResult
+	err = conn.Call(callCtx, "tools/call", map[string]interface{}{"name": name, "arguments": args}, &result)
+	if err != nil && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		conn.Kill()
+		return nil, fmt.Errorf("plugin tool %s timed out after %s", name, timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// stop 停止进程，不再重启
+func (p *pluginProcess) stop() {
+	p.mu.Lock()
+	p.stopped = true
+	conn := p.conn
+	p.conn = nil
+	p.mu.Unlock()
+	if conn != nil {
+		conn.Close(pluginShutdownGrace)
+	}
+}
+
+// PluginTool 插件提供的工具
+type PluginTool struct {
+	name        string
+	description string
+	parameters  map[string]interface{}
+	timeout     time.Duration
+	plugin      *pluginProcess
+}
+
+// Name 返回工具名称
+func (t *PluginTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *PluginTool) Description() string {
+	return t.description
+}
+
+// Parameters 返回插件声明的参数 JSON Schema
+func (t *PluginTool) Parameters() map[string]interface{} {
+	return t.parameters
+}
+
+// Plugin 返回工具所属插件的名称
+func (t *PluginTool) Plugin() string {
+	return t.plugin.manifest.Name
+}
+
+// Execute 执行工具功能
+func (t *PluginTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// Run 调用插件；字符串结果原样返回，其他 JSON 结果格式化为文本
+func (t *PluginTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	result, err := t.plugin.call(ctx, t.name, input, t.timeout)
+	if err != nil {
+		return nil, err
+	}
+	var content interface{}
+	if len(result.Content) > 0 {
+		if err := json.Unmarshal(result.Content, &content); err != nil {
+			return nil, fmt.Errorf("decode plugin result failed: %v", err)
+		}
+	}
+	text, ok := content.(string)
+	if !ok && content != nil {
+		data, _ := json.MarshalIndent(content, "", "  ")
+		text = string(data)
+	}
+	if result.IsError {
+		return nil, fmt.Errorf("%s: %s", t.name, text)
+	}
+	return text, nil
+}
@@ -0,0 +1,239 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 单条插件消息的大小上限
+const maxPluginMessageSize = 16 * 1024 * 1024
+
+// 保留插件 stderr 末尾的字节数，进程退出时附在错误信息中
+const pluginStderrTail = 4096
+
+// pluginMessage JSON-RPC 2.0 消息
+type pluginMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *pluginRPCError `json:"error,omitempty"`
+}
+
+// pluginRPCError JSON-RPC 错误
+type pluginRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *pluginRPCError) Error() string {
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("plugin error %d: %s (%s)", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
+
+// tailBuffer 只保留最后 size 个字节
+type tailBuffer struct {
+	mu   sync.Mutex
+	size int
+	buf  []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.size {
+		b.buf = b.buf[len(b.buf)-b.size:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
+
+// pluginConn 一个运行中的插件进程，请求和响应按行通过 stdin/stdout 传输
+type pluginConn struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stderr  *tailBuffer
+	nextID  int64
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[int64]chan pluginMessage
+	closed  chan struct{}
+	err     error
+}
+
+// startPluginConn 启动插件进程
+func startPluginConn(m pluginManifest, env []string) (*pluginConn, error) {
+	cmd := exec.Command(m.Command, m.Args...)
+	cmd.Dir = m.Dir
+	cmd.Env = append(os.Environ(), env...)
+	for k, v := range m.Env {
+		cmd.Env = append(cmd.Env, k+"="+os.ExpandEnv(v))
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("create stdin pipe failed: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("create stdout pipe failed: %v", err)
+	}
+	c := &pluginConn{
+		cmd:     cmd,
+		stdin:   stdin,
+		stderr:  &tailBuffer{size: pluginStderrTail},
+		pending: make(map[int64]chan pluginMessage),
+		closed:  make(chan struct{}),
+	}
+	cmd.Stderr = c.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start plugin %s failed: %v", m.Name, err)
+	}
+	go c.readLoop(stdout)
+	return c, nil
+}
+
+// readLoop 读取响应并分发给等待中的调用；插件发来的通知和请求被忽略
+func (c *pluginConn) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxPluginMessageSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var msg pluginMessage
+		if err := json.Unmarshal(line, &msg); err != nil || msg.ID == nil || msg.Method != "" {
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+
+	// stdout 关闭后等待进程退出，把退出状态和 stderr 末尾作为错误原因；
+	// 输出无法解析（例如单行过长）时结束进程
+	err := scanner.Err()
+	if err != nil {
+		c.Kill()
+	}
+	waitErr := c.cmd.Wait()
+	if err == nil {
+		err = waitErr
+	}
+	if err == nil {
+		err = fmt.Errorf("plugin exited")
+	} else {
+		err = fmt.Errorf("plugin exited: %v", err)
+	}
+	if tail := c.stderr.String(); tail != "" {
+		err = fmt.Errorf("%v: %s", err, tail)
+	}
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.closed)
+}
+
+// Call 发送请求并等待结果
+func (c *pluginConn) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan pluginMessage, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.send(pluginMessage{ID: &id, Method: method, Params: params}); err != nil {
+		c.forget(id)
+		return fmt.Errorf("send %s failed: %v", method, err)
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil && len(msg.Result) > 0 {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("decode %s result failed: %v", method, err)
+			}
+		}
+		return nil
+	case <-c.closed:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.err
+	case <-ctx.Done():
+		c.forget(id)
+		return ctx.Err()
+	}
+}
+
+// Notify 发送不需要响应的通知
+func (c *pluginConn) Notify(method string, params interface{}) error {
+	return c.send(pluginMessage{Method: method, Params: params})
+}
+
+func (c *pluginConn) send(msg pluginMessage) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err = c.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (c *pluginConn) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Close 发送 shutdown 通知并关闭 stdin，grace 内未退出则结束进程
+func (c *pluginConn) Close(grace time.Duration) {
+	c.Notify("shutdown", nil)
+	c.stdin.Close()
+	select {
+	case <-c.closed:
+	case <-time.After(grace):
+		c.Kill()
+		<-c.closed
+	}
+}
+
+// Kill 立即结束进程
+func (c *pluginConn) Kill() {
+	if c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+}
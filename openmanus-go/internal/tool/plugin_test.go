@@ -0,0 +1,399 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openmanus/openmanus-go/internal/sandbox"
+)
+
+// TestMain 测试程序同样作为插件进程以及命名空间沙箱的初始化进程重新执行
+func TestMain(m *testing.M) {
+	sandbox.Init()
+	if os.Getenv("OPENMANUS_TEST_PLUGIN") == "1" {
+		runTestPlugin()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin 按插件协议提供 echo、text、fail、rpc_error、crash、sleep、env 工具；
+// 每次启动和收到 shutdown 时在 PLUGIN_TEST_DIR/events 中记录一行
+func runTestPlugin() {
+	dir := os.Getenv("PLUGIN_TEST_DIR")
+	logEvent := func(event string) {
+		f, err := os.OpenFile(filepath.Join(dir, "events"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintln(f, event)
+			f.Close()
+		}
+	}
+	logEvent(fmt.Sprintf("start %d", os.Getpid()))
+	if _, err := os.Stat(filepath.Join(dir, "fail_init")); err == nil {
+		fmt.Fprintln(os.Stderr, "init refused")
+		os.Exit(3)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	reply := func(id *int64, result interface{}, rpcErr *pluginRPCError) {
+		msg := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+		if rpcErr != nil {
+			msg["error"] = rpcErr
+		} else {
+			msg["result"] = result
+		}
+		out.Encode(msg)
+	}
+	// 非 JSON 输出和插件发来的通知都应被主机忽略
+	fmt.Println("plugin booting")
+	out.Encode(map[string]interface{}{"jsonrpc": "2.0", "method": "log", "params": "ready"})
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg struct {
+			ID     *int64 `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Name      string                 `json:"name"`
+				Arguments map[string]interface{} `json:"arguments"`
+				Workspace string                 `json:"workspace"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &msg) != nil {
+			continue
+		}
+		switch msg.Method {
+		case "initialize":
+			tools := []pluginToolSpec{{Name: "echo", Description: "返回参数", Parameters: map[string]interface{}{"type": "object"}}}
+			for _, name := range []string{"text", "fail", "rpc_error", "crash", "sleep", "env"} {
+				tools = append(tools, pluginToolSpec{Name: name})
+			}
+			tools = append(tools, pluginToolSpec{Name: "slow", Timeout: 7})
+			reply(msg.ID, pluginInfo{Name: "test", Version: "1.0", Tools: tools}, nil)
+		case "tools/call":
+			switch msg.Params.Name {
+			case "echo":
+				reply(msg.ID, map[string]interface{}{"content": msg.Params.Arguments}, nil)
+			case "text":
+				reply(msg.ID, map[string]interface{}{"content": "plain text"}, nil)
+			case "fail":
+				reply(msg.ID, map[string]interface{}{"content": "bad input", "is_error": true}, nil)
+			case "rpc_error":
+				reply(msg.ID, nil, &pluginRPCError{Code: -32000, Message: "nope"})
+			case "crash":
+				fmt.Fprintln(os.Stderr, "boom")
+				os.Exit(2)
+			case "sleep":
+				time.Sleep(time.Minute)
+			case "env":
+				reply(msg.ID, map[string]interface{}{"content": os.Getenv("GREETING") + "|" + os.Getenv("OPENMANUS_WORKSPACE")}, nil)
+			}
+		case "shutdown":
+			logEvent("shutdown")
+			return
+		}
+	}
+}
+
+// testPlugin 通过插件目录中的清单加载的测试插件
+type testPlugin struct {
+	manager *PluginManager
+	tools   map[string]*PluginTool
+	dir     string
+}
+
+// newTestPlugin 加载测试插件；backoff 为崩溃后首次重启前的等待时间
+func newTestPlugin(t *testing.T, config PluginConfig, backoff time.Duration) *testPlugin {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := pluginRestartBackoff
+	pluginRestartBackoff = backoff
+
+	dir := t.TempDir()
+	manifest, _ := json.Marshal(map[string]interface{}{
+		"command": exe,
+		"env": map[string]string{
+			"OPENMANUS_TEST_PLUGIN": "1",
+			"PLUGIN_TEST_DIR":       dir,
+			"GREETING":              "${TEST_PLUGIN_GREETING}",
+		},
+	})
+	config.Dir = filepath.Join(dir, "plugins")
+	writeTestFile(t, config.Dir, "test/plugin.json", string(manifest))
+
+	m := NewPluginManager(config)
+	t.Cleanup(func() {
+		m.Close()
+		pluginRestartBackoff = old
+	})
+	loaded, err := m.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := make(map[string]*PluginTool)
+	for _, tool := range loaded {
+		tools[tool.Name()] = tool
+	}
+	return &testPlugin{manager: m, tools: tools, dir: dir}
+}
+
+// events 返回插件记录的事件
+func (p *testPlugin) events() []string {
+	data, _ := os.ReadFile(filepath.Join(p.dir, "events"))
+	return strings.Fields(strings.ReplaceAll(string(data), "start ", "start:"))
+}
+
+// starts 返回插件进程的启动次数
+func (p *testPlugin) starts() int {
+	n := 0
+	for _, e := range p.events() {
+		if strings.HasPrefix(e, "start:") {
+			n++
+		}
+	}
+	return n
+}
+
+// waitFor 等待条件成立
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitRunning 等待插件重启完成、可以接受调用
+func (p *testPlugin) waitRunning(t *testing.T) {
+	t.Helper()
+	waitFor(t, 10*time.Second, "plugin restart", func() bool {
+		_, err := p.tools["echo"].plugin.connection()
+		return err == nil
+	})
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "zeta.sh", "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(dir, "zeta.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "notes.txt", "not executable")
+	writeTestFile(t, dir, ".hidden/plugin.json", `{"command": "x"}`)
+	writeTestFile(t, dir, "jira/plugin.json", `{"name": "jira", "command": "bin/jira", "args": ["--stdio"], "timeout": 5}`)
+	writeTestFile(t, dir, "python/plugin.json", `{"command": "python3"}`)
+	writeTestFile(t, dir, "empty/readme.md", "no manifest")
+	writeTestFile(t, dir, "broken/plugin.json", `{"name": "broken"}`)
+
+	manifests, err := discoverPlugins(dir)
+	if err == nil || !strings.Contains(err.Error(), "command is required") {
+		t.Fatalf("broken manifest error = %v", err)
+	}
+	abs, _ := filepath.Abs(dir)
+	want := []pluginManifest{
+		// 相对路径的命令相对于插件目录，不含路径的命令按 PATH 查找
+		{Name: "jira", Command: filepath.Join(abs, "jira", "bin", "jira"), Args: []string{"--stdio"}, Timeout: 5, Dir: filepath.Join(abs, "jira")},
+		{Name: "python", Command: "python3", Dir: filepath.Join(abs, "python")},
+		{Name: "zeta", Command: filepath.Join(abs, "zeta.sh"), Dir: abs},
+	}
+	if !reflect.DeepEqual(manifests, want) {
+		t.Fatalf("manifests =\n%+v\nwant\n%+v", manifests, want)
+	}
+
+	if manifests, err := discoverPlugins(filepath.Join(dir, "missing")); err != nil || manifests != nil {
+		t.Fatalf("missing dir = %v, %v", manifests, err)
+	}
+}
+
+func TestPluginToolCall(t *testing.T) {
+	t.Setenv("TEST_PLUGIN_GREETING", "hello")
+	p := newTestPlugin(t, PluginConfig{Timeout: 3 * time.Second, Workspace: "/work"}, time.Second)
+	var names []string
+	for _, tool := range p.manager.Tools() {
+		names = append(names, tool.Name())
+	}
+	if want := []string{"echo", "text", "fail", "rpc_error", "crash", "sleep", "env", "slow"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("tools = %v", names)
+	}
+	if tool := p.tools["echo"]; tool.Plugin() != "test" || tool.Description() != "返回参数" || tool.timeout != 3*time.Second {
+		t.Fatalf("echo tool = %+v", tool)
+	}
+	// 工具声明的超时覆盖全局配置
+	if p.tools["slow"].timeout != 7*time.Second {
+		t.Fatalf("slow timeout = %s", p.tools["slow"].timeout)
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		tool    string
+		input   interface{}
+		want    string
+		wantErr string
+	}{
+		{tool: "echo", input: map[string]interface{}{"n": 1.0}, want: "{\n  \"n\": 1\n}"},
+		{tool: "echo", want: "{}"},
+		{tool: "text", want: "plain text"},
+		// 清单中的环境变量引用在启动时展开
+		{tool: "env", want: "hello|/work"},
+		{tool: "fail", wantErr: "fail: bad input"},
+		{tool: "rpc_error", wantErr: "plugin error -32000: nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			out, err := p.tools[tt.tool].Run(ctx, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tt.want {
+				t.Fatalf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+
+	tc := NewToolCollection()
+	tc.Register("echo", NewTerminateTool())
+	if got := p.manager.Register(tc); len(got) != 7 || got[0] != "text" {
+		t.Fatalf("registered = %v", got)
+	}
+	if starts := p.starts(); starts != 1 {
+		t.Fatalf("plugin started %d times", starts)
+	}
+}
+
+func TestPluginRestartsAfterCrash(t *testing.T) {
+	const backoff = 200 * time.Millisecond
+	p := newTestPlugin(t, PluginConfig{}, backoff)
+
+	crashed := time.Now()
+	_, err := p.tools["crash"].Run(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("crash error = %v", err)
+	}
+	// 重启前的调用立即失败
+	if _, err := p.tools["echo"].Run(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "restarting") {
+		t.Fatalf("call while restarting: %v", err)
+	}
+	p.waitRunning(t)
+	if elapsed := time.Since(crashed); elapsed < backoff {
+		t.Fatalf("restarted after %s, backoff is %s", elapsed, backoff)
+	}
+	if out, err := p.tools["text"].Run(context.Background(), nil); err != nil || out != "plain text" {
+		t.Fatalf("after restart = %v, %v", out, err)
+	}
+	if starts := p.starts(); starts != 2 {
+		t.Fatalf("plugin started %d times", starts)
+	}
+}
+
+func TestPluginRestartBackoff(t *testing.T) {
+	const backoff = 50 * time.Millisecond
+	p := newTestPlugin(t, PluginConfig{MaxRestarts: 10}, backoff)
+
+	// 重启失败时等待时间翻倍：50ms、100ms、200ms
+	writeTestFile(t, p.dir, "fail_init", "")
+	crashed := time.Now()
+	p.tools["crash"].Run(context.Background(), nil)
+	waitFor(t, 10*time.Second, "three failed restarts", func() bool { return p.starts() >= 4 })
+	if err := os.Remove(filepath.Join(p.dir, "fail_init")); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(crashed); elapsed < backoff+2*backoff+4*backoff {
+		t.Fatalf("three restarts took %s", elapsed)
+	}
+	p.waitRunning(t)
+	if out, err := p.tools["text"].Run(context.Background(), nil); err != nil || out != "plain text" {
+		t.Fatalf("after recovery = %v, %v", out, err)
+	}
+}
+
+func TestPluginDisabledAfterMaxRestarts(t *testing.T) {
+	p := newTestPlugin(t, PluginConfig{MaxRestarts: 2}, 10*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		p.tools["crash"].Run(context.Background(), nil)
+		waitFor(t, 10*time.Second, "plugin restart", func() bool { return p.starts() == i+2 })
+		p.waitRunning(t)
+	}
+	p.tools["crash"].Run(context.Background(), nil)
+	var err error
+	waitFor(t, 10*time.Second, "plugin disabled", func() bool {
+		_, err = p.tools["echo"].Run(context.Background(), nil)
+		return err != nil && strings.Contains(err.Error(), "disabled")
+	})
+	// 停用原因包含最后一次退出时的 stderr
+	if !strings.Contains(err.Error(), "restarted 2 times") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("disabled error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if starts := p.starts(); starts != 3 {
+		t.Fatalf("plugin started %d times after being disabled", starts)
+	}
+}
+
+func TestPluginTimeoutKillsProcess(t *testing.T) {
+	p := newTestPlugin(t, PluginConfig{Timeout: 100 * time.Millisecond}, 10*time.Millisecond)
+
+	start := time.Now()
+	_, err := p.tools["sleep"].Run(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Fatalf("timeout error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout took %s", elapsed)
+	}
+	// 超时的进程被结束并重启，之后的调用不受影响
+	waitFor(t, 10*time.Second, "plugin restart", func() bool { return p.starts() == 2 })
+	p.waitRunning(t)
+	if out, err := p.tools["text"].Run(context.Background(), nil); err != nil || out != "plain text" {
+		t.Fatalf("after timeout = %v, %v", out, err)
+	}
+
+	// 调用方取消不结束进程
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.tools["sleep"].Run(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("canceled call: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if starts := p.starts(); starts != 2 {
+		t.Fatalf("plugin restarted after caller cancellation: %d starts", starts)
+	}
+}
+
+func TestPluginManagerClose(t *testing.T) {
+	p := newTestPlugin(t, PluginConfig{}, 10*time.Millisecond)
+	p.manager.Close()
+
+	// 插件收到 shutdown 后退出，不再重启
+	if events := p.events(); len(events) != 2 || events[1] != "shutdown" {
+		t.Fatalf("events = %v", events)
+	}
+	if _, err := p.tools["echo"].Run(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "stopped") {
+		t.Fatalf("call after close: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if starts := p.starts(); starts != 1 {
+		t.Fatalf("plugin restarted after close: %d starts", starts)
+	}
+}
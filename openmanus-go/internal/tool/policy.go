@@ -0,0 +1,562 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 策略动作
+const (
+	PolicyAllow = "allow"
+	PolicyDeny  = "deny"
+	PolicyAsk   = "ask"
+)
+
+// ErrPolicyDenied 调用被策略拒绝或未获批准
+var ErrPolicyDenied = errors.New("tool call denied by policy")
+
+// 默认等待审批的时间
+const defaultApprovalTimeout = 5 * time.Minute
+
+// PolicyRule 一条策略规则，所有非空条件都满足时规则命中
+type PolicyRule struct {
+	// Name 规则名，写入审计日志
+	Name string
+	// Tool 工具名通配，例如 "shell"、"file*"，为空匹配所有工具
+	Tool string
+	// Action allow、deny 或 ask
+	Action string
+	// Command 匹配命令文本的正则：字符串输入或 command、cmd、script、code 参数
+	Command string
+	// Args 参数名到正则的映射，参数值转换为文本后匹配
+	Args map[string]string
+	// Paths 路径参数的通配，相对工作区根目录
+	Paths []string
+	// OutsideWorkspace 任一路径参数位于工作区之外时命中
+	OutsideWorkspace bool
+	// Hosts 参数中 URL 主机名的通配，例如 "*.internal"、"169.254.169.254"
+	Hosts []string
+	// Reason 命中时的说明
+	Reason string
+}
+
+// PolicyConfig 策略配置
+type PolicyConfig struct {
+	// Default 没有规则命中时的动作，默认 allow
+	Default string
+	Rules   []PolicyRule
+	// Approver 处理 ask 决策，为空时 ask 视为 deny
+	Approver Approver
+	// ApprovalTimeout 等待审批的时间
+	ApprovalTimeout time.Duration
+	// Audit 记录每个决策，为空时不记录
+	Audit *AuditLog
+	// Workspace 判断路径是否在工作区内，为空时使用默认工作区
+	Workspace *Workspace
+}
+
+// compiledRule 预编译正则的规则
+type compiledRule struct {
+	PolicyRule
+	command *regexp.Regexp
+	args    map[string]*regexp.Regexp
+}
+
+// PolicyEngine 在工具调用前评估策略
+type PolicyEngine struct {
+	config PolicyConfig
+	rules  []compiledRule
+}
+
+// NewPolicyEngine 创建策略引擎，规则按顺序匹配，第一条命中的规则生效
+func NewPolicyEngine(config PolicyConfig) (*PolicyEngine, error) {
+	if config.Default == "" {
+		config.Default = PolicyAllow
+	}
+	if !validPolicyAction(config.Default) {
+		return nil, fmt.Errorf("%w: invalid default policy action %q", ErrInvalidArgs, config.Default)
+	}
+	if config.ApprovalTimeout <= 0 {
+		config.ApprovalTimeout = defaultApprovalTimeout
+	}
+	e := &PolicyEngine{config: config}
+	for i, r := range config.Rules {
+		if r.Name == "" {
+			r.Name = "rule-" + strconv.Itoa(i+1)
+		}
+		r.Action = strings.ToLower(r.Action)
+		if !validPolicyAction(r.Action) {
+			return nil, fmt.Errorf("%w: policy rule %s: invalid action %q", ErrInvalidArgs, r.Name, r.Action)
+		}
+		c := compiledRule{PolicyRule: r, args: make(map[string]*regexp.Regexp)}
+		var err error
+		if r.Command != "" {
+			if c.command, err = regexp.Compile(r.Command); err != nil {
+				return nil, fmt.Errorf("%w: policy rule %s: %v", ErrInvalidArgs, r.Name, err)
+			}
+		}
+		for k, pattern := range r.Args {
+			if c.args[k], err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("%w: policy rule %s: arg %s: %v", ErrInvalidArgs, r.Name, k, err)
+			}
+		}
+		e.rules = append(e.rules, c)
+	}
+	return e, nil
+}
+
+func validPolicyAction(a string) bool {
+	return a == PolicyAllow || a == PolicyDeny || a == PolicyAsk
+}
+
+// PolicyDecision 策略评估结果
+type PolicyDecision struct {
+	Action string `json:"action"`
+	// Rule 命中的规则名，为空表示使用默认动作
+	Rule   string `json:"rule,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Evaluate 评估一次调用，不触发审批
+func (e *PolicyEngine) Evaluate(call *ToolCall) PolicyDecision {
+	facts := e.inspect(call)
+	for _, r := range e.rules {
+		if r.matches(call.Name, facts) {
+			reason := r.Reason
+			if reason == "" {
+				reason = "matched policy rule " + r.Name
+			}
+			return PolicyDecision{Action: r.Action, Rule: r.Name, Reason: reason}
+		}
+	}
+	return PolicyDecision{Action: e.config.Default, Reason: "default policy"}
+}
+
+// Check 评估调用，ask 时等待审批，并记录审计日志；拒绝时返回 ErrPolicyDenied
+func (e *PolicyEngine) Check(ctx context.Context, call *ToolCall) error {
+	decision := e.Evaluate(call)
+	record := AuditRecord{
+		Time:     time.Now(),
+		Tool:     call.Name,
+		Input:    summarizeInput(call.Input),
+		Decision: decision.Action,
+		Rule:     decision.Rule,
+		Reason:   decision.Reason,
+	}
+	allowed := decision.Action == PolicyAllow
+	if decision.Action == PolicyAsk {
+		if e.config.Approver == nil {
+			record.Outcome = "no approver configured"
+		} else {
+			askCtx, cancel := context.WithTimeout(ctx, e.config.ApprovalTimeout)
+			approval, err := e.config.Approver.Approve(askCtx, ApprovalRequest{
+				Tool:   call.Name,
+				Input:  record.Input,
+				Rule:   decision.Rule,
+				Reason: decision.Reason,
+			})
+			cancel()
+			switch {
+			case err != nil:
+				record.Outcome = "approval failed: " + err.Error()
+			case approval.Approved:
+				allowed = true
+				record.Outcome = "approved"
+			default:
+				record.Outcome = "rejected"
+			}
+			record.Approver = approval.By
+			record.Note = approval.Note
+		}
+	}
+	record.Allowed = allowed
+	if e.config.Audit != nil {
+		e.config.Audit.Record(record)
+	}
+	if !allowed {
+		msg := decision.Reason
+		if record.Outcome != "" {
+			msg += " (" + record.Outcome + ")"
+		}
+		if record.Note != "" {
+			msg += ": " + record.Note
+		}
+		return fmt.Errorf("%w: %s: %s", ErrPolicyDenied, call.Name, msg)
+	}
+	return nil
+}
+
+// PolicyMiddleware 在调用工具前执行策略检查
+func PolicyMiddleware(e *PolicyEngine) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, call *ToolCall) (interface{}, error) {
+			if err := e.Check(ctx, call); err != nil {
+				return nil, err
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// callFacts 从调用参数中提取的、供规则匹配的信息
+type callFacts struct {
+	command string
+	args    map[string]string
+	// paths 工作区根目录下的相对路径，工作区外的路径保留绝对路径
+	paths   []string
+	outside bool
+	hosts   []string
+}
+
+// 视为命令文本的参数名
+var policyCommandKeys = []string{"command", "cmd", "script", "code"}
+
+// 视为路径的参数名
+var policyPathKeys = map[string]bool{
+	"path": true, "paths": true, "file": true, "file_path": true, "filename": true, "dir": true,
+	"directory": true, "cwd": true, "src": true, "dst": true, "source": true, "destination": true,
+	"output_dir": true, "old_path": true, "new_path": true,
+}
+
+// inspect 提取命令、参数文本、路径和主机名
+func (e *PolicyEngine) inspect(call *ToolCall) callFacts {
+	facts := callFacts{args: make(map[string]string)}
+	args, _ := call.Input.(map[string]interface{})
+	switch v := call.Input.(type) {
+	case string:
+		facts.command = v
+		// 字符串输入可能是 JSON 参数
+		var m map[string]interface{}
+		if json.Unmarshal([]byte(v), &m) == nil {
+			args = m
+		}
+	case []byte:
+		facts.command = string(v)
+	}
+	for k, v := range args {
+		facts.args[k] = policyText(v)
+	}
+	if facts.command == "" {
+		for _, k := range policyCommandKeys {
+			if s, ok := args[k].(string); ok && s != "" {
+				facts.command = s
+				break
+			}
+		}
+	}
+
+	ws, _ := workspaceOrDefault(e.config.Workspace)
+	for _, p := range policyPathArgs(args) {
+		rel, inside := policyPath(ws, p)
+		facts.paths = append(facts.paths, rel)
+		facts.outside = facts.outside || !inside
+	}
+	for _, v := range args {
+		for _, s := range policyStrings(v) {
+			if u, err := url.Parse(s); err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "ws" || u.Scheme == "wss") {
+				facts.hosts = append(facts.hosts, strings.ToLower(u.Hostname()))
+			}
+		}
+	}
+	for _, m := range urlPattern.FindAllString(facts.command, -1) {
+		if u, err := url.Parse(m); err == nil && u.Host != "" {
+			facts.hosts = append(facts.hosts, strings.ToLower(u.Hostname()))
+		}
+	}
+	return facts
+}
+
+// policyPathArgs 取出参数中的路径：路径参数、options 中的路径（文件工具 move/copy 的 target）
+// 以及 patch 参数中补丁修改的文件
+func policyPathArgs(args map[string]interface{}) []string {
+	var paths []string
+	for k, v := range args {
+		key := strings.ToLower(k)
+		switch {
+		case policyPathKeys[key]:
+			paths = append(paths, policyStrings(v)...)
+		case key == "options":
+			opts, _ := v.(map[string]interface{})
+			for ok, ov := range opts {
+				if ok = strings.ToLower(ok); policyPathKeys[ok] || ok == "target" {
+					paths = append(paths, policyStrings(ov)...)
+				}
+			}
+		case key == "patch":
+			text, _ := v.(string)
+			var strip *int
+			switch n := args["strip"].(type) {
+			case float64:
+				level := int(n)
+				strip = &level
+			case int:
+				strip = &n
+			}
+			paths = append(paths, patchTargets(text, strip)...)
+		}
+	}
+	return paths
+}
+
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?|wss?)://[^\s'"<>]+`)
+
+// policyPath 把路径转换为相对工作区的形式，并判断是否在工作区内
+func policyPath(ws *Workspace, p string) (string, bool) {
+	if ws == nil {
+		return filepath.ToSlash(p), !filepath.IsAbs(p) && !strings.HasPrefix(filepath.Clean(p), "..")
+	}
+	abs := p
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(ws.Root(), p)
+	}
+	abs = filepath.Clean(abs)
+	if resolved, err := resolveExisting(abs); err == nil {
+		abs = resolved
+	}
+	if !ws.contains(abs) {
+		return filepath.ToSlash(abs), false
+	}
+	return filepath.ToSlash(ws.Rel(abs)), true
+}
+
+// policyStrings 取出参数值中的字符串
+func policyStrings(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []interface{}:
+		var out []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// policyText 把参数值转换为用于正则匹配的文本
+func policyText(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	case float64, bool, int, int64:
+		return fmt.Sprint(t)
+	default:
+		data, _ := json.Marshal(t)
+		return string(data)
+	}
+}
+
+// matches 判断规则是否命中
+func (r *compiledRule) matches(tool string, f callFacts) bool {
+	if r.Tool != "" {
+		if ok, _ := path.Match(r.Tool, tool); !ok {
+			return false
+		}
+	}
+	if r.command != nil && !r.command.MatchString(f.command) {
+		return false
+	}
+	for k, re := range r.args {
+		v, ok := f.args[k]
+		if !ok || !re.MatchString(v) {
+			return false
+		}
+	}
+	if len(r.Paths) > 0 && !anyPathRule(r.Paths, f.paths) {
+		return false
+	}
+	if r.OutsideWorkspace && !f.outside {
+		return false
+	}
+	if len(r.Hosts) > 0 && !anyHost(r.Hosts, f.hosts) {
+		return false
+	}
+	return true
+}
+
+// anyPathRule 判断任一路径命中任一规则，规则语义与工作区的只读、禁止规则相同
+func anyPathRule(patterns, paths []string) bool {
+	for _, p := range paths {
+		for _, pattern := range patterns {
+			if matchPathRule(pattern, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// anyHost 判断任一主机名匹配任一通配
+func anyHost(patterns, hosts []string) bool {
+	for _, h := range hosts {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(strings.ToLower(pattern), h); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// summarizeInput 生成用于审批和审计的参数摘要
+func summarizeInput(input interface{}) string {
+	s := policyText(input)
+	if len(s) > 1000 {
+		s = s[:1000] + "..."
+	}
+	return s
+}
+
+// ApprovalRequest 待审批的调用
+type ApprovalRequest struct {
+	ID        string    `json:"id"`
+	Tool      string    `json:"tool"`
+	Input     string    `json:"input"`
+	Rule      string    `json:"rule,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Approval 审批结果
+type Approval struct {
+	Approved bool   `json:"approved"`
+	By       string `json:"by,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+// Approver 处理需要人工确认的调用
+type Approver interface {
+	Approve(ctx context.Context, req ApprovalRequest) (Approval, error)
+}
+
+// ErrApprovalNotFound 审批请求不存在或已处理
+var ErrApprovalNotFound = errors.New("approval request not found or already decided")
+
+// ApprovalBroker 在策略引擎和审批端（命令行、HTTP）之间转发审批请求
+type ApprovalBroker struct {
+	mu          sync.Mutex
+	seq         int
+	pending     map[string]*pendingApproval
+	subscribers []func(ApprovalRequest)
+}
+
+type pendingApproval struct {
+	request  ApprovalRequest
+	decision chan Approval
+	done     chan struct{}
+}
+
+// NewApprovalBroker 创建审批中转
+func NewApprovalBroker() *ApprovalBroker {
+	return &ApprovalBroker{pending: make(map[string]*pendingApproval)}
+}
+
+// Subscribe 订阅新的审批请求，回调在独立的 goroutine 中执行
+func (b *ApprovalBroker) Subscribe(fn func(ApprovalRequest)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Approve 提交审批请求并等待决定，实现 Approver；没有订阅者时请求仍可通过 Pending 和 Decide 处理
+func (b *ApprovalBroker) Approve(ctx context.Context, req ApprovalRequest) (Approval, error) {
+	b.mu.Lock()
+	b.seq++
+	req.ID = strconv.Itoa(b.seq)
+	req.CreatedAt = time.Now()
+	p := &pendingApproval{request: req, decision: make(chan Approval, 1), done: make(chan struct{})}
+	b.pending[req.ID] = p
+	subscribers := make([]func(ApprovalRequest), len(b.subscribers))
+	copy(subscribers, b.subscribers)
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.pending, req.ID)
+		b.mu.Unlock()
+		close(p.done)
+	}()
+	for _, fn := range subscribers {
+		go fn(req)
+	}
+
+	select {
+	case d := <-p.decision:
+		return d, nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return Approval{}, fmt.Errorf("timed out waiting for approval")
+		}
+		return Approval{}, ctx.Err()
+	}
+}
+
+// Decide 对指定请求做出决定
+func (b *ApprovalBroker) Decide(id string, approval Approval) error {
+	b.mu.Lock()
+	p, ok := b.pending[id]
+	if ok {
+		delete(b.pending, id)
+	}
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+	p.decision <- approval
+	return nil
+}
+
+// Done 返回请求结束（已决定、超时或取消）时关闭的通道，请求不存在时返回已关闭的通道
+func (b *ApprovalBroker) Done(id string) <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if p, ok := b.pending[id]; ok {
+		return p.done
+	}
+	return closedChan
+}
+
+// Pending 按提交顺序返回等待审批的请求
+func (b *ApprovalBroker) Pending() []ApprovalRequest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	reqs := make([]ApprovalRequest, 0, len(b.pending))
+	for _, p := range b.pending {
+		reqs = append(reqs, p.request)
+	}
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].CreatedAt.Before(reqs[j].CreatedAt)
+	})
+	return reqs
+}
+
+var (
+	defaultApprovalBroker     *ApprovalBroker
+	defaultApprovalBrokerOnce sync.Once
+)
+
+// DefaultApprovalBroker 返回进程内共享的审批中转，命令行和 HTTP 服务都通过它审批
+func DefaultApprovalBroker() *ApprovalBroker {
+	defaultApprovalBrokerOnce.Do(func() {
+		defaultApprovalBroker = NewApprovalBroker()
+	})
+	return defaultApprovalBroker
+}
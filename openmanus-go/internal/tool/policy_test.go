@@ -0,0 +1,229 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPolicy(t *testing.T, config PolicyConfig) *PolicyEngine {
+	t.Helper()
+	if config.Workspace == nil {
+		config.Workspace = newTestWorkspace(t)
+	}
+	e, err := NewPolicyEngine(config)
+	if err != nil {
+		t.Fatalf("NewPolicyEngine: %v", err)
+	}
+	return e
+}
+
+func TestNewPolicyEngineValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config PolicyConfig
+	}{
+		{"default action", PolicyConfig{Default: "maybe"}},
+		{"rule action", PolicyConfig{Rules: []PolicyRule{{Action: "block"}}}},
+		{"command regex", PolicyConfig{Rules: []PolicyRule{{Action: PolicyDeny, Command: "("}}}},
+		{"args regex", PolicyConfig{Rules: []PolicyRule{{Action: PolicyDeny, Args: map[string]string{"x": "["}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPolicyEngine(tt.config); !errors.Is(err, ErrInvalidArgs) {
+				t.Fatalf("got %v, want ErrInvalidArgs", err)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	e := newTestPolicy(t, PolicyConfig{
+		Default: PolicyAllow,
+		Rules: []PolicyRule{
+			{Name: "rm", Tool: "shell", Action: PolicyAsk, Command: `\brm\s+-[a-z]*r`},
+			{Name: "secrets", Action: PolicyDeny, Paths: []string{"secrets"}},
+			{Name: "outside", Action: PolicyDeny, OutsideWorkspace: true},
+			{Name: "metadata", Action: PolicyDeny, Hosts: []string{"169.254.169.254", "*.internal"}},
+			{Name: "post", Tool: "http*", Action: PolicyAsk, Args: map[string]string{"method": "(?i)^(post|put|delete)$"}},
+		},
+	})
+	patch := "--- a/src/main.go\n+++ b/src/main.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"--- /dev/null\n+++ b/secrets/token\n@@ -0,0 +1 @@\n+x\n"
+
+	tests := []struct {
+		name  string
+		tool  string
+		input interface{}
+		rule  string
+	}{
+		{"shell string", "shell", "rm -rf build", "rm"},
+		{"shell command arg", "shell", map[string]interface{}{"command": "ls && rm -r x"}, "rm"},
+		{"shell json string", "shell", `{"command": "rm -fr /tmp/x"}`, "rm"},
+		{"other tool same command", "python", map[string]interface{}{"code": "rm -rf x"}, ""},
+		{"harmless shell", "shell", "ls -la", ""},
+		{"path rule", "file", map[string]interface{}{"action": "read", "path": "secrets/key.txt"}, "secrets"},
+		{"path list", "file", map[string]interface{}{"paths": []interface{}{"a.txt", "secrets"}}, "secrets"},
+		{"options target", "file", map[string]interface{}{"action": "copy", "path": "a.txt", "options": map[string]interface{}{"target": "secrets/a.txt"}}, "secrets"},
+		{"options target outside", "file", map[string]interface{}{"action": "move", "path": "a.txt", "options": map[string]interface{}{"target": "../a.txt"}}, "outside"},
+		{"patch paths", "patch", map[string]interface{}{"patch": patch}, "secrets"},
+		{"patch with strip", "patch", map[string]interface{}{"patch": "--- x/secrets/a\n+++ y/secrets/a\n@@ -1 +1 @@\n-a\n+b\n", "strip": float64(1)}, "secrets"},
+		{"unparsable patch headers", "git", map[string]interface{}{"operation": "apply", "patch": "--- a/secrets/k\n+++ b/secrets/k\nGIT binary patch\n"}, "secrets"},
+		{"patch outside", "patch", map[string]interface{}{"patch": "--- a/../x\n+++ b/../x\n@@ -1 +1 @@\n-a\n+b\n"}, "outside"},
+		{"absolute path", "file", map[string]interface{}{"path": "/etc/passwd"}, "outside"},
+		{"dotdot path", "file", map[string]interface{}{"path": "../x"}, "outside"},
+		{"url arg", "http_request", map[string]interface{}{"url": "http://169.254.169.254/latest"}, "metadata"},
+		{"url in command", "shell", "curl -s http://db.internal:8080/x", "metadata"},
+		{"args regex", "http_request", map[string]interface{}{"url": "https://example.com", "method": "POST"}, "post"},
+		{"args regex no match", "http_request", map[string]interface{}{"url": "https://example.com", "method": "GET"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := e.Evaluate(&ToolCall{Name: tt.tool, Input: tt.input})
+			if d.Rule != tt.rule {
+				t.Fatalf("rule = %q (%s), want %q", d.Rule, d.Action, tt.rule)
+			}
+			if tt.rule == "" && d.Action != PolicyAllow {
+				t.Fatalf("default action = %s", d.Action)
+			}
+		})
+	}
+}
+
+func TestPolicyPathRuleFollowsSymlinks(t *testing.T) {
+	ws := newTestWorkspace(t)
+	if err := os.MkdirAll(filepath.Join(ws.Root(), "secrets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("secrets", filepath.Join(ws.Root(), "innocent")); err != nil {
+		t.Fatal(err)
+	}
+	e := newTestPolicy(t, PolicyConfig{
+		Workspace: ws,
+		Rules:     []PolicyRule{{Name: "secrets", Action: PolicyDeny, Paths: []string{"secrets"}}},
+	})
+	d := e.Evaluate(&ToolCall{Name: "file", Input: map[string]interface{}{"path": "innocent/key"}})
+	if d.Action != PolicyDeny {
+		t.Fatalf("decision = %+v", d)
+	}
+}
+
+// staticApprover 固定返回结果的审批者
+type staticApprover struct {
+	approval Approval
+	err      error
+	got      ApprovalRequest
+}
+
+func (a *staticApprover) Approve(ctx context.Context, req ApprovalRequest) (Approval, error) {
+	a.got = req
+	return a.approval, a.err
+}
+
+func TestPolicyCheck(t *testing.T) {
+	rules := []PolicyRule{
+		{Name: "ask-shell", Tool: "shell", Action: PolicyAsk, Reason: "confirm shell"},
+		{Name: "deny-http", Tool: "http_request", Action: PolicyDeny},
+	}
+	tests := []struct {
+		name     string
+		tool     string
+		approver Approver
+		allowed  bool
+		outcome  string
+	}{
+		{"allow by default", "file", nil, true, ""},
+		{"deny rule", "http_request", nil, false, ""},
+		{"ask without approver", "shell", nil, false, "no approver configured"},
+		{"approved", "shell", &staticApprover{approval: Approval{Approved: true, By: "alice"}}, true, "approved"},
+		{"rejected", "shell", &staticApprover{approval: Approval{By: "bob", Note: "no"}}, false, "rejected"},
+		{"approval error", "shell", &staticApprover{err: errors.New("boom")}, false, "approval failed: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+			audit, err := NewAuditLog(auditPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer audit.Close()
+			e := newTestPolicy(t, PolicyConfig{Rules: rules, Approver: tt.approver, Audit: audit})
+
+			err = e.Check(context.Background(), &ToolCall{Name: tt.tool, Input: "ls"})
+			if tt.allowed != (err == nil) {
+				t.Fatalf("Check = %v, want allowed %v", err, tt.allowed)
+			}
+			if err != nil && !errors.Is(err, ErrPolicyDenied) {
+				t.Fatalf("got %v, want ErrPolicyDenied", err)
+			}
+
+			data, err := os.ReadFile(auditPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var record AuditRecord
+			if err := json.Unmarshal(data, &record); err != nil {
+				t.Fatalf("audit record %q: %v", data, err)
+			}
+			if record.Tool != tt.tool || record.Allowed != tt.allowed || record.Outcome != tt.outcome {
+				t.Fatalf("audit record = %+v", record)
+			}
+		})
+	}
+}
+
+func TestApprovalBroker(t *testing.T) {
+	b := NewApprovalBroker()
+	requests := make(chan ApprovalRequest, 1)
+	b.Subscribe(func(req ApprovalRequest) { requests <- req })
+
+	result := make(chan error, 1)
+	go func() {
+		approval, err := b.Approve(context.Background(), ApprovalRequest{Tool: "shell"})
+		if err == nil && (!approval.Approved || approval.By != "alice") {
+			err = errors.New("unexpected approval")
+		}
+		result <- err
+	}()
+	req := <-requests
+	done := b.Done(req.ID)
+	if len(b.Pending()) != 1 {
+		t.Fatalf("pending = %+v", b.Pending())
+	}
+	if err := b.Decide(req.ID, Approval{Approved: true, By: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Done not closed after decision")
+	}
+	if err := b.Decide(req.ID, Approval{}); !errors.Is(err, ErrApprovalNotFound) {
+		t.Fatalf("second decision: %v", err)
+	}
+
+	// 超时后请求被撤下
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	go func() {
+		_, err := b.Approve(ctx, ApprovalRequest{Tool: "shell"})
+		result <- err
+	}()
+	req = <-requests
+	done = b.Done(req.ID)
+	if err := <-result; err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("Approve after timeout: %v", err)
+	}
+	<-done
+	if len(b.Pending()) != 0 {
+		t.Fatalf("pending after timeout = %+v", b.Pending())
+	}
+}
@@ -0,0 +1,155 @@
+package tool
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRules 某个主机的 robots.txt 中适用于爬虫的规则
+type robotsRules struct {
+	rules []robotsRule
+	// delay Crawl-delay 指定的请求间隔
+	delay time.Duration
+	// disallowAll 无法获取 robots.txt（网络错误或 5xx）时不抓取该主机
+	disallowAll bool
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsGroup robots.txt 中的一组 User-agent 及其规则
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+	delay  time.Duration
+}
+
+// parseRobots 解析 robots.txt，选取 User-agent 与 agent 匹配的组，没有时使用 "*" 组
+func parseRobots(data, agent string) *robotsRules {
+	var groups []*robotsGroup
+	var cur *robotsGroup
+	// 连续的 User-agent 行属于同一组
+	inAgents := false
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents || cur == nil {
+				cur = &robotsGroup{}
+				groups = append(groups, cur)
+			}
+			cur.agents = append(cur.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if cur == nil {
+				continue
+			}
+			// 空的 Disallow 表示允许全部
+			if value == "" {
+				continue
+			}
+			cur.rules = append(cur.rules, robotsRule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			inAgents = false
+			if cur == nil {
+				continue
+			}
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+				cur.delay = time.Duration(secs * float64(time.Second))
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	token := robotsAgentToken(agent)
+	var matched, wildcard []*robotsGroup
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a == "*" {
+				wildcard = append(wildcard, g)
+			} else if token != "" && strings.HasPrefix(token, a) {
+				matched = append(matched, g)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		matched = wildcard
+	}
+	r := &robotsRules{}
+	for _, g := range matched {
+		r.rules = append(r.rules, g.rules...)
+		if g.delay > r.delay {
+			r.delay = g.delay
+		}
+	}
+	return r
+}
+
+// robotsAgentToken 取 User-Agent 的产品名，例如 "OpenManus-Go/1.0" 取 "openmanus-go"
+func robotsAgentToken(agent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(agent), "/")
+	token, _, _ = strings.Cut(token, " ")
+	return strings.ToLower(token)
+}
+
+// allowed 判断路径（含查询串）是否允许抓取：最长匹配的规则生效，长度相同时 Allow 优先
+func (r *robotsRules) allowed(p string) bool {
+	if r == nil {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, p) {
+			continue
+		}
+		n := len(rule.pattern)
+		if n > best || (n == best && rule.allow) {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+// matchRobotsPattern 匹配 robots.txt 的路径规则：前缀匹配，"*" 匹配任意字符，结尾的 "$" 表示路径结束
+func matchRobotsPattern(pattern, p string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(p, parts[0]) {
+		return false
+	}
+	rest := p[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			// 最后一段必须位于路径末尾
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
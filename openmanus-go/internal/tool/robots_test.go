@@ -0,0 +1,115 @@
+package tool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchRobotsPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/private", "/private/a.html", true},
+		{"/private", "/privateer", true},
+		{"/private/", "/private", false},
+		{"/", "/anything", true},
+		{"/*.pdf", "/docs/a.pdf", true},
+		{"/*.pdf", "/docs/a.pdf?x=1", true},
+		{"/*.pdf$", "/docs/a.pdf", true},
+		{"/*.pdf$", "/docs/a.pdf?x=1", false},
+		{"/page$", "/page", true},
+		{"/page$", "/page/2", false},
+		{"/a*b*c", "/a-x-b-y-c-z", true},
+		{"/a*b*c", "/a-c-b", false},
+		{"/*?sort=", "/list?sort=asc", true},
+		{"/search", "/Search", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			if got := matchRobotsPattern(tt.pattern, tt.path); got != tt.match {
+				t.Fatalf("match = %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	const robots = `# 示例
+User-agent: *
+Disallow: /private/
+Allow: /private/public/
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: OpenManus-Go
+User-agent: other-bot
+Disallow: /no-openmanus
+Allow: /page
+Disallow: /page
+Crawl-delay: 0.5
+
+User-agent: blocked-bot
+Disallow: /
+`
+	tests := []struct {
+		name    string
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"wildcard group disallow", "SomeBot/2.0", "/private/x", false},
+		{"longer allow wins", "SomeBot/2.0", "/private/public/x", true},
+		{"anchored pattern", "SomeBot/2.0", "/files/a.pdf", false},
+		{"anchored pattern with query", "SomeBot/2.0", "/files/a.pdf?v=1", true},
+		{"not listed", "SomeBot/2.0", "/index.html", true},
+		{"specific group replaces wildcard", "OpenManus-Go/1.0", "/private/x", true},
+		{"specific group disallow", "OpenManus-Go/1.0", "/no-openmanus/x", false},
+		{"allow wins tie", "OpenManus-Go/1.0", "/page", true},
+		{"agent in shared group", "other-bot", "/no-openmanus", false},
+		{"disallow all", "blocked-bot/1.0", "/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRobots(robots, tt.agent).allowed(tt.path); got != tt.allowed {
+				t.Fatalf("allowed(%s) for %s = %v, want %v", tt.path, tt.agent, got, tt.allowed)
+			}
+		})
+	}
+
+	delays := []struct {
+		agent string
+		delay time.Duration
+	}{
+		{"SomeBot/2.0", 2 * time.Second},
+		{"OpenManus-Go/1.0", 500 * time.Millisecond},
+		{"blocked-bot", 0},
+	}
+	for _, tt := range delays {
+		if got := parseRobots(robots, tt.agent).delay; got != tt.delay {
+			t.Fatalf("delay for %s = %v, want %v", tt.agent, got, tt.delay)
+		}
+	}
+}
+
+func TestRobotsRulesEdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   *robotsRules
+		allowed bool
+	}{
+		{"nil rules", nil, true},
+		{"empty file", parseRobots("", "bot"), true},
+		{"empty disallow", parseRobots("User-agent: *\nDisallow:\n", "bot"), true},
+		{"rules before any agent are ignored", parseRobots("Disallow: /\nUser-agent: *\nAllow: /x\n", "bot"), true},
+		{"unreachable robots", &robotsRules{disallowAll: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.allowed("/a"); got != tt.allowed {
+				t.Fatalf("allowed = %v, want %v", got, tt.allowed)
+			}
+		})
+	}
+}
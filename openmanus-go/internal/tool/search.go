@@ -0,0 +1,393 @@
+package tool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 搜索默认限制
+const (
+	defaultGlobLimit  = 200
+	defaultGrepLimit  = 100
+	maxGrepFileSize   = 5 * 1024 * 1024
+	maxGrepLineLength = 500
+)
+
+// decodeArgs 将 map 形式的输入转换为结构体
+func decodeArgs(input interface{}, out interface{}) error {
+	args, ok := input.(map[string]interface{})
+	if !ok {
+		return ErrInvalidArgs
+	}
+	jsonData, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("invalid input format: %v", err)
+	}
+	if err := json.Unmarshal(jsonData, out); err != nil {
+		return fmt.Errorf("invalid input format: %v", err)
+	}
+	return nil
+}
+
+// GlobTool 递归文件匹配工具
+type GlobTool struct {
+	name        string
+	description string
+	workspace   *Workspace
+}
+
+// NewGlobTool 创建文件匹配工具
+func NewGlobTool(ws *Workspace) *GlobTool {
+	return &GlobTool{
+		name:        "glob",
+		description: "按 glob 模式递归查找文件（支持 **），自动跳过 .gitignore 中忽略的文件",
+		workspace:   ws,
+	}
+}
+
+// Name 返回工具名称
+func (t *GlobTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *GlobTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *GlobTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// GlobInput 文件匹配参数
+type GlobInput struct {
+	Pattern     string `json:"pattern"`
+	Path        string `json:"path,omitempty"`
+	IncludeDirs bool   `json:"include_dirs,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+}
+
+// GlobResult 文件匹配结果
+type GlobResult struct {
+	Matches   []string `json:"matches"`
+	Count     int      `json:"count"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// Run 执行文件匹配
+func (t *GlobTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in GlobInput
+	if s, ok := input.(string); ok {
+		in.Pattern = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Pattern == "" {
+		return nil, ErrInvalidArgs
+	}
+	if in.Path == "" {
+		in.Path = "."
+	}
+	if in.Limit <= 0 {
+		in.Limit = defaultGlobLimit
+	}
+
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return nil, err
+	}
+	base, err := ws.Resolve(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	baseRel := ws.Rel(base)
+
+	// 不含 "/" 的模式匹配任意层级的文件名
+	pattern := strings.TrimPrefix(filepath.ToSlash(in.Pattern), "./")
+	anchored := strings.Contains(pattern, "/")
+
+	result := GlobResult{Matches: make([]string, 0)}
+	err = walkWorkspace(ctx, ws, in.Path, func(rel string, d fs.DirEntry) error {
+		if rel == baseRel || (d.IsDir() && !in.IncludeDirs) {
+			return nil
+		}
+		sub := strings.TrimPrefix(rel, baseRel+"/")
+		if baseRel == "." {
+			sub = rel
+		}
+		target := sub
+		if !anchored {
+			target = path.Base(sub)
+		}
+		if !matchGlob(pattern, target) {
+			return nil
+		}
+		if len(result.Matches) >= in.Limit {
+			result.Truncated = true
+			return filepath.SkipAll
+		}
+		result.Matches = append(result.Matches, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(result.Matches)
+	result.Count = len(result.Matches)
+	return result, nil
+}
+
+// GrepTool 正则内容搜索工具
+type GrepTool struct {
+	name        string
+	description string
+	workspace   *Workspace
+}
+
+// NewGrepTool 创建内容搜索工具
+func NewGrepTool(ws *Workspace) *GrepTool {
+	return &GrepTool{
+		name:        "grep",
+		description: "在工作区文件中按正则搜索内容，支持文件过滤、上下文行和结果数量限制",
+		workspace:   ws,
+	}
+}
+
+// Name 返回工具名称
+func (t *GrepTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *GrepTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *GrepTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// GrepInput 内容搜索参数
+type GrepInput struct {
+	Pattern    string `json:"pattern"`
+	Path       string `json:"path,omitempty"`
+	Include    string `json:"include,omitempty"`
+	IgnoreCase bool   `json:"ignore_case,omitempty"`
+	Literal    bool   `json:"literal,omitempty"`
+	Context    int    `json:"context,omitempty"`
+	Before     int    `json:"before,omitempty"`
+	After      int    `json:"after,omitempty"`
+	MaxResults int    `json:"max_results,omitempty"`
+	MaxPerFile int    `json:"max_per_file,omitempty"`
+	FilesOnly  bool   `json:"files_only,omitempty"`
+}
+
+// GrepMatch 单条匹配
+type GrepMatch struct {
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// GrepResult 内容搜索结果
+type GrepResult struct {
+	Matches       []GrepMatch `json:"matches,omitempty"`
+	Files         []string    `json:"files,omitempty"`
+	FilesSearched int         `json:"files_searched"`
+	Truncated     bool        `json:"truncated,omitempty"`
+}
+
+// Run 执行内容搜索
+func (t *GrepTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in GrepInput
+	if s, ok := input.(string); ok {
+		in.Pattern = s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Pattern == "" {
+		return nil, ErrInvalidArgs
+	}
+	if in.Path == "" {
+		in.Path = "."
+	}
+	if in.MaxResults <= 0 {
+		in.MaxResults = defaultGrepLimit
+	}
+	if in.Context > 0 {
+		if in.Before == 0 {
+			in.Before = in.Context
+		}
+		if in.After == 0 {
+			in.After = in.Context
+		}
+	}
+
+	expr := in.Pattern
+	if in.Literal {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if in.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	result := GrepResult{}
+	total := 0
+	err = walkWorkspace(ctx, ws, in.Path, func(rel string, d fs.DirEntry) error {
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		if in.Include != "" {
+			include := filepath.ToSlash(in.Include)
+			target := path.Base(rel)
+			if strings.Contains(include, "/") {
+				target = rel
+			}
+			if !matchGlob(include, target) {
+				return nil
+			}
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxGrepFileSize {
+			return nil
+		}
+
+		remaining := in.MaxResults - total
+		matches, err := grepFile(filepath.Join(ws.Root(), filepath.FromSlash(rel)), rel, re, in, remaining)
+		if err != nil {
+			return nil
+		}
+		result.FilesSearched++
+		if len(matches) == 0 {
+			return nil
+		}
+		if in.FilesOnly {
+			result.Files = append(result.Files, rel)
+			total++
+		} else {
+			result.Matches = append(result.Matches, matches...)
+			total += len(matches)
+		}
+		if total >= in.MaxResults {
+			result.Truncated = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// grepFile 搜索单个文件，二进制文件直接跳过
+func grepFile(fullPath, rel string, re *regexp.Regexp, in GrepInput, remaining int) ([]GrepMatch, error) {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	sample, _ := reader.Peek(sniffSize)
+	if isBinary(sample) {
+		return nil, nil
+	}
+
+	limit := remaining
+	if in.MaxPerFile > 0 && in.MaxPerFile < limit {
+		limit = in.MaxPerFile
+	}
+	if in.FilesOnly {
+		limit = 1
+	}
+
+	var matches []GrepMatch
+	var before []string
+	// pending 记录仍在收集后续上下文的匹配
+	var pending []int
+	lineNo := 0
+	for {
+		raw, err := reader.ReadBytes('\n')
+		if len(raw) > 0 {
+			lineNo++
+			line := clipLine(string(bytes.TrimRight(raw, "\r\n")))
+
+			next := pending[:0]
+			for _, idx := range pending {
+				matches[idx].After = append(matches[idx].After, line)
+				if len(matches[idx].After) < in.After {
+					next = append(next, idx)
+				}
+			}
+			pending = next
+
+			if len(matches) < limit && re.Match(bytes.TrimRight(raw, "\r\n")) {
+				m := GrepMatch{File: rel, Line: lineNo, Text: line}
+				if in.Before > 0 && len(before) > 0 {
+					m.Before = append([]string(nil), before...)
+				}
+				matches = append(matches, m)
+				if in.After > 0 {
+					pending = append(pending, len(matches)-1)
+				}
+			} else if len(matches) >= limit && len(pending) == 0 {
+				break
+			}
+
+			if in.Before > 0 {
+				before = append(before, line)
+				if len(before) > in.Before {
+					before = before[1:]
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+// clipLine 截断过长的行，避免压缩文件等撑爆输出
+func clipLine(line string) string {
+	if len(line) <= maxGrepLineLength {
+		return line
+	}
+	return string(trimIncompleteRune([]byte(line[:maxGrepLineLength]))) + "..."
+}
+
+// workspaceOrDefault 返回指定工作区，为空时使用默认工作区
+func workspaceOrDefault(ws *Workspace) (*Workspace, error) {
+	if ws != nil {
+		return ws, nil
+	}
+	return GetDefaultWorkspace()
+}
@@ -0,0 +1,236 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestSearchWorkspace 创建包含忽略文件、拒绝文件、回收站和符号链接的工作区
+func newTestSearchWorkspace(t *testing.T) *Workspace {
+	t.Helper()
+	ws := newTestWorkspace(t)
+	root := ws.Root()
+	files := map[string]string{
+		".gitignore":        "*.log\n",
+		"main.go":           "package main\n\nfunc main() {}\n",
+		"pkg/util.go":       "package pkg\n// TODO: fix\nfunc Helper() {}\n",
+		"pkg/util_test.go":  "package pkg\n",
+		"docs/readme.md":    "Hello\ntodo later\nTODO now\n",
+		"app.log":           "TODO ignored\n",
+		".env":              "TODO=denied\n",
+		".trash/old.go":     "// TODO trashed\n",
+		"node_modules/x.js": "// TODO vendored\n",
+		"bin.dat":           "TODO\x00binary",
+	}
+	for name, content := range files {
+		writeTestFile(t, root, name, content)
+	}
+
+	outside := t.TempDir()
+	writeTestFile(t, outside, "secret.go", "// TODO outside\n")
+	for link, target := range map[string]string{
+		"escape":    outside,
+		"escape.go": filepath.Join(outside, "secret.go"),
+		"inside.go": filepath.Join(root, "main.go"),
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ws
+}
+
+func TestGlobTool(t *testing.T) {
+	gt := NewGlobTool(newTestSearchWorkspace(t))
+	tests := []struct {
+		name      string
+		input     map[string]interface{}
+		matches   []string
+		truncated bool
+		wantErr   error
+	}{
+		{
+			name:    "file name pattern skips ignored, trash and outside links",
+			input:   map[string]interface{}{"pattern": "*.go"},
+			matches: []string{"inside.go", "main.go", "pkg/util.go", "pkg/util_test.go"},
+		},
+		{
+			name:    "path pattern",
+			input:   map[string]interface{}{"pattern": "pkg/*.go"},
+			matches: []string{"pkg/util.go", "pkg/util_test.go"},
+		},
+		{
+			name:    "double star",
+			input:   map[string]interface{}{"pattern": "**/*_test.go"},
+			matches: []string{"pkg/util_test.go"},
+		},
+		{
+			name:    "relative to path",
+			input:   map[string]interface{}{"pattern": "*.go", "path": "pkg"},
+			matches: []string{"pkg/util.go", "pkg/util_test.go"},
+		},
+		{
+			name:    "ignored and denied files",
+			input:   map[string]interface{}{"pattern": ".*"},
+			matches: []string{".gitignore"},
+		},
+		{
+			name:    "include dirs",
+			input:   map[string]interface{}{"pattern": "pkg", "include_dirs": true},
+			matches: []string{"pkg"},
+		},
+		{
+			name:      "limit",
+			input:     map[string]interface{}{"pattern": "*.go", "limit": 1},
+			matches:   []string{"inside.go"},
+			truncated: true,
+		},
+		{
+			name:    "missing pattern",
+			input:   map[string]interface{}{},
+			wantErr: ErrInvalidArgs,
+		},
+		{
+			name:    "path outside workspace",
+			input:   map[string]interface{}{"pattern": "*", "path": ".."},
+			wantErr: ErrPathOutsideWorkspace,
+		},
+		{
+			name:    "symlink outside workspace",
+			input:   map[string]interface{}{"pattern": "*", "path": "escape"},
+			wantErr: ErrPathOutsideWorkspace,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := gt.Run(context.Background(), tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := out.(GlobResult)
+			if !reflect.DeepEqual(res.Matches, tt.matches) || res.Count != len(tt.matches) || res.Truncated != tt.truncated {
+				t.Fatalf("got %+v, want matches %v truncated %v", res, tt.matches, tt.truncated)
+			}
+		})
+	}
+}
+
+// grepLocations 将匹配结果转换为 "文件:行号" 形式
+func grepLocations(matches []GrepMatch) []string {
+	var out []string
+	for _, m := range matches {
+		out = append(out, fmt.Sprintf("%s:%d", m.File, m.Line))
+	}
+	return out
+}
+
+func TestGrepTool(t *testing.T) {
+	gt := NewGrepTool(newTestSearchWorkspace(t))
+	tests := []struct {
+		name      string
+		input     interface{}
+		matches   []string
+		files     []string
+		truncated bool
+	}{
+		{
+			name:    "skips ignored, denied, trash, binary and outside links",
+			input:   map[string]interface{}{"pattern": "TODO"},
+			matches: []string{"docs/readme.md:3", "pkg/util.go:2"},
+		},
+		{
+			name:    "string input",
+			input:   "Helper",
+			matches: []string{"pkg/util.go:3"},
+		},
+		{
+			name:    "ignore case",
+			input:   map[string]interface{}{"pattern": "todo", "ignore_case": true},
+			matches: []string{"docs/readme.md:2", "docs/readme.md:3", "pkg/util.go:2"},
+		},
+		{
+			name:    "include",
+			input:   map[string]interface{}{"pattern": "TODO", "include": "*.go"},
+			matches: []string{"pkg/util.go:2"},
+		},
+		{
+			name:    "literal",
+			input:   map[string]interface{}{"pattern": "Helper()", "literal": true},
+			matches: []string{"pkg/util.go:3"},
+		},
+		{
+			name:    "path",
+			input:   map[string]interface{}{"pattern": "TODO", "path": "docs", "ignore_case": true, "max_per_file": 1},
+			matches: []string{"docs/readme.md:2"},
+		},
+		{
+			name:  "files only",
+			input: map[string]interface{}{"pattern": "package", "files_only": true},
+			files: []string{"main.go", "pkg/util.go", "pkg/util_test.go"},
+		},
+		{
+			name:      "max results",
+			input:     map[string]interface{}{"pattern": "TODO", "max_results": 1},
+			matches:   []string{"docs/readme.md:3"},
+			truncated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := gt.Run(context.Background(), tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := out.(GrepResult)
+			if got := grepLocations(res.Matches); !reflect.DeepEqual(got, tt.matches) {
+				t.Fatalf("matches = %v, want %v", got, tt.matches)
+			}
+			if !reflect.DeepEqual(res.Files, tt.files) || res.Truncated != tt.truncated {
+				t.Fatalf("files = %v truncated = %v, want %v %v", res.Files, res.Truncated, tt.files, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestGrepToolContext(t *testing.T) {
+	gt := NewGrepTool(newTestSearchWorkspace(t))
+	out, err := gt.Run(context.Background(), map[string]interface{}{"pattern": "TODO: fix", "context": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := out.(GrepResult)
+	want := []GrepMatch{{
+		File:   "pkg/util.go",
+		Line:   2,
+		Text:   "// TODO: fix",
+		Before: []string{"package pkg"},
+		After:  []string{"func Helper() {}"},
+	}}
+	if !reflect.DeepEqual(res.Matches, want) {
+		t.Fatalf("matches = %+v, want %+v", res.Matches, want)
+	}
+}
+
+func TestGrepToolInvalidInput(t *testing.T) {
+	gt := NewGrepTool(newTestSearchWorkspace(t))
+	if _, err := gt.Run(context.Background(), map[string]interface{}{}); !errors.Is(err, ErrInvalidArgs) {
+		t.Fatalf("missing pattern: got %v", err)
+	}
+	if _, err := gt.Run(context.Background(), map[string]interface{}{"pattern": "("}); err == nil {
+		t.Fatal("invalid regexp accepted")
+	}
+	if _, err := gt.Run(context.Background(), map[string]interface{}{"pattern": "x", "path": "escape"}); !errors.Is(err, ErrPathOutsideWorkspace) {
+		t.Fatalf("outside path: got %v", err)
+	}
+}
@@ -1,30 +1,158 @@
 package tool
 
 import (
-	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/openmanus/openmanus-go/internal/sandbox"
 )
 
-type ShellTool struct{}
+// DefaultShellSession 未指定沙箱的 ShellTool 在全局沙箱管理器中使用的会话
+const DefaultShellSession = "shell"
+
+// ShellTool 在沙箱中执行 shell 命令，分别返回标准输出、标准错误和退出码
+type ShellTool struct {
+	// Sandbox 执行命令的沙箱，为 nil 时使用全局沙箱管理器中 DefaultShellSession 会话的沙箱
+	// （Linux 上按 sandbox.isolation 配置使用命名空间沙箱，工作目录为管理器的工作区，
+	// 默认即工具工作区），未设置管理器时使用按 sandbox.DefaultLimits 限制资源、在当前目录执行的
+	// BaseSandbox；两者的资源限制均来自配置 [sandbox.limits]，其他系统上以 sh -c 执行
+	Sandbox sandbox.Sandbox
+	// MaxOutput 标准输出和标准错误各自保留的最大字节数，为 0 时使用 sandbox.DefaultMaxOutput
+	MaxOutput int
+	// OnOutput 命令执行过程中收到输出时调用，用于实时显示
+	OnOutput func(stream string, data []byte)
+}
+
+// NewShellTool 创建 shell 工具，sb 为 nil 时使用全局沙箱管理器分配的沙箱
+func NewShellTool(sb sandbox.Sandbox) *ShellTool {
+	return &ShellTool{Sandbox: sb}
+}
 
 func (s *ShellTool) Name() string {
 	return "ShellTool"
 }
 
+// ExclusiveKeys 命令可能读写任意文件，shell 调用依次执行
+func (s *ShellTool) ExclusiveKeys(input interface{}) []string {
+	return []string{"shell"}
+}
+
+// ShellInput 命令参数
+type ShellInput struct {
+	Command string `json:"command"`
+}
+
+// ShellResult 命令执行结果；命令以非零退出码结束或触发资源限制时同样作为结果返回
+type ShellResult struct {
+	Command  string `json:"command"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+	// Signal 终止命令的信号名
+	Signal     string `json:"signal,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	// PeakMemory 内存峰值（字节）
+	PeakMemory      int64 `json:"peak_memory,omitempty"`
+	StdoutTruncated bool  `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool  `json:"stderr_truncated,omitempty"`
+	// Violation 触发的资源限制，例如 timeout、oom、seccomp
+	Violation string `json:"violation,omitempty"`
+	// Unenforced 已配置但沙箱无法施加的资源限制，例如 memory
+	Unenforced []string `json:"unenforced,omitempty"`
+	// Error 沙箱报告的错误
+	Error string `json:"error,omitempty"`
+}
+
+// String 返回标准输出、标准错误和退出状态
+func (r ShellResult) String() string {
+	var b strings.Builder
+	b.WriteString(r.Stdout)
+	if r.StdoutTruncated {
+		b.WriteString("\n[stdout truncated]")
+	}
+	if r.Stderr != "" || r.StderrTruncated {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("[stderr]\n")
+		b.WriteString(r.Stderr)
+		if r.StderrTruncated {
+			b.WriteString("\n[stderr truncated]")
+		}
+	}
+	if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+	switch {
+	case r.Signal != "":
+		fmt.Fprintf(&b, "[killed by %s]", r.Signal)
+	default:
+		fmt.Fprintf(&b, "[exit code %d]", r.ExitCode)
+	}
+	if r.Error != "" {
+		fmt.Fprintf(&b, "\n[error] %s", r.Error)
+	}
+	return b.String()
+}
+
+// Run 执行命令，输入为命令字符串或 {"command": ...}
 func (s *ShellTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
-	cmdStr, ok := input.(string)
-	if !ok {
+	var in ShellInput
+	if cmd, ok := input.(string); ok {
+		in.Command = cmd
+	} else if err := decodeArgs(input, &in); err != nil {
 		return nil, ErrInvalidInput
 	}
-	cmd := exec.CommandContext(ctx, "sh", "-c", cmdStr)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &out
-	if err := cmd.Run(); err != nil {
-		return out.String(), err
+	if strings.TrimSpace(in.Command) == "" {
+		return nil, ErrInvalidInput
+	}
+
+	res, err := s.currentSandbox().Run(ctx, in.Command, sandbox.ExecOptions{
+		MaxOutput: s.MaxOutput,
+		OnOutput:  s.OnOutput,
+	})
+	if res == nil {
+		// 命令未能启动
+		return nil, err
+	}
+	// 调用方取消时命令被终止，不把不完整的输出当作结果
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	result := ShellResult{
+		Command:         in.Command,
+		Stdout:          res.Stdout,
+		Stderr:          res.Stderr,
+		ExitCode:        res.ExitCode,
+		Signal:          res.Signal,
+		DurationMs:      res.Duration.Round(time.Millisecond).Milliseconds(),
+		PeakMemory:      res.PeakMemory,
+		StdoutTruncated: res.StdoutTruncated,
+		StderrTruncated: res.StderrTruncated,
+		Violation:       res.Violation,
+		Unenforced:      res.Unenforced,
+	}
+	// 非零退出码已体现在结果中，其他错误（资源限制、沙箱故障）附在结果里
+	var exitErr *exec.ExitError
+	if err != nil && (res.Violation != "" || !errors.As(err, &exitErr)) {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// currentSandbox 返回执行命令的沙箱
+func (s *ShellTool) currentSandbox() sandbox.Sandbox {
+	if s.Sandbox != nil {
+		return s.Sandbox
+	}
+	if m := sandbox.DefaultManager(); m != nil {
+		return m.Session(DefaultShellSession)
 	}
-	return out.String(), nil
+	return sandbox.NewBaseSandbox()
 }
 
-var ErrInvalidInput = &ToolError{"ShellTool: 输入必须为字符串"}
+var ErrInvalidInput = &ToolError{"ShellTool: 输入必须为命令字符串或包含 command 的对象"}
@@ -0,0 +1,120 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openmanus/openmanus-go/internal/sandbox"
+)
+
+func TestShellTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands use sh syntax")
+	}
+	st := NewShellTool(nil)
+	tests := []struct {
+		name     string
+		input    interface{}
+		stdout   string
+		stderr   string
+		exitCode int
+	}{
+		{"string input", "echo hello", "hello\n", "", 0},
+		{"object input", map[string]interface{}{"command": "echo out; echo err >&2"}, "out\n", "err\n", 0},
+		{"non-zero exit is a result", "exit 3", "", "", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := st.Run(context.Background(), tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := out.(ShellResult)
+			if res.Stdout != tt.stdout || res.Stderr != tt.stderr || res.ExitCode != tt.exitCode || res.Error != "" {
+				t.Fatalf("result = %+v", res)
+			}
+		})
+	}
+
+	for _, input := range []interface{}{"", "   ", map[string]interface{}{"cmd": "ls"}, 42} {
+		if _, err := st.Run(context.Background(), input); err != ErrInvalidInput {
+			t.Fatalf("input %v: got %v, want ErrInvalidInput", input, err)
+		}
+	}
+}
+
+func TestShellToolDefaultManagerWorkspace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands use sh syntax")
+	}
+	ws := newTestWorkspace(t)
+	m, err := sandbox.NewManager(sandbox.ManagerConfig{
+		Workspace: ws.Root(),
+		Namespace: sandbox.DefaultNamespaceConfig(),
+		Limits:    sandbox.ResourceLimits{TimeLimit: 30},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sandbox.SetDefaultManager(m)
+	t.Cleanup(func() {
+		sandbox.SetDefaultManager(nil)
+		m.Close()
+	})
+	writeTestFile(t, ws.Root(), "src/input.txt", "from tools\n")
+
+	// 未指定沙箱时在默认会话的沙箱中执行，工作目录为工具工作区
+	out, err := NewShellTool(nil).Run(context.Background(), "cat src/input.txt && echo done > out.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := out.(ShellResult); res.Stdout != "from tools\n" || res.ExitCode != 0 {
+		t.Fatalf("result = %+v", res)
+	}
+	if data, err := os.ReadFile(filepath.Join(ws.Root(), "out.txt")); err != nil || string(data) != "done\n" {
+		t.Fatalf("out.txt = %q, %v", data, err)
+	}
+	// 会话释放后工作区保留
+	m.Release(DefaultShellSession)
+	if _, err := os.Stat(filepath.Join(ws.Root(), "src", "input.txt")); err != nil {
+		t.Fatalf("workspace cleared on release: %v", err)
+	}
+}
+
+func TestShellToolCanceled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands use sh syntax")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	out, err := NewShellTool(nil).Run(ctx, "echo partial; sleep 10")
+	if !errors.Is(err, context.DeadlineExceeded) || out != nil {
+		t.Fatalf("got %v, %v; want context.DeadlineExceeded", out, err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("command was not stopped on cancel")
+	}
+}
+
+func TestShellToolDefaultLimitsRunToolchains(t *testing.T) {
+	// 默认资源限制下 Go 等预留大量地址空间、使用大量线程的工具链可以运行
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go not found")
+	}
+	out, err := NewShellTool(nil).Run(context.Background(), goBin+" version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := out.(ShellResult); res.ExitCode != 0 || !strings.HasPrefix(res.Stdout, "go version") {
+		t.Fatalf("result = %+v", res)
+	}
+}
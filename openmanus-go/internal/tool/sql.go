@@ -0,0 +1,991 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQL 工具默认限制
+const (
+	defaultSQLMaxRows    = 100
+	defaultSQLCellLength = 200
+	maxSQLLoadSize       = 200 * 1024 * 1024
+)
+
+// ErrSQLWriteNotAllowed 只读模式下的写操作
+var ErrSQLWriteNotAllowed = errors.New("sql writes are disabled, set tools.sql.allow_write to enable")
+
+var (
+	sqlIdentRe    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	sqlNonIdentRe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+	// 数据库的打开和只读开关只能通过工具操作修改，防止绕过只读限制或在工作区外创建文件；
+	// VACUUM INTO 即使被 query_only 拒绝也会先创建目标文件
+	sqlForbiddenRe = regexp.MustCompile(`(?i)\b(attach|detach)\b|\bpragma\b[^;]*\b(query_only|writable_schema)\b|\bvacuum\b[^;]*\binto\b`)
+)
+
+// SQLConfig SQL 工具配置
+type SQLConfig struct {
+	// AllowWrite 允许修改已打开的 SQLite 数据库，默认只读
+	AllowWrite    bool
+	MaxRows       int
+	MaxCellLength int
+	Timeout       time.Duration
+}
+
+// SQLTool 基于 SQLite 的查询工具，CSV/JSON 文件加载到内存库中，SQLite 文件以 ATTACH 方式打开
+type SQLTool struct {
+	name        string
+	description string
+	config      SQLConfig
+	workspace   *Workspace
+
+	mu   sync.Mutex
+	db   *sql.DB
+	conn *sql.Conn
+}
+
+// NewSQLTool 创建 SQL 工具，ws 为空时使用默认工作区
+func NewSQLTool(config SQLConfig, ws *Workspace) *SQLTool {
+	if config.MaxRows <= 0 {
+		config.MaxRows = defaultSQLMaxRows
+	}
+	if config.MaxCellLength <= 0 {
+		config.MaxCellLength = defaultSQLCellLength
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &SQLTool{
+		name:        "sql",
+		description: "SQLite 查询工具：open 打开 SQLite 数据库，load 将 CSV/JSON 文件加载为表，describe 查看表结构，query 执行只读查询，exec 执行写操作（需配置允许）",
+		config:      config,
+		workspace:   ws,
+	}
+}
+
+// Name 返回工具名称
+func (t *SQLTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *SQLTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *SQLTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// SQLInput SQL 工具参数
+type SQLInput struct {
+	Operation string        `json:"operation"`
+	SQL       string        `json:"sql,omitempty"`
+	Params    []interface{} `json:"params,omitempty"`
+	MaxRows   int           `json:"max_rows,omitempty"`
+	// open / load 参数
+	Path      string `json:"path,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Table     string `json:"table,omitempty"`
+	Format    string `json:"format,omitempty"`
+	Delimiter string `json:"delimiter,omitempty"`
+	NoHeader  bool   `json:"no_header,omitempty"`
+	Replace   bool   `json:"replace,omitempty"`
+}
+
+// SQLColumn 结果列
+type SQLColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// SQLResult 查询结果
+type SQLResult struct {
+	Columns      []SQLColumn     `json:"columns,omitempty"`
+	Rows         [][]interface{} `json:"rows,omitempty"`
+	RowCount     int             `json:"row_count"`
+	Truncated    bool            `json:"truncated,omitempty"`
+	RowsAffected int64           `json:"rows_affected,omitempty"`
+	Elapsed      string          `json:"elapsed"`
+}
+
+// String 以文本表格形式输出结果，便于直接反馈给 LLM
+func (r SQLResult) String() string {
+	if len(r.Columns) == 0 {
+		return fmt.Sprintf("OK, %d rows affected (%s)", r.RowsAffected, r.Elapsed)
+	}
+	var sb strings.Builder
+	names := make([]string, len(r.Columns))
+	for i, c := range r.Columns {
+		names[i] = fmt.Sprintf("%s (%s)", c.Name, c.Type)
+	}
+	sb.WriteString("| " + strings.Join(names, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(r.Columns)) + "\n")
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			if v == nil {
+				cells[i] = "NULL"
+			} else {
+				cells[i] = strings.ReplaceAll(fmt.Sprint(v), "|", `\|`)
+			}
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	fmt.Fprintf(&sb, "%d rows", r.RowCount)
+	if r.Truncated {
+		sb.WriteString(" (truncated)")
+	}
+	fmt.Fprintf(&sb, ", %s", r.Elapsed)
+	return sb.String()
+}
+
+// SQLTableInfo 表结构
+type SQLTableInfo struct {
+	Schema   string      `json:"schema"`
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Columns  []SQLColumn `json:"columns"`
+	RowCount int64       `json:"row_count"`
+	Source   string      `json:"source,omitempty"`
+}
+
+// SQLSchema 数据库结构描述
+type SQLSchema struct {
+	Tables []SQLTableInfo `json:"tables"`
+}
+
+// String 输出便于规划查询的结构描述
+func (s SQLSchema) String() string {
+	if len(s.Tables) == 0 {
+		return "没有可用的表，请先使用 open 或 load 操作"
+	}
+	var sb strings.Builder
+	for _, t := range s.Tables {
+		name := t.Name
+		if t.Schema != "main" {
+			name = t.Schema + "." + t.Name
+		}
+		fmt.Fprintf(&sb, "%s %s (%d rows)", t.Type, name, t.RowCount)
+		if t.Source != "" {
+			fmt.Fprintf(&sb, " from %s", t.Source)
+		}
+		sb.WriteString("\n")
+		for _, c := range t.Columns {
+			fmt.Fprintf(&sb, "  - %s %s\n", c.Name, c.Type)
+		}
+	}
+	return sb.String()
+}
+
+// 预定义的 SQL 操作
+const (
+	SQLOpOpen     = "open"
+	SQLOpLoad     = "load"
+	SQLOpQuery    = "query"
+	SQLOpExec     = "exec"
+	SQLOpDescribe = "describe"
+	SQLOpClose    = "close"
+)
+
+// Run 执行 SQL 操作
+func (t *SQLTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in SQLInput
+	if s, ok := input.(string); ok {
+		in.Operation, in.SQL = SQLOpQuery, s
+	} else if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Operation == "" {
+		in.Operation = SQLOpQuery
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, t.config.Timeout)
+	defer cancel()
+	conn, err := t.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if sqlForbiddenRe.MatchString(in.SQL) {
+		return nil, fmt.Errorf("%w: use the open and close operations instead of ATTACH/DETACH, PRAGMA query_only or VACUUM INTO", ErrInvalidOperation)
+	}
+
+	switch in.Operation {
+	case SQLOpOpen:
+		return t.open(ctx, conn, in)
+	case SQLOpLoad:
+		return t.load(ctx, conn, in)
+	case SQLOpQuery:
+		if strings.TrimSpace(in.SQL) == "" {
+			return nil, ErrInvalidArgs
+		}
+		return t.query(ctx, conn, in)
+	case SQLOpExec:
+		if strings.TrimSpace(in.SQL) == "" {
+			return nil, ErrInvalidArgs
+		}
+		if !t.config.AllowWrite {
+			return nil, ErrSQLWriteNotAllowed
+		}
+		return t.exec(ctx, conn, in)
+	case SQLOpDescribe:
+		return t.describe(ctx, conn, in.Table)
+	case SQLOpClose:
+		return t.detach(ctx, conn, in.Name)
+	default:
+		return nil, ErrInvalidOperation
+	}
+}
+
+// connection 返回唯一的数据库连接；只读模式下通过 query_only 拒绝所有写入
+func (t *SQLTool) connection(ctx context.Context) (*sql.Conn, error) {
+	if t.conn != nil {
+		return t.conn, nil
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite failed: %v", err)
+	}
+	// 内存库只在单个连接内可见
+	db.SetMaxOpenConns(1)
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open sqlite failed: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE _sources (name TEXT PRIMARY KEY, path TEXT)"); err != nil {
+		conn.Close()
+		db.Close()
+		return nil, err
+	}
+	t.db, t.conn = db, conn
+	return conn, t.setQueryOnly(ctx, !t.config.AllowWrite)
+}
+
+func (t *SQLTool) setQueryOnly(ctx context.Context, on bool) error {
+	value := "OFF"
+	if on {
+		value = "ON"
+	}
+	_, err := t.conn.ExecContext(ctx, "PRAGMA query_only = "+value)
+	return err
+}
+
+// Close 关闭数据库连接
+func (t *SQLTool) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.db == nil {
+		return nil
+	}
+	t.conn.Close()
+	err := t.db.Close()
+	t.db, t.conn = nil, nil
+	return err
+}
+
+// quoteIdent 转义 SQL 标识符
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// tableNameFromPath 由文件名生成合法的表名
+func tableNameFromPath(p string) string {
+	base := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+	name := sqlNonIdentRe.ReplaceAllString(base, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "t_" + name
+	}
+	return name
+}
+
+// open 以 ATTACH 方式打开工作区中的 SQLite 数据库，未允许写入时以只读模式打开
+func (t *SQLTool) open(ctx context.Context, conn *sql.Conn, in SQLInput) (interface{}, error) {
+	if in.Path == "" {
+		return nil, ErrInvalidArgs
+	}
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return nil, err
+	}
+	full, err := ws.Resolve(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(full); err != nil {
+		return nil, err
+	}
+	name := in.Name
+	if name == "" {
+		name = tableNameFromPath(full)
+	}
+	if !sqlIdentRe.MatchString(name) || strings.EqualFold(name, "main") || strings.EqualFold(name, "temp") {
+		return nil, fmt.Errorf("%w: invalid database name %q", ErrInvalidArgs, name)
+	}
+
+	mode := "ro"
+	if t.config.AllowWrite {
+		mode = "rw"
+	}
+	uri := fmt.Sprintf("file:%s?mode=%s", sqliteURIPath(full), mode)
+	// ATTACH 本身不修改数据，但 query_only 会拒绝它
+	if err := t.setQueryOnly(ctx, false); err != nil {
+		return nil, err
+	}
+	_, err = conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+quoteIdent(name), uri)
+	if qerr := t.setQueryOnly(ctx, !t.config.AllowWrite); err == nil {
+		err = qerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open database failed: %v", err)
+	}
+	return t.describeSchema(ctx, conn, name, "")
+}
+
+// sqliteURIPath 转义 SQLite URI 文件名中的特殊字符
+func sqliteURIPath(p string) string {
+	r := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+	return r.Replace(filepath.ToSlash(p))
+}
+
+// detach 关闭打开的数据库或删除加载的表
+func (t *SQLTool) detach(ctx context.Context, conn *sql.Conn, name string) (interface{}, error) {
+	if name == "" {
+		return nil, ErrInvalidArgs
+	}
+	if err := t.setQueryOnly(ctx, false); err != nil {
+		return nil, err
+	}
+	defer t.setQueryOnly(ctx, !t.config.AllowWrite)
+
+	var source string
+	err := conn.QueryRowContext(ctx, "SELECT path FROM _sources WHERE name = ?", name).Scan(&source)
+	if err == nil {
+		if _, err := conn.ExecContext(ctx, "DROP TABLE "+quoteIdent(name)); err != nil {
+			return nil, err
+		}
+		_, err = conn.ExecContext(ctx, "DELETE FROM _sources WHERE name = ?", name)
+		return SQLResult{Elapsed: "0s"}, err
+	}
+	if _, err := conn.ExecContext(ctx, "DETACH DATABASE "+quoteIdent(name)); err != nil {
+		return nil, err
+	}
+	return SQLResult{Elapsed: "0s"}, nil
+}
+
+// load 将 CSV 或 JSON 文件加载为内存库中的表，列类型根据数据推断
+func (t *SQLTool) load(ctx context.Context, conn *sql.Conn, in SQLInput) (interface{}, error) {
+	if in.Path == "" {
+		return nil, ErrInvalidArgs
+	}
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return nil, err
+	}
+	full, err := ws.Resolve(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxSQLLoadSize {
+		return nil, fmt.Errorf("file too large to load: %d bytes", info.Size())
+	}
+
+	format := strings.ToLower(in.Format)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(full)), ".")
+	}
+	var columns []string
+	var rows [][]interface{}
+	switch format {
+	case "csv", "tsv", "txt":
+		delim := ','
+		if format == "tsv" {
+			delim = '\t'
+		}
+		if in.Delimiter != "" {
+			delim, _ = utf8.DecodeRuneInString(in.Delimiter)
+		}
+		columns, rows, err = readCSVRecords(full, delim, !in.NoHeader)
+	case "json", "jsonl", "ndjson":
+		columns, rows, err = readJSONRecords(full)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q, use csv, tsv, json or jsonl", ErrInvalidArgs, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns found in %s", in.Path)
+	}
+
+	table := in.Table
+	if table == "" {
+		table = tableNameFromPath(full)
+	}
+	if strings.HasPrefix(table, "_") || strings.HasPrefix(strings.ToLower(table), "sqlite_") {
+		return nil, fmt.Errorf("%w: invalid table name %q", ErrInvalidArgs, table)
+	}
+
+	if err := t.setQueryOnly(ctx, false); err != nil {
+		return nil, err
+	}
+	defer t.setQueryOnly(ctx, !t.config.AllowWrite)
+	if err := createTable(ctx, conn, table, columns, rows, in.Replace); err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "INSERT OR REPLACE INTO _sources (name, path) VALUES (?, ?)", table, ws.Rel(full)); err != nil {
+		return nil, err
+	}
+	return t.describeSchema(ctx, conn, "main", table)
+}
+
+// createTable 在事务中建表并插入数据
+func createTable(ctx context.Context, conn *sql.Conn, table string, columns []string, rows [][]interface{}, replace bool) error {
+	types := inferColumnTypes(columns, rows)
+	defs := make([]string, len(columns))
+	seen := make(map[string]int)
+	for i, col := range columns {
+		name := strings.TrimSpace(col)
+		if name == "" {
+			name = fmt.Sprintf("column%d", i+1)
+		}
+		// 重复列名加序号区分
+		key := strings.ToLower(name)
+		if n := seen[key]; n > 0 {
+			name = fmt.Sprintf("%s_%d", name, n+1)
+		}
+		seen[key]++
+		defs[i] = quoteIdent(name) + " " + types[i]
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if replace {
+		if _, err := tx.ExecContext(ctx, "DROP TABLE IF EXISTS "+quoteIdent(table)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(table), strings.Join(defs, ", "))); err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return fmt.Errorf("table %q already exists, set replace to overwrite it", table)
+		}
+		return err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%s)", quoteIdent(table), placeholders))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		values := make([]interface{}, len(columns))
+		for i := range values {
+			if i < len(row) {
+				values[i] = convertCell(row[i], types[i])
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// inferColumnTypes 推断列类型：全部为整数时为 INTEGER，全部为数值时为 REAL，否则为 TEXT
+func inferColumnTypes(columns []string, rows [][]interface{}) []string {
+	types := make([]string, len(columns))
+	for i := range columns {
+		isInt, isReal, any := true, true, false
+		for _, row := range rows {
+			if i >= len(row) || row[i] == nil {
+				continue
+			}
+			switch v := row[i].(type) {
+			case float64:
+				any = true
+				if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+					isInt = false
+				}
+			case bool:
+				any = true
+			case string:
+				s := strings.TrimSpace(v)
+				if s == "" {
+					continue
+				}
+				any = true
+				if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+					isInt = false
+					if _, err := strconv.ParseFloat(s, 64); err != nil {
+						isReal = false
+					}
+				}
+			default:
+				any = true
+				isInt, isReal = false, false
+			}
+			if !isInt && !isReal {
+				break
+			}
+		}
+		switch {
+		case !any:
+			types[i] = "TEXT"
+		case isInt:
+			types[i] = "INTEGER"
+		case isReal:
+			types[i] = "REAL"
+		default:
+			types[i] = "TEXT"
+		}
+	}
+	return types
+}
+
+// convertCell 按列类型转换单元格，数值列中的空字符串视为 NULL
+func convertCell(v interface{}, typ string) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case bool:
+		if typ == "TEXT" {
+			return strconv.FormatBool(val)
+		}
+		if val {
+			return 1
+		}
+		return 0
+	case float64:
+		if typ == "INTEGER" {
+			return int64(val)
+		}
+		if typ == "TEXT" {
+			return strconv.FormatFloat(val, 'f', -1, 64)
+		}
+		return val
+	case string:
+		if typ == "TEXT" {
+			return val
+		}
+		s := strings.TrimSpace(val)
+		if s == "" {
+			return nil
+		}
+		if typ == "INTEGER" {
+			n, _ := strconv.ParseInt(s, 10, 64)
+			return n
+		}
+		f, _ := strconv.ParseFloat(s, 64)
+		return f
+	default:
+		// 嵌套对象和数组以 JSON 文本保存
+		data, _ := json.Marshal(val)
+		return string(data)
+	}
+}
+
+// readCSVRecords 读取 CSV，header 为 false 时生成 column1... 列名
+func readCSVRecords(path string, delim rune, header bool) ([]string, [][]interface{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	return parseCSVRecords(file, delim, header)
+}
+
+// parseCSVRecords 解析 CSV 内容，header 为 false 时生成 column1、column2 等列名
+func parseCSVRecords(r io.Reader, delim rune, header bool) ([]string, [][]interface{}, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.Comma = delim
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var columns []string
+	var rows [][]interface{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse csv failed: %v", err)
+		}
+		if columns == nil && header {
+			if len(record) > 0 {
+				// 去掉 UTF-8 BOM
+				record[0] = strings.TrimPrefix(record[0], "\ufeff")
+			}
+			columns = record
+			continue
+		}
+		row := make([]interface{}, len(record))
+		for i, v := range record {
+			row[i] = v
+		}
+		rows = append(rows, row)
+	}
+	if !header {
+		width := 0
+		for _, row := range rows {
+			if len(row) > width {
+				width = len(row)
+			}
+		}
+		for i := 0; i < width; i++ {
+			columns = append(columns, fmt.Sprintf("column%d", i+1))
+		}
+	}
+	return columns, rows, nil
+}
+
+// readJSONRecords 读取 JSON 记录：对象数组、含数组字段的对象或 JSON Lines
+func readJSONRecords(path string) ([]string, [][]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := parseJSONRecords(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns, rows := jsonRecordRows(records)
+	return columns, rows, nil
+}
+
+// jsonRecordRows 将 JSON 记录转换为按首次出现顺序排列的列和行
+func jsonRecordRows(records []jsonRecord) ([]string, [][]interface{}) {
+	var columns []string
+	index := make(map[string]int)
+	for _, rec := range records {
+		for _, key := range rec.keys {
+			if _, ok := index[key]; !ok {
+				index[key] = len(columns)
+				columns = append(columns, key)
+			}
+		}
+	}
+	rows := make([][]interface{}, len(records))
+	for i, rec := range records {
+		row := make([]interface{}, len(columns))
+		for key, v := range rec.values {
+			row[index[key]] = v
+		}
+		rows[i] = row
+	}
+	return columns, rows
+}
+
+// jsonRecord 保留字段原始顺序的 JSON 对象
+type jsonRecord struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// parseJSONRecords 解析多种 JSON 记录格式
+func parseJSONRecords(data []byte) ([]jsonRecord, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" {
+		return nil, nil
+	}
+	switch trimmed[0] {
+	case '[':
+		var raw []json.RawMessage
+		if err := json.Unmarshal([]byte(trimmed), &raw); err != nil {
+			return nil, fmt.Errorf("parse json failed: %v", err)
+		}
+		return decodeJSONRecords(raw)
+	case '{':
+		// 单个对象：取其中第一个对象数组字段，例如 {"data": [...]}；否则按 JSON Lines 解析
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(trimmed), &obj); err == nil {
+			for _, key := range []string{"data", "records", "rows", "items"} {
+				var raw []json.RawMessage
+				if json.Unmarshal(obj[key], &raw) == nil && len(raw) > 0 {
+					return decodeJSONRecords(raw)
+				}
+			}
+		}
+		var raw []json.RawMessage
+		dec := json.NewDecoder(strings.NewReader(trimmed))
+		for {
+			var msg json.RawMessage
+			if err := dec.Decode(&msg); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("parse json lines failed: %v", err)
+			}
+			raw = append(raw, msg)
+		}
+		return decodeJSONRecords(raw)
+	default:
+		return nil, fmt.Errorf("parse json failed: expected an array of objects")
+	}
+}
+
+// decodeJSONRecords 按字段出现顺序解码对象
+func decodeJSONRecords(raw []json.RawMessage) ([]jsonRecord, error) {
+	records := make([]jsonRecord, 0, len(raw))
+	for i, msg := range raw {
+		dec := json.NewDecoder(strings.NewReader(string(msg)))
+		tok, err := dec.Token()
+		if err != nil || tok != json.Delim('{') {
+			return nil, fmt.Errorf("parse json failed: record %d is not an object", i)
+		}
+		rec := jsonRecord{values: make(map[string]interface{})}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("parse json failed: %v", err)
+			}
+			key := keyTok.(string)
+			var value interface{}
+			if err := dec.Decode(&value); err != nil {
+				return nil, fmt.Errorf("parse json failed: %v", err)
+			}
+			if _, ok := rec.values[key]; !ok {
+				rec.keys = append(rec.keys, key)
+			}
+			rec.values[key] = value
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// query 执行查询并返回有界结果
+func (t *SQLTool) query(ctx context.Context, conn *sql.Conn, in SQLInput) (interface{}, error) {
+	maxRows := t.config.MaxRows
+	if in.MaxRows > 0 && in.MaxRows < maxRows {
+		maxRows = in.MaxRows
+	}
+
+	start := time.Now()
+	rows, err := conn.QueryContext(ctx, in.SQL, in.Params...)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	result := SQLResult{Columns: make([]SQLColumn, len(colTypes)), Rows: make([][]interface{}, 0)}
+	for i, ct := range colTypes {
+		result.Columns[i] = SQLColumn{Name: ct.Name(), Type: strings.ToUpper(ct.DatabaseTypeName())}
+	}
+
+	for rows.Next() {
+		if len(result.Rows) >= maxRows {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(colTypes))
+		ptrs := make([]interface{}, len(colTypes))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			values[i] = t.formatCell(v)
+			// 表达式列没有声明类型，根据值推断
+			if result.Columns[i].Type == "" && v != nil {
+				result.Columns[i].Type = valueType(v)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, sqlError(err)
+	}
+	result.RowCount = len(result.Rows)
+	result.Elapsed = time.Since(start).Round(time.Microsecond).String()
+	return result, nil
+}
+
+// exec 执行写语句
+func (t *SQLTool) exec(ctx context.Context, conn *sql.Conn, in SQLInput) (interface{}, error) {
+	start := time.Now()
+	res, err := conn.ExecContext(ctx, in.SQL, in.Params...)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	affected, _ := res.RowsAffected()
+	return SQLResult{RowsAffected: affected, Elapsed: time.Since(start).Round(time.Microsecond).String()}, nil
+}
+
+// sqlError 将只读模式下的写入错误转换为明确的提示
+func sqlError(err error) error {
+	msg := err.Error()
+	if strings.Contains(msg, "attempt to write a readonly database") || strings.Contains(msg, "query_only") {
+		return fmt.Errorf("%w: %v", ErrSQLWriteNotAllowed, err)
+	}
+	return err
+}
+
+// formatCell 截断过长的文本，二进制数据只显示长度
+func (t *SQLTool) formatCell(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		if utf8.Valid(val) {
+			return t.clipCell(string(val))
+		}
+		return fmt.Sprintf("<blob %d bytes>", len(val))
+	case string:
+		return t.clipCell(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	default:
+		return val
+	}
+}
+
+func (t *SQLTool) clipCell(s string) string {
+	if utf8.RuneCountInString(s) <= t.config.MaxCellLength {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:t.config.MaxCellLength]) + "..."
+}
+
+// valueType 返回值对应的 SQLite 存储类型
+func valueType(v interface{}) string {
+	switch v.(type) {
+	case int64, int, bool:
+		return "INTEGER"
+	case float64:
+		return "REAL"
+	case []byte:
+		return "BLOB"
+	default:
+		return "TEXT"
+	}
+}
+
+// describe 描述所有数据库中的表，table 非空时只描述该表（可写作 schema.table）
+func (t *SQLTool) describe(ctx context.Context, conn *sql.Conn, table string) (interface{}, error) {
+	schema := ""
+	if i := strings.Index(table, "."); i > 0 {
+		schema, table = table[:i], table[i+1:]
+	}
+	return t.describeSchema(ctx, conn, schema, table)
+}
+
+// describeSchema 列出表结构和行数
+func (t *SQLTool) describeSchema(ctx context.Context, conn *sql.Conn, schema, table string) (SQLSchema, error) {
+	var schemas []string
+	if schema != "" {
+		schemas = []string{schema}
+	} else {
+		rows, err := conn.QueryContext(ctx, "SELECT name FROM pragma_database_list WHERE name <> 'temp' ORDER BY seq")
+		if err != nil {
+			return SQLSchema{}, err
+		}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return SQLSchema{}, err
+			}
+			schemas = append(schemas, name)
+		}
+		rows.Close()
+	}
+
+	sources := make(map[string]string)
+	if rows, err := conn.QueryContext(ctx, "SELECT name, path FROM _sources"); err == nil {
+		for rows.Next() {
+			var name, path string
+			if rows.Scan(&name, &path) == nil {
+				sources[name] = path
+			}
+		}
+		rows.Close()
+	}
+
+	result := SQLSchema{Tables: make([]SQLTableInfo, 0)}
+	for _, s := range schemas {
+		query := fmt.Sprintf("SELECT name, type FROM %s.sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%%'", quoteIdent(s))
+		args := []interface{}{}
+		if table != "" {
+			query += " AND name = ?"
+			args = append(args, table)
+		}
+		query += " ORDER BY name"
+		rows, err := conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return SQLSchema{}, err
+		}
+		var tables []SQLTableInfo
+		for rows.Next() {
+			info := SQLTableInfo{Schema: s}
+			if err := rows.Scan(&info.Name, &info.Type); err != nil {
+				rows.Close()
+				return SQLSchema{}, err
+			}
+			if s == "main" && info.Name == "_sources" {
+				continue
+			}
+			if s == "main" {
+				info.Source = sources[info.Name]
+			}
+			tables = append(tables, info)
+		}
+		rows.Close()
+
+		for i := range tables {
+			info := &tables[i]
+			cols, err := conn.QueryContext(ctx, "SELECT name, type FROM pragma_table_info(?, ?)", info.Name, s)
+			if err != nil {
+				return SQLSchema{}, err
+			}
+			for cols.Next() {
+				var col SQLColumn
+				if err := cols.Scan(&col.Name, &col.Type); err != nil {
+					cols.Close()
+					return SQLSchema{}, err
+				}
+				info.Columns = append(info.Columns, col)
+			}
+			cols.Close()
+			conn.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s.%s", quoteIdent(s), quoteIdent(info.Name))).Scan(&info.RowCount)
+		}
+		result.Tables = append(result.Tables, tables...)
+	}
+	if table != "" && len(result.Tables) == 0 {
+		return SQLSchema{}, fmt.Errorf("table %q not found", table)
+	}
+	return result, nil
+}
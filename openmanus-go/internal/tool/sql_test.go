@@ -0,0 +1,133 @@
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLForbiddenStatements(t *testing.T) {
+	tests := []struct {
+		sql       string
+		forbidden bool
+	}{
+		{"ATTACH DATABASE '/etc/x.db' AS x", true},
+		{"attach '/tmp/x.db' as x", true},
+		{"DETACH x", true},
+		{"PRAGMA query_only = 0", true},
+		{"pragma main.query_only=OFF", true},
+		{"PRAGMA writable_schema = 1", true},
+		{"VACUUM INTO '/tmp/copy.db'", true},
+		{"vacuum main into 'x.db'", true},
+		{"SELECT 1; PRAGMA query_only = 0", true},
+		{"SELECT * FROM t", false},
+		{"SELECT attached FROM t", false},
+		{"PRAGMA table_info(t)", false},
+		{"VACUUM", false},
+		{"SELECT 'query_only' AS s", false},
+		{"INSERT INTO t SELECT * FROM s", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			if got := sqlForbiddenRe.MatchString(tt.sql); got != tt.forbidden {
+				t.Fatalf("forbidden = %v, want %v", got, tt.forbidden)
+			}
+		})
+	}
+}
+
+// newTestSQLTool 创建 SQL 工具，并在工作区中准备一个 CSV 文件和一个 SQLite 数据库
+func newTestSQLTool(t *testing.T, allowWrite bool) *SQLTool {
+	t.Helper()
+	ws := newTestWorkspace(t)
+	writeTestFile(t, ws.Root(), "people.csv", "name,age\nann,30\nbob,41\n")
+
+	db, err := sql.Open("sqlite", filepath.Join(ws.Root(), "app.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, title TEXT); INSERT INTO items (title) VALUES ('a'), ('b')"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	st := NewSQLTool(SQLConfig{AllowWrite: allowWrite}, ws)
+	t.Cleanup(func() { st.Close() })
+	for _, args := range []map[string]interface{}{
+		{"operation": SQLOpLoad, "path": "people.csv"},
+		{"operation": SQLOpOpen, "path": "app.db"},
+	} {
+		if _, err := st.Run(context.Background(), args); err != nil {
+			t.Fatalf("%v: %v", args["operation"], err)
+		}
+	}
+	return st
+}
+
+func TestSQLReadOnly(t *testing.T) {
+	st := newTestSQLTool(t, false)
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		wantErr error
+	}{
+		{"select loaded table", map[string]interface{}{"sql": "SELECT name FROM people WHERE age > 35"}, nil},
+		{"select opened database", map[string]interface{}{"sql": "SELECT count(*) FROM app.items"}, nil},
+		{"insert via query", map[string]interface{}{"sql": "INSERT INTO people VALUES ('eve', 20)"}, ErrSQLWriteNotAllowed},
+		{"update opened database", map[string]interface{}{"sql": "UPDATE app.items SET title = 'x'"}, ErrSQLWriteNotAllowed},
+		{"temp table", map[string]interface{}{"sql": "CREATE TEMP TABLE x (a)"}, ErrSQLWriteNotAllowed},
+		{"cte write", map[string]interface{}{"sql": "WITH n AS (SELECT 1) DELETE FROM people"}, ErrSQLWriteNotAllowed},
+		{"exec operation", map[string]interface{}{"operation": SQLOpExec, "sql": "DELETE FROM people"}, ErrSQLWriteNotAllowed},
+		{"disable query_only", map[string]interface{}{"sql": "PRAGMA query_only = 0"}, ErrInvalidOperation},
+		{"attach", map[string]interface{}{"sql": "ATTACH DATABASE 'other.db' AS o"}, ErrInvalidOperation},
+		{"vacuum into", map[string]interface{}{"sql": "VACUUM INTO 'copy.db'"}, ErrInvalidOperation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.Run(context.Background(), tt.args)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 工具自身的 load、open、close 操作不受只读模式影响，结束后恢复只读
+	if _, err := st.Run(context.Background(), map[string]interface{}{"operation": SQLOpClose, "name": "app"}); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	_, err := st.Run(context.Background(), map[string]interface{}{"sql": "DELETE FROM people"})
+	if !errors.Is(err, ErrSQLWriteNotAllowed) {
+		t.Fatalf("query_only not restored after close: %v", err)
+	}
+	out, err := st.Run(context.Background(), map[string]interface{}{"sql": "SELECT count(*) AS n FROM people"})
+	if err != nil || out.(SQLResult).Rows[0][0] != int64(2) {
+		t.Fatalf("rows = %+v, %v", out, err)
+	}
+}
+
+func TestSQLAllowWrite(t *testing.T) {
+	st := newTestSQLTool(t, true)
+
+	out, err := st.Run(context.Background(), map[string]interface{}{
+		"operation": SQLOpExec,
+		"sql":       "UPDATE app.items SET title = ? WHERE id = 1",
+		"params":    []interface{}{"changed"},
+	})
+	if err != nil || out.(SQLResult).RowsAffected != 1 {
+		t.Fatalf("exec = %+v, %v", out, err)
+	}
+	out, err = st.Run(context.Background(), map[string]interface{}{"sql": "SELECT title FROM app.items WHERE id = 1"})
+	if err != nil || out.(SQLResult).Rows[0][0] != "changed" {
+		t.Fatalf("select = %+v, %v", out, err)
+	}
+	// 允许写入时数据库选项仍然只能通过工具操作修改
+	if _, err := st.Run(context.Background(), map[string]interface{}{"operation": SQLOpExec, "sql": "ATTACH 'x.db' AS x"}); !errors.Is(err, ErrInvalidOperation) {
+		t.Fatalf("attach with allow_write: %v", err)
+	}
+}
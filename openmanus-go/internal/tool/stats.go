@@ -0,0 +1,359 @@
+package tool
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// errSingularMatrix 矩阵不可逆，通常是自变量之间完全共线
+var errSingularMatrix = errors.New("matrix is singular")
+
+// roundFloat 保留 10 位有效数字，避免输出中出现浮点误差尾数
+func roundFloat(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return v
+	}
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 10, 64), 64)
+	return r
+}
+
+func roundFloats(values []float64) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = roundFloat(v)
+	}
+	return out
+}
+
+// dropNaN 去掉缺失值
+func dropNaN(values []float64) []float64 {
+	out := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// stddev 样本标准差，少于两个值时为 0
+func stddev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	ss := 0.0
+	for _, v := range values {
+		ss += (v - m) * (v - m)
+	}
+	return math.Sqrt(ss / float64(len(values)-1))
+}
+
+// quantile 已排序数据的分位数，使用线性插值
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// pearson 皮尔逊相关系数，任一变量方差为 0 时返回 NaN
+func pearson(x, y []float64) float64 {
+	mx, my := mean(x), mean(y)
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return math.NaN()
+	}
+	r := sxy / math.Sqrt(sxx*syy)
+	return math.Max(-1, math.Min(1, r))
+}
+
+// ranks 计算秩，相同值取平均秩
+func ranks(values []float64) []float64 {
+	index := make([]int, len(values))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(a, b int) bool { return values[index[a]] < values[index[b]] })
+	out := make([]float64, len(values))
+	for i := 0; i < len(index); {
+		j := i
+		for j+1 < len(index) && values[index[j+1]] == values[index[i]] {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			out[index[k]] = rank
+		}
+		i = j + 1
+	}
+	return out
+}
+
+// invertMatrix Gauss-Jordan 消元求逆矩阵（部分主元）
+func invertMatrix(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	m := make([][]float64, n)
+	scale := 0.0
+	for i := range a {
+		m[i] = make([]float64, 2*n)
+		copy(m[i], a[i])
+		m[i][n+i] = 1
+		for _, v := range a[i] {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) <= 1e-12*math.Max(scale, 1) {
+			return nil, errSingularMatrix
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		p := m[col][col]
+		for c := range m[col] {
+			m[col][c] /= p
+		}
+		for r := 0; r < n; r++ {
+			if r == col || m[r][col] == 0 {
+				continue
+			}
+			f := m[r][col]
+			for c := range m[r] {
+				m[r][c] -= f * m[col][c]
+			}
+		}
+	}
+	inv := make([][]float64, n)
+	for i := range m {
+		inv[i] = m[i][n:]
+	}
+	return inv, nil
+}
+
+// olsFit 普通最小二乘拟合，x 不含截距列；返回系数（首项为截距）及 (X'X)^-1
+func olsFit(x [][]float64, y []float64) ([]float64, [][]float64, error) {
+	p := len(x[0]) + 1
+	xtx := make([][]float64, p)
+	for i := range xtx {
+		xtx[i] = make([]float64, p)
+	}
+	xty := make([]float64, p)
+	row := make([]float64, p)
+	for i := range x {
+		row[0] = 1
+		copy(row[1:], x[i])
+		for a := 0; a < p; a++ {
+			xty[a] += row[a] * y[i]
+			for b := a; b < p; b++ {
+				xtx[a][b] += row[a] * row[b]
+			}
+		}
+	}
+	for a := 0; a < p; a++ {
+		for b := 0; b < a; b++ {
+			xtx[a][b] = xtx[b][a]
+		}
+	}
+	inv, err := invertMatrix(xtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	beta := make([]float64, p)
+	for a := 0; a < p; a++ {
+		for b := 0; b < p; b++ {
+			beta[a] += inv[a][b] * xty[b]
+		}
+	}
+	return beta, inv, nil
+}
+
+func sqDist(a, b []float64) float64 {
+	d := 0.0
+	for i := range a {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return d
+}
+
+// kmeansResult 单次 k-means 的结果
+type kmeansResult struct {
+	labels     []int
+	centroids  [][]float64
+	inertia    float64
+	iterations int
+}
+
+// kmeans 多次以 k-means++ 初始化运行 Lloyd 迭代，返回簇内平方和最小的一次
+func kmeans(points [][]float64, k, maxIter, runs int, rng *rand.Rand) kmeansResult {
+	var best kmeansResult
+	for run := 0; run < runs; run++ {
+		res := kmeansOnce(points, k, maxIter, rng)
+		if run == 0 || res.inertia < best.inertia {
+			best = res
+		}
+	}
+	return best
+}
+
+func kmeansOnce(points [][]float64, k, maxIter int, rng *rand.Rand) kmeansResult {
+	n, dim := len(points), len(points[0])
+
+	// k-means++ 初始化：按到最近中心距离的平方加权抽样
+	centroids := make([][]float64, 0, k)
+	centroids = append(centroids, append([]float64(nil), points[rng.Intn(n)]...))
+	dist := make([]float64, n)
+	for len(centroids) < k {
+		total := 0.0
+		for i, p := range points {
+			dist[i] = math.Inf(1)
+			for _, c := range centroids {
+				dist[i] = math.Min(dist[i], sqDist(p, c))
+			}
+			total += dist[i]
+		}
+		next := rng.Intn(n)
+		if total > 0 {
+			target := rng.Float64() * total
+			for i, d := range dist {
+				target -= d
+				if target <= 0 {
+					next = i
+					break
+				}
+			}
+		}
+		centroids = append(centroids, append([]float64(nil), points[next]...))
+	}
+
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = -1
+	}
+	iter := 0
+	for iter < maxIter {
+		iter++
+		changed := false
+		for i, p := range points {
+			nearest, nearestDist := 0, math.Inf(1)
+			for c, centroid := range centroids {
+				if d := sqDist(p, centroid); d < nearestDist {
+					nearest, nearestDist = c, d
+				}
+			}
+			if labels[i] != nearest {
+				labels[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		counts := make([]int, k)
+		for c := range centroids {
+			centroids[c] = make([]float64, dim)
+		}
+		for i, p := range points {
+			counts[labels[i]]++
+			for j, v := range p {
+				centroids[labels[i]][j] += v
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				// 空簇：取离当前中心最远的点作为新中心
+				far, farDist := 0, -1.0
+				for i, p := range points {
+					if d := sqDist(p, centroids[labels[i]]); d > farDist {
+						far, farDist = i, d
+					}
+				}
+				copy(centroids[c], points[far])
+				continue
+			}
+			for j := range centroids[c] {
+				centroids[c][j] /= float64(counts[c])
+			}
+		}
+	}
+
+	inertia := 0.0
+	for i, p := range points {
+		inertia += sqDist(p, centroids[labels[i]])
+	}
+	return kmeansResult{labels: labels, centroids: centroids, inertia: inertia, iterations: iter}
+}
+
+// movingAverageFit 简单移动平均：返回一步预测值（前 window 个为 NaN）和最后一个窗口的均值
+func movingAverageFit(values []float64, window int) ([]float64, float64) {
+	fitted := make([]float64, len(values))
+	sum := 0.0
+	for i, v := range values {
+		if i < window {
+			fitted[i] = math.NaN()
+		} else {
+			fitted[i] = sum / float64(window)
+			sum -= values[i-window]
+		}
+		sum += v
+	}
+	return fitted, sum / float64(window)
+}
+
+// exponentialSmoothingFit 简单指数平滑：返回一步预测值（首个为 NaN）和最终平滑水平
+func exponentialSmoothingFit(values []float64, alpha float64) ([]float64, float64) {
+	fitted := make([]float64, len(values))
+	fitted[0] = math.NaN()
+	level := values[0]
+	for i := 1; i < len(values); i++ {
+		fitted[i] = level
+		level = alpha*values[i] + (1-alpha)*level
+	}
+	return fitted, level
+}
+
+// fitErrors 计算一步预测的平均绝对误差和均方根误差，忽略 NaN 预测
+func fitErrors(values, fitted []float64) (mae, rmse float64) {
+	n := 0
+	for i, f := range fitted {
+		if math.IsNaN(f) {
+			continue
+		}
+		e := values[i] - f
+		mae += math.Abs(e)
+		rmse += e * e
+		n++
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return mae / float64(n), math.Sqrt(rmse / float64(n))
+}
@@ -0,0 +1,131 @@
+package tool
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// approxEqual 按 roundFloat 的精度比较浮点数
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func approxSlice(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.IsNaN(b[i]) {
+			if !math.IsNaN(a[i]) {
+				return false
+			}
+			continue
+		}
+		if !approxEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBasicStats(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{2, 4, 5, 4, 5}
+	sorted := []float64{2, 4, 4, 5, 5}
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"mean x", mean(x), 3},
+		{"mean y", mean(y), 4},
+		{"mean empty", mean(nil), 0},
+		{"stddev x", stddev(x), math.Sqrt(2.5)},
+		{"stddev y", stddev(y), math.Sqrt(1.5)},
+		{"stddev single", stddev([]float64{7}), 0},
+		{"p25", quantile(sorted, 0.25), 4},
+		{"median", quantile(sorted, 0.5), 4},
+		{"p75", quantile(sorted, 0.75), 5},
+		{"interpolated", quantile([]float64{1, 2, 3, 4}, 0.5), 2.5},
+		{"pearson", pearson(x, y), 6 / math.Sqrt(60)},
+		{"spearman", pearson(ranks(x), ranks(y)), 7 / math.Sqrt(90)},
+		{"perfect negative", pearson(x, []float64{10, 8, 6, 4, 2}), -1},
+		{"roundFloat", roundFloat(0.1 + 0.2), 0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !approxEqual(tt.got, tt.want) {
+				t.Fatalf("got %.12g, want %.12g", tt.got, tt.want)
+			}
+		})
+	}
+
+	if r := pearson(x, []float64{3, 3, 3, 3, 3}); !math.IsNaN(r) {
+		t.Fatalf("pearson with constant column = %g, want NaN", r)
+	}
+	if got := ranks(y); !approxSlice(got, []float64{1, 2.5, 4.5, 2.5, 4.5}) {
+		t.Fatalf("ranks = %v", got)
+	}
+	if got := dropNaN([]float64{1, math.NaN(), 2}); !approxSlice(got, []float64{1, 2}) {
+		t.Fatalf("dropNaN = %v", got)
+	}
+}
+
+func TestOLSFit(t *testing.T) {
+	x := [][]float64{{1}, {2}, {3}, {4}, {5}}
+	y := []float64{2, 4, 5, 4, 5}
+	beta, inv, err := olsFit(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approxSlice(beta, []float64{2.2, 0.6}) {
+		t.Fatalf("beta = %v", beta)
+	}
+	// (X'X)^-1 = [[55, -15], [-15, 5]] / 50
+	want := [][]float64{{1.1, -0.3}, {-0.3, 0.1}}
+	for i := range want {
+		if !approxSlice(inv[i], want[i]) {
+			t.Fatalf("inv = %v, want %v", inv, want)
+		}
+	}
+
+	// 两个自变量：y = 1 + 2*a - b 精确成立
+	x2 := [][]float64{{0, 1}, {1, 0}, {2, 3}, {3, 1}, {4, 5}}
+	y2 := make([]float64, len(x2))
+	for i, row := range x2 {
+		y2[i] = 1 + 2*row[0] - row[1]
+	}
+	if beta, _, err := olsFit(x2, y2); err != nil || !approxSlice(beta, []float64{1, 2, -1}) {
+		t.Fatalf("beta = %v, %v", beta, err)
+	}
+
+	collinear := [][]float64{{1, 2}, {2, 4}, {3, 6}, {4, 8}}
+	if _, _, err := olsFit(collinear, []float64{1, 2, 3, 4}); !errors.Is(err, errSingularMatrix) {
+		t.Fatalf("collinear features: %v", err)
+	}
+}
+
+func TestForecastFits(t *testing.T) {
+	values := []float64{2, 4, 5, 4, 5}
+	nan := math.NaN()
+
+	fitted, next := movingAverageFit(values, 2)
+	if !approxSlice(fitted, []float64{nan, nan, 3, 4.5, 4.5}) || next != 4.5 {
+		t.Fatalf("moving average = %v, %g", fitted, next)
+	}
+	mae, rmse := fitErrors(values, fitted)
+	if !approxEqual(mae, 1) || !approxEqual(rmse, math.Sqrt(1.5)) {
+		t.Fatalf("moving average errors = %g, %g", mae, rmse)
+	}
+
+	fitted, next = exponentialSmoothingFit(values, 0.5)
+	if !approxSlice(fitted, []float64{nan, 2, 3, 4, 4}) || next != 4.5 {
+		t.Fatalf("exponential smoothing = %v, %g", fitted, next)
+	}
+	mae, rmse = fitErrors(values, fitted)
+	if !approxEqual(mae, 1.25) || !approxEqual(rmse, 1.5) {
+		t.Fatalf("exponential smoothing errors = %g, %g", mae, rmse)
+	}
+}
@@ -0,0 +1,376 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认返回的符号数量上限
+const defaultSymbolLimit = 100
+
+// GoSymbolTool 基于 go/parser 的 Go 符号索引工具
+type GoSymbolTool struct {
+	name        string
+	description string
+	workspace   *Workspace
+
+	mu    sync.Mutex
+	files map[string]*goFileIndex
+}
+
+// goFileIndex 单个 Go 文件的索引，按修改时间失效
+type goFileIndex struct {
+	modTime time.Time
+	pkg     string
+	defs    []GoSymbol
+	refs    []GoSymbol
+}
+
+// GoSymbol 符号定义或引用
+type GoSymbol struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Receiver string `json:"receiver,omitempty"`
+	Package  string `json:"package"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Text     string `json:"text,omitempty"`
+	Doc      string `json:"doc,omitempty"`
+}
+
+// GoSymbolInput 符号查询参数
+type GoSymbolInput struct {
+	Operation string `json:"operation"`
+	Name      string `json:"name,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// GoSymbolResult 符号查询结果
+type GoSymbolResult struct {
+	Symbols      []GoSymbol `json:"symbols"`
+	Count        int        `json:"count"`
+	FilesIndexed int        `json:"files_indexed"`
+	Truncated    bool       `json:"truncated,omitempty"`
+	Errors       []string   `json:"errors,omitempty"`
+}
+
+// 预定义的符号查询操作
+const (
+	SymbolOpDefinitions = "definitions" // 查找定义
+	SymbolOpReferences  = "references"  // 查找引用
+	SymbolOpList        = "list"        // 列出文件或目录中的定义
+)
+
+// NewGoSymbolTool 创建 Go 符号工具
+func NewGoSymbolTool(ws *Workspace) *GoSymbolTool {
+	return &GoSymbolTool{
+		name:        "go_symbols",
+		description: "Go 代码符号索引，支持查找函数、方法、类型、变量和常量的定义与引用（name 可写作 Type.Method）",
+		workspace:   ws,
+		files:       make(map[string]*goFileIndex),
+	}
+}
+
+// Name 返回工具名称
+func (t *GoSymbolTool) Name() string {
+	return t.name
+}
+
+// Description 返回工具描述
+func (t *GoSymbolTool) Description() string {
+	return t.description
+}
+
+// Execute 执行工具功能
+func (t *GoSymbolTool) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return t.Run(ctx, args)
+}
+
+// Run 执行符号查询
+func (t *GoSymbolTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	var in GoSymbolInput
+	if err := decodeArgs(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Operation == "" {
+		in.Operation = SymbolOpDefinitions
+	}
+	switch in.Operation {
+	case SymbolOpDefinitions, SymbolOpReferences:
+		if in.Name == "" {
+			return nil, ErrInvalidArgs
+		}
+	case SymbolOpList:
+	default:
+		return nil, ErrInvalidOperation
+	}
+	if in.Path == "" {
+		in.Path = "."
+	}
+	if in.Limit <= 0 {
+		in.Limit = defaultSymbolLimit
+	}
+
+	ws, err := workspaceOrDefault(t.workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Type.Method 形式限定接收者
+	name, receiver := in.Name, ""
+	if i := strings.LastIndex(name, "."); i > 0 {
+		receiver, name = name[:i], name[i+1:]
+	}
+
+	result := GoSymbolResult{Symbols: make([]GoSymbol, 0)}
+	err = t.eachFile(ctx, ws, in.Path, func(idx *goFileIndex, parseErr error, rel string) {
+		result.FilesIndexed++
+		if parseErr != nil {
+			result.Errors = append(result.Errors, parseErr.Error())
+			return
+		}
+
+		var candidates []GoSymbol
+		switch in.Operation {
+		case SymbolOpDefinitions, SymbolOpList:
+			candidates = idx.defs
+		case SymbolOpReferences:
+			candidates = idx.refs
+		}
+		for _, sym := range candidates {
+			if in.Name != "" {
+				if sym.Name != name {
+					continue
+				}
+				// 引用没有类型信息，只按名称匹配
+				if receiver != "" && in.Operation != SymbolOpReferences && sym.Receiver != receiver {
+					continue
+				}
+			}
+			if in.Kind != "" && sym.Kind != in.Kind {
+				continue
+			}
+			if len(result.Symbols) >= in.Limit {
+				result.Truncated = true
+				return
+			}
+			result.Symbols = append(result.Symbols, sym)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Count = len(result.Symbols)
+	return result, nil
+}
+
+// eachFile 遍历路径下的 Go 文件并返回（可能来自缓存的）索引
+func (t *GoSymbolTool) eachFile(ctx context.Context, ws *Workspace, start string, fn func(idx *goFileIndex, parseErr error, rel string)) error {
+	full, err := ws.Resolve(start)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		idx, parseErr := t.index(full, ws.Rel(full), info.ModTime())
+		fn(idx, parseErr, ws.Rel(full))
+		return nil
+	}
+
+	var rels []string
+	err = walkWorkspace(ctx, ws, start, func(rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			if base := filepath.Base(rel); base == "vendor" || base == "testdata" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(rel, ".go") {
+			rels = append(rels, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(rels)
+
+	for _, rel := range rels {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		full := filepath.Join(ws.Root(), filepath.FromSlash(rel))
+		info, err := os.Stat(full)
+		if err != nil {
+			continue
+		}
+		idx, parseErr := t.index(full, rel, info.ModTime())
+		fn(idx, parseErr, rel)
+	}
+	return nil
+}
+
+// index 解析单个文件，修改时间未变时复用缓存
+func (t *GoSymbolTool) index(full, rel string, modTime time.Time) (*goFileIndex, error) {
+	t.mu.Lock()
+	cached, ok := t.files[full]
+	t.mu.Unlock()
+	if ok && cached.modTime.Equal(modTime) {
+		return cached, nil
+	}
+
+	src, err := os.ReadFile(full)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", rel, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, full, src, parser.ParseComments|parser.SkipObjectResolution)
+	// 缺少 package 子句时没有可用的部分语法树
+	if err != nil && (file == nil || !file.Package.IsValid()) {
+		return nil, fmt.Errorf("%s: %v", rel, err)
+	}
+
+	lines := strings.Split(string(src), "\n")
+	idx := &goFileIndex{modTime: modTime, pkg: file.Name.Name}
+	mk := func(ident *ast.Ident, kind, recv string, doc *ast.CommentGroup) GoSymbol {
+		pos := fset.Position(ident.Pos())
+		sym := GoSymbol{
+			Name:     ident.Name,
+			Kind:     kind,
+			Receiver: recv,
+			Package:  idx.pkg,
+			File:     rel,
+			Line:     pos.Line,
+			Column:   pos.Column,
+		}
+		if pos.Line > 0 && pos.Line-1 < len(lines) {
+			sym.Text = clipLine(strings.TrimSpace(lines[pos.Line-1]))
+		}
+		if doc != nil {
+			sym.Doc = strings.SplitN(strings.TrimSpace(doc.Text()), "\n", 2)[0]
+		}
+		return sym
+	}
+
+	defIdents := make(map[*ast.Ident]bool)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			kind, recv := "func", ""
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				kind, recv = "method", receiverName(d.Recv.List[0].Type)
+			}
+			idx.defs = append(idx.defs, mk(d.Name, kind, recv, d.Doc))
+			defIdents[d.Name] = true
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					doc := s.Doc
+					if doc == nil {
+						doc = d.Doc
+					}
+					kind := "type"
+					if _, ok := s.Type.(*ast.InterfaceType); ok {
+						kind = "interface"
+					} else if _, ok := s.Type.(*ast.StructType); ok {
+						kind = "struct"
+					}
+					idx.defs = append(idx.defs, mk(s.Name, kind, "", doc))
+					defIdents[s.Name] = true
+					// 结构体字段和接口方法以所属类型作为接收者
+					idx.defs = append(idx.defs, memberSymbols(s, mk, defIdents)...)
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					doc := s.Doc
+					if doc == nil {
+						doc = d.Doc
+					}
+					for _, n := range s.Names {
+						if n.Name == "_" {
+							continue
+						}
+						idx.defs = append(idx.defs, mk(n, kind, "", doc))
+						defIdents[n] = true
+					}
+				}
+			}
+		}
+	}
+
+	// 引用为语法层面的同名标识符，不做类型检查
+	ast.Inspect(file, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || defIdents[ident] || ident.Name == "_" {
+			return true
+		}
+		idx.refs = append(idx.refs, mk(ident, "reference", "", nil))
+		return true
+	})
+
+	t.mu.Lock()
+	t.files[full] = idx
+	t.mu.Unlock()
+	return idx, nil
+}
+
+// memberSymbols 提取结构体字段和接口方法
+func memberSymbols(s *ast.TypeSpec, mk func(*ast.Ident, string, string, *ast.CommentGroup) GoSymbol, defIdents map[*ast.Ident]bool) []GoSymbol {
+	var fields *ast.FieldList
+	kind := "field"
+	switch typ := s.Type.(type) {
+	case *ast.StructType:
+		fields = typ.Fields
+	case *ast.InterfaceType:
+		fields = typ.Methods
+		kind = "method"
+	}
+	if fields == nil {
+		return nil
+	}
+	var out []GoSymbol
+	for _, f := range fields.List {
+		for _, n := range f.Names {
+			out = append(out, mk(n, kind, s.Name.Name, f.Doc))
+			defIdents[n] = true
+		}
+	}
+	return out
+}
+
+// receiverName 提取方法接收者的类型名，去掉指针和类型参数
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.ParenExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
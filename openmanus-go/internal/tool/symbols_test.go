@@ -0,0 +1,203 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testSymbolSource = `package shapes
+
+// Shape 几何图形
+type Shape interface {
+	// Area 面积
+	Area() float64
+}
+
+// Rect 矩形
+type Rect struct {
+	W, H float64
+}
+
+// Area 返回矩形面积
+func (r *Rect) Area() float64 { return r.W * r.H }
+
+// Box 泛型接收者
+type Box[T any] struct{ v T }
+
+func (b Box[T]) Area() float64 { return 0 }
+
+// Unit 单位面积
+const Unit = 1.0
+
+var _ Shape = (*Rect)(nil)
+
+// Total 计算总面积
+func Total(shapes []Shape) float64 {
+	sum := 0.0
+	for _, s := range shapes {
+		sum += s.Area()
+	}
+	return sum
+}
+`
+
+// symbolKeys 将符号转换为 "kind receiver.name file:line" 形式
+func symbolKeys(symbols []GoSymbol) []string {
+	var out []string
+	for _, s := range symbols {
+		name := s.Name
+		if s.Receiver != "" {
+			name = s.Receiver + "." + name
+		}
+		out = append(out, fmt.Sprintf("%s %s %s:%d", s.Kind, name, s.File, s.Line))
+	}
+	return out
+}
+
+func TestGoSymbolTool(t *testing.T) {
+	ws := newTestWorkspace(t)
+	writeTestFile(t, ws.Root(), "shapes/shapes.go", testSymbolSource)
+	writeTestFile(t, ws.Root(), "main.go", "package main\n\nfunc main() { _ = shapes.Total(nil) }\n")
+	writeTestFile(t, ws.Root(), "vendor/x/x.go", "package x\n\nfunc Total() {}\n")
+	writeTestFile(t, ws.Root(), "testdata/t.go", "package t\n\nfunc Total() {}\n")
+	st := NewGoSymbolTool(ws)
+
+	tests := []struct {
+		name      string
+		input     map[string]interface{}
+		symbols   []string
+		truncated bool
+	}{
+		{
+			name:    "definition skips vendor and testdata",
+			input:   map[string]interface{}{"name": "Total"},
+			symbols: []string{"func Total shapes/shapes.go:28"},
+		},
+		{
+			name:  "methods and interface members",
+			input: map[string]interface{}{"name": "Area"},
+			symbols: []string{
+				"method Shape.Area shapes/shapes.go:6",
+				"method Rect.Area shapes/shapes.go:15",
+				"method Box.Area shapes/shapes.go:20",
+			},
+		},
+		{
+			name:    "receiver qualified",
+			input:   map[string]interface{}{"name": "Rect.Area"},
+			symbols: []string{"method Rect.Area shapes/shapes.go:15"},
+		},
+		{
+			name:    "kind filter",
+			input:   map[string]interface{}{"operation": "list", "path": "shapes", "kind": "struct"},
+			symbols: []string{"struct Rect shapes/shapes.go:10", "struct Box shapes/shapes.go:18"},
+		},
+		{
+			name:  "list file",
+			input: map[string]interface{}{"operation": "list", "path": "shapes/shapes.go", "limit": 4},
+			symbols: []string{
+				"interface Shape shapes/shapes.go:4",
+				"method Shape.Area shapes/shapes.go:6",
+				"struct Rect shapes/shapes.go:10",
+				"field Rect.W shapes/shapes.go:11",
+			},
+			truncated: true,
+		},
+		{
+			name:    "references",
+			input:   map[string]interface{}{"operation": "references", "name": "Total"},
+			symbols: []string{"reference Total main.go:3"},
+		},
+		{
+			name:    "references ignore receiver",
+			input:   map[string]interface{}{"operation": "references", "name": "Rect.Area"},
+			symbols: []string{"reference Area shapes/shapes.go:31"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := st.Run(context.Background(), tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := out.(GoSymbolResult)
+			if got := symbolKeys(res.Symbols); !reflect.DeepEqual(got, tt.symbols) {
+				t.Fatalf("symbols = %v, want %v", got, tt.symbols)
+			}
+			if res.Count != len(tt.symbols) || res.Truncated != tt.truncated || len(res.Errors) != 0 {
+				t.Fatalf("result = %+v", res)
+			}
+		})
+	}
+}
+
+func TestGoSymbolToolOutput(t *testing.T) {
+	ws := newTestWorkspace(t)
+	writeTestFile(t, ws.Root(), "shapes.go", testSymbolSource)
+	writeTestFile(t, ws.Root(), "broken.go", "not go source\n")
+	st := NewGoSymbolTool(ws)
+
+	out, err := st.Run(context.Background(), map[string]interface{}{"name": "Unit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := out.(GoSymbolResult)
+	want := []GoSymbol{{
+		Name:    "Unit",
+		Kind:    "const",
+		Package: "shapes",
+		File:    "shapes.go",
+		Line:    23,
+		Column:  7,
+		Text:    "const Unit = 1.0",
+		Doc:     "Unit 单位面积",
+	}}
+	if !reflect.DeepEqual(res.Symbols, want) {
+		t.Fatalf("symbols = %+v, want %+v", res.Symbols, want)
+	}
+	// 无法解析的文件记录为错误，不影响其他文件
+	if res.FilesIndexed != 2 || len(res.Errors) != 1 {
+		t.Fatalf("files indexed = %d, errors = %v", res.FilesIndexed, res.Errors)
+	}
+
+	// 文件修改后重新索引
+	full := filepath.Join(ws.Root(), "shapes.go")
+	writeTestFile(t, ws.Root(), "shapes.go", "package shapes\n\nconst Unit = 2.0\n")
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(full, later, later); err != nil {
+		t.Fatal(err)
+	}
+	out, err = st.Run(context.Background(), map[string]interface{}{"name": "Unit", "path": "shapes.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.(GoSymbolResult).Symbols; len(got) != 1 || got[0].Line != 3 {
+		t.Fatalf("reindexed symbols = %+v", got)
+	}
+}
+
+func TestGoSymbolToolInvalidInput(t *testing.T) {
+	st := NewGoSymbolTool(newTestWorkspace(t))
+	tests := []struct {
+		name  string
+		input map[string]interface{}
+		want  error
+	}{
+		{"missing name", map[string]interface{}{"operation": "definitions"}, ErrInvalidArgs},
+		{"unknown operation", map[string]interface{}{"operation": "rename", "name": "x"}, ErrInvalidOperation},
+		{"outside workspace", map[string]interface{}{"operation": "list", "path": ".."}, ErrPathOutsideWorkspace},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := st.Run(context.Background(), tt.input); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -20,17 +20,47 @@ func (e *ToolError) Error() string {
 	return e.msg
 }
 
-// ToolCollection 工具集合
+// ToolCollection 工具集合；通过 Get 取得的工具在调用时经过中间件链，
+// 声明为 Cacheable 的工具的结果在集合范围内缓存
 type ToolCollection struct {
-	tools map[string]Tool
-	mu    sync.Mutex
+	tools      map[string]Tool
+	middleware []ToolMiddleware
+	cache      *ResultCache
+	mu         sync.Mutex
 }
 
-// NewToolCollection 创建工具集合
+// NewToolCollection 创建工具集合，默认带上 SetDefaultMiddleware 设置的中间件和
+// SetDefaultResultCacheConfig 设置的结果缓存
 func NewToolCollection() *ToolCollection {
-	return &ToolCollection{
-		tools: make(map[string]Tool),
+	tc := &ToolCollection{
+		tools:      make(map[string]Tool),
+		middleware: DefaultMiddleware(),
 	}
+	if config := DefaultResultCacheConfig(); !config.Disabled {
+		tc.cache = NewResultCache(config)
+	}
+	return tc
+}
+
+// SetCache 替换结果缓存，例如在多个集合间共享；nil 表示关闭缓存
+func (tc *ToolCollection) SetCache(cache *ResultCache) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.cache = cache
+}
+
+// Cache 返回结果缓存，未启用时为 nil
+func (tc *ToolCollection) Cache() *ResultCache {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.cache
+}
+
+// Use 追加中间件，先添加的在外层
+func (tc *ToolCollection) Use(mw ...ToolMiddleware) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.middleware = append(tc.middleware[:len(tc.middleware):len(tc.middleware)], mw...)
 }
 
 // Register 注册工具
@@ -44,12 +74,18 @@ func (tc *ToolCollection) Register(name string, tool Tool) error {
 	return nil
 }
 
-// Get 获取工具
+// Get 获取工具；配置了中间件时返回经过中间件链的包装，原始工具可通过 UnwrapTool 取得
 func (tc *ToolCollection) Get(name string) (Tool, error) {
 	tc.mu.Lock()
 	defer tc.mu.Unlock()
 	if tool, ok := tc.tools[name]; ok {
-		return tool, nil
+		mw := tc.middleware
+		if tc.cache != nil {
+			if _, ok := tool.(Cacheable); ok {
+				mw = append(mw[:len(mw):len(mw)], CacheMiddleware(tc.cache))
+			}
+		}
+		return wrapTool(name, tool, mw), nil
 	}
 	return nil, fmt.Errorf("工具 %s 不存在", name)
 }
@@ -0,0 +1,151 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 图表文件默认保存目录（相对工作区）
+const defaultChartDir = "charts"
+
+// ChartArtifact 生成的图表文件，路径相对工作区
+type ChartArtifact struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	SVG   string `json:"svg,omitempty"`
+	PNG   string `json:"png,omitempty"`
+}
+
+var chartNameRe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// renderCharts 渲染图表并生成 HTML 报告，返回写入 DataAnalysisOutput.Visualization 的内容
+func (d *DataAnalysisTool) renderCharts(ds *dataset, in DataAnalysisInput, result interface{}) (map[string]interface{}, error) {
+	ws, err := workspaceOrDefault(nil)
+	if err != nil {
+		return nil, err
+	}
+	dir := in.OutputDir
+	if dir == "" {
+		dir = defaultChartDir
+	}
+	base := chartNameRe.ReplaceAllString(in.Name, "-")
+	if base == "" {
+		base = fmt.Sprintf("%s-%s", in.Operation, time.Now().Format("20060102-150405"))
+	}
+
+	var artifacts []ChartArtifact
+	var svgs []template.HTML
+	for i, spec := range in.Charts {
+		c, err := buildChart(ds, spec)
+		if err != nil {
+			return nil, fmt.Errorf("chart %d: %w", i+1, err)
+		}
+		artifact := ChartArtifact{Type: c.spec.Type, Title: c.spec.Title}
+		name := path.Join(dir, fmt.Sprintf("%s-%d-%s", base, i+1, c.spec.Type))
+		svg := c.renderSVG()
+		svgs = append(svgs, template.HTML(svg))
+
+		formats := spec.Formats
+		if len(formats) == 0 {
+			formats = stringList{ChartFormatSVG}
+		}
+		for _, format := range formats {
+			switch strings.ToLower(format) {
+			case ChartFormatSVG:
+				full, err := ws.WriteFile(name+".svg", svg)
+				if err != nil {
+					return nil, err
+				}
+				artifact.SVG = ws.Rel(full)
+			case ChartFormatPNG:
+				data, err := c.renderPNG()
+				if err != nil {
+					return nil, err
+				}
+				full, err := ws.WriteFile(name+".png", data)
+				if err != nil {
+					return nil, err
+				}
+				artifact.PNG = ws.Rel(full)
+			default:
+				return nil, fmt.Errorf("%w: unsupported chart format %q, use svg or png", ErrInvalidArgs, format)
+			}
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	report, err := chartReport(in, result, artifacts, svgs)
+	if err != nil {
+		return nil, err
+	}
+	full, err := ws.WriteFile(path.Join(dir, base+".html"), report)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"charts": artifacts,
+		"report": ws.Rel(full),
+	}, nil
+}
+
+var chartReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; margin: 32px; color: #333; }
+figure { margin: 24px 0; }
+figcaption { font-size: 13px; color: #666; }
+pre { background: #f6f8fa; padding: 12px; overflow: auto; font-size: 12px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Source}} · {{.Generated}}</p>
+{{range $i, $svg := .Charts}}<figure>
+{{$svg}}
+<figcaption>{{(index $.Artifacts $i).Title}}</figcaption>
+</figure>
+{{end}}{{if .Result}}<h2>Result</h2>
+<pre>{{.Result}}</pre>
+{{end}}</body>
+</html>
+`))
+
+// chartReport 生成内嵌 SVG 图表和分析结果的 HTML 报告
+func chartReport(in DataAnalysisInput, result interface{}, artifacts []ChartArtifact, svgs []template.HTML) ([]byte, error) {
+	title := in.Title
+	if title == "" {
+		title = "Data analysis: " + in.Operation
+	}
+	source := in.Path
+	if source == "" {
+		source = "inline data"
+	}
+	var resultText string
+	if result != nil {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		resultText = string(data)
+	}
+
+	var buf bytes.Buffer
+	err := chartReportTemplate.Execute(&buf, map[string]interface{}{
+		"Title":     title,
+		"Source":    source,
+		"Generated": time.Now().Format("2006-01-02 15:04:05"),
+		"Charts":    svgs,
+		"Artifacts": artifacts,
+		"Result":    resultText,
+	})
+	return buf.Bytes(), err
+}
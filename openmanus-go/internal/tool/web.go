@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -16,12 +17,10 @@ type WebScraperTool struct {
 	client *http.Client
 }
 
-// NewWebScraperTool 创建 Web 抓取工具
+// NewWebScraperTool 创建 Web 抓取工具，请求受 DefaultHTTPConfig 中的主机限制
 func NewWebScraperTool() *WebScraperTool {
 	return &WebScraperTool{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client: NewHTTPClient(DefaultHTTPConfig().Hosts, 30*time.Second),
 	}
 }
 
@@ -46,6 +45,16 @@ type WebResponse struct {
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// String 返回状态行和响应体
+func (r WebResponse) String() string {
+	return fmt.Sprintf("HTTP %d %s\n%v", r.StatusCode, http.StatusText(r.StatusCode), r.Body)
+}
+
+// Cacheable 状态码表示失败的响应不缓存
+func (r WebResponse) Cacheable() bool {
+	return r.StatusCode < 400
+}
+
 func (w *WebScraperTool) Run(ctx context.Context, input interface{}) (interface{}, error) {
 	// 解析输入
 	var req WebRequest
@@ -75,7 +84,7 @@ func (w *WebScraperTool) Run(ctx context.Context, input interface{}) (interface{
 	}
 
 	// 创建请求
-	var bodyReader *strings.Reader
+	var bodyReader io.Reader
 	if req.Body != nil {
 		bodyData, err := json.Marshal(req.Body)
 		if err != nil {
@@ -102,11 +111,20 @@ func (w *WebScraperTool) Run(ctx context.Context, input interface{}) (interface{
 	}
 	defer resp.Body.Close()
 
-	// 读取响应
-	body, err := ioutil.ReadAll(resp.Body)
+	// 读取响应；options.max_bytes 限制读取的字节数
+	var reader io.Reader = resp.Body
+	maxBytes, _ := req.Options["max_bytes"].(float64)
+	if maxBytes > 0 {
+		reader = io.LimitReader(resp.Body, int64(maxBytes)+1)
+	}
+	body, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %v", err)
 	}
+	truncated := maxBytes > 0 && len(body) > int(maxBytes)
+	if truncated {
+		body = body[:int(maxBytes)]
+	}
 
 	// 解析响应头
 	headers := make(map[string]string)
@@ -122,6 +140,9 @@ func (w *WebScraperTool) Run(ctx context.Context, input interface{}) (interface{
 		Metadata: map[string]interface{}{
 			"duration": time.Since(startTime).String(),
 			"time":     time.Now(),
+			// 跟随重定向后的地址
+			"url":       resp.Request.URL.String(),
+			"truncated": truncated,
 		},
 	}
 
@@ -133,6 +154,26 @@ func (w *WebScraperTool) Run(ctx context.Context, input interface{}) (interface{
 	return result, nil
 }
 
+// CachePolicy 没有请求体的 GET 和 HEAD 请求可以缓存，按缓存的默认有效期过期
+func (w *WebScraperTool) CachePolicy(input interface{}) (CachePolicy, bool) {
+	var req WebRequest
+	switch v := input.(type) {
+	case string:
+		return CachePolicy{Persistent: true}, true
+	case map[string]interface{}:
+		if err := decodeArgs(v, &req); err != nil {
+			return CachePolicy{}, false
+		}
+	default:
+		return CachePolicy{}, false
+	}
+	method := strings.ToUpper(req.Method)
+	if (method != "" && method != MethodGet && method != MethodHead) || req.Body != nil {
+		return CachePolicy{}, false
+	}
+	return CachePolicy{Persistent: true}, true
+}
+
 // WebScraperConfig Web 抓取工具配置
 type WebScraperConfig struct {
 	Timeout          time.Duration
@@ -145,17 +186,16 @@ type WebScraperConfig struct {
 
 // NewWebScraperToolWithConfig 使用配置创建 Web 抓取工具
 func NewWebScraperToolWithConfig(config WebScraperConfig) *WebScraperTool {
-	client := &http.Client{
-		Timeout: config.Timeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if !config.FollowRedirects {
-				return http.ErrUseLastResponse
-			}
-			if len(via) >= config.MaxRedirects {
-				return fmt.Errorf("stopped after %d redirects", config.MaxRedirects)
-			}
-			return nil
-		},
+	client := NewHTTPClient(DefaultHTTPConfig().Hosts, config.Timeout)
+	checkHost := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !config.FollowRedirects {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= config.MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", config.MaxRedirects)
+		}
+		return checkHost(req, via)
 	}
 
 	return &WebScraperTool{
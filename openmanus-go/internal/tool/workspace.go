@@ -0,0 +1,367 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认的文件和目录权限
+const (
+	DefaultFileMode os.FileMode = 0644
+	DefaultDirMode  os.FileMode = 0755
+)
+
+// 超过该大小的文件不保存上一版本
+const maxVersionSize = 10 * 1024 * 1024
+
+// 路径校验错误
+var (
+	ErrPathOutsideWorkspace = errors.New("path is outside workspace")
+	ErrPathDenied           = errors.New("path is denied by workspace rules")
+	ErrPathReadOnly         = errors.New("path is read-only")
+)
+
+// WorkspaceConfig 工作区配置
+type WorkspaceConfig struct {
+	// Root 工作区根目录，所有路径都被限制在其中
+	Root string
+	// ReadOnly 只读路径规则（相对根目录的 glob，语法同 .gitignore 的简单形式）
+	ReadOnly []string
+	// Deny 禁止访问的路径规则
+	Deny []string
+	// TrashDir 软删除目录（相对根目录）
+	TrashDir string
+}
+
+// DefaultWorkspaceConfig 默认工作区配置
+func DefaultWorkspaceConfig(root string) WorkspaceConfig {
+	return WorkspaceConfig{
+		Root:     root,
+		ReadOnly: []string{".git"},
+		Deny:     []string{".env", "*.pem", "*.key", ".ssh"},
+		TrashDir: ".trash",
+	}
+}
+
+// Workspace 受限的文件系统视图，负责路径解析、访问规则和安全写入
+type Workspace struct {
+	root     string
+	trashDir string
+	readOnly []string
+	deny     []string
+}
+
+// NewWorkspace 创建工作区，根目录会被解析为不含符号链接的绝对路径
+func NewWorkspace(config WorkspaceConfig) (*Workspace, error) {
+	root := config.Root
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve workspace root failed: %v", err)
+	}
+	if err := os.MkdirAll(root, DefaultDirMode); err != nil {
+		return nil, fmt.Errorf("create workspace root failed: %v", err)
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve workspace root failed: %v", err)
+	}
+
+	trashDir := config.TrashDir
+	if trashDir == "" {
+		trashDir = ".trash"
+	}
+	return &Workspace{
+		root:     root,
+		trashDir: filepath.ToSlash(filepath.Clean(trashDir)),
+		readOnly: config.ReadOnly,
+		deny:     config.Deny,
+	}, nil
+}
+
+// Root 返回工作区根目录
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// Rel 返回相对根目录的路径（使用 / 分隔）
+func (w *Workspace) Rel(abs string) string {
+	rel, err := filepath.Rel(w.root, abs)
+	if err != nil {
+		return abs
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Resolve 解析供读取的路径：相对路径基于根目录，符号链接解析后必须仍位于工作区内
+func (w *Workspace) Resolve(p string) (string, error) {
+	abs := p
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(w.root, p)
+	}
+	abs = filepath.Clean(abs)
+
+	resolved, err := resolveExisting(abs)
+	if err != nil {
+		return "", err
+	}
+	if !w.contains(resolved) {
+		return "", fmt.Errorf("%w: %s", ErrPathOutsideWorkspace, p)
+	}
+	if err := w.checkDeny(resolved, p); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// ResolveWrite 解析供修改的路径，额外检查只读规则和回收站目录
+func (w *Workspace) ResolveWrite(p string) (string, error) {
+	resolved, err := w.Resolve(p)
+	if err != nil {
+		return "", err
+	}
+	if err := w.checkWrite(resolved, p); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// checkDeny 检查工作区内的绝对路径是否被禁止访问
+func (w *Workspace) checkDeny(abs, p string) error {
+	rel := w.Rel(abs)
+	for _, pattern := range w.deny {
+		if matchPathRule(pattern, rel) {
+			return fmt.Errorf("%w: %s", ErrPathDenied, p)
+		}
+	}
+	return nil
+}
+
+// checkWrite 检查工作区内的绝对路径是否允许修改
+func (w *Workspace) checkWrite(abs, p string) error {
+	rel := w.Rel(abs)
+	if rel == "." {
+		return fmt.Errorf("%w: workspace root", ErrPathReadOnly)
+	}
+	if matchPathRule(w.trashDir, rel) {
+		return fmt.Errorf("%w: %s", ErrPathReadOnly, p)
+	}
+	for _, pattern := range w.readOnly {
+		if matchPathRule(pattern, rel) {
+			return fmt.Errorf("%w: %s", ErrPathReadOnly, p)
+		}
+	}
+	return nil
+}
+
+// contains 判断路径是否位于根目录内
+func (w *Workspace) contains(p string) bool {
+	if p == w.root {
+		return true
+	}
+	return strings.HasPrefix(p, w.root+string(filepath.Separator))
+}
+
+// WriteFile 原子写入文件：先写同目录临时文件，再重命名覆盖；被覆盖的内容保存为上一版本
+func (w *Workspace) WriteFile(p string, data []byte) (string, error) {
+	target, err := w.ResolveWrite(p)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), DefaultDirMode); err != nil {
+		return "", fmt.Errorf("create parent directory failed: %v", err)
+	}
+	if err := w.saveVersion(target); err != nil {
+		return "", err
+	}
+	return target, atomicWriteFile(target, data)
+}
+
+// versionPath 返回文件上一版本的保存位置，位于回收站目录下
+func (w *Workspace) versionPath(target string) string {
+	return filepath.Join(w.root, filepath.FromSlash(w.trashDir), "versions", filepath.FromSlash(w.Rel(target)))
+}
+
+// saveVersion 保存文件被覆盖前的内容，文件不存在或过大时跳过
+func (w *Workspace) saveVersion(target string) error {
+	info, err := os.Stat(target)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxVersionSize {
+		return nil
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return nil
+	}
+	dest := w.versionPath(target)
+	if err := os.MkdirAll(filepath.Dir(dest), DefaultDirMode); err != nil {
+		return fmt.Errorf("create version directory failed: %v", err)
+	}
+	return atomicWriteFile(dest, data)
+}
+
+// LastVersion 返回文件最近一次通过 WriteFile 覆盖前的内容，没有保存版本时返回 os.ErrNotExist
+func (w *Workspace) LastVersion(p string) ([]byte, error) {
+	target, err := w.Resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(w.versionPath(target))
+}
+
+// Remove 软删除：将文件或目录移动到回收站，返回回收站中的路径。
+// 只解析父目录中的符号链接，删除符号链接时移动的是链接本身，链接可以指向工作区外
+func (w *Workspace) Remove(p string) (string, error) {
+	abs := p
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(w.root, p)
+	}
+	abs = filepath.Clean(abs)
+	parent, err := w.Resolve(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(parent, filepath.Base(abs))
+	if !w.contains(target) {
+		return "", fmt.Errorf("%w: %s", ErrPathOutsideWorkspace, p)
+	}
+	if err := w.checkDeny(target, p); err != nil {
+		return "", err
+	}
+	if err := w.checkWrite(target, p); err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(target); err != nil {
+		return "", err
+	}
+
+	trash := filepath.Join(w.root, filepath.FromSlash(w.trashDir))
+	if err := os.MkdirAll(trash, DefaultDirMode); err != nil {
+		return "", fmt.Errorf("create trash directory failed: %v", err)
+	}
+	dest := filepath.Join(trash, fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405.000000000"), filepath.Base(target)))
+	if err := os.Rename(target, dest); err != nil {
+		return "", fmt.Errorf("move to trash failed: %v", err)
+	}
+	return dest, nil
+}
+
+// atomicWriteFile 原子写入，已存在的文件保留原有权限
+func atomicWriteFile(target string, data []byte) error {
+	mode := DefaultFileMode
+	if info, err := os.Stat(target); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", target)
+		}
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file failed: %v", err)
+	}
+	tmpName := tmp.Name()
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmpName)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		cleanup()
+		return fmt.Errorf("write temp file failed: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return fmt.Errorf("sync temp file failed: %v", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		cleanup()
+		return fmt.Errorf("chmod temp file failed: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("close temp file failed: %v", err)
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("rename temp file failed: %v", err)
+	}
+	return nil
+}
+
+// resolveExisting 解析路径中已存在部分的符号链接，不存在的部分原样拼接
+func resolveExisting(abs string) (string, error) {
+	existing := abs
+	var rest []string
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", fmt.Errorf("resolve path failed: %v", err)
+	}
+	return filepath.Join(append([]string{resolved}, rest...)...), nil
+}
+
+// matchPathRule 判断相对路径是否命中规则：
+// 含 "/" 的规则相对根目录匹配，否则匹配任意层级的文件名；规则命中目录时其下所有内容都命中
+func matchPathRule(pattern, rel string) bool {
+	pattern = strings.Trim(filepath.ToSlash(pattern), "/")
+	if pattern == "" || rel == "." {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	anchored := strings.Contains(pattern, "/")
+	for i := range parts {
+		candidate := parts[i]
+		if anchored {
+			candidate = strings.Join(parts[:i+1], "/")
+		}
+		if ok, _ := path.Match(pattern, candidate); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	defaultWorkspace   *Workspace
+	defaultWorkspaceMu sync.Mutex
+)
+
+// SetDefaultWorkspace 设置未显式指定工作区的文件工具所使用的工作区
+func SetDefaultWorkspace(ws *Workspace) {
+	defaultWorkspaceMu.Lock()
+	defer defaultWorkspaceMu.Unlock()
+	defaultWorkspace = ws
+}
+
+// GetDefaultWorkspace 获取默认工作区，未设置时以当前目录为根
+func GetDefaultWorkspace() (*Workspace, error) {
+	defaultWorkspaceMu.Lock()
+	defer defaultWorkspaceMu.Unlock()
+	if defaultWorkspace == nil {
+		ws, err := NewWorkspace(DefaultWorkspaceConfig("."))
+		if err != nil {
+			return nil, err
+		}
+		defaultWorkspace = ws
+	}
+	return defaultWorkspace, nil
+}
@@ -0,0 +1,148 @@
+package tool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestWorkspace(t *testing.T) *Workspace {
+	t.Helper()
+	ws, err := NewWorkspace(DefaultWorkspaceConfig(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewWorkspace: %v", err)
+	}
+	return ws
+}
+
+func TestWorkspaceResolve(t *testing.T) {
+	ws := newTestWorkspace(t)
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(ws.Root(), "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		write   bool
+		wantErr error
+	}{
+		{"relative", "a/b.txt", false, nil},
+		{"dotdot", "../x", false, ErrPathOutsideWorkspace},
+		{"symlink outside", "escape/f", false, ErrPathOutsideWorkspace},
+		{"denied", ".env", false, ErrPathDenied},
+		{"denied glob", "keys/server.pem", false, ErrPathDenied},
+		{"read-only", ".git/config", true, ErrPathReadOnly},
+		{"read-only allows read", ".git/config", false, nil},
+		{"trash", ".trash/x", true, ErrPathReadOnly},
+		{"root", ".", true, ErrPathReadOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolve := ws.Resolve
+			if tt.write {
+				resolve = ws.ResolveWrite
+			}
+			_, err := resolve(tt.path)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkspaceWriteFileKeepsVersion(t *testing.T) {
+	ws := newTestWorkspace(t)
+	if _, err := ws.WriteFile("f.txt", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.WriteFile("f.txt", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	prev, err := ws.LastVersion("f.txt")
+	if err != nil || string(prev) != "v1" {
+		t.Fatalf("LastVersion = %q, %v", prev, err)
+	}
+}
+
+func TestWorkspaceRemoveSymlink(t *testing.T) {
+	ws := newTestWorkspace(t)
+	root := ws.Root()
+	if err := os.WriteFile(filepath.Join(root, "target.txt"), []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	if err := os.WriteFile(outside, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		link   string
+		target string
+	}{
+		{"inside", "inside-link", filepath.Join(root, "target.txt")},
+		{"outside", "outside-link", outside},
+		{"dangling", "dangling-link", filepath.Join(root, "missing")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Symlink(tt.target, filepath.Join(root, tt.link)); err != nil {
+				t.Fatal(err)
+			}
+			dest, err := ws.Remove(tt.link)
+			if err != nil {
+				t.Fatalf("Remove: %v", err)
+			}
+			info, err := os.Lstat(dest)
+			if err != nil || info.Mode()&os.ModeSymlink == 0 {
+				t.Fatalf("trash entry is not the link itself: %v", err)
+			}
+			if _, err := os.Lstat(filepath.Join(root, tt.link)); !os.IsNotExist(err) {
+				t.Fatalf("link still exists: %v", err)
+			}
+			if _, err := os.Lstat(tt.target); err != nil && tt.name != "dangling" {
+				t.Fatalf("link target was removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestWorkspaceRemoveRules(t *testing.T) {
+	ws := newTestWorkspace(t)
+	root := ws.Root()
+	for _, p := range []string{".git/HEAD", ".env", "dir/file"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(p)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, p), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path    string
+		wantErr error
+	}{
+		{".git/HEAD", ErrPathReadOnly},
+		{".env", ErrPathDenied},
+		{"../x", ErrPathOutsideWorkspace},
+		{"dir/file", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := ws.Remove(tt.path)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}